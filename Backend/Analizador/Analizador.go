@@ -3,142 +3,234 @@ package analizador
 import (
 	"errors"
 	"fmt"
+	globals "godisk/Global"
 	comandos "godisk/Instrucciones"
 	instrucciones "godisk/Instrucciones/Discos"
 	usuarios "godisk/Instrucciones/Usuarios"
 	reportes "godisk/Reportes"
+	utilidades "godisk/Utilidades"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 )
 
-var mapaComandos = map[string]func([]string) (string, error){
-	"mkdisk": func(args []string) (string, error) {
+var mapaComandos = map[string]func(*globals.Sesion, []string) (string, error){
+	"mkdisk": func(sesion *globals.Sesion, args []string) (string, error) {
 		resultado, err := instrucciones.AnalizarMkdisk(args)
 		return fmt.Sprintf("%v", resultado), err
 	},
-	"fdisk": func(args []string) (string, error) {
+	"fdisk": func(sesion *globals.Sesion, args []string) (string, error) {
 		resultado, err := instrucciones.AnalizarFdisk(args)
 		return fmt.Sprintf("%v", resultado), err
 	},
-	"mount": func(args []string) (string, error) {
+	"mount": func(sesion *globals.Sesion, args []string) (string, error) {
 		resultado, err := instrucciones.AnalizarMount(args)
 		return fmt.Sprintf("%v", resultado), err
 	},
-	"mounted": func(args []string) (string, error) {
+	"mounted": func(sesion *globals.Sesion, args []string) (string, error) {
 		resultado, err := instrucciones.Mounted(args)
 		return fmt.Sprintf("%v", resultado), err
 	},
-	"unmount": func(args []string) (string, error) {
+	"unmount": func(sesion *globals.Sesion, args []string) (string, error) {
 		result, err := instrucciones.AnalizarUnmount(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkfs": func(args []string) (string, error) {
+	"mkfs": func(sesion *globals.Sesion, args []string) (string, error) {
 		result, err := instrucciones.AnalizarMkfs(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rep": func(args []string) (string, error) {
+	"rep": func(sesion *globals.Sesion, args []string) (string, error) {
 		result, err := reportes.AnalizarRep(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"login": func(args []string) (string, error) {
-		result, err := usuarios.AnalizarLogin(args)
+	"login": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := usuarios.AnalizarLogin(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"logout": func(args []string) (string, error) {
-		result, err := usuarios.AnalizarLogout(args)
+	"logout": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := usuarios.AnalizarLogout(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkgrp": func(args []string) (string, error) {
-		result, err := usuarios.AnalizarMkgrp(args)
+	"mkgrp": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := usuarios.AnalizarMkgrp(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rmgrp": func(args []string) (string, error) {
-		result, err := usuarios.AnalizarRmgrp(args)
+	"rmgrp": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := usuarios.AnalizarRmgrp(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkusr": func(args []string) (string, error) {
-		result, err := usuarios.AnalizarMkusr(args)
+	"mkusr": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := usuarios.AnalizarMkusr(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rmusr": func(args []string) (string, error) {
-		result, err := usuarios.AnalizarRmusr(args)
+	"rmusr": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := usuarios.AnalizarRmusr(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"chgrp": func(args []string) (string, error) {
-		result, err := usuarios.AnalizarChgrp(args)
+	"passwd": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := usuarios.AnalizarPasswd(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkfile": func(args []string) (string, error) {
-		result, err := comandos.AnalizarMkfile(args)
+	"chgrp": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := usuarios.AnalizarChgrp(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkdir": func(args []string) (string, error) {
-		result, err := comandos.AnalizarMkdir(args)
+	"mkfile": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarMkfile(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"cat": func(args []string) (string, error) {
-		result, err := comandos.AnalizarCat(args)
+	"mkdir": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarMkdir(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rmdisk": func(args []string) (string, error) {
+	"cat": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarCat(sesion, args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"rmdisk": func(sesion *globals.Sesion, args []string) (string, error) {
 		result, err := instrucciones.AnalizarRmdisk(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rename": func(args []string) (string, error) {
-		result, err := comandos.AnalizarRename(args)
+	"rename": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarRename(sesion, args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"copy": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarCopy(sesion, args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"move": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarMove(sesion, args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"chmod": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarChmod(sesion, args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"chown": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarChown(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"edit": func(args []string) (string, error) {
-		result, err := comandos.AnalizarEdit(args)
+	"edit": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarEdit(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"find": func(args []string) (string, error) {
-		result, err := comandos.AnalizarFind(args)
+	"find": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarFind(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"remove": func(args []string) (string, error) {
-		result, err := comandos.AnalizarRemove(args)
+	"remove": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarRemove(sesion, args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"lsblk": func(args []string) (string, error) {
+	"lsblk": func(sesion *globals.Sesion, args []string) (string, error) {
 		result, err := instrucciones.AnalizarListPartitions(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"journaling": func(args []string) (string, error) {
+	"journaling": func(sesion *globals.Sesion, args []string) (string, error) {
 		result, err := comandos.AnalizarJournaling(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"loss": func(args []string) (string, error) {
+	"loss": func(sesion *globals.Sesion, args []string) (string, error) {
 		result, err := comandos.AnalizarLoss(args)
 		return result, err
 	},
-	"recovery": func(args []string) (string, error) {
+	"recovery": func(sesion *globals.Sesion, args []string) (string, error) {
 		result, err := comandos.AnalizarRecovery(args)
 		return result, err
 	},
+	"fsck": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarFsck(args)
+		return result, err
+	},
+	"snapshot": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarSnapshot(sesion, args)
+		return result, err
+	},
+	"undo": func(sesion *globals.Sesion, args []string) (string, error) {
+		result, err := comandos.AnalizarUndo(args)
+		return result, err
+	},
 }
 
-func Analizador(entrada string) (string, error) {
-	entrada = strings.TrimSpace(entrada)
+// Comandos que además de la salida en texto devuelven datos estructurados
+var mapaDatos = map[string]func(*globals.Sesion, []string) (string, interface{}, error){
+	"lsblk": func(_ *globals.Sesion, args []string) (string, interface{}, error) {
+		return instrucciones.AnalizarListPartitionsConDatos(args)
+	},
+	"find": comandos.AnalizarFindConDatos,
+	"journaling": func(_ *globals.Sesion, args []string) (string, interface{}, error) {
+		return comandos.AnalizarJournalingConDatos(args)
+	},
+	"fsck": func(_ *globals.Sesion, args []string) (string, interface{}, error) {
+		return comandos.AnalizarFsckConDatos(args)
+	},
+}
 
-	if entrada == "" {
-		return "", nil
+// Resultado describe la ejecución de una línea para las respuestas en formato JSON
+type Resultado struct {
+	Linea   int         `json:"line"`
+	Comando string      `json:"command"`
+	Args    []string    `json:"args"`
+	Ok      bool        `json:"ok"`
+	Salida  string      `json:"output"`
+	Error   string      `json:"error"`
+	Datos   interface{} `json:"data"`
+}
+
+// AnalizarDetallado ejecuta una línea con el usuario de la sesión y devuelve su resultado
+// estructurado, o nil si la línea está vacía o es un comentario
+func AnalizarDetallado(sesion *globals.Sesion, entrada string) *Resultado {
+	tokens, err := utilidades.Tokenizar(entrada)
+	if err != nil {
+		return &Resultado{Args: []string{}, Error: err.Error()}
+	}
+	if len(tokens) == 0 {
+		return nil
 	}
 
-	if strings.HasPrefix(entrada, "#") {
-		return "", nil
+	nombre := strings.ToLower(tokens[0])
+	resultado := &Resultado{Comando: nombre, Args: tokens[1:]}
+
+	var salida string
+	if funcionDatos, existe := mapaDatos[nombre]; existe {
+		if err := validarSoloLectura(sesion, nombre, tokens[1:]); err != nil {
+			resultado.Error = err.Error()
+			return resultado
+		}
+		liberar := bloquearComando(sesion, nombre, tokens[1:])
+		salida, resultado.Datos, err = funcionDatos(sesion, tokens[1:])
+		liberar()
+	} else {
+		salida, err = Analizador(sesion, entrada)
 	}
 
-	tokens := strings.Fields(entrada)
+	if err != nil {
+		resultado.Error = err.Error()
+		resultado.Datos = nil
+	} else {
+		resultado.Ok = true
+		resultado.Salida = salida
+	}
+
+	return resultado
+}
+
+// Analizador ejecuta una línea con el usuario de la sesión; login y logout lo cambian
+func Analizador(sesion *globals.Sesion, entrada string) (string, error) {
+	tokens, err := utilidades.Tokenizar(entrada)
+	if err != nil {
+		return "", err
+	}
 	if len(tokens) == 0 {
-		return "", errors.New("no se proporcionó ningún comando")
+		return "", nil
 	}
 
-	funcionComando, existe := mapaComandos[tokens[0]]
+	nombre := strings.ToLower(tokens[0])
+	funcionComando, existe := mapaComandos[nombre]
 	if !existe {
-		switch tokens[0] {
+		switch nombre {
 		case "clear":
 			return limpiarTerminal()
 		case "exit":
@@ -148,7 +240,14 @@ func Analizador(entrada string) (string, error) {
 		}
 	}
 
-	return funcionComando(tokens[1:])
+	if err := validarSoloLectura(sesion, nombre, tokens[1:]); err != nil {
+		return "", err
+	}
+
+	liberar := bloquearComando(sesion, nombre, tokens[1:])
+	defer liberar()
+
+	return funcionComando(sesion, tokens[1:])
 }
 
 func limpiarTerminal() (string, error) {
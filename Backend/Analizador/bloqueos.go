@@ -0,0 +1,143 @@
+package analizador
+
+import (
+	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"strings"
+)
+
+// alcanceBloqueo indica qué recurso hay que bloquear antes de ejecutar un comando
+type alcanceBloqueo int
+
+const (
+	// el disco indicado con -path (comandos que crean o reescriben el MBR/EBR)
+	discoPorRuta alcanceBloqueo = iota + 1
+	// el disco que contiene la partición indicada con -id
+	discoPorId
+	// la partición indicada con -id
+	particionPorId
+	// la partición de la sesión activa
+	particionDeSesion
+)
+
+type reglaBloqueo struct {
+	alcance   alcanceBloqueo
+	escritura bool
+}
+
+// Los comandos que no aparecen (mounted, logout, ...) no tocan los discos
+var reglasBloqueo = map[string]reglaBloqueo{
+	"mkdisk":  {discoPorRuta, true},
+	"rmdisk":  {discoPorRuta, true},
+	"fdisk":   {discoPorRuta, true},
+	"mount":   {discoPorRuta, true},
+	"lsblk":   {discoPorRuta, false},
+	"unmount": {discoPorId, true},
+	// restore reemplaza el archivo del disco completo
+	"snapshot": {discoPorId, true},
+
+	"mkfs":       {particionPorId, true},
+	"loss":       {particionPorId, true},
+	"recovery":   {particionPorId, true},
+	"undo":       {particionPorId, true},
+	"fsck":       {particionPorId, true},
+	"journaling": {particionPorId, false},
+	"rep":        {particionPorId, false},
+	// migra a pbkdf2 las contraseñas guardadas en texto plano, reescribiendo users.txt
+	"login": {particionPorId, true},
+
+	"mkgrp":  {particionDeSesion, true},
+	"rmgrp":  {particionDeSesion, true},
+	"mkusr":  {particionDeSesion, true},
+	"rmusr":  {particionDeSesion, true},
+	"passwd": {particionDeSesion, true},
+	"chgrp":  {particionDeSesion, true},
+	"mkfile": {particionDeSesion, true},
+	"mkdir":  {particionDeSesion, true},
+	"remove": {particionDeSesion, true},
+	"rename": {particionDeSesion, true},
+	"copy":   {particionDeSesion, true},
+	"move":   {particionDeSesion, true},
+	"chmod":  {particionDeSesion, true},
+	"chown":  {particionDeSesion, true},
+	"edit":   {particionDeSesion, true},
+	"cat":    {particionDeSesion, false},
+	"find":   {particionDeSesion, false},
+}
+
+// validarSoloLectura rechaza los comandos que escriben en una partición montada con mount -ro.
+// fsck sin -repair y snapshot salvo restore solo leen la partición, aunque se bloqueen como escritura;
+// login tampoco escribe en una partición de solo lectura, donde no migra las contraseñas.
+func validarSoloLectura(sesion *globals.Sesion, nombre string, args []string) error {
+	regla, existe := reglasBloqueo[nombre]
+	if !existe || !regla.escritura || nombre == "login" {
+		return nil
+	}
+
+	params, err := utilidades.ParsearArgumentos(args)
+	if err != nil {
+		return nil
+	}
+	if nombre == "fsck" && !params.Banderas["repair"] {
+		return nil
+	}
+	if nombre == "snapshot" {
+		if !strings.EqualFold(params.Valor("action"), "restore") {
+			return nil
+		}
+		return globals.ValidarEscritura(params.Valor("id"))
+	}
+
+	switch regla.alcance {
+	case particionPorId:
+		return globals.ValidarEscritura(params.Valor("id"))
+	case particionDeSesion:
+		if sesion.EstaLogueado() {
+			return globals.ValidarEscritura(sesion.Usuario.Id)
+		}
+	}
+	return nil
+}
+
+// bloquearComando toma el bloqueo que necesita el comando y devuelve la función que lo libera.
+// Si no se puede determinar el recurso (parámetros inválidos, partición no montada, ...)
+// no se bloquea nada y el propio comando reportará el error.
+func bloquearComando(sesion *globals.Sesion, nombre string, args []string) func() {
+	sinBloqueo := func() {}
+
+	regla, existe := reglasBloqueo[nombre]
+	if !existe {
+		return sinBloqueo
+	}
+
+	params, err := utilidades.ParsearArgumentos(args)
+	if err != nil {
+		return sinBloqueo
+	}
+
+	switch regla.alcance {
+	case discoPorRuta:
+		if ruta := params.Valor("path"); ruta != "" {
+			return globals.Discos.BloquearDisco(ruta, regla.escritura)
+		}
+	case discoPorId:
+		if ruta, montada := globals.RutaMontaje(params.Valor("id")); montada {
+			return globals.Discos.BloquearDisco(ruta, regla.escritura)
+		}
+	case particionPorId:
+		id := params.Valor("id")
+		if ruta, montada := globals.RutaMontaje(id); montada {
+			return globals.Discos.BloquearParticion(ruta, id, regla.escritura)
+		}
+	case particionDeSesion:
+		if !sesion.EstaLogueado() {
+			return sinBloqueo
+		}
+		id := sesion.Usuario.Id
+		if ruta, montada := globals.RutaMontaje(id); montada {
+			return globals.Discos.BloquearParticion(ruta, id, regla.escritura)
+		}
+	}
+
+	return sinBloqueo
+}
@@ -7,10 +7,20 @@ import (
 	"strings"
 )
 
+// BlockSize es el tamaño de bloque por defecto de mkfs
 const BlockSize = 64
 
+// TamanosBloque son los tamaños de bloque que acepta mkfs
+var TamanosBloque = []int32{64, 128, 256, 512, 1024}
+
+// ArchivoBloque guarda el contenido de un archivo; su tamaño es el S_block_size de la partición
 type ArchivoBloque struct {
-	B_content [BlockSize]byte
+	B_content []byte
+}
+
+// NuevoBloqueArchivo devuelve un bloque de archivo vacío del tamaño de bloque de la partición
+func (sb *Superbloque) NuevoBloqueArchivo() *ArchivoBloque {
+	return &ArchivoBloque{B_content: make([]byte, sb.S_block_size)}
 }
 
 func (fb *ArchivoBloque) Encode(file *os.File, offset int64) error {
@@ -22,7 +32,10 @@ func (fb *ArchivoBloque) Encode(file *os.File, offset int64) error {
 }
 
 func (fb *ArchivoBloque) Decode(file *os.File, offset int64) error {
-	err := utilidades.LeerDesdeArchivo(file, offset, &fb.B_content)
+	if len(fb.B_content) == 0 {
+		return fmt.Errorf("el bloque de archivo no tiene tamaño; créelo con NuevoBloqueArchivo")
+	}
+	err := utilidades.LeerDesdeArchivo(file, offset, fb.B_content)
 	if err != nil {
 		return fmt.Errorf("error reading FileBlock from file: %w", err)
 	}
@@ -41,8 +54,8 @@ func (fb *ArchivoBloque) GetContent() string {
 }
 
 func (fb *ArchivoBloque) SetContent(content string) error {
-	if len(content) > BlockSize {
-		return fmt.Errorf("el tamaño del contenido excede el tamaño del bloque de %d bytes", BlockSize)
+	if len(content) > len(fb.B_content) {
+		return fmt.Errorf("el tamaño del contenido excede el tamaño del bloque de %d bytes", len(fb.B_content))
 	}
 	fb.ClearContent()
 	copy(fb.B_content[:], content)
@@ -50,7 +63,7 @@ func (fb *ArchivoBloque) SetContent(content string) error {
 }
 
 func (fb *ArchivoBloque) EspacioDisponible() int {
-	return BlockSize - fb.EspacioUsado()
+	return len(fb.B_content) - fb.EspacioUsado()
 }
 
 func (fb *ArchivoBloque) TieneEspacio() bool {
@@ -81,8 +94,8 @@ func (fb *ArchivoBloque) ClearContent() {
 	}
 }
 
-func NewArchivoBloque(content string) (*ArchivoBloque, error) {
-	fb := &ArchivoBloque{}
+func (sb *Superbloque) NewArchivoBloque(content string) (*ArchivoBloque, error) {
+	fb := sb.NuevoBloqueArchivo()
 	err := fb.SetContent(content)
 	if err != nil {
 		return nil, err
@@ -90,14 +103,14 @@ func NewArchivoBloque(content string) (*ArchivoBloque, error) {
 	return fb, nil
 }
 
-func SplitContent(content string) ([]*ArchivoBloque, error) {
+func (sb *Superbloque) SplitContent(content string) ([]*ArchivoBloque, error) {
 	var blocks []*ArchivoBloque
 	for len(content) > 0 {
-		end := BlockSize
-		if len(content) < BlockSize {
+		end := int(sb.S_block_size)
+		if len(content) < end {
 			end = len(content)
 		}
-		fb, err := NewArchivoBloque(content[:end])
+		fb, err := sb.NewArchivoBloque(content[:end])
 		if err != nil {
 			return nil, err
 		}
@@ -32,7 +32,7 @@ func (sb *Superbloque) crearArchivoEnInodo(archivo *os.File, indiceInodo int32,
 			}
 			return nil
 		}
-		bloque := &FolderBlock{}
+		bloque := sb.NuevoBloqueCarpeta()
 		err := bloque.Decode(archivo, int64(sb.S_block_start+(indiceBloque*sb.S_block_size)))
 		if err != nil {
 			return fmt.Errorf("error al deserializar bloque %d: %v", indiceBloque, err)
@@ -43,7 +43,9 @@ func (sb *Superbloque) crearArchivoEnInodo(archivo *os.File, indiceInodo int32,
 				fmt.Printf("El inodo %d ya está ocupado, continuando.\n", contenido.B_inodo)
 				continue
 			}
-			copy(contenido.B_name[:], []byte(destArchivo))
+			if err := sb.AsignarNombreEntrada(archivo, &contenido, destArchivo); err != nil {
+				return err
+			}
 			contenido.B_inodo = sb.S_inodes_count
 			bloque.B_content[indiceContenido] = contenido
 			err = bloque.Encode(archivo, int64(sb.S_block_start+(indiceBloque*sb.S_block_size)))
@@ -62,22 +64,21 @@ func (sb *Superbloque) crearArchivoEnInodo(archivo *os.File, indiceInodo int32,
 				I_type:  [1]byte{'1'},
 				I_perm:  [3]byte{'6', '6', '4'},
 			}
+			if err := sb.PrepararAsignacion(archivo, int32(len(contenidoArchivo))); err != nil {
+				return err
+			}
 			for i := 0; i < len(contenidoArchivo); i++ {
-				inodoArchivo.I_block[i] = sb.S_blocks_count
-				bloqueArchivo := &ArchivoBloque{
-					B_content: [64]byte{},
+				indiceBloqueArchivo, err := inodoArchivo.AddBlock(archivo, sb)
+				if err != nil {
+					return fmt.Errorf("error al asignar bloque de archivo: %v", err)
 				}
+				bloqueArchivo := sb.NuevoBloqueArchivo()
 				copy(bloqueArchivo.B_content[:], contenidoArchivo[i])
-				err = bloqueArchivo.Encode(archivo, int64(sb.S_first_blo))
+				err = bloqueArchivo.Encode(archivo, int64(sb.S_block_start+(indiceBloqueArchivo*sb.S_block_size)))
 				if err != nil {
 					return fmt.Errorf("error al serializar bloque de archivo: %v", err)
 				}
 				fmt.Printf("Bloque de archivo '%s' serializado correctamente.\n", destArchivo)
-				err = sb.UpdateBitmapBlock(archivo, sb.S_blocks_count, true)
-				if err != nil {
-					return fmt.Errorf("error al actualizar bitmap de bloque: %v", err)
-				}
-				sb.UpdateSuperblockAfterBlockAllocation()
 			}
 			err = inodoArchivo.Encode(archivo, int64(sb.S_first_ino))
 			if err != nil {
@@ -162,14 +163,7 @@ func (sb *Superbloque) CrearNuevoBloqueCarpeta(archivo *os.File, indiceInodo int
 				fmt.Printf("Error al conseguir el id del padre para crear la carpeta que contendrá el archivo: %s", destArchivo)
 				return err
 			}
-			bloqueCarpeta := &FolderBlock{
-				B_content: [4]FolderContent{
-					{B_name: [12]byte{'.'}, B_inodo: int32(indiceInodoPadre)},
-					{B_name: [12]byte{'.', '.'}, B_inodo: indiceInodo},
-					{B_name: [12]byte{'-'}, B_inodo: -1},
-					{B_name: [12]byte{'-'}, B_inodo: -1},
-				},
-			}
+			bloqueCarpeta := sb.NewFolderBlock(int32(indiceInodoPadre), indiceInodo, nil)
 			fmt.Printf("Serializando el bloque de la carpeta que va a contener el archivo '%s'\n", destArchivo)
 			err = bloqueCarpeta.Encode(archivo, int64(sb.S_first_blo))
 			if err != nil {
@@ -196,7 +190,7 @@ func (sb *Superbloque) CrearNuevoBloqueCarpeta(archivo *os.File, indiceInodo int
 	}
 	return nil
 }
-func (sb *Superbloque) deleteFileInInode(file *os.File, inodeIndex int32, fileName string, parentPath ...string) error {
+func (sb *Superbloque) deleteFileInInode(file *os.File, inodeIndex int32, fileName string) error {
 	dirInode := &Inodo{}
 	err := dirInode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
 	if err != nil {
@@ -213,7 +207,7 @@ func (sb *Superbloque) deleteFileInInode(file *os.File, inodeIndex int32, fileNa
 	}
 
 	for _, blockIndex := range blockIndexes {
-		block := &FolderBlock{}
+		block := sb.NuevoBloqueCarpeta()
 		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
 
 		if err := block.Decode(file, blockOffset); err != nil {
@@ -221,7 +215,7 @@ func (sb *Superbloque) deleteFileInInode(file *os.File, inodeIndex int32, fileNa
 		}
 
 		for i, content := range block.B_content {
-			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			contentName := sb.NombreEntrada(file, content)
 
 			if content.B_inodo != -1 && strings.EqualFold(contentName, fileName) {
 				fileInodeIndex := content.B_inodo
@@ -237,39 +231,6 @@ func (sb *Superbloque) deleteFileInInode(file *os.File, inodeIndex int32, fileNa
 					return fmt.Errorf("el inodo %d no es un archivo sino de tipo %c", fileInodeIndex, fileInode.I_type[0])
 				}
 
-				if sb.S_filesystem_type == 3 {
-					var fullPath string
-					if len(parentPath) > 0 && parentPath[0] != "" {
-						fullPath = parentPath[0] + "/" + fileName
-					} else {
-						fullPath = "/" + fileName
-					}
-
-					journaling_start := int64(sb.JournalStart())
-
-					fileData, err := fileInode.ReadData(file, sb)
-					fileContent := ""
-					if err != nil {
-						fmt.Printf("Error leyendo contenido del archivo para journal: %v\n", err)
-					} else {
-						fileContent = string(fileData)
-					}
-
-					if err := AddJournalEntry(
-						file,
-						journaling_start,
-						JOURNAL_ENTRIES,
-						"rm",
-						fullPath,
-						fileContent,
-						sb,
-					); err != nil {
-						fmt.Printf("Advertencia: error registrando operación en journal: %v\n", err)
-					} else {
-						fmt.Printf("Operación 'rm %s' registrada en journal correctamente\n", fullPath)
-					}
-				}
-
 				if err := fileInode.FreeAllBlocks(file, sb); err != nil {
 					return fmt.Errorf("error liberando bloques del archivo: %w", err)
 				}
@@ -279,6 +240,9 @@ func (sb *Superbloque) deleteFileInInode(file *os.File, inodeIndex int32, fileNa
 				}
 				sb.UpdateSuperblockAfterInodeDeallocation()
 
+				if err := sb.LiberarNombreEntrada(file, content); err != nil {
+					return err
+				}
 				block.B_content[i] = FolderContent{B_name: [12]byte{'-'}, B_inodo: -1}
 				if err := block.Encode(file, blockOffset); err != nil {
 					return fmt.Errorf("error actualizando bloque de directorio: %w", err)
@@ -323,13 +287,13 @@ func (sb *Superbloque) DeleteFile(file *os.File, parentsDir []string, fileName s
 				break
 			}
 
-			block := &FolderBlock{}
+			block := sb.NuevoBloqueCarpeta()
 			if err := block.Decode(file, int64(sb.S_block_start+blockIndex*sb.S_block_size)); err != nil {
 				return fmt.Errorf("error deserializando bloque %d: %w", blockIndex, err)
 			}
 
 			for _, content := range block.B_content {
-				contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+				contentName := sb.NombreEntrada(file, content)
 
 				if content.B_inodo != -1 && strings.EqualFold(contentName, dirName) {
 					subDirInode := &Inodo{}
@@ -12,6 +12,9 @@ const (
 )
 
 func (sb *Superbloque) CreateBitMaps(file *os.File) error {
+	// los bitmaps en memoria de una partición formateada de nuevo ya no sirven
+	DescartarBitmaps(file.Name())
+
 	err := sb.createBitmap(file, sb.S_bm_inode_start, sb.S_inodes_count+sb.S_free_inodes_count, false)
 	if err != nil {
 		return fmt.Errorf("error creando bitmap de inodos: %w", err)
@@ -52,79 +55,32 @@ func (sb *Superbloque) createBitmap(file *os.File, start int32, count int32, occ
 }
 
 func (sb *Superbloque) UpdateBitmapInode(file *os.File, position int32, occupied bool) error {
-	return sb.updateBitmap(file, sb.S_bm_inode_start, position, occupied)
-}
-
-func (sb *Superbloque) UpdateBitmapBlock(file *os.File, position int32, occupied bool) error {
-	return sb.updateBitmap(file, sb.S_bm_block_start, position, occupied)
-}
-
-func (sb *Superbloque) updateBitmap(file *os.File, start int32, position int32, occupied bool) error {
-	byteIndex := position / 8
-	bitOffset := position % 8
-
-	_, err := file.Seek(int64(start)+int64(byteIndex), 0)
-	if err != nil {
-		return fmt.Errorf("error buscando la posición en el bitmap: %w", err)
-	}
-
-	var byteVal byte
-	err = binary.Read(file, binary.LittleEndian, &byteVal)
+	bm, err := sb.bitmapsEnMemoria(file)
 	if err != nil {
-		return fmt.Errorf("error leyendo el byte del bitmap: %w", err)
-	}
-
-	if occupied {
-		byteVal |= (1 << bitOffset)
-	} else {
-		byteVal &= ^(1 << bitOffset)
+		return err
 	}
 
-	_, err = file.Seek(int64(start)+int64(byteIndex), 0)
-	if err != nil {
-		return fmt.Errorf("error buscando la posición en el bitmap para escribir: %w", err)
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+	if position < 0 || position >= bm.totalInodos {
+		return fmt.Errorf("inodo %d fuera del bitmap", position)
 	}
-
-	err = binary.Write(file, binary.LittleEndian, &byteVal)
-	if err != nil {
-		return fmt.Errorf("error escribiendo el byte actualizado del bitmap: %w", err)
+	if !occupied && position < bm.primerInodoLibre {
+		bm.primerInodoLibre = position
 	}
-
-	return nil
+	return bm.cambiarBit(file, bm.inodos, sb.S_bm_inode_start, position, occupied)
 }
 
-func (sb *Superbloque) isBlockFree(file *os.File, start int32, position int32) (bool, error) {
-	byteIndex := position / 8
-	bitOffset := position % 8
-
-	_, err := file.Seek(int64(start)+int64(byteIndex), 0)
-	if err != nil {
-		return false, fmt.Errorf("error buscando la posición en el bitmap: %w", err)
-	}
-
-	var byteVal byte
-	err = binary.Read(file, binary.LittleEndian, &byteVal)
-	if err != nil {
-		return false, fmt.Errorf("error leyendo el byte del bitmap: %w", err)
-	}
-
-	return (byteVal & (1 << bitOffset)) == 0, nil
-}
-
-func (sb *Superbloque) isInodeFree(file *os.File, start int32, position int32) (bool, error) {
-	byteIndex := position / 8
-	bitOffset := position % 8
-
-	_, err := file.Seek(int64(start)+int64(byteIndex), 0)
+func (sb *Superbloque) UpdateBitmapBlock(file *os.File, position int32, occupied bool) error {
+	bm, err := sb.bitmapsEnMemoria(file)
 	if err != nil {
-		return false, fmt.Errorf("error buscando el byte en el bitmap de inodos: %w", err)
+		return err
 	}
 
-	var byteVal byte
-	err = binary.Read(file, binary.LittleEndian, &byteVal)
-	if err != nil {
-		return false, fmt.Errorf("error leyendo el byte del bitmap de inodos: %w", err)
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+	if position < 0 || position >= bm.totalBloques {
+		return fmt.Errorf("bloque %d fuera del bitmap", position)
 	}
-
-	return (byteVal & (1 << bitOffset)) == 0, nil
+	return bm.cambiarBit(file, bm.bloques, sb.S_bm_block_start, position, occupied)
 }
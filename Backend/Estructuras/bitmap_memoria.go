@@ -0,0 +1,281 @@
+package estructuras
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Ajustes con los que se eligen los tramos de bloques libres; son los mismos valores de Part_fit
+const (
+	AjustePrimero byte = 'F'
+	AjusteMejor   byte = 'B'
+	AjustePeor    byte = 'W'
+)
+
+// bitmapsParticion es la copia en memoria de los bitmaps de una partición. Se carga una sola
+// vez y cada cambio se escribe también en el disco, así que los reportes y fsck pueden seguir
+// leyendo el archivo.
+type bitmapsParticion struct {
+	mutex        sync.Mutex
+	inodos       []byte
+	bloques      []byte
+	totalInodos  int32
+	totalBloques int32
+
+	// ningún inodo antes de primerInodoLibre está libre
+	primerInodoLibre int32
+
+	// tramo de bloques libres del que se siguen tomando bloques
+	tramoSiguiente int32
+	tramoFin       int32
+	// cantidad de bloques que se pedirán para el próximo tramo
+	pedido int32
+}
+
+var (
+	mutexBitmaps     sync.Mutex
+	bitmapsCargados  = map[string]*bitmapsParticion{}
+	ajustesParticion = map[string]byte{}
+
+	// los benchmarks la desactivan para medir la asignación leyendo los bitmaps del disco
+	// cada vez, como antes de tenerlos en memoria
+	usarCacheBitmaps = true
+)
+
+func rutaBitmaps(ruta string) string {
+	if absoluta, err := filepath.Abs(ruta); err == nil {
+		return absoluta
+	}
+	return filepath.Clean(ruta)
+}
+
+// cada partición de un disco tiene su propio S_bm_inode_start
+func claveBitmaps(ruta string, sb *Superbloque) string {
+	return fmt.Sprintf("%s#%d", rutaBitmaps(ruta), sb.S_bm_inode_start)
+}
+
+// RegistrarAjuste guarda el Part_fit de la partición para elegir los tramos de bloques
+func (sb *Superbloque) RegistrarAjuste(ruta string, ajuste byte) {
+	mutexBitmaps.Lock()
+	defer mutexBitmaps.Unlock()
+	ajustesParticion[claveBitmaps(ruta, sb)] = ajuste
+}
+
+// DescartarBitmaps olvida los bitmaps en memoria de todas las particiones del disco; se usa
+// cuando los bitmaps se reescriben completos (mkfs, loss, fsck -repair) o el disco deja de usarse
+func DescartarBitmaps(ruta string) {
+	prefijo := rutaBitmaps(ruta) + "#"
+
+	mutexBitmaps.Lock()
+	defer mutexBitmaps.Unlock()
+	for clave := range bitmapsCargados {
+		if strings.HasPrefix(clave, prefijo) {
+			delete(bitmapsCargados, clave)
+		}
+	}
+}
+
+func (sb *Superbloque) bitmapsEnMemoria(file *os.File) (*bitmapsParticion, error) {
+	clave := claveBitmaps(file.Name(), sb)
+
+	mutexBitmaps.Lock()
+	defer mutexBitmaps.Unlock()
+	if bm, existe := bitmapsCargados[clave]; existe {
+		return bm, nil
+	}
+
+	bm := &bitmapsParticion{
+		totalInodos:  sb.TotalInodos(),
+		totalBloques: sb.TotalBloques(),
+		pedido:       1,
+	}
+	bm.inodos = make([]byte, (bm.totalInodos+7)/8)
+	if _, err := file.ReadAt(bm.inodos, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, fmt.Errorf("error leyendo el bitmap de inodos: %w", err)
+	}
+	bm.bloques = make([]byte, (bm.totalBloques+7)/8)
+	if _, err := file.ReadAt(bm.bloques, int64(sb.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error leyendo el bitmap de bloques: %w", err)
+	}
+
+	if usarCacheBitmaps {
+		bitmapsCargados[clave] = bm
+	}
+	return bm, nil
+}
+
+func (sb *Superbloque) ajuste(file *os.File) byte {
+	mutexBitmaps.Lock()
+	defer mutexBitmaps.Unlock()
+	if ajuste, existe := ajustesParticion[claveBitmaps(file.Name(), sb)]; existe {
+		return ajuste
+	}
+	return AjustePrimero
+}
+
+// PrepararAsignacion avisa que se van a pedir cantidad bloques seguidos (por ejemplo los de
+// un archivo nuevo) para que el siguiente tramo se elija con ese tamaño
+func (sb *Superbloque) PrepararAsignacion(file *os.File, cantidad int32) error {
+	bm, err := sb.bitmapsEnMemoria(file)
+	if err != nil {
+		return err
+	}
+
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+	if cantidad < 1 {
+		cantidad = 1
+	}
+	bm.pedido = cantidad
+	bm.tramoSiguiente, bm.tramoFin = 0, 0
+	return nil
+}
+
+func ocupado(mapa []byte, posicion int32) bool {
+	return mapa[posicion/8]&(1<<(posicion%8)) != 0
+}
+
+// siguienteLibre devuelve la primera posición libre desde inicio, o -1 si no hay.
+// Salta de a un byte los tramos completamente ocupados.
+func siguienteLibre(mapa []byte, inicio, total int32) int32 {
+	for posicion := inicio; posicion < total; {
+		if posicion%8 == 0 && mapa[posicion/8] == 0xFF {
+			posicion += 8
+			continue
+		}
+		if !ocupado(mapa, posicion) {
+			return posicion
+		}
+		posicion++
+	}
+	return -1
+}
+
+// finTramo devuelve la primera posición ocupada desde inicio (o total)
+func finTramo(mapa []byte, inicio, total int32) int32 {
+	for posicion := inicio; posicion < total; {
+		if posicion%8 == 0 && mapa[posicion/8] == 0x00 {
+			posicion += 8
+			continue
+		}
+		if ocupado(mapa, posicion) {
+			return posicion
+		}
+		posicion++
+	}
+	return total
+}
+
+// elegirTramo busca un tramo de al menos pedido bloques libres según el ajuste. Si ninguno
+// alcanza, devuelve el más grande que haya para que la asignación continúe en otro tramo.
+func (bm *bitmapsParticion) elegirTramo(ajuste byte, pedido int32) (int32, int32) {
+	mejorInicio, mejorFin := int32(-1), int32(-1)
+	mayorInicio, mayorFin := int32(-1), int32(-1)
+
+	for inicio := siguienteLibre(bm.bloques, 0, bm.totalBloques); inicio != -1; {
+		fin := finTramo(bm.bloques, inicio, bm.totalBloques)
+		largo := fin - inicio
+
+		if mayorInicio == -1 || largo > mayorFin-mayorInicio {
+			mayorInicio, mayorFin = inicio, fin
+		}
+		if largo >= pedido {
+			switch {
+			case ajuste == AjustePrimero:
+				return inicio, fin
+			case mejorInicio == -1,
+				ajuste == AjusteMejor && largo < mejorFin-mejorInicio,
+				ajuste == AjustePeor && largo > mejorFin-mejorInicio:
+				mejorInicio, mejorFin = inicio, fin
+			}
+		}
+
+		if fin >= bm.totalBloques {
+			break
+		}
+		inicio = siguienteLibre(bm.bloques, fin, bm.totalBloques)
+	}
+
+	if mejorInicio != -1 {
+		return mejorInicio, mejorFin
+	}
+	return mayorInicio, mayorFin
+}
+
+// reservarBloque devuelve el siguiente bloque libre del tramo actual y elige uno nuevo
+// cuando se termina o cuando otra asignación ocupó el bloque que seguía
+func (bm *bitmapsParticion) reservarBloque(ajuste byte) int32 {
+	if bm.tramoSiguiente >= bm.tramoFin || ocupado(bm.bloques, bm.tramoSiguiente) {
+		inicio, fin := bm.elegirTramo(ajuste, bm.pedido)
+		if inicio == -1 {
+			return -1
+		}
+		bm.tramoSiguiente, bm.tramoFin = inicio, fin
+		// lo que falte después de este tramo se pide como un tramo nuevo
+		bm.pedido -= fin - inicio
+		if bm.pedido < 1 {
+			bm.pedido = 1
+		}
+	}
+
+	bloque := bm.tramoSiguiente
+	bm.tramoSiguiente++
+	return bloque
+}
+
+func (bm *bitmapsParticion) reservarInodo() int32 {
+	inodo := siguienteLibre(bm.inodos, bm.primerInodoLibre, bm.totalInodos)
+	if inodo != -1 {
+		bm.primerInodoLibre = inodo + 1
+	}
+	return inodo
+}
+
+// cambiarBit actualiza el bit en memoria y escribe el byte en el disco
+func (bm *bitmapsParticion) cambiarBit(file *os.File, mapa []byte, inicio int32, posicion int32, ocupar bool) error {
+	byteIndex := posicion / 8
+	if ocupar {
+		mapa[byteIndex] |= 1 << (posicion % 8)
+	} else {
+		mapa[byteIndex] &^= 1 << (posicion % 8)
+	}
+
+	if _, err := file.WriteAt(mapa[byteIndex:byteIndex+1], int64(inicio)+int64(byteIndex)); err != nil {
+		return fmt.Errorf("error escribiendo el byte actualizado del bitmap: %w", err)
+	}
+	return nil
+}
+
+func ultimoOcupado(mapa []byte, total int32) int32 {
+	for posicion := total - 1; posicion >= 0; posicion-- {
+		if ocupado(mapa, posicion) {
+			return posicion
+		}
+	}
+	return -1
+}
+
+// ajustarContadores deja los contadores del superbloque cubriendo hasta el último inodo y
+// bloque ocupados en los bitmaps, como espera la asignación secuencial de mkdir y mkfile
+func (sb *Superbloque) ajustarContadores(file *os.File) error {
+	bm, err := sb.bitmapsEnMemoria(file)
+	if err != nil {
+		return err
+	}
+
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+	inodos := ultimoOcupado(bm.inodos, bm.totalInodos) + 1
+	bloques := ultimoOcupado(bm.bloques, bm.totalBloques) + 1
+
+	sb.S_inodes_count = inodos
+	sb.S_free_inodes_count = bm.totalInodos - inodos
+	sb.S_blocks_count = bloques
+	sb.S_free_blocks_count = bm.totalBloques - bloques
+	sb.S_first_ino = sb.S_inode_start + inodos*sb.S_inode_size
+	sb.S_first_blo = sb.S_block_start + bloques*sb.S_block_size
+	return nil
+}
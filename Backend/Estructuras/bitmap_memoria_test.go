@@ -0,0 +1,173 @@
+package estructuras
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// silenciarSalida descarta lo que imprimen las funciones de asignación mientras dura la prueba
+func silenciarSalida(tb testing.TB) {
+	tb.Helper()
+	salida := os.Stdout
+	nulo, err := os.Open(os.DevNull)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	os.Stdout = nulo
+	tb.Cleanup(func() {
+		os.Stdout = salida
+		nulo.Close()
+	})
+}
+
+// particionPrueba crea un archivo con una partición recién formateada que empieza en el byte 0,
+// con n inodos y tres bloques por inodo como mkfs por defecto. Con entradasJournal en 0 es EXT2;
+// si no, es EXT3 con un journal de esa cantidad de registros.
+func particionPrueba(tb testing.TB, n int32, entradasJournal int32) (*os.File, *Superbloque) {
+	tb.Helper()
+	tamanoInodo := int32(binary.Size(Inodo{}))
+	tipo := int32(2)
+	inicioBmInodos := int32(binary.Size(Superbloque{}))
+	if entradasJournal > 0 {
+		tipo = 3
+		inicioBmInodos += TamanoJournal(entradasJournal)
+	}
+	inicioBmBloques := inicioBmInodos + n
+	inicioInodos := inicioBmBloques + 3*n
+	inicioBloques := inicioInodos + tamanoInodo*n
+
+	sb := &Superbloque{
+		S_filesystem_type:   tipo | CaracteristicaNombresLargos,
+		S_free_inodes_count: n,
+		S_free_blocks_count: 3 * n,
+		S_magic:             0xEF53,
+		S_inode_size:        tamanoInodo,
+		S_block_size:        BlockSize,
+		S_first_ino:         inicioInodos,
+		S_first_blo:         inicioBloques,
+		S_bm_inode_start:    inicioBmInodos,
+		S_bm_block_start:    inicioBmBloques,
+		S_inode_start:       inicioInodos,
+		S_block_start:       inicioBloques,
+	}
+
+	file, err := os.Create(filepath.Join(tb.TempDir(), "disco.mia"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		DescartarBitmaps(file.Name())
+		file.Close()
+	})
+	if err := file.Truncate(int64(inicioBloques) + int64(3*n)*int64(BlockSize)); err != nil {
+		tb.Fatal(err)
+	}
+	if err := sb.CreateBitMaps(file); err != nil {
+		tb.Fatal(err)
+	}
+	if entradasJournal > 0 {
+		err = sb.CreateUsersFileExt3(file, entradasJournal)
+	} else {
+		err = sb.CreateUsersFile(file)
+	}
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return file, sb
+}
+
+// conYSinCache corre el benchmark con los bitmaps en memoria y leyéndolos del disco en cada
+// asignación
+func conYSinCache(b *testing.B, fn func(b *testing.B)) {
+	for _, cache := range []bool{true, false} {
+		nombre := "cache"
+		if !cache {
+			nombre = "sin_cache"
+		}
+		b.Run(nombre, func(b *testing.B) {
+			usarCacheBitmaps = cache
+			defer func() { usarCacheBitmaps = true }()
+			fn(b)
+		})
+	}
+}
+
+func BenchmarkFindNextFreeBlock(b *testing.B) {
+	conYSinCache(b, func(b *testing.B) {
+		silenciarSalida(b)
+		file, sb := particionPrueba(b, 20000, 0)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := sb.FindNextFreeBlock(file); err != nil {
+				// la partición se llenó: se vuelve a dejar libre sin contar ese tiempo
+				b.StopTimer()
+				if err := sb.CreateBitMaps(file); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+		}
+	})
+}
+
+func BenchmarkFindNextFreeInode(b *testing.B) {
+	conYSinCache(b, func(b *testing.B) {
+		silenciarSalida(b)
+		file, sb := particionPrueba(b, 20000, 0)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := sb.FindNextFreeInode(file); err != nil {
+				b.StopTimer()
+				if err := sb.CreateBitMaps(file); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+		}
+	})
+}
+
+// BenchmarkCrearArchivos crea archivos de 1 KiB en /docs, como una serie de mkfile; cada lote
+// se hace en una partición nueva para que la carpeta no crezca sin límite
+func BenchmarkCrearArchivos(b *testing.B) {
+	const lote = 20
+	contenido := make([]string, 1024/BlockSize)
+	for i := range contenido {
+		contenido[i] = strings.Repeat("x", BlockSize)
+	}
+
+	conYSinCache(b, func(b *testing.B) {
+		silenciarSalida(b)
+		var file *os.File
+		var sb *Superbloque
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if i%lote == 0 {
+				b.StopTimer()
+				file, sb = particionPrueba(b, 20000, 0)
+				if err := sb.CrearCarpeta(file, []string{"docs"}, "docs", false); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+			nombre := fmt.Sprintf("archivo%d.txt", i%lote)
+			if err := sb.CrearArchivo(file, []string{"docs"}, nombre, 1024, contenido, false); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.StopTimer()
+		for i := 0; i <= (b.N-1)%lote; i++ {
+			if _, err := sb.BuscarInodoPorRuta(file, fmt.Sprintf("/docs/archivo%d.txt", i)); err != nil {
+				b.Fatalf("no se creó el archivo: %v", err)
+			}
+		}
+	})
+}
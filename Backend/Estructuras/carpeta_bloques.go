@@ -1,14 +1,15 @@
 package estructuras
 
 import (
+	"encoding/binary"
 	"fmt"
 	utilidades "godisk/Utilidades"
 	"os"
-	"strings"
 )
 
+// FolderBlock guarda las entradas de una carpeta; caben S_block_size/16 entradas por bloque
 type FolderBlock struct {
-	B_content [4]FolderContent
+	B_content []FolderContent
 }
 
 type FolderContent struct {
@@ -16,8 +17,13 @@ type FolderContent struct {
 	B_inodo int32
 }
 
+// NuevoBloqueCarpeta devuelve un bloque de carpeta vacío del tamaño de bloque de la partición
+func (sb *Superbloque) NuevoBloqueCarpeta() *FolderBlock {
+	return &FolderBlock{B_content: make([]FolderContent, sb.S_block_size/int32(binary.Size(FolderContent{})))}
+}
+
 func (fb *FolderBlock) Encode(file *os.File, offset int64) error {
-	err := utilidades.EscribirEnArchivo(file, offset, fb)
+	err := utilidades.EscribirEnArchivo(file, offset, fb.B_content)
 	if err != nil {
 		return fmt.Errorf("error writing FolderBlock to file: %w", err)
 	}
@@ -25,7 +31,10 @@ func (fb *FolderBlock) Encode(file *os.File, offset int64) error {
 }
 
 func (fb *FolderBlock) Decode(file *os.File, offset int64) error {
-	err := utilidades.LeerDesdeArchivo(file, offset, fb)
+	if len(fb.B_content) == 0 {
+		return fmt.Errorf("el bloque de carpeta no tiene tamaño; créelo con NuevoBloqueCarpeta")
+	}
+	err := utilidades.LeerDesdeArchivo(file, offset, fb.B_content)
 	if err != nil {
 		return fmt.Errorf("error reading FolderBlock from file: %w", err)
 	}
@@ -41,8 +50,8 @@ func (fb *FolderBlock) Print() {
 	}
 }
 
-func NewFolderBlock(selfInodo, parentInodo int32, additionalContents map[string]int32) *FolderBlock {
-	fb := &FolderBlock{}
+func (sb *Superbloque) NewFolderBlock(selfInodo, parentInodo int32, additionalContents map[string]int32) *FolderBlock {
+	fb := sb.NuevoBloqueCarpeta()
 
 	copy(fb.B_content[0].B_name[:], ".")
 	fb.B_content[0].B_inodo = selfInodo
@@ -75,45 +84,3 @@ func (fb *FolderBlock) IsFull() bool {
 	}
 	return true
 }
-
-func (fb *FolderBlock) RenameInFolderBlock(oldName string, newName string) error {
-	for i := 2; i < len(fb.B_content); i++ {
-		content := &fb.B_content[i]
-		currentName := strings.Trim(string(content.B_name[:]), "\x00 ")
-
-		if strings.EqualFold(currentName, oldName) && content.B_inodo != -1 {
-			if len(newName) > 12 {
-				return fmt.Errorf("el nuevo nombre '%s' es demasiado largo, máximo 12 caracteres", newName)
-			}
-			copy(content.B_name[:], newName)
-			for j := len(newName); j < 12; j++ {
-				content.B_name[j] = 0
-			}
-
-			return nil
-		}
-	}
-
-	return fmt.Errorf("el nombre '%s' no fue encontrado en los inodos 3 o 4", oldName)
-}
-
-func (fb *FolderBlock) RemoveEntry(file *os.File, name string, blockOffset int64) error {
-	for i := 2; i < len(fb.B_content); i++ {
-		content := &fb.B_content[i]
-		currentName := strings.Trim(string(content.B_name[:]), "\x00 ")
-
-		if strings.EqualFold(currentName, name) && content.B_inodo != -1 {
-			content.B_inodo = -1
-			copy(content.B_name[:], strings.Repeat("\x00", len(content.B_name)))
-			err := fb.Encode(file, blockOffset)
-			if err != nil {
-				return fmt.Errorf("error al serializar el FolderBlock después de eliminar la entrada '%s': %w", name, err)
-			}
-
-			return nil
-		}
-
-	}
-
-	return fmt.Errorf("la entrada '%s' no fue encontrada en el FolderBlock", name)
-}
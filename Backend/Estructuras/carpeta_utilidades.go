@@ -4,6 +4,7 @@ import (
 	"fmt"
 	utilidades "godisk/Utilidades"
 	"os"
+	"path"
 	"strings"
 	"time"
 )
@@ -90,7 +91,7 @@ func (sb *Superbloque) ValidarExistenciaDeDirectorio(archivo *os.File, indiceIno
 		}
 
 		fmt.Printf("Deserializando bloque %d del inodo %d\n", indiceBloque, indiceInodo)
-		bloque := &FolderBlock{}
+		bloque := sb.NuevoBloqueCarpeta()
 
 		err := bloque.Decode(archivo, int64(sb.S_block_start+(indiceBloque*sb.S_block_size)))
 
@@ -109,7 +110,7 @@ func (sb *Superbloque) ValidarExistenciaDeDirectorio(archivo *os.File, indiceIno
 				break
 			}
 
-			nombreContenido := strings.Trim(string(contenido.B_name[:]), "\x00 ")
+			nombreContenido := sb.NombreEntrada(archivo, contenido)
 			nombreDirectorio := strings.Trim(destDir, "\x00 ")
 			fmt.Printf("Comparando '%s' con el nombre de la carpeta padre '%s'\n", nombreContenido, nombreDirectorio)
 
@@ -146,7 +147,7 @@ func (sb *Superbloque) ValidarExistenciaCarpeta(archivo *os.File, indiceInodo in
 		}
 
 		fmt.Printf("Deserializando bloque %d del inodo %d\n", indiceBloque, indiceInodo)
-		bloque := &FolderBlock{}
+		bloque := sb.NuevoBloqueCarpeta()
 
 		err := bloque.Decode(archivo, int64(sb.S_block_start+(indiceBloque*sb.S_block_size)))
 
@@ -165,7 +166,7 @@ func (sb *Superbloque) ValidarExistenciaCarpeta(archivo *os.File, indiceInodo in
 				break
 			}
 
-			nombreContenido := strings.Trim(string(contenido.B_name[:]), "\x00 ")
+			nombreContenido := sb.NombreEntrada(archivo, contenido)
 			fmt.Printf("Comparando '%s' con el nombre de la carpeta existente '%s'\n", nombreContenido, destDir)
 			if strings.EqualFold(nombreContenido, destDir) {
 				return true, nil
@@ -199,7 +200,7 @@ func (sb *Superbloque) ValidarPadreDirectorio(archivo *os.File, indiceInodo int3
 		}
 
 		fmt.Printf("Deserializando bloque %d del inodo %d\n", indiceBloque, indiceInodo)
-		bloque := &FolderBlock{}
+		bloque := sb.NuevoBloqueCarpeta()
 		err := bloque.Decode(archivo, int64(sb.S_block_start+(indiceBloque*sb.S_block_size)))
 
 		if err != nil {
@@ -222,7 +223,7 @@ func (sb *Superbloque) ValidarPadreDirectorio(archivo *os.File, indiceInodo int3
 				padreDir = padresDir[0]
 			}
 
-			nombreContenido := strings.Trim(string(contenido.B_name[:]), "\x00 ")
+			nombreContenido := sb.NombreEntrada(archivo, contenido)
 			nombreDirPadre := strings.Trim(padreDir, "\x00 ")
 			fmt.Printf("Comparando '%s' con el nombre de la carpeta padre '%s'\n", nombreContenido, nombreDirPadre)
 
@@ -265,7 +266,7 @@ func (sb *Superbloque) CrearCarpetaEnInodo(archivo *os.File, indiceInodo int32,
 		}
 
 		fmt.Printf("Deserializando bloque %d del inodo %d\n", indiceBloque, indiceInodo)
-		bloque := &FolderBlock{}
+		bloque := sb.NuevoBloqueCarpeta()
 
 		err := bloque.Decode(archivo, int64(sb.S_block_start+(indiceBloque*sb.S_block_size)))
 
@@ -286,7 +287,9 @@ func (sb *Superbloque) CrearCarpetaEnInodo(archivo *os.File, indiceInodo int32,
 
 			fmt.Printf("Asignando el nombre del directorio '%s' al bloque en la posición %d\n", destDir, indiceContenido)
 
-			copy(contenido.B_name[:], destDir)
+			if err := sb.AsignarNombreEntrada(archivo, &contenido, destDir); err != nil {
+				return err
+			}
 			contenido.B_inodo = sb.S_inodes_count
 
 			bloque.B_content[indiceContenido] = contenido
@@ -327,14 +330,7 @@ func (sb *Superbloque) CrearCarpetaEnInodo(archivo *os.File, indiceInodo int32,
 
 			sb.UpdateSuperblockAfterInodeAllocation()
 
-			bloqueCarpeta := &FolderBlock{
-				B_content: [4]FolderContent{
-					{B_name: [12]byte{'.'}, B_inodo: contenido.B_inodo},
-					{B_name: [12]byte{'.', '.'}, B_inodo: indiceInodo},
-					{B_name: [12]byte{'-'}, B_inodo: -1},
-					{B_name: [12]byte{'-'}, B_inodo: -1},
-				},
-			}
+			bloqueCarpeta := sb.NewFolderBlock(contenido.B_inodo, indiceInodo, nil)
 
 			fmt.Printf("Serializando el bloque de la carpeta '%s'\n", destDir)
 
@@ -377,30 +373,13 @@ func (sb *Superbloque) deleteFolderInInode(file *os.File, inodeIndex int32, dirP
 		fullPath = dirPath[0]
 	}
 
-	journaling_start := int64(sb.JournalStart())
-	if sb.S_filesystem_type == 3 {
-		if err := AddJournalEntry(
-			file,
-			journaling_start,
-			JOURNAL_ENTRIES,
-			"rmdir",
-			fullPath,
-			"",
-			sb,
-		); err != nil {
-			fmt.Printf("Advertencia: error registrando operación en journal: %v\n", err)
-		} else {
-			fmt.Printf("Operación 'rmdir %s' registrada en journal correctamente\n", fullPath)
-		}
-	}
-
 	blockIndexes, err := dirInode.GetDataBlockIndexes(file, sb)
 	if err != nil {
 		return fmt.Errorf("error obteniendo bloques de datos del directorio: %w", err)
 	}
 
 	for _, blockIndex := range blockIndexes {
-		block := &FolderBlock{}
+		block := sb.NuevoBloqueCarpeta()
 		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
 		if err := block.Decode(file, blockOffset); err != nil {
 			return fmt.Errorf("error deserializando bloque %d: %w", blockIndex, err)
@@ -413,7 +392,7 @@ func (sb *Superbloque) deleteFolderInInode(file *os.File, inodeIndex int32, dirP
 				continue
 			}
 
-			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			contentName := sb.NombreEntrada(file, content)
 			fmt.Printf("Eliminando contenido '%s' en inodo %d\n", contentName, content.B_inodo)
 
 			childPath := fullPath
@@ -429,46 +408,10 @@ func (sb *Superbloque) deleteFolderInInode(file *os.File, inodeIndex int32, dirP
 			}
 
 			if childInode.I_type[0] == '0' {
-				if sb.S_filesystem_type == 3 {
-					if err := AddJournalEntry(
-						file,
-						journaling_start,
-						JOURNAL_ENTRIES,
-						"rmdir",
-						childPath,
-						"",
-						sb,
-					); err != nil {
-						fmt.Printf("Advertencia: error registrando eliminación de subcarpeta en journal: %v\n", err)
-					}
-				}
-
 				if err := sb.deleteFolderInInode(file, content.B_inodo, childPath); err != nil {
 					return fmt.Errorf("error eliminando subcarpeta '%s': %w", contentName, err)
 				}
 			} else {
-				if sb.S_filesystem_type == 3 {
-					fileData, err := childInode.ReadData(file, sb)
-					fileContent := ""
-					if err == nil {
-						fileContent = string(fileData)
-					}
-
-					if err := AddJournalEntry(
-						file,
-						journaling_start,
-						JOURNAL_ENTRIES,
-						"rm",
-						childPath,
-						fileContent,
-						sb,
-					); err != nil {
-						fmt.Printf("Advertencia: error registrando eliminación de archivo '%s' en journal: %v\n", contentName, err)
-					} else {
-						fmt.Printf("Operación 'rm %s' registrada en journal correctamente\n", childPath)
-					}
-				}
-
 				if err := childInode.FreeAllBlocks(file, sb); err != nil {
 					return fmt.Errorf("error liberando bloques del archivo '%s': %w", contentName, err)
 				}
@@ -479,6 +422,10 @@ func (sb *Superbloque) deleteFolderInInode(file *os.File, inodeIndex int32, dirP
 				sb.UpdateSuperblockAfterInodeDeallocation()
 				fmt.Printf("Archivo '%s' eliminado (inodo %d)\n", contentName, content.B_inodo)
 			}
+
+			if err := sb.LiberarNombreEntrada(file, content); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -515,7 +462,7 @@ func (sb *Superbloque) deleteFolderFromDirectory(file *os.File, parentInodeIndex
 	}
 
 	for _, blockIndex := range blockIndexes {
-		block := &FolderBlock{}
+		block := sb.NuevoBloqueCarpeta()
 		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
 
 		if err := block.Decode(file, blockOffset); err != nil {
@@ -523,7 +470,7 @@ func (sb *Superbloque) deleteFolderFromDirectory(file *os.File, parentInodeIndex
 		}
 
 		for i, content := range block.B_content {
-			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			contentName := sb.NombreEntrada(file, content)
 
 			if content.B_inodo != -1 && strings.EqualFold(contentName, folderName) {
 				folderInode := &Inodo{}
@@ -538,6 +485,9 @@ func (sb *Superbloque) deleteFolderFromDirectory(file *os.File, parentInodeIndex
 				if err := sb.deleteFolderInInode(file, content.B_inodo, fullPath); err != nil {
 					return fmt.Errorf("error eliminando carpeta '%s': %w", folderName, err)
 				}
+				if err := sb.LiberarNombreEntrada(file, content); err != nil {
+					return err
+				}
 
 				block.B_content[i] = FolderContent{
 					B_name:  [12]byte{'-'},
@@ -595,13 +545,13 @@ func (sb *Superbloque) DeleteFolder(file *os.File, parentsDir []string, folderNa
 				break
 			}
 
-			block := &FolderBlock{}
+			block := sb.NuevoBloqueCarpeta()
 			if err := block.Decode(file, int64(sb.S_block_start+blockIndex*sb.S_block_size)); err != nil {
 				return fmt.Errorf("error deserializando bloque %d: %w", blockIndex, err)
 			}
 
 			for _, content := range block.B_content {
-				contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+				contentName := sb.NombreEntrada(file, content)
 
 				if content.B_inodo != -1 && strings.EqualFold(contentName, dirName) {
 					subDirInode := &Inodo{}
@@ -627,3 +577,292 @@ func (sb *Superbloque) DeleteFolder(file *os.File, parentsDir []string, folderNa
 
 	return sb.deleteFolderFromDirectory(file, currentInodeIndex, folderName, fullPath)
 }
+
+func (sb *Superbloque) AgregarEntradaCarpeta(archivo *os.File, indiceCarpeta int32, nombre string, indiceHijo int32) error {
+	if err := sb.ValidarNombre(nombre); err != nil {
+		return err
+	}
+
+	carpeta := &Inodo{}
+	offsetCarpeta := sb.CalculateInodeOffset(indiceCarpeta)
+	if err := carpeta.Decode(archivo, offsetCarpeta); err != nil {
+		return fmt.Errorf("error al deserializar inodo %d: %v", indiceCarpeta, err)
+	}
+	if carpeta.I_type[0] != '0' {
+		return fmt.Errorf("el inodo %d no es una carpeta", indiceCarpeta)
+	}
+
+	indicesBloques, err := carpeta.GetDataBlockIndexes(archivo, sb)
+	if err != nil {
+		return err
+	}
+
+	indicePadre := indiceCarpeta
+	for i, indiceBloque := range indicesBloques {
+		offsetBloque := int64(sb.S_block_start + (indiceBloque * sb.S_block_size))
+		bloque := sb.NuevoBloqueCarpeta()
+		if err := bloque.Decode(archivo, offsetBloque); err != nil {
+			return fmt.Errorf("error al deserializar bloque %d: %v", indiceBloque, err)
+		}
+		if i == 0 {
+			indicePadre = bloque.B_content[1].B_inodo
+		}
+
+		for j := 2; j < len(bloque.B_content); j++ {
+			if bloque.B_content[j].B_inodo != -1 {
+				continue
+			}
+
+			if err := sb.AsignarNombreEntrada(archivo, &bloque.B_content[j], nombre); err != nil {
+				return err
+			}
+			bloque.B_content[j].B_inodo = indiceHijo
+			if err := bloque.Encode(archivo, offsetBloque); err != nil {
+				return err
+			}
+
+			carpeta.ActualizarMtime()
+			return carpeta.Encode(archivo, offsetCarpeta)
+		}
+	}
+
+	// AddBlock usa los bloques directos libres y después los de apuntadores
+	nuevoBloque, err := carpeta.AddBlock(archivo, sb)
+	if err != nil {
+		return fmt.Errorf("la carpeta del inodo %d no tiene espacio para más entradas: %v", indiceCarpeta, err)
+	}
+
+	bloque := sb.NewFolderBlock(indiceCarpeta, indicePadre, nil)
+	if err := sb.AsignarNombreEntrada(archivo, &bloque.B_content[2], nombre); err != nil {
+		return err
+	}
+	bloque.B_content[2].B_inodo = indiceHijo
+	if err := bloque.Encode(archivo, int64(sb.S_block_start+(nuevoBloque*sb.S_block_size))); err != nil {
+		return err
+	}
+
+	carpeta.ActualizarMtime()
+	return carpeta.Encode(archivo, offsetCarpeta)
+}
+
+func (sb *Superbloque) BuscarEntradaCarpeta(archivo *os.File, indiceCarpeta int32, nombre string) (int32, error) {
+	carpeta := &Inodo{}
+	if err := carpeta.Decode(archivo, sb.CalculateInodeOffset(indiceCarpeta)); err != nil {
+		return -1, fmt.Errorf("error al deserializar inodo %d: %v", indiceCarpeta, err)
+	}
+	if carpeta.I_type[0] != '0' {
+		return -1, fmt.Errorf("el inodo %d no es una carpeta", indiceCarpeta)
+	}
+
+	indicesBloques, err := carpeta.GetDataBlockIndexes(archivo, sb)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, indiceBloque := range indicesBloques {
+		bloque := sb.NuevoBloqueCarpeta()
+		if err := bloque.Decode(archivo, int64(sb.S_block_start+(indiceBloque*sb.S_block_size))); err != nil {
+			return -1, fmt.Errorf("error al deserializar bloque %d: %v", indiceBloque, err)
+		}
+
+		for _, contenido := range bloque.B_content {
+			nombreContenido := sb.NombreEntrada(archivo, contenido)
+			if contenido.B_inodo != -1 && strings.EqualFold(nombreContenido, nombre) {
+				return contenido.B_inodo, nil
+			}
+		}
+	}
+
+	return -1, nil
+}
+
+// RenombrarEntrada cambia el nombre de una entrada de la carpeta; los bloques de nombre
+// del nombre anterior se liberan y se reservan los que necesite el nuevo
+func (sb *Superbloque) RenombrarEntrada(archivo *os.File, indiceCarpeta int32, nombre string, nuevoNombre string) error {
+	if err := sb.ValidarNombre(nuevoNombre); err != nil {
+		return err
+	}
+	existente, err := sb.BuscarEntradaCarpeta(archivo, indiceCarpeta, nuevoNombre)
+	if err != nil {
+		return err
+	}
+	if existente != -1 {
+		return fmt.Errorf("ya existe un archivo o carpeta con el nombre '%s'", nuevoNombre)
+	}
+
+	carpeta := &Inodo{}
+	offsetCarpeta := sb.CalculateInodeOffset(indiceCarpeta)
+	if err := carpeta.Decode(archivo, offsetCarpeta); err != nil {
+		return fmt.Errorf("error al deserializar inodo %d: %v", indiceCarpeta, err)
+	}
+
+	indicesBloques, err := carpeta.GetDataBlockIndexes(archivo, sb)
+	if err != nil {
+		return err
+	}
+
+	for _, indiceBloque := range indicesBloques {
+		offsetBloque := int64(sb.S_block_start + (indiceBloque * sb.S_block_size))
+		bloque := sb.NuevoBloqueCarpeta()
+		if err := bloque.Decode(archivo, offsetBloque); err != nil {
+			return fmt.Errorf("error al deserializar bloque %d: %v", indiceBloque, err)
+		}
+
+		for j := 2; j < len(bloque.B_content); j++ {
+			contenido := &bloque.B_content[j]
+			if contenido.B_inodo == -1 || !strings.EqualFold(sb.NombreEntrada(archivo, *contenido), nombre) {
+				continue
+			}
+
+			if err := sb.LiberarNombreEntrada(archivo, *contenido); err != nil {
+				return err
+			}
+			if err := sb.AsignarNombreEntrada(archivo, contenido, nuevoNombre); err != nil {
+				return err
+			}
+			if err := bloque.Encode(archivo, offsetBloque); err != nil {
+				return err
+			}
+
+			carpeta.ActualizarMtime()
+			return carpeta.Encode(archivo, offsetCarpeta)
+		}
+	}
+
+	return fmt.Errorf("el nombre '%s' no fue encontrado en la carpeta", nombre)
+}
+
+func (sb *Superbloque) BuscarInodoPorRuta(archivo *os.File, ruta string) (int32, error) {
+	indiceActual := int32(0)
+	for _, nombre := range strings.Split(ruta, "/") {
+		if nombre == "" {
+			continue
+		}
+
+		siguiente, err := sb.BuscarEntradaCarpeta(archivo, indiceActual, nombre)
+		if err != nil {
+			return -1, err
+		}
+		if siguiente == -1 {
+			return -1, fmt.Errorf("no se encontró '%s' en la ruta '%s'", nombre, ruta)
+		}
+		indiceActual = siguiente
+	}
+
+	return indiceActual, nil
+}
+
+func (sb *Superbloque) quitarEntradaCarpeta(archivo *os.File, indiceCarpeta int32, nombre string) error {
+	carpeta := &Inodo{}
+	offsetCarpeta := sb.CalculateInodeOffset(indiceCarpeta)
+	if err := carpeta.Decode(archivo, offsetCarpeta); err != nil {
+		return fmt.Errorf("error al deserializar inodo %d: %v", indiceCarpeta, err)
+	}
+
+	for _, indiceBloque := range carpeta.I_block[:12] {
+		if indiceBloque == -1 {
+			continue
+		}
+
+		offsetBloque := int64(sb.S_block_start + (indiceBloque * sb.S_block_size))
+		bloque := sb.NuevoBloqueCarpeta()
+		if err := bloque.Decode(archivo, offsetBloque); err != nil {
+			return fmt.Errorf("error al deserializar bloque %d: %v", indiceBloque, err)
+		}
+
+		for j := 2; j < len(bloque.B_content); j++ {
+			contenido := bloque.B_content[j]
+			if contenido.B_inodo == -1 || !strings.EqualFold(sb.NombreEntrada(archivo, contenido), nombre) {
+				continue
+			}
+
+			if err := sb.LiberarNombreEntrada(archivo, contenido); err != nil {
+				return err
+			}
+			bloque.B_content[j] = FolderContent{B_inodo: -1}
+			if err := bloque.Encode(archivo, offsetBloque); err != nil {
+				return fmt.Errorf("error al serializar el bloque después de quitar la entrada '%s': %w", nombre, err)
+			}
+
+			carpeta.ActualizarMtime()
+			return carpeta.Encode(archivo, offsetCarpeta)
+		}
+	}
+
+	return fmt.Errorf("la entrada '%s' no fue encontrada en el inodo %d", nombre, indiceCarpeta)
+}
+
+// MoverEntrada reubica la entrada de origen dentro de la carpeta destino sin copiar
+// sus datos; solo se reescriben las entradas de carpeta y el '..' de los directorios.
+func (sb *Superbloque) MoverEntrada(archivo *os.File, origen string, destino string) error {
+	origen = path.Clean("/" + origen)
+	destino = path.Clean("/" + destino)
+
+	if origen == "/" {
+		return fmt.Errorf("no se puede mover la carpeta raíz")
+	}
+	if destino == origen || strings.HasPrefix(destino+"/", origen+"/") {
+		return fmt.Errorf("no se puede mover '%s' dentro de sí mismo", origen)
+	}
+
+	rutaPadre, nombre := path.Split(origen)
+	indicePadre, err := sb.BuscarInodoPorRuta(archivo, rutaPadre)
+	if err != nil {
+		return err
+	}
+	indiceEntrada, err := sb.BuscarEntradaCarpeta(archivo, indicePadre, nombre)
+	if err != nil {
+		return err
+	}
+	if indiceEntrada == -1 {
+		return fmt.Errorf("no se encontró '%s'", origen)
+	}
+
+	indiceDestino, err := sb.BuscarInodoPorRuta(archivo, destino)
+	if err != nil {
+		return err
+	}
+	existente, err := sb.BuscarEntradaCarpeta(archivo, indiceDestino, nombre)
+	if err != nil {
+		return err
+	}
+	if existente != -1 {
+		return fmt.Errorf("ya existe un archivo o carpeta con el nombre '%s' en '%s'", nombre, destino)
+	}
+
+	if err := sb.AgregarEntradaCarpeta(archivo, indiceDestino, nombre, indiceEntrada); err != nil {
+		return err
+	}
+	if err := sb.quitarEntradaCarpeta(archivo, indicePadre, nombre); err != nil {
+		return err
+	}
+
+	entrada := &Inodo{}
+	if err := entrada.Decode(archivo, sb.CalculateInodeOffset(indiceEntrada)); err != nil {
+		return fmt.Errorf("error al deserializar inodo %d: %v", indiceEntrada, err)
+	}
+	entrada.ActualizarCtime()
+
+	if entrada.I_type[0] == '0' {
+		for _, indiceBloque := range entrada.I_block[:12] {
+			if indiceBloque == -1 {
+				continue
+			}
+
+			offsetBloque := int64(sb.S_block_start + (indiceBloque * sb.S_block_size))
+			bloque := sb.NuevoBloqueCarpeta()
+			if err := bloque.Decode(archivo, offsetBloque); err != nil {
+				return fmt.Errorf("error al deserializar bloque %d: %v", indiceBloque, err)
+			}
+			if strings.Trim(string(bloque.B_content[1].B_name[:]), "\x00 ") != ".." {
+				continue
+			}
+			bloque.B_content[1].B_inodo = indiceDestino
+			if err := bloque.Encode(archivo, offsetBloque); err != nil {
+				return err
+			}
+		}
+	}
+
+	return entrada.Encode(archivo, sb.CalculateInodeOffset(indiceEntrada))
+}
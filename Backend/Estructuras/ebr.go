@@ -1,18 +1,24 @@
 package estructuras
 
 import (
+	"encoding/binary"
 	"fmt"
 	utilidades "godisk/Utilidades"
 	"os"
+	"strings"
 )
 
+// Ebr describe una partición lógica. Part_correlative y Part_id se agregaron para montarla; con
+// ellos el EBR pasó de 30 a 38 bytes y la partición empieza 8 bytes después.
 type Ebr struct {
-	Part_mount [1]byte
-	Part_fit   [1]byte
-	Part_start int32
-	Part_s     int32
-	Part_next  int32
-	Part_name  [16]byte
+	Part_mount       [1]byte
+	Part_fit         [1]byte
+	Part_start       int32
+	Part_s           int32
+	Part_next        int32
+	Part_name        [16]byte
+	Part_correlative int32
+	Part_id          [4]byte
 }
 
 func (e *Ebr) Codificar(archivo *os.File, posicion int64) error {
@@ -32,6 +38,12 @@ func (e *Ebr) Decodificar(file *os.File, position int64) error {
 	if err != nil {
 		return err
 	}
+	// en un disco con el EBR de 30 bytes la partición lógica nunca se montó ni se formateó, así
+	// que lo que sigue al EBR no es un montaje y la partición se toma como no montada
+	if !e.montajeValido() {
+		e.Part_correlative = 0
+		e.Part_id = [4]byte{}
+	}
 
 	fmt.Printf("EBR decodificado con éxito desde la posición %d.\n", position)
 	return nil
@@ -114,6 +126,45 @@ func EncontrarUltimoEBR(inicio int32, archivo *os.File) (*Ebr, error) {
 	return currentEBR, nil
 }
 
+// MontarParticion guarda en el EBR el correlativo y el ID con que se montó la partición lógica
+func (e *Ebr) MontarParticion(correlative int, id string) {
+	e.Part_correlative = int32(correlative)
+	e.Part_id = [4]byte{}
+	copy(e.Part_id[:], id)
+}
+
+// montajeValido indica si Part_correlative y Part_id son los que guardó mount: un correlativo de
+// partición montable y un ID vacío o de dígitos y una letra mayúscula
+func (e *Ebr) montajeValido() bool {
+	if e.Part_correlative < 0 || e.Part_correlative >= ParticionesMontables {
+		return false
+	}
+	id := strings.TrimRight(string(e.Part_id[:]), "\x00")
+	for i, c := range id {
+		letra := i == len(id)-1 && c >= 'A' && c <= 'Z'
+		if !letra && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Particion devuelve la partición lógica vista como una Partition. El inicio y el tamaño
+// no incluyen el EBR, así el sistema de archivos se escribe a continuación de él.
+func (e *Ebr) Particion() *Partition {
+	tamanoEBR := int32(binary.Size(Ebr{}))
+	return &Partition{
+		Part_status:      [1]byte{'1'},
+		Part_type:        [1]byte{'L'},
+		Part_fit:         e.Part_fit,
+		Part_start:       e.Part_start + tamanoEBR,
+		Part_s:           e.Part_s - tamanoEBR,
+		Part_name:        e.Part_name,
+		Part_correlative: e.Part_correlative,
+		Part_id:          e.Part_id,
+	}
+}
+
 func (e *Ebr) EstablecerSiguienteEBR(nuevoSiguiente int32) {
 	e.Part_next = nuevoSiguiente
 }
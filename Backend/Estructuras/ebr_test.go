@@ -0,0 +1,69 @@
+package estructuras
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTamanoEBR(t *testing.T) {
+	if tamano := binary.Size(Ebr{}); tamano != 38 {
+		t.Errorf("Ebr ocupa %d bytes, se esperaba 38", tamano)
+	}
+}
+
+func TestDecodificarEBR(t *testing.T) {
+	silenciarSalida(t)
+	file, err := os.Create(filepath.Join(t.TempDir(), "disco.mia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := file.Truncate(1024); err != nil {
+		t.Fatal(err)
+	}
+
+	montado := Ebr{}
+	montado.EstablecerEBR('W', 200, 100, -1, "logica")
+	montado.MontarParticion(4, "465A")
+
+	casos := []struct {
+		nombre      string
+		cola        []byte // los 8 bytes que siguen a los 30 del EBR anterior
+		correlativo int32
+		id          string
+	}{
+		{"montado", nil, 4, "465A"},
+		{"formato anterior con ceros", make([]byte, 8), 0, ""},
+		{"formato anterior con datos", []byte{0x53, 0xEF, 0x01, 0x00, 'x', 'y', 0, 1}, 0, ""},
+		{"correlativo fuera de rango", []byte{9, 0, 0, 0, '4', '6', '1', 'A'}, 0, ""},
+		{"ID con la letra en medio", []byte{4, 0, 0, 0, '4', 'A', '1', 0}, 0, ""},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if err := montado.Codificar(file, 100); err != nil {
+				t.Fatal(err)
+			}
+			if c.cola != nil {
+				if _, err := file.WriteAt(c.cola, 130); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ebr := Ebr{}
+			if err := ebr.Decodificar(file, 100); err != nil {
+				t.Fatal(err)
+			}
+			if ebr.Part_start != 100 || ebr.Part_s != 200 || ebr.Part_next != -1 {
+				t.Errorf("EBR leído %+v, se esperaba inicio 100, tamaño 200 y sin siguiente", ebr)
+			}
+			id := [4]byte{}
+			copy(id[:], c.id)
+			if ebr.Part_correlative != c.correlativo || ebr.Part_id != id {
+				t.Errorf("montaje leído %d %q, se esperaba %d %q", ebr.Part_correlative, ebr.Part_id, c.correlativo, c.id)
+			}
+		})
+	}
+}
@@ -31,14 +31,7 @@ func (sb *Superbloque) CreateUsersFile(file *os.File) error {
 
 	sb.UpdateSuperblockAfterInodeAllocation()
 
-	rootBlock := &FolderBlock{
-		B_content: [4]FolderContent{
-			{B_name: [12]byte{'.'}, B_inodo: 0},
-			{B_name: [12]byte{'.', '.'}, B_inodo: 0},
-			{B_name: [12]byte{'u', 's', 'e', 'r', 's', '.', 't', 'x', 't'}, B_inodo: sb.S_inodes_count},
-			{B_name: [12]byte{'-'}, B_inodo: -1},
-		},
-	}
+	rootBlock := sb.NewFolderBlock(0, 0, map[string]int32{"users.txt": sb.S_inodes_count})
 
 	err = rootBlock.Encode(file, int64(sb.S_block_start))
 	if err != nil {
@@ -52,27 +45,22 @@ func (sb *Superbloque) CreateUsersFile(file *os.File) error {
 
 	sb.UpdateSuperblockAfterBlockAllocation()
 
-	rootGroup := NewGroup("1", "root")
-	rootUser := NewUser("1", "root", "root", "123")
-	usersText := fmt.Sprintf("%s\n%s\n", rootGroup.ToString(), rootUser.ToString())
+	usersText, err := textoUsuariosInicial()
+	if err != nil {
+		return err
+	}
 
 	usersInode := &Inodo{
 		I_uid:   1,
 		I_gid:   1,
-		I_size:  int32(len(usersText)),
 		I_atime: float32(time.Now().Unix()),
 		I_ctime: float32(time.Now().Unix()),
 		I_mtime: float32(time.Now().Unix()),
-		I_block: [15]int32{1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, // Apunta al bloque 1 (users.txt)
-		I_type:  [1]byte{'1'},                                                         // Tipo archivo
+		I_block: [15]int32{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+		I_type:  [1]byte{'1'}, // Tipo archivo
 		I_perm:  [3]byte{'7', '7', '7'},
 	}
 
-	err = usersInode.Encode(file, int64(sb.S_first_ino))
-	if err != nil {
-		return fmt.Errorf("error al escribir el inodo de users.txt: %w", err)
-	}
-
 	err = sb.UpdateBitmapInode(file, 1, true)
 	if err != nil {
 		return fmt.Errorf("error al actualizar bitmap de inodos para users.txt: %w", err)
@@ -80,18 +68,16 @@ func (sb *Superbloque) CreateUsersFile(file *os.File) error {
 
 	sb.UpdateSuperblockAfterInodeAllocation()
 
-	usersBlock := &ArchivoBloque{}
-	copy(usersBlock.B_content[:], usersText)
-
-	err = usersBlock.Encode(file, int64(sb.S_first_blo))
+	// con la contraseña hasheada users.txt puede ocupar más de un bloque
+	err = usersInode.WriteData(file, sb, []byte(usersText))
 	if err != nil {
-		return fmt.Errorf("error al escribir el bloque de users.txt: %w", err)
+		return fmt.Errorf("error al escribir el contenido de users.txt: %w", err)
 	}
-	err = sb.UpdateBitmapBlock(file, 1, true)
+
+	err = usersInode.Encode(file, sb.CalculateInodeOffset(1))
 	if err != nil {
-		return fmt.Errorf("error al actualizar el bitmap de bloques para users.txt: %w", err)
+		return fmt.Errorf("error al escribir el inodo de users.txt: %w", err)
 	}
-	sb.UpdateSuperblockAfterBlockAllocation()
 
 	fmt.Println("Archivo users.txt creado correctamente.")
 	fmt.Println("Superbloque después de la creación de users.txt:")
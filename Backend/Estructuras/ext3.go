@@ -5,27 +5,20 @@ import (
 	"os"
 )
 
-func (sb *Superbloque) CreateUsersFileExt3(file *os.File, journaling_start int64) error {
+func (sb *Superbloque) CreateUsersFileExt3(file *os.File, journalEntries int32) error {
 	fmt.Println("Inicializando área de journaling para EXT3...")
-	err := InitializeJournalArea(file, journaling_start, JOURNAL_ENTRIES)
+	err := InitializeJournalArea(file, sb, journalEntries)
 	if err != nil {
 		return fmt.Errorf("error al inicializar el área de journaling: %w", err)
 	}
-
-	nextJournalIndex, err := GetNextEmptyJournalIndex(file, journaling_start, JOURNAL_ENTRIES)
-	if err != nil {
-		return fmt.Errorf("error obteniendo el siguiente índice de journal: %w", err)
-	}
-	fmt.Printf("Siguiente índice de journal disponible: %d\n", nextJournalIndex)
+	fmt.Printf("Journal circular de %d registros\n", journalEntries)
 
 	err = AddJournalEntry(
 		file,
-		journaling_start,
-		JOURNAL_ENTRIES,
+		sb,
 		"mkdir",
 		"/",
 		"",
-		sb,
 	)
 	if err != nil {
 		return fmt.Errorf("error al guardar la entrada de la raíz en el journal: %w", err)
@@ -51,14 +44,7 @@ func (sb *Superbloque) CreateUsersFileExt3(file *os.File, journaling_start int64
 		return fmt.Errorf("error al crear el inodo raíz: %w", err)
 	}
 
-	rootBlock := &FolderBlock{
-		B_content: [4]FolderContent{
-			{B_name: [12]byte{'.'}, B_inodo: 0},
-			{B_name: [12]byte{'.', '.'}, B_inodo: 0},
-			{B_name: [12]byte{'u', 's', 'e', 'r', 's', '.', 't', 'x', 't'}, B_inodo: sb.S_inodes_count},
-			{B_name: [12]byte{'-'}, B_inodo: -1},
-		},
-	}
+	rootBlock := sb.NewFolderBlock(0, 0, map[string]int32{"users.txt": sb.S_inodes_count})
 
 	err = sb.UpdateBitmapBlock(file, rootBlockIndex, true)
 	if err != nil {
@@ -72,61 +58,48 @@ func (sb *Superbloque) CreateUsersFileExt3(file *os.File, journaling_start int64
 
 	sb.UpdateSuperblockAfterBlockAllocation()
 
-	rootGroup := NewGroup("1", "root")
-	rootUser := NewUser("1", "root", "root", "123")
-	usersText := fmt.Sprintf("%s\n%s\n", rootGroup.ToString(), rootUser.ToString())
-
-	err = AddJournalEntry(
-		file,
-		journaling_start,
-		JOURNAL_ENTRIES,
-		"mkfile",
-		"/users.txt",
-		usersText,
-		sb,
-	)
+	usersText, err := textoUsuariosInicial()
 	if err != nil {
-		return fmt.Errorf("error al guardar la entrada del archivo /users.txt en el journal: %w", err)
+		return err
 	}
 
-	usersBlockIndex, err := sb.FindNextFreeBlock(file)
+	t := &TransaccionJournal{}
+	t.Agregar("mkfile", "/users.txt", usersText)
+	t.Agregar("chown", "/users.txt", "1,1")
+	t.Agregar("chmod", "/users.txt", "777")
+	err = t.Confirmar(file, sb)
 	if err != nil {
-		return fmt.Errorf("error al encontrar el primer bloque libre para /users.txt: %w", err)
+		return fmt.Errorf("error al guardar la entrada del archivo /users.txt en el journal: %w", err)
 	}
-	fileBlocks := [15]int32{usersBlockIndex, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}
 
 	usersInode := &Inodo{}
 	err = usersInode.CreateInode(
 		file,
 		sb,
 		'1',
-		int32(len(usersText)),
-		fileBlocks,
+		0,
+		[15]int32{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
 		[3]byte{'7', '7', '7'},
 	)
 	if err != nil {
 		return fmt.Errorf("error al crear el inodo de /users.txt: %w", err)
 	}
-	usersBlock := &ArchivoBloque{
-		B_content: [64]byte{},
-	}
-	usersBlock.AppendContent(usersText)
-	err = usersBlock.Encode(file, int64(sb.S_first_blo))
+
+	// con la contraseña hasheada users.txt puede ocupar más de un bloque
+	err = usersInode.WriteData(file, sb, []byte(usersText))
 	if err != nil {
-		return fmt.Errorf("error serializando el bloque de /users.txt: %w", err)
+		return fmt.Errorf("error al escribir el contenido de /users.txt: %w", err)
 	}
-	err = sb.UpdateBitmapBlock(file, usersBlockIndex, true)
+	err = usersInode.Encode(file, sb.CalculateInodeOffset(1))
 	if err != nil {
-		return fmt.Errorf("error actualizando el bitmap de bloques para /users.txt: %w", err)
+		return fmt.Errorf("error al actualizar el inodo de /users.txt: %w", err)
 	}
 
-	sb.UpdateSuperblockAfterBlockAllocation()
-
 	fmt.Println("Bloques")
 	sb.PrintBlocks(file.Name())
 
 	fmt.Println("Journal Entries:")
-	entries, err := FindValidJournalEntries(file, journaling_start, JOURNAL_ENTRIES)
+	entries, err := FindValidJournalEntries(file, sb)
 	if err != nil {
 		fmt.Printf("Error leyendo entradas de journal: %v\n", err)
 	} else {
@@ -0,0 +1,725 @@
+package estructuras
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Tipos de hallazgo que reporta VerificarSistemaArchivos
+const (
+	FsckInodoInvalido       = "inodo_invalido"
+	FsckInodoHuerfano       = "inodo_huerfano"
+	FsckInodoNoMarcado      = "inodo_no_marcado"
+	FsckBloqueFueraRango    = "bloque_fuera_de_rango"
+	FsckBloqueDuplicado     = "bloque_duplicado"
+	FsckBloqueHuerfano      = "bloque_huerfano"
+	FsckBloqueNoMarcado     = "bloque_no_marcado"
+	FsckEntradaColgante     = "entrada_colgante"
+	FsckNombreDanado        = "nombre_danado"
+	FsckReferenciaDoble     = "referencia_duplicada"
+	FsckEnlacePropio        = "enlace_propio"
+	FsckEnlacePadre         = "enlace_padre"
+	FsckContadorSuperbloque = "contador_superbloque"
+)
+
+const nombreLostFound = "lost+found"
+
+// HallazgoFsck es una inconsistencia encontrada por fsck
+type HallazgoFsck struct {
+	Tipo        string `json:"type"`
+	Inodo       int32  `json:"inode"`
+	Bloque      int32  `json:"block"`
+	Ruta        string `json:"path,omitempty"`
+	Descripcion string `json:"description"`
+	Reparado    bool   `json:"repaired"`
+}
+
+// ResultadoFsck resume la verificación de un sistema de archivos
+type ResultadoFsck struct {
+	InodosTotales  int32          `json:"inodes_total"`
+	BloquesTotales int32          `json:"blocks_total"`
+	InodosUsados   int32          `json:"inodes_used"`
+	BloquesUsados  int32          `json:"blocks_used"`
+	Reparar        bool           `json:"repair"`
+	Hallazgos      []HallazgoFsck `json:"findings"`
+}
+
+// TotalInodos calcula la cantidad de inodos a partir de la distribución de la partición,
+// sin depender de los contadores del superbloque
+func (sb *Superbloque) TotalInodos() int32 {
+	if sb.S_inode_size <= 0 {
+		return 0
+	}
+	return (sb.S_block_start - sb.S_inode_start) / sb.S_inode_size
+}
+
+// TotalBloques calcula la cantidad de bloques a partir del tamaño del bitmap de bloques
+func (sb *Superbloque) TotalBloques() int32 {
+	return sb.S_inode_start - sb.S_bm_block_start
+}
+
+// referenciaBloque indica dónde está guardado el apuntador a un bloque:
+// en el I_block del inodo (puntero = -1) o en un bloque de apuntadores
+type referenciaBloque struct {
+	inodo   int32
+	puntero int32
+	indice  int
+}
+
+type carpetaPendiente struct {
+	inodo int32
+	padre int32 // -1 si no se conoce el padre esperado
+	ruta  string
+}
+
+type verificadorFsck struct {
+	file      *os.File
+	sb        *Superbloque
+	reparar   bool
+	resultado *ResultadoFsck
+
+	bitmapInodos  []bool
+	bitmapBloques []bool
+	alcanzados    []bool
+	duenos        []int32
+	rutas         map[int32]string
+}
+
+// VerificarSistemaArchivos recorre el árbol desde el inodo 0 y lo compara con los bitmaps y los
+// contadores del superbloque. Con reparar corrige los bitmaps, los contadores, las entradas
+// inválidas y enlaza los inodos huérfanos en /lost+found. El superbloque se modifica en memoria;
+// quien llama debe guardarlo.
+func VerificarSistemaArchivos(file *os.File, sb *Superbloque, reparar bool) (*ResultadoFsck, error) {
+	if sb.S_magic != 0xEF53 {
+		return nil, errors.New("la partición no tiene un sistema de archivos válido")
+	}
+
+	v := &verificadorFsck{
+		file:    file,
+		sb:      sb,
+		reparar: reparar,
+		resultado: &ResultadoFsck{
+			InodosTotales:  sb.TotalInodos(),
+			BloquesTotales: sb.TotalBloques(),
+			Reparar:        reparar,
+			Hallazgos:      []HallazgoFsck{},
+		},
+		rutas: make(map[int32]string),
+	}
+	if v.resultado.InodosTotales <= 0 || v.resultado.BloquesTotales <= 0 {
+		return nil, errors.New("la distribución del superbloque es inválida")
+	}
+
+	var err error
+	if v.bitmapInodos, err = v.leerBitmap(sb.S_bm_inode_start, v.resultado.InodosTotales); err != nil {
+		return nil, err
+	}
+	if v.bitmapBloques, err = v.leerBitmap(sb.S_bm_block_start, v.resultado.BloquesTotales); err != nil {
+		return nil, err
+	}
+	v.alcanzados = make([]bool, v.resultado.InodosTotales)
+	v.duenos = make([]int32, v.resultado.BloquesTotales)
+	for i := range v.duenos {
+		v.duenos[i] = -1
+	}
+
+	var raiz Inodo
+	if err := raiz.Decode(file, sb.CalculateInodeOffset(0)); err != nil {
+		return nil, err
+	}
+	if raiz.I_type[0] != '0' {
+		v.reportar(FsckInodoInvalido, 0, -1, "/", "el inodo raíz no es una carpeta", false)
+		return v.resultado, errors.New("el inodo raíz está dañado; use recovery para reconstruir el sistema de archivos")
+	}
+
+	v.alcanzados[0] = true
+	if err := v.recorrerCarpetas(carpetaPendiente{inodo: 0, padre: 0, ruta: "/"}); err != nil {
+		return nil, err
+	}
+
+	if err := v.revisarHuerfanos(); err != nil {
+		return nil, err
+	}
+	if err := v.revisarBitmaps(); err != nil {
+		return nil, err
+	}
+	v.revisarContadores()
+
+	return v.resultado, nil
+}
+
+func (v *verificadorFsck) reportar(tipo string, inodo, bloque int32, ruta, descripcion string, reparado bool) {
+	v.resultado.Hallazgos = append(v.resultado.Hallazgos, HallazgoFsck{
+		Tipo:        tipo,
+		Inodo:       inodo,
+		Bloque:      bloque,
+		Ruta:        ruta,
+		Descripcion: descripcion,
+		Reparado:    reparado,
+	})
+}
+
+func (v *verificadorFsck) leerBitmap(inicio int32, cantidad int32) ([]bool, error) {
+	bytesBitmap := make([]byte, (cantidad+7)/8)
+	if _, err := v.file.ReadAt(bytesBitmap, int64(inicio)); err != nil {
+		return nil, fmt.Errorf("error leyendo el bitmap en %d: %w", inicio, err)
+	}
+
+	bits := make([]bool, cantidad)
+	for i := range bits {
+		bits[i] = bytesBitmap[i/8]&(1<<(i%8)) != 0
+	}
+	return bits, nil
+}
+
+func (v *verificadorFsck) escribirBitmap(inicio int32, bits []bool) error {
+	bytesBitmap := make([]byte, (len(bits)+7)/8)
+	for i, ocupado := range bits {
+		if ocupado {
+			bytesBitmap[i/8] |= 1 << (i % 8)
+		}
+	}
+	if _, err := v.file.WriteAt(bytesBitmap, int64(inicio)); err != nil {
+		return fmt.Errorf("error escribiendo el bitmap en %d: %w", inicio, err)
+	}
+	DescartarBitmaps(v.file.Name())
+	return nil
+}
+
+func (v *verificadorFsck) offsetBloque(bloque int32) int64 {
+	return int64(v.sb.S_block_start) + int64(bloque)*int64(v.sb.S_block_size)
+}
+
+func (v *verificadorFsck) inodoValido(indice int32) (*Inodo, bool) {
+	if indice < 0 || indice >= v.resultado.InodosTotales {
+		return nil, false
+	}
+	var inodo Inodo
+	if err := inodo.Decode(v.file, v.sb.CalculateInodeOffset(indice)); err != nil {
+		return nil, false
+	}
+	if inodo.I_type[0] != '0' && inodo.I_type[0] != '1' {
+		return nil, false
+	}
+	return &inodo, true
+}
+
+// reclamar registra que el bloque pertenece al inodo. Devuelve false si la referencia es inválida
+// (fuera de rango o ya usada por otro inodo) y debe descartarse.
+func (v *verificadorFsck) reclamar(bloque int32, ref referenciaBloque, ruta string) bool {
+	if bloque < 0 || bloque >= v.resultado.BloquesTotales {
+		v.reportar(FsckBloqueFueraRango, ref.inodo, bloque, ruta,
+			fmt.Sprintf("el inodo %d apunta a un bloque fuera de rango", ref.inodo), v.reparar)
+		return false
+	}
+	if dueno := v.duenos[bloque]; dueno != -1 {
+		v.reportar(FsckBloqueDuplicado, ref.inodo, bloque, ruta,
+			fmt.Sprintf("el bloque también pertenece al inodo %d; se quita la referencia del inodo %d", dueno, ref.inodo), v.reparar)
+		return false
+	}
+	v.duenos[bloque] = ref.inodo
+	return true
+}
+
+// reclamarNombre reclama para la carpeta los bloques de nombre de una de sus entradas;
+// si alguno no se puede reclamar se sueltan los demás y la entrada debe descartarse
+func (v *verificadorFsck) reclamarNombre(carpeta int32, bloques []int32, ruta string) bool {
+	for i, bloque := range bloques {
+		if !v.reclamar(bloque, referenciaBloque{inodo: carpeta, puntero: -1, indice: -1}, ruta) {
+			for _, reclamado := range bloques[:i] {
+				v.duenos[reclamado] = -1
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// recorrerBloques reclama todos los bloques del inodo, incluidos los de apuntadores, y devuelve
+// los bloques de datos en orden. Con reparar quita del inodo las referencias inválidas.
+func (v *verificadorFsck) recorrerBloques(indice int32, inodo *Inodo, ruta string) ([]int32, error) {
+	var datos []int32
+	modificado := false
+
+	for slot := 0; slot < len(inodo.I_block); slot++ {
+		bloque := inodo.I_block[slot]
+		if bloque == -1 {
+			continue
+		}
+		if !v.reclamar(bloque, referenciaBloque{inodo: indice, puntero: -1, indice: slot}, ruta) {
+			if v.reparar {
+				inodo.I_block[slot] = -1
+				modificado = true
+			}
+			continue
+		}
+
+		if slot < 12 {
+			datos = append(datos, bloque)
+			continue
+		}
+
+		hijos, err := v.recorrerApuntadores(indice, bloque, slot-11, ruta)
+		if err != nil {
+			return nil, err
+		}
+		datos = append(datos, hijos...)
+	}
+
+	if modificado {
+		if err := inodo.Encode(v.file, v.sb.CalculateInodeOffset(indice)); err != nil {
+			return nil, err
+		}
+	}
+	return datos, nil
+}
+
+func (v *verificadorFsck) recorrerApuntadores(indice, bloque int32, nivel int, ruta string) ([]int32, error) {
+	pb := v.sb.NuevoBloqueApuntadores()
+	if err := pb.Decode(v.file, v.offsetBloque(bloque)); err != nil {
+		return nil, err
+	}
+
+	var datos []int32
+	modificado := false
+	for i, apuntador := range pb.B_pointers {
+		if apuntador == -1 {
+			continue
+		}
+		if !v.reclamar(apuntador, referenciaBloque{inodo: indice, puntero: bloque, indice: i}, ruta) {
+			if v.reparar {
+				pb.B_pointers[i] = -1
+				modificado = true
+			}
+			continue
+		}
+
+		if nivel == 1 {
+			datos = append(datos, apuntador)
+			continue
+		}
+		hijos, err := v.recorrerApuntadores(indice, apuntador, nivel-1, ruta)
+		if err != nil {
+			return nil, err
+		}
+		datos = append(datos, hijos...)
+	}
+
+	if modificado {
+		if err := pb.Encode(v.file, v.offsetBloque(bloque)); err != nil {
+			return nil, err
+		}
+	}
+	return datos, nil
+}
+
+// recorrerCarpetas recorre en anchura el subárbol que comienza en inicio
+func (v *verificadorFsck) recorrerCarpetas(inicio carpetaPendiente) error {
+	cola := []carpetaPendiente{inicio}
+	v.rutas[inicio.inodo] = inicio.ruta
+
+	for len(cola) > 0 {
+		actual := cola[0]
+		cola = cola[1:]
+
+		inodo, valido := v.inodoValido(actual.inodo)
+		if !valido {
+			continue
+		}
+
+		bloques, err := v.recorrerBloques(actual.inodo, inodo, actual.ruta)
+		if err != nil {
+			return err
+		}
+		if inodo.I_type[0] != '0' {
+			continue
+		}
+
+		for posicion, bloque := range bloques {
+			carpeta := v.sb.NuevoBloqueCarpeta()
+			if err := carpeta.Decode(v.file, v.offsetBloque(bloque)); err != nil {
+				return err
+			}
+
+			modificado := false
+			for j := range carpeta.B_content {
+				entrada := &carpeta.B_content[j]
+				nombre := strings.TrimRight(string(entrada.B_name[:]), "\x00")
+
+				if posicion == 0 && j == 0 {
+					if entrada.B_inodo != actual.inodo {
+						v.reportar(FsckEnlacePropio, actual.inodo, bloque, actual.ruta,
+							fmt.Sprintf("'.' apunta al inodo %d", entrada.B_inodo), v.reparar)
+						entrada.B_inodo = actual.inodo
+						modificado = true
+					}
+					continue
+				}
+				if posicion == 0 && j == 1 {
+					if actual.padre != -1 && entrada.B_inodo != actual.padre {
+						v.reportar(FsckEnlacePadre, actual.inodo, bloque, actual.ruta,
+							fmt.Sprintf("'..' apunta al inodo %d en lugar de %d", entrada.B_inodo, actual.padre), v.reparar)
+						entrada.B_inodo = actual.padre
+						modificado = true
+					}
+					continue
+				}
+				// Los bloques de ampliación de una carpeta también guardan '.' y '..'
+				if entrada.B_inodo == -1 || nombre == "." || nombre == ".." {
+					continue
+				}
+
+				nombre, bloquesNombre, err := v.sb.LeerNombreLargo(v.file, *entrada)
+				if err != nil {
+					v.reportar(FsckNombreDanado, entrada.B_inodo, bloque, actual.ruta,
+						fmt.Sprintf("la cadena de bloques del nombre está dañada: %v", err), v.reparar)
+					*entrada = FolderContent{B_name: [12]byte{'-'}, B_inodo: -1}
+					modificado = true
+					continue
+				}
+
+				rutaHijo := path.Join(actual.ruta, nombre)
+				if _, valido := v.inodoValido(entrada.B_inodo); !valido {
+					v.reportar(FsckEntradaColgante, entrada.B_inodo, bloque, rutaHijo,
+						fmt.Sprintf("la entrada '%s' apunta a un inodo inexistente o vacío", nombre), v.reparar)
+					*entrada = FolderContent{B_name: [12]byte{'-'}, B_inodo: -1}
+					modificado = true
+					continue
+				}
+				if v.alcanzados[entrada.B_inodo] {
+					v.reportar(FsckReferenciaDoble, entrada.B_inodo, bloque, rutaHijo,
+						fmt.Sprintf("el inodo ya está enlazado en %s", v.rutas[entrada.B_inodo]), v.reparar)
+					*entrada = FolderContent{B_name: [12]byte{'-'}, B_inodo: -1}
+					modificado = true
+					continue
+				}
+				if !v.reclamarNombre(actual.inodo, bloquesNombre, rutaHijo) {
+					*entrada = FolderContent{B_name: [12]byte{'-'}, B_inodo: -1}
+					modificado = true
+					continue
+				}
+
+				v.alcanzados[entrada.B_inodo] = true
+				v.rutas[entrada.B_inodo] = rutaHijo
+				cola = append(cola, carpetaPendiente{inodo: entrada.B_inodo, padre: actual.inodo, ruta: rutaHijo})
+			}
+
+			if modificado && v.reparar {
+				if err := carpeta.Encode(v.file, v.offsetBloque(bloque)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// revisarHuerfanos busca inodos marcados en el bitmap que no se alcanzan desde la raíz.
+// Solo se enlazan en lost+found los que no son hijos de otro huérfano; sus hijos se recorren con ellos.
+func (v *verificadorFsck) revisarHuerfanos() error {
+	candidatos := map[int32]*Inodo{}
+	for i := int32(0); i < v.resultado.InodosTotales; i++ {
+		if !v.bitmapInodos[i] || v.alcanzados[i] {
+			continue
+		}
+		if inodo, valido := v.inodoValido(i); valido {
+			candidatos[i] = inodo
+		}
+	}
+	if len(candidatos) == 0 {
+		return nil
+	}
+
+	hijosDeHuerfanos := map[int32]bool{}
+	for indice, inodo := range candidatos {
+		if inodo.I_type[0] != '0' {
+			continue
+		}
+		for _, bloque := range inodo.I_block[:12] {
+			if bloque < 0 || bloque >= v.resultado.BloquesTotales {
+				continue
+			}
+			carpeta := v.sb.NuevoBloqueCarpeta()
+			if err := carpeta.Decode(v.file, v.offsetBloque(bloque)); err != nil {
+				return err
+			}
+			for _, entrada := range carpeta.B_content {
+				nombre := strings.TrimRight(string(entrada.B_name[:]), "\x00")
+				if nombre != "." && nombre != ".." && entrada.B_inodo != indice {
+					hijosDeHuerfanos[entrada.B_inodo] = true
+				}
+			}
+		}
+	}
+
+	// Primero los huérfanos que no cuelgan de otro huérfano; luego los que quedan (ciclos).
+	// Se recorren todos antes de enlazarlos para que lost+found no tome bloques que todavía
+	// no se sabía que estaban en uso.
+	var huerfanos []int32
+	for _, soloRaices := range []bool{true, false} {
+		for i := int32(0); i < v.resultado.InodosTotales; i++ {
+			if _, esCandidato := candidatos[i]; !esCandidato || v.alcanzados[i] {
+				continue
+			}
+			if soloRaices && hijosDeHuerfanos[i] {
+				continue
+			}
+
+			rutaHuerfano := fmt.Sprintf("/%s/#%d", nombreLostFound, i)
+			v.reportar(FsckInodoHuerfano, i, -1, rutaHuerfano, "inodo en uso que no se alcanza desde la raíz", v.reparar)
+
+			v.alcanzados[i] = true
+			if err := v.recorrerCarpetas(carpetaPendiente{inodo: i, padre: -1, ruta: rutaHuerfano}); err != nil {
+				return err
+			}
+			huerfanos = append(huerfanos, i)
+		}
+	}
+
+	if !v.reparar {
+		return nil
+	}
+
+	lostFound, err := v.obtenerLostFound()
+	if err != nil {
+		return err
+	}
+	for _, huerfano := range huerfanos {
+		if err := v.agregarEntrada(lostFound, fmt.Sprintf("#%d", huerfano), huerfano); err != nil {
+			return err
+		}
+		if err := v.enlazarPadre(huerfano, lostFound); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enlazarPadre apunta el '..' de una carpeta huérfana a su nueva carpeta padre
+func (v *verificadorFsck) enlazarPadre(carpetaInodo, padre int32) error {
+	inodo, valido := v.inodoValido(carpetaInodo)
+	if !valido || inodo.I_type[0] != '0' || inodo.I_block[0] == -1 {
+		return nil
+	}
+
+	carpeta := v.sb.NuevoBloqueCarpeta()
+	if err := carpeta.Decode(v.file, v.offsetBloque(inodo.I_block[0])); err != nil {
+		return err
+	}
+	carpeta.B_content[1].B_inodo = padre
+	return carpeta.Encode(v.file, v.offsetBloque(inodo.I_block[0]))
+}
+
+func (v *verificadorFsck) revisarBitmaps() error {
+	for i := int32(0); i < v.resultado.InodosTotales; i++ {
+		switch {
+		case v.alcanzados[i] && !v.bitmapInodos[i]:
+			v.reportar(FsckInodoNoMarcado, i, -1, v.rutas[i], "inodo en uso marcado como libre en el bitmap", v.reparar)
+		case !v.alcanzados[i] && v.bitmapInodos[i]:
+			v.reportar(FsckInodoHuerfano, i, -1, "", "inodo vacío marcado como ocupado en el bitmap", v.reparar)
+		}
+		if v.alcanzados[i] {
+			v.resultado.InodosUsados++
+		}
+	}
+
+	for i := int32(0); i < v.resultado.BloquesTotales; i++ {
+		usado := v.duenos[i] != -1
+		switch {
+		case usado && !v.bitmapBloques[i]:
+			v.reportar(FsckBloqueNoMarcado, v.duenos[i], i, v.rutas[v.duenos[i]], "bloque en uso marcado como libre en el bitmap", v.reparar)
+		case !usado && v.bitmapBloques[i]:
+			v.reportar(FsckBloqueHuerfano, -1, i, "", "bloque marcado como ocupado que ningún inodo usa", v.reparar)
+		}
+		if usado {
+			v.resultado.BloquesUsados++
+		}
+	}
+
+	if !v.reparar {
+		return nil
+	}
+	if err := v.escribirBitmap(v.sb.S_bm_inode_start, v.alcanzados); err != nil {
+		return err
+	}
+	usados := make([]bool, len(v.duenos))
+	for i, dueno := range v.duenos {
+		usados[i] = dueno != -1
+	}
+	return v.escribirBitmap(v.sb.S_bm_block_start, usados)
+}
+
+// revisarContadores compara el superbloque con lo encontrado. Los asignadores del sistema de archivos
+// entregan inodos y bloques de forma secuencial a partir de los contadores, por lo que estos deben
+// cubrir hasta el último inodo y bloque en uso aunque queden huecos libres por debajo.
+func (v *verificadorFsck) revisarContadores() {
+	inodosContados := v.ultimoInodoUsado() + 1
+	bloquesContados := v.ultimoBloqueUsado() + 1
+
+	revisar := func(campo string, actual *int32, esperado int32) {
+		if *actual == esperado {
+			return
+		}
+		v.reportar(FsckContadorSuperbloque, -1, -1, "",
+			fmt.Sprintf("%s es %d y debería ser %d", campo, *actual, esperado), v.reparar)
+		if v.reparar {
+			*actual = esperado
+		}
+	}
+
+	revisar("s_inodes_count", &v.sb.S_inodes_count, inodosContados)
+	revisar("s_free_inodes_count", &v.sb.S_free_inodes_count, v.resultado.InodosTotales-inodosContados)
+	revisar("s_blocks_count", &v.sb.S_blocks_count, bloquesContados)
+	revisar("s_free_blocks_count", &v.sb.S_free_blocks_count, v.resultado.BloquesTotales-bloquesContados)
+	revisar("s_first_ino", &v.sb.S_first_ino, v.sb.S_inode_start+inodosContados*v.sb.S_inode_size)
+	revisar("s_first_blo", &v.sb.S_first_blo, v.sb.S_block_start+bloquesContados*v.sb.S_block_size)
+}
+
+func (v *verificadorFsck) ultimoInodoUsado() int32 {
+	for i := v.resultado.InodosTotales - 1; i >= 0; i-- {
+		if v.alcanzados[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+func (v *verificadorFsck) ultimoBloqueUsado() int32 {
+	for i := v.resultado.BloquesTotales - 1; i >= 0; i-- {
+		if v.duenos[i] != -1 {
+			return i
+		}
+	}
+	return -1
+}
+
+func (v *verificadorFsck) asignarInodo() (int32, error) {
+	for i := int32(0); i < v.resultado.InodosTotales; i++ {
+		if !v.alcanzados[i] && !v.bitmapInodos[i] {
+			v.alcanzados[i] = true
+			v.bitmapInodos[i] = true
+			return i, nil
+		}
+	}
+	return -1, errors.New("no hay inodos libres para reparar el sistema de archivos")
+}
+
+func (v *verificadorFsck) asignarBloque(dueno int32) (int32, error) {
+	for i := int32(0); i < v.resultado.BloquesTotales; i++ {
+		if v.duenos[i] == -1 && !v.bitmapBloques[i] {
+			v.duenos[i] = dueno
+			v.bitmapBloques[i] = true
+			return i, nil
+		}
+	}
+	return -1, errors.New("no hay bloques libres para reparar el sistema de archivos")
+}
+
+// obtenerLostFound devuelve el inodo de /lost+found y lo crea si no existe
+func (v *verificadorFsck) obtenerLostFound() (int32, error) {
+	raiz, _ := v.inodoValido(0)
+	for _, bloque := range raiz.I_block[:12] {
+		if bloque == -1 || v.duenos[bloque] != 0 {
+			continue
+		}
+		carpeta := v.sb.NuevoBloqueCarpeta()
+		if err := carpeta.Decode(v.file, v.offsetBloque(bloque)); err != nil {
+			return -1, err
+		}
+		for _, entrada := range carpeta.B_content {
+			if strings.TrimRight(string(entrada.B_name[:]), "\x00") == nombreLostFound && entrada.B_inodo != -1 {
+				if inodo, valido := v.inodoValido(entrada.B_inodo); valido && inodo.I_type[0] == '0' {
+					return entrada.B_inodo, nil
+				}
+			}
+		}
+	}
+
+	indice, err := v.asignarInodo()
+	if err != nil {
+		return -1, err
+	}
+	bloque, err := v.asignarBloque(indice)
+	if err != nil {
+		return -1, err
+	}
+
+	ahora := float32(time.Now().Unix())
+	inodo := &Inodo{
+		I_uid:   1,
+		I_gid:   1,
+		I_atime: ahora,
+		I_ctime: ahora,
+		I_mtime: ahora,
+		I_block: [15]int32{bloque, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+		I_type:  [1]byte{'0'},
+		I_perm:  [3]byte{'7', '7', '0'},
+	}
+	if err := inodo.Encode(v.file, v.sb.CalculateInodeOffset(indice)); err != nil {
+		return -1, err
+	}
+	if err := v.sb.NewFolderBlock(indice, 0, nil).Encode(v.file, v.offsetBloque(bloque)); err != nil {
+		return -1, err
+	}
+	if err := v.agregarEntrada(0, nombreLostFound, indice); err != nil {
+		return -1, err
+	}
+
+	v.rutas[indice] = "/" + nombreLostFound
+	return indice, nil
+}
+
+// agregarEntrada enlaza hijo dentro de la carpeta usando sus bloques directos; si hace falta un
+// bloque nuevo lleva . y .. como los demás bloques de la carpeta
+func (v *verificadorFsck) agregarEntrada(carpetaInodo int32, nombre string, hijo int32) error {
+	inodo, valido := v.inodoValido(carpetaInodo)
+	if !valido || inodo.I_type[0] != '0' {
+		return fmt.Errorf("el inodo %d no es una carpeta", carpetaInodo)
+	}
+
+	contenido := FolderContent{B_inodo: hijo}
+	copy(contenido.B_name[:], nombre)
+
+	padre := carpetaInodo
+	for slot := 0; slot < 12; slot++ {
+		bloque := inodo.I_block[slot]
+		if bloque == -1 {
+			nuevo, err := v.asignarBloque(carpetaInodo)
+			if err != nil {
+				return err
+			}
+			carpeta := v.sb.NewFolderBlock(carpetaInodo, padre, nil)
+			carpeta.B_content[2] = contenido
+			if err := carpeta.Encode(v.file, v.offsetBloque(nuevo)); err != nil {
+				return err
+			}
+			inodo.I_block[slot] = nuevo
+			return inodo.Encode(v.file, v.sb.CalculateInodeOffset(carpetaInodo))
+		}
+		if v.duenos[bloque] != carpetaInodo {
+			continue
+		}
+
+		carpeta := v.sb.NuevoBloqueCarpeta()
+		if err := carpeta.Decode(v.file, v.offsetBloque(bloque)); err != nil {
+			return err
+		}
+		if slot == 0 {
+			padre = carpeta.B_content[1].B_inodo
+		}
+		for j := 2; j < len(carpeta.B_content); j++ {
+			if carpeta.B_content[j].B_inodo == -1 {
+				carpeta.B_content[j] = contenido
+				return carpeta.Encode(v.file, v.offsetBloque(bloque))
+			}
+		}
+	}
+
+	return fmt.Errorf("la carpeta %s no tiene espacio para más entradas", v.rutas[carpetaInodo])
+}
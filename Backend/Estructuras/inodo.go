@@ -75,7 +75,7 @@ func (inode *Inodo) GetAllBlockIndexes(file *os.File, sb *Superbloque) ([]int32,
 	if inode.I_block[12] != -1 {
 		blockIndexes = append(blockIndexes, inode.I_block[12])
 
-		pb := &PointerBlock{}
+		pb := sb.NuevoBloqueApuntadores()
 		pbOffset := int64(sb.S_block_start + inode.I_block[12]*sb.S_block_size)
 		err := pb.Decode(file, pbOffset)
 		if err != nil {
@@ -92,7 +92,7 @@ func (inode *Inodo) GetAllBlockIndexes(file *os.File, sb *Superbloque) ([]int32,
 	if inode.I_block[13] != -1 {
 		blockIndexes = append(blockIndexes, inode.I_block[13])
 
-		primPB := &PointerBlock{}
+		primPB := sb.NuevoBloqueApuntadores()
 		primOffset := int64(sb.S_block_start + inode.I_block[13]*sb.S_block_size)
 		err := primPB.Decode(file, primOffset)
 		if err != nil {
@@ -103,7 +103,7 @@ func (inode *Inodo) GetAllBlockIndexes(file *os.File, sb *Superbloque) ([]int32,
 			if primPointer != -1 {
 				blockIndexes = append(blockIndexes, int32(primPointer))
 
-				secPB := &PointerBlock{}
+				secPB := sb.NuevoBloqueApuntadores()
 				secOffset := int64(sb.S_block_start + int32(primPointer)*sb.S_block_size)
 				err := secPB.Decode(file, secOffset)
 				if err != nil {
@@ -121,7 +121,7 @@ func (inode *Inodo) GetAllBlockIndexes(file *os.File, sb *Superbloque) ([]int32,
 
 	if inode.I_block[14] != -1 {
 		blockIndexes = append(blockIndexes, inode.I_block[14])
-		primPB := &PointerBlock{}
+		primPB := sb.NuevoBloqueApuntadores()
 		primOffset := int64(sb.S_block_start + inode.I_block[14]*sb.S_block_size)
 		err := primPB.Decode(file, primOffset)
 		if err != nil {
@@ -132,7 +132,7 @@ func (inode *Inodo) GetAllBlockIndexes(file *os.File, sb *Superbloque) ([]int32,
 			if primPointer != -1 {
 				blockIndexes = append(blockIndexes, int32(primPointer))
 
-				secPB := &PointerBlock{}
+				secPB := sb.NuevoBloqueApuntadores()
 				secOffset := int64(sb.S_block_start + int32(primPointer)*sb.S_block_size)
 				err := secPB.Decode(file, secOffset)
 				if err != nil {
@@ -142,7 +142,7 @@ func (inode *Inodo) GetAllBlockIndexes(file *os.File, sb *Superbloque) ([]int32,
 				for _, secPointer := range secPB.B_pointers {
 					if secPointer != -1 {
 						blockIndexes = append(blockIndexes, int32(secPointer))
-						tercPB := &PointerBlock{}
+						tercPB := sb.NuevoBloqueApuntadores()
 						tercOffset := int64(sb.S_block_start + int32(secPointer)*sb.S_block_size)
 						err := tercPB.Decode(file, tercOffset)
 						if err != nil {
@@ -186,7 +186,7 @@ func (inode *Inodo) AddBlockWithIndirection(file *os.File, sb *Superbloque) (int
 			return -1, fmt.Errorf("error al crear bloque de apuntadores simple: %w", err)
 		}
 
-		pb := &PointerBlock{}
+		pb := sb.NuevoBloqueApuntadores()
 		for i := range pb.B_pointers {
 			pb.B_pointers[i] = -1
 		}
@@ -208,7 +208,7 @@ func (inode *Inodo) AddBlockWithIndirection(file *os.File, sb *Superbloque) (int
 			return -1, fmt.Errorf("error al crear bloque de apuntadores doble: %w", err)
 		}
 
-		dpb := &PointerBlock{}
+		dpb := sb.NuevoBloqueApuntadores()
 		for i := range dpb.B_pointers {
 			dpb.B_pointers[i] = -1
 		}
@@ -230,7 +230,7 @@ func (inode *Inodo) AddBlockWithIndirection(file *os.File, sb *Superbloque) (int
 			return -1, fmt.Errorf("error al crear bloque de apuntadores triple: %w", err)
 		}
 
-		tpb := &PointerBlock{}
+		tpb := sb.NuevoBloqueApuntadores()
 		for i := range tpb.B_pointers {
 			tpb.B_pointers[i] = -1
 		}
@@ -254,7 +254,7 @@ func (inode *Inodo) AddBlockToSimpleIndirect(file *os.File, sb *Superbloque) (in
 		return -1, fmt.Errorf("no existe bloque indirecto simple")
 	}
 
-	pb := &PointerBlock{}
+	pb := sb.NuevoBloqueApuntadores()
 	pbOffset := int64(sb.S_block_start + inode.I_block[12]*sb.S_block_size)
 	if err := pb.Decode(file, pbOffset); err != nil {
 		return -1, fmt.Errorf("error al leer bloque de apuntadores: %w", err)
@@ -296,7 +296,7 @@ func (inode *Inodo) AddBlockToDoubleIndirect(file *os.File, sb *Superbloque) (in
 		return -1, fmt.Errorf("no existe bloque indirecto doble")
 	}
 
-	primPB := &PointerBlock{}
+	primPB := sb.NuevoBloqueApuntadores()
 	primOffset := int64(sb.S_block_start + inode.I_block[13]*sb.S_block_size)
 	if err := primPB.Decode(file, primOffset); err != nil {
 		return -1, fmt.Errorf("error al leer bloque de apuntadores primario: %w", err)
@@ -313,7 +313,7 @@ func (inode *Inodo) AddBlockToDoubleIndirect(file *os.File, sb *Superbloque) (in
 				return -1, fmt.Errorf("error actualizando bitmap: %w", err)
 			}
 
-			secPB := &PointerBlock{}
+			secPB := sb.NuevoBloqueApuntadores()
 			for j := range secPB.B_pointers {
 				secPB.B_pointers[j] = -1
 			}
@@ -346,7 +346,7 @@ func (inode *Inodo) AddBlockToDoubleIndirect(file *os.File, sb *Superbloque) (in
 
 			return newDataBlockIndex, nil
 		} else {
-			secPB := &PointerBlock{}
+			secPB := sb.NuevoBloqueApuntadores()
 			secOffset := int64(sb.S_block_start + int32(primPointer)*sb.S_block_size)
 			if err := secPB.Decode(file, secOffset); err != nil {
 				return -1, fmt.Errorf("error leyendo bloque de apuntadores secundario: %w", err)
@@ -385,7 +385,7 @@ func (inode *Inodo) AddBlockToTripleIndirect(file *os.File, sb *Superbloque) (in
 		return -1, fmt.Errorf("no existe bloque indirecto triple")
 	}
 
-	primPB := &PointerBlock{}
+	primPB := sb.NuevoBloqueApuntadores()
 	primOffset := int64(sb.S_block_start + inode.I_block[14]*sb.S_block_size)
 	if err := primPB.Decode(file, primOffset); err != nil {
 		return -1, fmt.Errorf("error al leer bloque de apuntadores primario: %w", err)
@@ -402,7 +402,7 @@ func (inode *Inodo) AddBlockToTripleIndirect(file *os.File, sb *Superbloque) (in
 				return -1, fmt.Errorf("error actualizando bitmap: %w", err)
 			}
 
-			secPB := &PointerBlock{}
+			secPB := sb.NuevoBloqueApuntadores()
 			for j := range secPB.B_pointers {
 				secPB.B_pointers[j] = -1
 			}
@@ -416,7 +416,7 @@ func (inode *Inodo) AddBlockToTripleIndirect(file *os.File, sb *Superbloque) (in
 				return -1, fmt.Errorf("error actualizando bitmap: %w", err)
 			}
 
-			tercPB := &PointerBlock{}
+			tercPB := sb.NuevoBloqueApuntadores()
 			for j := range tercPB.B_pointers {
 				tercPB.B_pointers[j] = -1
 			}
@@ -456,7 +456,7 @@ func (inode *Inodo) AddBlockToTripleIndirect(file *os.File, sb *Superbloque) (in
 
 			return newDataBlockIndex, nil
 		} else {
-			secPB := &PointerBlock{}
+			secPB := sb.NuevoBloqueApuntadores()
 			secOffset := int64(sb.S_block_start + int32(primPointer)*sb.S_block_size)
 			if err := secPB.Decode(file, secOffset); err != nil {
 				return -1, fmt.Errorf("error leyendo bloque de apuntadores secundario: %w", err)
@@ -473,7 +473,7 @@ func (inode *Inodo) AddBlockToTripleIndirect(file *os.File, sb *Superbloque) (in
 						return -1, fmt.Errorf("error actualizando bitmap: %w", err)
 					}
 
-					tercPB := &PointerBlock{}
+					tercPB := sb.NuevoBloqueApuntadores()
 					for j := range tercPB.B_pointers {
 						tercPB.B_pointers[j] = -1
 					}
@@ -505,7 +505,7 @@ func (inode *Inodo) AddBlockToTripleIndirect(file *os.File, sb *Superbloque) (in
 
 					return newDataBlockIndex, nil
 				} else {
-					tercPB := &PointerBlock{}
+					tercPB := sb.NuevoBloqueApuntadores()
 					tercOffset := int64(sb.S_block_start + int32(secPointer)*sb.S_block_size)
 					if err := tercPB.Decode(file, tercOffset); err != nil {
 						return -1, fmt.Errorf("error leyendo bloque de apuntadores terciario: %w", err)
@@ -574,7 +574,7 @@ func (inode *Inodo) FreeAllBlocks(file *os.File, sb *Superbloque) error {
 
 func (inode *Inodo) CheckAndFreeEmptyIndirectBlocks(file *os.File, sb *Superbloque) error {
 	if inode.I_block[12] != -1 {
-		pb := &PointerBlock{}
+		pb := sb.NuevoBloqueApuntadores()
 		pbOffset := int64(sb.S_block_start + inode.I_block[12]*sb.S_block_size)
 		if err := pb.Decode(file, pbOffset); err != nil {
 			return fmt.Errorf("error leyendo bloque indirecto simple: %w", err)
@@ -598,7 +598,7 @@ func (inode *Inodo) CheckAndFreeEmptyIndirectBlocks(file *os.File, sb *Superbloq
 	}
 
 	if inode.I_block[13] != -1 {
-		primPB := &PointerBlock{}
+		primPB := sb.NuevoBloqueApuntadores()
 		primOffset := int64(sb.S_block_start + inode.I_block[13]*sb.S_block_size)
 		if err := primPB.Decode(file, primOffset); err != nil {
 			return fmt.Errorf("error leyendo bloque indirecto doble: %w", err)
@@ -609,7 +609,7 @@ func (inode *Inodo) CheckAndFreeEmptyIndirectBlocks(file *os.File, sb *Superbloq
 
 		for i, primPointer := range primPB.B_pointers {
 			if primPointer != -1 {
-				secPB := &PointerBlock{}
+				secPB := sb.NuevoBloqueApuntadores()
 				secOffset := int64(sb.S_block_start + int32(primPointer)*sb.S_block_size)
 				if err := secPB.Decode(file, secOffset); err != nil {
 					return fmt.Errorf("error leyendo bloque secundario: %w", err)
@@ -657,7 +657,7 @@ func (inode *Inodo) CheckAndFreeEmptyIndirectBlocks(file *os.File, sb *Superbloq
 	}
 
 	if inode.I_block[14] != -1 {
-		primPB := &PointerBlock{}
+		primPB := sb.NuevoBloqueApuntadores()
 		primOffset := int64(sb.S_block_start + inode.I_block[14]*sb.S_block_size)
 		if err := primPB.Decode(file, primOffset); err != nil {
 			return fmt.Errorf("error leyendo bloque indirecto triple: %w", err)
@@ -672,7 +672,7 @@ func (inode *Inodo) CheckAndFreeEmptyIndirectBlocks(file *os.File, sb *Superbloq
 				continue
 			}
 
-			secPB := &PointerBlock{}
+			secPB := sb.NuevoBloqueApuntadores()
 			secOffset := int64(sb.S_block_start + int32(primPointer)*sb.S_block_size)
 			if err := secPB.Decode(file, secOffset); err != nil {
 				return fmt.Errorf("error leyendo bloque secundario en triple: %w", err)
@@ -687,7 +687,7 @@ func (inode *Inodo) CheckAndFreeEmptyIndirectBlocks(file *os.File, sb *Superbloq
 					continue
 				}
 
-				tercPB := &PointerBlock{}
+				tercPB := sb.NuevoBloqueApuntadores()
 				tercOffset := int64(sb.S_block_start + int32(secPointer)*sb.S_block_size)
 				if err := tercPB.Decode(file, tercOffset); err != nil {
 					return fmt.Errorf("error leyendo bloque terciario: %w", err)
@@ -760,12 +760,12 @@ func (inode *Inodo) ReadData(file *os.File, sb *Superbloque) ([]byte, error) {
 			break
 		}
 
-		fileBlock := &ArchivoBloque{}
+		fileBlock := sb.NuevoBloqueArchivo()
 		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
 		if err := fileBlock.Decode(file, blockOffset); err != nil {
 			return nil, err
 		}
-		bytesFromBlock := BlockSize
+		bytesFromBlock := int(sb.S_block_size)
 		if bytesFromBlock > bytesToRead {
 			bytesFromBlock = bytesToRead
 		}
@@ -795,6 +795,9 @@ func (inode *Inodo) WriteData(file *os.File, sb *Superbloque, data []byte) error
 		}
 
 		blocksNeeded := (newSize + sb.S_block_size - 1) / sb.S_block_size
+		if err := sb.PrepararAsignacion(file, blocksNeeded); err != nil {
+			return err
+		}
 
 		for i := int32(0); i < blocksNeeded; i++ {
 			_, err := inode.AddBlock(file, sb)
@@ -857,7 +860,7 @@ func (inode *Inodo) GetDataBlockIndexes(file *os.File, sb *Superbloque) ([]int32
 	}
 
 	if inode.I_block[12] != -1 {
-		pb := &PointerBlock{}
+		pb := sb.NuevoBloqueApuntadores()
 		pbOffset := int64(sb.S_block_start + inode.I_block[12]*sb.S_block_size)
 		if err := pb.Decode(file, pbOffset); err != nil {
 			return nil, fmt.Errorf("error leyendo bloque indirecto simple: %w", err)
@@ -871,7 +874,7 @@ func (inode *Inodo) GetDataBlockIndexes(file *os.File, sb *Superbloque) ([]int32
 	}
 
 	if inode.I_block[13] != -1 {
-		primPB := &PointerBlock{}
+		primPB := sb.NuevoBloqueApuntadores()
 		primOffset := int64(sb.S_block_start + inode.I_block[13]*sb.S_block_size)
 		if err := primPB.Decode(file, primOffset); err != nil {
 			return nil, fmt.Errorf("error leyendo bloque indirecto doble: %w", err)
@@ -879,7 +882,7 @@ func (inode *Inodo) GetDataBlockIndexes(file *os.File, sb *Superbloque) ([]int32
 
 		for _, primPointer := range primPB.B_pointers {
 			if primPointer != -1 {
-				secPB := &PointerBlock{}
+				secPB := sb.NuevoBloqueApuntadores()
 				secOffset := int64(sb.S_block_start + primPointer*sb.S_block_size)
 				if err := secPB.Decode(file, secOffset); err != nil {
 					return nil, fmt.Errorf("error leyendo bloque secundario: %w", err)
@@ -895,7 +898,7 @@ func (inode *Inodo) GetDataBlockIndexes(file *os.File, sb *Superbloque) ([]int32
 	}
 
 	if inode.I_block[14] != -1 {
-		primPB := &PointerBlock{}
+		primPB := sb.NuevoBloqueApuntadores()
 		primOffset := int64(sb.S_block_start + inode.I_block[14]*sb.S_block_size)
 		if err := primPB.Decode(file, primOffset); err != nil {
 			return nil, fmt.Errorf("error leyendo bloque indirecto triple: %w", err)
@@ -903,7 +906,7 @@ func (inode *Inodo) GetDataBlockIndexes(file *os.File, sb *Superbloque) ([]int32
 
 		for _, primPointer := range primPB.B_pointers {
 			if primPointer != -1 {
-				secPB := &PointerBlock{}
+				secPB := sb.NuevoBloqueApuntadores()
 				secOffset := int64(sb.S_block_start + primPointer*sb.S_block_size)
 				if err := secPB.Decode(file, secOffset); err != nil {
 					return nil, fmt.Errorf("error leyendo bloque secundario en indirección triple: %w", err)
@@ -911,7 +914,7 @@ func (inode *Inodo) GetDataBlockIndexes(file *os.File, sb *Superbloque) ([]int32
 
 				for _, secPointer := range secPB.B_pointers {
 					if secPointer != -1 {
-						tercPB := &PointerBlock{}
+						tercPB := sb.NuevoBloqueApuntadores()
 						tercOffset := int64(sb.S_block_start + secPointer*sb.S_block_size)
 						if err := tercPB.Decode(file, tercOffset); err != nil {
 							return nil, fmt.Errorf("error leyendo bloque terciario: %w", err)
@@ -3,25 +3,80 @@ package estructuras
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	utilidades "godisk/Utilidades"
+	"hash/crc32"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// JOURNAL_ENTRIES es la cantidad de entradas del formato anterior del journal, que no tenía
+// encabezado y siempre ocupaba 50 posiciones
 const JOURNAL_ENTRIES = 50
 
+// Límites para mkfs -journal_entries
+const (
+	JournalMinimo int32 = 16
+	JournalMaximo int32 = 65536
+)
+
+// MagicJournal identifica el encabezado del journal circular ("JRNL")
+const MagicJournal int32 = 0x4C4E524A
+
+// Tipos de registro del journal
+const (
+	RegistroDatos  byte = 'D'
+	RegistroCommit byte = 'C'
+)
+
+// Journal es un registro del journal circular. Una transacción son uno o más registros de
+// datos con el mismo J_transaction seguidos de un registro de commit; el contenido de las
+// operaciones se reparte entre los J_data de los registros de datos.
 type Journal struct {
-	J_count   int32
-	J_content Information
+	J_sequence    uint32
+	J_transaction uint32
+	J_type        [1]byte
+	J_length      int32
+	J_date        uint32
+	J_data        [111]byte
+}
+
+// EncabezadoJournal va al final del área del journal, justo antes del bitmap de inodos, para
+// poder ubicarlo desde el superbloque sin conocer la cantidad de registros
+type EncabezadoJournal struct {
+	J_magic       int32
+	J_entries     int32
+	J_head        int32 // registro más antiguo
+	J_tail        int32 // posición del siguiente registro
+	J_used        int32
+	J_sequence    uint32 // siguiente número de secuencia
+	J_transaction uint32 // siguiente transacción
+}
+
+// EntradaJournal es una operación reconstruida a partir de los registros de una transacción
+// confirmada
+type EntradaJournal struct {
+	Secuencia   uint32
+	Transaccion uint32
+	Operacion   string
+	Ruta        string
+	Contenido   string
+	Fecha       time.Time
 }
 
-type Information struct {
-	I_operation [10]byte
-	I_path      [32]byte
-	I_content   [64]byte
-	I_date      uint32
+// entradaLegado es el formato anterior: una operación por posición y el contenido recortado
+type entradaLegado struct {
+	J_count   int32
+	J_content struct {
+		I_operation [10]byte
+		I_path      [32]byte
+		I_content   [64]byte
+		I_date      uint32
+	}
 }
 
 func (journal *Journal) Encode(file *os.File, offset int64) error {
@@ -42,219 +97,621 @@ func (journal *Journal) Decode(file *os.File, offset int64) error {
 	return nil
 }
 
-func (journal *Journal) Print() {
-	date := time.Unix(int64(journal.J_content.I_date), 0)
+func (e EntradaJournal) Print() {
 	fmt.Println("Journal:")
-	fmt.Printf("J_count: %d\n", journal.J_count)
-	fmt.Println("Information:")
-	fmt.Printf("I_operation: %s\n", strings.TrimSpace(string(journal.J_content.I_operation[:])))
-	fmt.Printf("I_path: %s\n", strings.TrimSpace(string(journal.J_content.I_path[:])))
-	fmt.Printf("I_content: %s\n", strings.TrimSpace(string(journal.J_content.I_content[:])))
-	fmt.Printf("I_date: %s\n", date.Format(time.RFC3339))
-}
-
-func (j *Journal) CreateJournalEntry(op, path, content string) {
-	*j = Journal{}
-	copy(j.J_content.I_operation[:], op)
-	copy(j.J_content.I_path[:], path)
-	copy(j.J_content.I_content[:], content)
-	j.J_content.I_date = uint32(time.Now().Unix())
-}
-
-func (journal *Journal) GenerateJournalTable(journalIndex int32) string {
-	date := time.Unix(int64(journal.J_content.I_date), 0).Format(time.RFC3339)
-	operation := strings.TrimSpace(string(journal.J_content.I_operation[:]))
-	path := strings.TrimSpace(string(journal.J_content.I_path[:]))
-	content := strings.TrimSpace(string(journal.J_content.I_content[:]))
-
-	table := fmt.Sprintf(`journal_table_%d [label=<
-        <TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0" CELLPADDING="4">
-            <TR>
-                <TD COLSPAN="2" BGCOLOR="#4CAF50"><FONT COLOR="#FFFFFF">Journal Entry %d</FONT></TD>
-            </TR>
-            <TR>
-                <TD BGCOLOR="#FF7043">Operation:</TD>
-                <TD>%s</TD>
-            </TR>
-            <TR>
-                <TD BGCOLOR="#FF7043">Path:</TD>
-                <TD>%s</TD>
-            </TR>
-            <TR>
-                <TD BGCOLOR="#FF7043">Content:</TD>
-                <TD>%s</TD>
-            </TR>
-            <TR>
-                <TD BGCOLOR="#FF7043">Date:</TD>
-                <TD>%s</TD>
-            </TR>
-        </TABLE>
-    >];`, journalIndex, journalIndex, operation, path, content, date)
-
-	return table
-}
-
-func (journal *Journal) GenerateGraph(journalStart int64, journalCount int32, file *os.File) (string, error) {
-	dotContent := ""
-	entrySize := int64(binary.Size(Journal{}))
-
-	for i := int32(0); i < journalCount; i++ {
-		offset := journalStart + int64(i)*entrySize
-		err := journal.Decode(file, offset)
-		if err != nil {
-			return "", fmt.Errorf("error al deserializar el journal %d en offset %d: %v", i, offset, err)
+	fmt.Printf("Secuencia: %d (transacción %d)\n", e.Secuencia, e.Transaccion)
+	fmt.Printf("Operación: %s\n", e.Operacion)
+	fmt.Printf("Ruta: %s\n", e.Ruta)
+	fmt.Printf("Contenido: %s\n", e.Contenido)
+	fmt.Printf("Fecha: %s\n", e.Fecha.Format(time.RFC3339))
+}
+
+// TamanoJournal devuelve los bytes que ocupa un journal de entradas registros con su encabezado
+func TamanoJournal(entradas int32) int32 {
+	return entradas*int32(binary.Size(Journal{})) + int32(binary.Size(EncabezadoJournal{}))
+}
+
+func (sb *Superbloque) JournalEnd() int32 {
+	return sb.S_bm_inode_start
+}
+
+// journalParticion es el journal abierto de una partición EXT3
+type journalParticion struct {
+	file       *os.File
+	sb         *Superbloque
+	inicio     int64
+	encabezado EncabezadoJournal
+	// legado tiene las entradas de un journal con el formato anterior; se convierte al
+	// formato circular la primera vez que se escribe en él
+	legado []EntradaJournal
+}
+
+func (sb *Superbloque) abrirJournal(file *os.File) (*journalParticion, error) {
+	j := &journalParticion{file: file, sb: sb}
+	posEncabezado := int64(sb.JournalEnd()) - int64(binary.Size(EncabezadoJournal{}))
+	if err := utilidades.LeerDesdeArchivo(file, posEncabezado, &j.encabezado); err != nil {
+		return nil, fmt.Errorf("error leyendo el encabezado del journal: %w", err)
+	}
+
+	e := j.encabezado
+	if e.J_magic == MagicJournal {
+		if e.J_entries < 1 || e.J_head < 0 || e.J_head >= e.J_entries ||
+			e.J_tail < 0 || e.J_tail >= e.J_entries || e.J_used < 0 || e.J_used > e.J_entries {
+			return nil, errors.New("el encabezado del journal está dañado")
+		}
+		j.inicio = int64(sb.JournalEnd()) - int64(TamanoJournal(e.J_entries))
+		return j, nil
+	}
+
+	legado, err := sb.leerJournalLegado(file)
+	if err != nil {
+		return nil, err
+	}
+	j.legado = legado
+	return j, nil
+}
+
+func (sb *Superbloque) leerJournalLegado(file *os.File) ([]EntradaJournal, error) {
+	tamano := int64(binary.Size(entradaLegado{}))
+	inicio := int64(sb.JournalEnd()) - JOURNAL_ENTRIES*tamano
+
+	var entradas []EntradaJournal
+	for i := int64(0); i < JOURNAL_ENTRIES; i++ {
+		var registro entradaLegado
+		if err := utilidades.LeerDesdeArchivo(file, inicio+i*tamano, &registro); err != nil {
+			return nil, fmt.Errorf("error leyendo la entrada %d del journal: %w", i, err)
 		}
-		operation := strings.TrimSpace(string(journal.J_content.I_operation[:]))
-		if operation == "" {
+		operacion := limpiarCampo(registro.J_content.I_operation[:])
+		if operacion == "" {
 			break
 		}
-		dotContent += journal.GenerateJournalTable(i)
+		entradas = append(entradas, EntradaJournal{
+			Secuencia:   uint32(i + 1),
+			Transaccion: uint32(i + 1),
+			Operacion:   operacion,
+			Ruta:        limpiarCampo(registro.J_content.I_path[:]),
+			Contenido:   limpiarCampo(registro.J_content.I_content[:]),
+			Fecha:       time.Unix(int64(registro.J_content.I_date), 0),
+		})
 	}
+	return entradas, nil
+}
+
+func limpiarCampo(buf []byte) string {
+	if fin := bytes.IndexByte(buf, 0); fin != -1 {
+		buf = buf[:fin]
+	}
+	return strings.TrimSpace(string(buf))
+}
 
-	return dotContent, nil
+func (j *journalParticion) posicion(indice int32) int64 {
+	return j.inicio + int64(indice)*int64(binary.Size(Journal{}))
 }
 
-func (journal *Journal) SaveJournalEntry(file *os.File, journaling_start int64, operation string, path string, content string) error {
-	journal.CreateJournalEntry(operation, path, content)
-	entrySize := int64(binary.Size(Journal{}))
-	offset := journaling_start + int64(journal.J_count)*entrySize
+func (j *journalParticion) guardarEncabezado() error {
+	posEncabezado := int64(j.sb.JournalEnd()) - int64(binary.Size(EncabezadoJournal{}))
+	if err := utilidades.EscribirEnArchivo(j.file, posEncabezado, &j.encabezado); err != nil {
+		return fmt.Errorf("error escribiendo el encabezado del journal: %w", err)
+	}
+	return nil
+}
+
+// InitializeJournalArea crea un journal vacío de entradas registros que termina en el bitmap de
+// inodos
+func InitializeJournalArea(file *os.File, sb *Superbloque, entradas int32) error {
+	inicio := int64(sb.JournalEnd()) - int64(TamanoJournal(entradas))
+	if err := ZeroRegion(file, inicio, int64(entradas)*int64(binary.Size(Journal{}))); err != nil {
+		return fmt.Errorf("error limpiando el área del journal: %w", err)
+	}
 
-	err := journal.Encode(file, offset)
+	j := &journalParticion{file: file, sb: sb, inicio: inicio}
+	j.encabezado = EncabezadoJournal{
+		J_magic:       MagicJournal,
+		J_entries:     entradas,
+		J_sequence:    1,
+		J_transaction: 1,
+	}
+	return j.guardarEncabezado()
+}
+
+// convertirLegado pasa un journal del formato anterior al circular en el mismo espacio y vuelve
+// a escribir sus entradas, cada una como una transacción
+func (j *journalParticion) convertirLegado() error {
+	area := JOURNAL_ENTRIES * int32(binary.Size(entradaLegado{}))
+	entradas := (area - int32(binary.Size(EncabezadoJournal{}))) / int32(binary.Size(Journal{}))
+	inicioArea := int64(j.sb.JournalEnd()) - int64(area)
+	if err := ZeroRegion(j.file, inicioArea, int64(area)); err != nil {
+		return fmt.Errorf("error limpiando el journal anterior: %w", err)
+	}
+	if err := InitializeJournalArea(j.file, j.sb, entradas); err != nil {
+		return err
+	}
+
+	legado := j.legado
+	nuevo, err := j.sb.abrirJournal(j.file)
 	if err != nil {
-		return fmt.Errorf("error al guardar la entrada de journal: %w", err)
+		return err
+	}
+	*j = *nuevo
+	for _, entrada := range legado {
+		t := &TransaccionJournal{}
+		t.Agregar(entrada.Operacion, entrada.Ruta, entrada.Contenido)
+		if err := j.escribir(t, entrada.Fecha); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func CalculateJournalingSpace(n int32) int64 {
-	return int64(n) * int64(binary.Size(Journal{}))
+// TransaccionJournal agrupa operaciones que se confirman juntas en el journal
+type TransaccionJournal struct {
+	operaciones []EntradaJournal
+	// numero es el número con que se confirmó; 0 si todavía no se confirmó
+	numero uint32
 }
 
-func InitializeJournalArea(file *os.File, journalStart int64, n int32) error {
-	entrySize := int64(binary.Size(Journal{}))
+func (t *TransaccionJournal) Agregar(operacion, ruta, contenido string) {
+	t.operaciones = append(t.operaciones, EntradaJournal{Operacion: operacion, Ruta: ruta, Contenido: contenido})
+}
 
-	nullJournal := &Journal{
-		J_content: Information{
-			I_operation: [10]byte{},
-			I_path:      [32]byte{},
-			I_content:   [64]byte{},
-			I_date:      0,
-		},
+// Igual indica si las dos transacciones tienen las mismas operaciones en el mismo orden
+func (t *TransaccionJournal) Igual(otra *TransaccionJournal) bool {
+	if len(t.operaciones) != len(otra.operaciones) {
+		return false
+	}
+	for i, op := range t.operaciones {
+		o := otra.operaciones[i]
+		if op.Operacion != o.Operacion || op.Ruta != o.Ruta || op.Contenido != o.Contenido {
+			return false
+		}
 	}
+	return true
+}
+
+// AgregarPropietario agrega las operaciones chown y chmod que dejan el inodo de la ruta con el
+// mismo dueño y permisos que inodo
+func (t *TransaccionJournal) AgregarPropietario(ruta string, inodo *Inodo) {
+	t.Agregar("chown", ruta, fmt.Sprintf("%d,%d", inodo.I_uid, inodo.I_gid))
+	t.Agregar("chmod", ruta, string(inodo.I_perm[:]))
+}
+
+// Operaciones que guardan cómo estaba una ruta antes de la operación que las sigue, para que
+// undo pueda revertirla. Al reproducir el journal no hacen nada.
+const (
+	PreimagenContenido   = "prev-data"
+	PreimagenPermisos    = "prev-perm"
+	PreimagenPropietario = "prev-owner"
+)
 
-	for i := int32(0); i < n; i++ {
-		nullJournal.J_count = i
-		offset := journalStart + entrySize*int64(i)
+// AgregarPreimagen guarda el dueño y los permisos que tiene ahora el inodo de la ruta
+func (t *TransaccionJournal) AgregarPreimagen(ruta string, inodo *Inodo) {
+	t.Agregar(PreimagenPropietario, ruta, fmt.Sprintf("%d,%d", inodo.I_uid, inodo.I_gid))
+	t.Agregar(PreimagenPermisos, ruta, string(inodo.I_perm[:]))
+}
 
-		if err := utilidades.EscribirEnArchivo(file, offset, nullJournal); err != nil {
-			return fmt.Errorf("error inicializando journal slot %d (off %d): %w", i, offset, err)
+// OperacionAnulada marca una transacción que se confirmó antes de hacer la operación pero que
+// no se hizo o no se hizo como se registró; su contenido es el número de esa transacción.
+// Recovery, la reproducción al montar y undo saltan las transacciones anuladas.
+const OperacionAnulada = "abort"
+
+// OperacionMontaje marca un montaje con la partición consistente: al montar una partición que no
+// se desmontó correctamente solo se reproducen las transacciones posteriores a la última marca
+const OperacionMontaje = "mount"
+
+// OperacionPuntoControl marca que las transacciones hasta la del número de su contenido, ya
+// aplicadas en el disco, se descartaron del journal para hacer espacio. La misma transacción
+// guarda users.txt como estaba en ese momento, para que recovery pueda reconstruir los usuarios.
+const OperacionPuntoControl = "checkpoint"
+
+// registrosAnulacion son los registros que ocupa una transacción abort (uno de datos y el
+// commit). Se dejan libres al confirmar cualquier otra transacción para poder anularla siempre.
+const registrosAnulacion = 2
+
+// TransaccionesDescartadas devuelve el número de la última transacción que un punto de control
+// descartó del journal, o 0 si el journal conserva todo desde el formateo
+func TransaccionesDescartadas(entries []EntradaJournal) uint32 {
+	var descartadas uint32
+	for _, e := range entries {
+		if e.Operacion != OperacionPuntoControl {
+			continue
+		}
+		if numero, err := strconv.ParseUint(e.Contenido, 10, 32); err == nil && uint32(numero) > descartadas {
+			descartadas = uint32(numero)
 		}
 	}
+	return descartadas
+}
 
-	return nil
+// transaccionesAnuladas devuelve las transacciones anuladas por alguna operación abort
+func transaccionesAnuladas(entries []EntradaJournal) map[uint32]bool {
+	anuladas := make(map[uint32]bool)
+	for _, e := range entries {
+		if e.Operacion != OperacionAnulada {
+			continue
+		}
+		if numero, err := strconv.ParseUint(e.Contenido, 10, 32); err == nil {
+			anuladas[uint32(numero)] = true
+		}
+	}
+	return anuladas
 }
 
-func FindValidJournalEntries(file *os.File, journalStart int64, maxEntries int32) ([]Journal, error) {
-	var entries []Journal
-	entrySize := int64(binary.Size(Journal{}))
+// Cada operación se guarda como: largo de la operación (1 byte), operación, largo de la ruta
+// (2 bytes), ruta, largo del contenido (4 bytes) y contenido
+func (t *TransaccionJournal) serializar() []byte {
+	var buf bytes.Buffer
+	for _, op := range t.operaciones {
+		buf.WriteByte(byte(len(op.Operacion)))
+		buf.WriteString(op.Operacion)
+		binary.Write(&buf, binary.LittleEndian, uint16(len(op.Ruta)))
+		buf.WriteString(op.Ruta)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(op.Contenido)))
+		buf.WriteString(op.Contenido)
+	}
+	return buf.Bytes()
+}
 
-	validOps := map[string]bool{
-		"mkdir": true, "mkfile": true, "rm": true, "rmdir": true,
-		"edit": true, "cat": true, "rename": true, "copy": true,
+func deserializarOperaciones(datos []byte) ([]EntradaJournal, error) {
+	var operaciones []EntradaJournal
+	lector := bytes.NewReader(datos)
+	leerCampo := func(largo int) (string, error) {
+		campo := make([]byte, largo)
+		_, err := io.ReadFull(lector, campo)
+		return string(campo), err
 	}
 
-	for i := int32(0); i < maxEntries; i++ {
-		offset := journalStart + int64(i)*entrySize
-		journal := &Journal{}
+	for lector.Len() > 0 {
+		var largoOp uint8
+		var largoRuta uint16
+		var largoContenido uint32
+		var op EntradaJournal
+		var err error
 
-		if err := journal.Decode(file, offset); err != nil {
-			break
+		if err = binary.Read(lector, binary.LittleEndian, &largoOp); err != nil {
+			return nil, err
 		}
-
-		rawOp := journal.J_content.I_operation[:]
-		nullPos := 0
-		for ; nullPos < len(rawOp); nullPos++ {
-			if rawOp[nullPos] == 0 {
-				break
-			}
+		if op.Operacion, err = leerCampo(int(largoOp)); err != nil {
+			return nil, err
 		}
-		operation := string(rawOp[:nullPos])
-		operation = strings.TrimSpace(operation)
-
-		if operation == "" {
-			break
+		if err = binary.Read(lector, binary.LittleEndian, &largoRuta); err != nil {
+			return nil, err
+		}
+		if op.Ruta, err = leerCampo(int(largoRuta)); err != nil {
+			return nil, err
+		}
+		if err = binary.Read(lector, binary.LittleEndian, &largoContenido); err != nil {
+			return nil, err
+		}
+		if int64(largoContenido) > int64(lector.Len()) {
+			return nil, errors.New("contenido incompleto")
 		}
+		if op.Contenido, err = leerCampo(int(largoContenido)); err != nil {
+			return nil, err
+		}
+		operaciones = append(operaciones, op)
+	}
+	return operaciones, nil
+}
 
-		if _, ok := validOps[operation]; !ok {
-			continue
+// Confirmar escribe los registros de datos de la transacción y después su commit. Si el journal
+// no tiene espacio descarta las transacciones más antiguas y deja un punto de control.
+func (t *TransaccionJournal) Confirmar(file *os.File, sb *Superbloque) error {
+	if len(t.operaciones) == 0 {
+		return nil
+	}
+
+	j, err := sb.abrirJournal(file)
+	if err != nil {
+		return err
+	}
+	if j.encabezado.J_magic != MagicJournal {
+		if err := j.convertirLegado(); err != nil {
+			return fmt.Errorf("error convirtiendo el journal al formato circular: %w", err)
 		}
-		entries = append(entries, *journal)
 	}
 
-	return entries, nil
+	if err := j.escribir(t, time.Now()); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("error sincronizando archivo: %w", err)
+	}
+	return nil
 }
 
-func IsEmptyJournal(j *Journal) bool {
-	op := strings.TrimSpace(
-		string(bytes.TrimRight(j.J_content.I_operation[:], "\x00")),
-	)
-	return op == ""
+// registrosNecesarios devuelve cuántos registros ocupan los datos serializados de una
+// transacción, contando el commit
+func registrosNecesarios(datos []byte) int32 {
+	tamanoDatos := len(Journal{}.J_data)
+	return int32((len(datos)+tamanoDatos-1)/tamanoDatos) + 1
 }
 
-func AddJournalEntry(file *os.File, journalStart int64, maxEntries int32, operation string, path string, content string, sb *Superbloque) error {
-	expectedStart := int64(sb.JournalStart())
-	if journalStart != expectedStart {
-		journalStart = expectedStart
+func (j *journalParticion) escribir(t *TransaccionJournal, fecha time.Time) error {
+	datos := t.serializar()
+	necesarios := registrosNecesarios(datos)
+	e := &j.encabezado
+
+	reserva := int32(registrosAnulacion)
+	if len(t.operaciones) > 0 && t.operaciones[0].Operacion == OperacionAnulada {
+		reserva = 0
+	}
+	if necesarios+reserva > e.J_entries {
+		return fmt.Errorf("la operación ocupa %d registros y el journal solo tiene %d", necesarios, e.J_entries)
+	}
+	if e.J_used+necesarios+reserva > e.J_entries {
+		if err := j.puntoControl(necesarios+reserva, fecha); err != nil {
+			return err
+		}
 	}
 
-	nextIndex, err := GetNextEmptyJournalIndex(file, journalStart, maxEntries)
+	numero, err := j.agregar(datos, fecha)
 	if err != nil {
-		return fmt.Errorf("error buscando el siguiente índice disponible: %w", err)
+		return err
+	}
+	t.numero = numero
+	return j.guardarEncabezado()
+}
+
+// agregar escribe en la cola los registros de datos y el commit de una transacción que ya cabe
+// y devuelve su número; el encabezado lo guarda quien llama
+func (j *journalParticion) agregar(datos []byte, fecha time.Time) (uint32, error) {
+	e := &j.encabezado
+	transaccion := e.J_transaction
+	e.J_transaction++
+	escribirRegistro := func(registro *Journal) error {
+		registro.J_sequence = e.J_sequence
+		registro.J_transaction = transaccion
+		registro.J_date = uint32(fecha.Unix())
+		if err := registro.Encode(j.file, j.posicion(e.J_tail)); err != nil {
+			return fmt.Errorf("error escribiendo el registro %d del journal: %w", e.J_sequence, err)
+		}
+		e.J_sequence++
+		e.J_tail = (e.J_tail + 1) % e.J_entries
+		e.J_used++
+		return nil
+	}
+
+	tamanoDatos := len(Journal{}.J_data)
+	registrosDatos := int(registrosNecesarios(datos)) - 1
+	for i := 0; i < registrosDatos; i++ {
+		registro := &Journal{J_type: [1]byte{RegistroDatos}}
+		registro.J_length = int32(copy(registro.J_data[:], datos[i*tamanoDatos:]))
+		if err := escribirRegistro(registro); err != nil {
+			return 0, err
+		}
 	}
 
-	if nextIndex >= maxEntries {
-		nextIndex = 0
+	commit := &Journal{J_type: [1]byte{RegistroCommit}, J_length: int32(len(datos))}
+	binary.LittleEndian.PutUint32(commit.J_data[0:4], crc32.ChecksumIEEE(datos))
+	binary.LittleEndian.PutUint32(commit.J_data[4:8], uint32(registrosDatos))
+	if err := escribirRegistro(commit); err != nil {
+		return 0, err
 	}
+	return transaccion, nil
+}
 
-	journal := &Journal{
-		J_count: nextIndex,
+// descartarTransaccion avanza la cabeza del journal hasta pasar el commit más antiguo y devuelve
+// el número de la transacción descartada
+func (j *journalParticion) descartarTransaccion() (uint32, error) {
+	e := &j.encabezado
+	var transaccion uint32
+	for e.J_used > 0 {
+		registro := &Journal{}
+		if err := registro.Decode(j.file, j.posicion(e.J_head)); err != nil {
+			return 0, err
+		}
+		e.J_head = (e.J_head + 1) % e.J_entries
+		e.J_used--
+		transaccion = registro.J_transaction
+		if registro.J_type[0] == RegistroCommit {
+			break
+		}
 	}
+	return transaccion, nil
+}
 
-	journal.CreateJournalEntry(operation, path, content)
-	offset := journalStart + int64(nextIndex)*int64(binary.Size(Journal{}))
+// transaccionControl arma el punto de control que descarta hasta la transacción indicada; si
+// usuarios es verdadero también guarda users.txt con su dueño y permisos
+func (j *journalParticion) transaccionControl(descartadas uint32, usuarios bool) *TransaccionJournal {
+	t := &TransaccionJournal{}
+	t.Agregar(OperacionPuntoControl, "/", strconv.FormatUint(uint64(descartadas), 10))
+	if !usuarios {
+		return t
+	}
 
-	journalEnd := int64(sb.JournalEnd())
-	if offset >= journalEnd {
-		return fmt.Errorf("error: intento de escritura fuera del área de journal (%d >= %d)",
-			offset, journalEnd)
+	indice, err := j.sb.BuscarInodoPorRuta(j.file, "/users.txt")
+	if err != nil {
+		return t
+	}
+	inodo := &Inodo{}
+	if err := inodo.Decode(j.file, j.sb.CalculateInodeOffset(indice)); err != nil {
+		return t
+	}
+	datos, err := inodo.ReadData(j.file, j.sb)
+	if err != nil {
+		return t
 	}
+	// mkfile no reemplaza un archivo que ya existe, así que el contenido va en un edit aparte
+	t.Agregar("mkfile", "/users.txt", "")
+	t.Agregar("edit", "/users.txt", OcultarContrasenas(string(datos)))
+	t.AgregarPropietario("/users.txt", inodo)
+	return t
+}
 
-	if err := journal.Encode(file, offset); err != nil {
-		return fmt.Errorf("error escribiendo nueva entrada de journal: %w", err)
+// puntoControl hace espacio para espacio registros. Las transacciones anteriores a la que se va a
+// escribir ya están aplicadas en el disco, así que se descartan desde la cabeza hasta dejar libre
+// lo pedido y al menos la cuarta parte del journal, para no escribir un punto de control por
+// cada transacción. En su lugar queda una transacción checkpoint, sin users.txt si no cabe.
+func (j *journalParticion) puntoControl(espacio int32, fecha time.Time) error {
+	e := &j.encabezado
+	// el número de la última descartada se estima con el largo máximo
+	control := j.transaccionControl(^uint32(0), true)
+	minimo := espacio + registrosNecesarios(control.serializar())
+	if minimo > e.J_entries {
+		control = j.transaccionControl(^uint32(0), false)
+		minimo = espacio + registrosNecesarios(control.serializar())
+	}
+	if minimo > e.J_entries {
+		return fmt.Errorf("la operación ocupa %d registros y el journal solo tiene %d", espacio, e.J_entries)
 	}
+	objetivo := max(minimo, e.J_entries/4)
 
-	if err := file.Sync(); err != nil {
-		return fmt.Errorf("error sincronizando archivo: %w", err)
+	var descartadas uint32
+	for e.J_used > 0 && e.J_entries-e.J_used < objetivo {
+		numero, err := j.descartarTransaccion()
+		if err != nil {
+			return err
+		}
+		descartadas = numero
 	}
 
+	conUsuarios := len(control.operaciones) > 1
+	control = j.transaccionControl(descartadas, conUsuarios)
+	if _, err := j.agregar(control.serializar(), fecha); err != nil {
+		return err
+	}
 	return nil
 }
 
-func GetNextEmptyJournalIndex(file *os.File, journalStart int64, maxEntries int32) (int32, error) {
-	entrySize := int64(binary.Size(Journal{}))
+// leer devuelve las operaciones de las transacciones confirmadas en orden de secuencia. Los
+// registros sin commit o cuyo contenido no coincide con el commit se ignoran.
+func (j *journalParticion) leer() ([]EntradaJournal, error) {
+	if j.encabezado.J_magic != MagicJournal {
+		return j.legado, nil
+	}
 
-	for i := int32(0); i < maxEntries; i++ {
-		offset := journalStart + entrySize*int64(i)
+	var entradas []EntradaJournal
+	var pendientes []Journal
+	e := j.encabezado
+	for i := int32(0); i < e.J_used; i++ {
+		registro := Journal{}
+		if err := registro.Decode(j.file, j.posicion((e.J_head+i)%e.J_entries)); err != nil {
+			return nil, err
+		}
 
-		j := &Journal{}
-		if err := j.Decode(file, offset); err != nil {
-			return -1, fmt.Errorf("leer journal[%d] en off %d: %w", i, offset, err)
+		if registro.J_type[0] == RegistroDatos {
+			if len(pendientes) > 0 && pendientes[0].J_transaction != registro.J_transaction {
+				pendientes = nil
+			}
+			pendientes = append(pendientes, registro)
+			continue
+		}
+		if registro.J_type[0] != RegistroCommit {
+			pendientes = nil
+			continue
+		}
+
+		operaciones, ok := validarTransaccion(pendientes, registro)
+		pendientes = nil
+		if !ok {
+			continue
+		}
+		for k := range operaciones {
+			operaciones[k].Secuencia = registro.J_sequence - uint32(binary.LittleEndian.Uint32(registro.J_data[4:8]))
+			operaciones[k].Transaccion = registro.J_transaction
+			operaciones[k].Fecha = time.Unix(int64(registro.J_date), 0)
 		}
+		entradas = append(entradas, operaciones...)
+	}
+	return entradas, nil
+}
+
+func validarTransaccion(datos []Journal, commit Journal) ([]EntradaJournal, bool) {
+	if uint32(len(datos)) != binary.LittleEndian.Uint32(commit.J_data[4:8]) {
+		return nil, false
+	}
 
-		if IsEmptyJournal(j) {
-			return i, nil
+	var contenido []byte
+	for i, registro := range datos {
+		if registro.J_transaction != commit.J_transaction ||
+			registro.J_sequence != commit.J_sequence-uint32(len(datos)-i) ||
+			registro.J_length < 0 || int(registro.J_length) > len(registro.J_data) {
+			return nil, false
 		}
+		contenido = append(contenido, registro.J_data[:registro.J_length]...)
+	}
+	if int32(len(contenido)) != commit.J_length ||
+		crc32.ChecksumIEEE(contenido) != binary.LittleEndian.Uint32(commit.J_data[0:4]) {
+		return nil, false
+	}
+
+	operaciones, err := deserializarOperaciones(contenido)
+	if err != nil {
+		return nil, false
 	}
+	return operaciones, true
+}
+
+// FindValidJournalEntries devuelve las operaciones confirmadas del journal, de la más antigua a
+// la más reciente
+func FindValidJournalEntries(file *os.File, sb *Superbloque) ([]EntradaJournal, error) {
+	j, err := sb.abrirJournal(file)
+	if err != nil {
+		return nil, err
+	}
+	return j.leer()
+}
 
-	return 0, nil
+// EstadoJournal devuelve el encabezado del journal; en un journal del formato anterior J_magic
+// es 0
+func EstadoJournal(file *os.File, sb *Superbloque) (EncabezadoJournal, error) {
+	j, err := sb.abrirJournal(file)
+	if err != nil {
+		return EncabezadoJournal{}, err
+	}
+	if j.encabezado.J_magic != MagicJournal {
+		usadas := int32(len(j.legado))
+		return EncabezadoJournal{J_entries: JOURNAL_ENTRIES, J_used: usadas, J_sequence: uint32(usadas) + 1}, nil
+	}
+	return j.encabezado, nil
+}
+
+// AddJournalEntry registra una operación como una transacción propia
+func AddJournalEntry(file *os.File, sb *Superbloque, operation string, path string, content string) error {
+	t := &TransaccionJournal{}
+	t.Agregar(operation, path, content)
+	return t.Confirmar(file, sb)
+}
+
+// RegistrarJournal confirma la transacción si la partición es EXT3. Se llama antes de hacer la
+// operación en el disco (write-ahead): si devuelve un error, la operación no debe hacerse.
+func (sb *Superbloque) RegistrarJournal(file *os.File, t *TransaccionJournal) error {
+	if sb.TipoSistema() != 3 {
+		return nil
+	}
+	if err := t.Confirmar(file, sb); err != nil {
+		return fmt.Errorf("error registrando la operación en el journal: %w", err)
+	}
+	return nil
+}
+
+// ReemplazarJournal anula una transacción ya registrada y confirma en su lugar real, con lo que
+// la operación hizo de verdad; si real no tiene operaciones solo se anula
+func (sb *Superbloque) ReemplazarJournal(file *os.File, registrada, real *TransaccionJournal) error {
+	if sb.TipoSistema() != 3 || registrada.numero == 0 {
+		return nil
+	}
+	anulacion := &TransaccionJournal{}
+	anulacion.Agregar(OperacionAnulada, "", strconv.FormatUint(uint64(registrada.numero), 10))
+	if err := sb.RegistrarJournal(file, anulacion); err != nil {
+		return err
+	}
+	return sb.RegistrarJournal(file, real)
+}
+
+// AplicarConJournal registra la transacción y después hace la operación. Si el journal no la
+// acepta la operación no se hace; si la operación falla, la transacción se reemplaza por las
+// operaciones que alcanzaron a verse en el disco.
+func (sb *Superbloque) AplicarConJournal(file *os.File, t *TransaccionJournal, aplicar func() error) error {
+	if err := sb.RegistrarJournal(file, t); err != nil {
+		return err
+	}
+	err := aplicar()
+	if err == nil || t.numero == 0 {
+		return err
+	}
+	if errJournal := sb.ReemplazarJournal(file, t, sb.operacionesHechas(file, t)); errJournal != nil {
+		return fmt.Errorf("%w (además, %v)", err, errJournal)
+	}
+	return err
 }
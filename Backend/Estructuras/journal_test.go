@@ -0,0 +1,216 @@
+package estructuras
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// journalPrueba crea una partición EXT3 recién formateada con un journal de entradas registros
+func journalPrueba(t *testing.T, entradas int32) (*journalParticion, *Superbloque) {
+	t.Helper()
+	silenciarSalida(t)
+	file, sb := particionPrueba(t, 64, entradas)
+	j, err := sb.abrirJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j, sb
+}
+
+// registroEn lee el registro de la posición indice del journal
+func registroEn(t *testing.T, j *journalParticion, indice int32) Journal {
+	t.Helper()
+	registro := Journal{}
+	if err := registro.Decode(j.file, j.posicion(indice)); err != nil {
+		t.Fatal(err)
+	}
+	return registro
+}
+
+func TestTamanoRegistros(t *testing.T) {
+	if tamano := binary.Size(Journal{}); tamano != 128 {
+		t.Errorf("Journal ocupa %d bytes, se esperaba 128", tamano)
+	}
+	if tamano := binary.Size(EncabezadoJournal{}); tamano != 28 {
+		t.Errorf("EncabezadoJournal ocupa %d bytes, se esperaba 28", tamano)
+	}
+	if tamano := TamanoJournal(16); tamano != 16*128+28 {
+		t.Errorf("TamanoJournal(16) = %d, se esperaba %d", tamano, 16*128+28)
+	}
+}
+
+func TestEncabezadoAlFinal(t *testing.T) {
+	j, sb := journalPrueba(t, 32)
+
+	// el encabezado termina justo donde empieza el bitmap de inodos
+	crudo := make([]byte, binary.Size(EncabezadoJournal{}))
+	if _, err := j.file.ReadAt(crudo, int64(sb.S_bm_inode_start)-int64(len(crudo))); err != nil {
+		t.Fatal(err)
+	}
+	if string(crudo[:4]) != "JRNL" {
+		t.Errorf("el encabezado empieza con %q, se esperaba \"JRNL\"", crudo[:4])
+	}
+	if entradas := int32(binary.LittleEndian.Uint32(crudo[4:8])); entradas != 32 {
+		t.Errorf("J_entries = %d, se esperaba 32", entradas)
+	}
+	if j.inicio != int64(sb.S_bm_inode_start)-int64(TamanoJournal(32)) {
+		t.Errorf("los registros empiezan en %d, se esperaba %d", j.inicio, int64(sb.S_bm_inode_start)-int64(TamanoJournal(32)))
+	}
+
+	// el formateo deja la raíz y users.txt, cada una en su transacción
+	entries, err := FindValidJournalEntries(j.file, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || entries[0].Operacion != "mkdir" || entries[0].Ruta != "/" {
+		t.Fatalf("la primera operación del journal es %+v, se esperaba mkdir /", entries)
+	}
+}
+
+func TestCommitConCRC(t *testing.T) {
+	j, sb := journalPrueba(t, 32)
+	contenido := strings.Repeat("abcdefghij", 30)
+	tx := &TransaccionJournal{}
+	tx.Agregar("mkfile", "/home/a.txt", contenido)
+	if err := tx.Confirmar(j.file, sb); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := sb.abrirJournal(j.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	datos := tx.serializar()
+	registros := registrosNecesarios(datos)
+	if registros != 4 {
+		t.Fatalf("registrosNecesarios = %d, se esperaba 4 (%d bytes en tres registros de datos y el commit)", registros, len(datos))
+	}
+
+	ultimo := (j.encabezado.J_tail - 1 + j.encabezado.J_entries) % j.encabezado.J_entries
+	commit := registroEn(t, j, ultimo)
+	if commit.J_type[0] != RegistroCommit || commit.J_transaction != tx.numero {
+		t.Fatalf("el último registro es %c de la transacción %d, se esperaba el commit de %d", commit.J_type[0], commit.J_transaction, tx.numero)
+	}
+	if commit.J_length != int32(len(datos)) {
+		t.Errorf("J_length del commit = %d, se esperaba %d", commit.J_length, len(datos))
+	}
+	if crc := binary.LittleEndian.Uint32(commit.J_data[0:4]); crc != crc32.ChecksumIEEE(datos) {
+		t.Errorf("CRC del commit = %08x, se esperaba %08x", crc, crc32.ChecksumIEEE(datos))
+	}
+	if cantidad := binary.LittleEndian.Uint32(commit.J_data[4:8]); cantidad != 3 {
+		t.Errorf("el commit cuenta %d registros de datos, se esperaban 3", cantidad)
+	}
+
+	// un byte cambiado en los datos invalida la transacción completa
+	primero := (ultimo - 3 + j.encabezado.J_entries) % j.encabezado.J_entries
+	dato := registroEn(t, j, primero)
+	dato.J_data[20] ^= 0xFF
+	if err := dato.Encode(j.file, j.posicion(primero)); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := FindValidJournalEntries(j.file, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Transaccion == tx.numero {
+			t.Fatalf("la transacción %d con un registro dañado se leyó como válida: %+v", tx.numero, e)
+		}
+	}
+}
+
+func TestAnulacion(t *testing.T) {
+	j, sb := journalPrueba(t, 16)
+	tx := &TransaccionJournal{}
+	tx.Agregar("mkdir", "/home", "")
+	if err := tx.Confirmar(j.file, sb); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.ReemplazarJournal(j.file, tx, &TransaccionJournal{}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := FindValidJournalEntries(j.file, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ultima := entries[len(entries)-1]
+	if ultima.Operacion != OperacionAnulada || ultima.Contenido != strconv.FormatUint(uint64(tx.numero), 10) {
+		t.Errorf("la última operación es %s %q, se esperaba abort %d", ultima.Operacion, ultima.Contenido, tx.numero)
+	}
+	if !transaccionesAnuladas(entries)[tx.numero] {
+		t.Errorf("la transacción %d no quedó anulada", tx.numero)
+	}
+}
+
+func TestJournalLleno(t *testing.T) {
+	j, sb := journalPrueba(t, 16)
+
+	var ultima uint32
+	for i := 0; i < 40; i++ {
+		tx := &TransaccionJournal{}
+		tx.Agregar("mkdir", "/d"+strconv.Itoa(i), "")
+		if err := tx.Confirmar(j.file, sb); err != nil {
+			t.Fatalf("transacción %d: %v", i, err)
+		}
+		ultima = tx.numero
+
+		estado, err := EstadoJournal(j.file, sb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// siempre queda lugar para anular la transacción recién confirmada
+		if libres := estado.J_entries - estado.J_used; libres < registrosAnulacion {
+			t.Fatalf("transacción %d: quedan %d registros libres, se esperaban al menos %d", i, libres, registrosAnulacion)
+		}
+	}
+
+	entries, err := FindValidJournalEntries(j.file, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[len(entries)-1].Transaccion != ultima || entries[len(entries)-1].Ruta != "/d39" {
+		t.Errorf("la última operación es %+v, se esperaba mkdir /d39 de la transacción %d", entries[len(entries)-1], ultima)
+	}
+	descartadas := TransaccionesDescartadas(entries)
+	if descartadas == 0 {
+		t.Fatal("el journal dio la vuelta sin dejar un punto de control")
+	}
+	for _, e := range entries {
+		if e.Transaccion <= descartadas && e.Operacion != OperacionPuntoControl {
+			t.Errorf("la transacción %d sigue en el journal aunque el punto de control descartó hasta la %d", e.Transaccion, descartadas)
+		}
+	}
+
+	// el punto de control guarda users.txt para que recovery pueda reconstruir los usuarios
+	conUsuarios := false
+	for _, e := range entries {
+		if e.Operacion == "edit" && e.Ruta == "/users.txt" && strings.Contains(e.Contenido, "1,G,root") {
+			conUsuarios = true
+		}
+	}
+	if !conUsuarios {
+		t.Error("ningún punto de control guarda el contenido de users.txt")
+	}
+
+	// una transacción más grande que todo el journal se rechaza sin escribir nada
+	antes, err := EstadoJournal(j.file, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grande := &TransaccionJournal{}
+	grande.Agregar("mkfile", "/grande.txt", strings.Repeat("x", 16*111))
+	if err := grande.Confirmar(j.file, sb); err == nil {
+		t.Error("se confirmó una transacción más grande que el journal")
+	}
+	despues, err := EstadoJournal(j.file, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if despues != antes {
+		t.Errorf("el encabezado cambió al rechazar la transacción: %+v, antes %+v", despues, antes)
+	}
+}
@@ -1,12 +1,19 @@
 package estructuras
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math/rand"
 	"os"
+	"sort"
 )
 
+// ZeroRegion escribe ceros en el rango indicado en trozos de hasta 1 MiB
 func ZeroRegion(f *os.File, offset, length int64) error {
-	const chunk = 4096
+	chunk := int64(1 << 20)
+	if length < chunk {
+		chunk = length
+	}
 	buf := make([]byte, chunk)
 	var written int64
 	for written < length {
@@ -28,6 +35,7 @@ func CleanLossAreas(f *os.File, sb *Superbloque) error {
 	inodeSize := int64(sb.S_inode_size)
 	blockSize := int64(sb.S_block_size)
 
+	DescartarBitmaps(f.Name())
 	bmpInodeLen := (totalInodes + 7) / 8
 	if err := ZeroRegion(f, int64(sb.S_bm_inode_start), bmpInodeLen); err != nil {
 		return err
@@ -44,3 +52,194 @@ func CleanLossAreas(f *os.File, sb *Superbloque) error {
 	}
 	return f.Sync()
 }
+
+// Modos de loss; ModoPerdidaTodo es el comportamiento original que borra todas las áreas
+const (
+	ModoPerdidaTodo         = "all"
+	ModoPerdidaBitmapInodos = "bm_inode"
+	ModoPerdidaBitmapBloque = "bm_block"
+	ModoPerdidaInodos       = "inodes"
+	ModoPerdidaBloques      = "blocks"
+	ModoPerdidaSuperbloque  = "superblock"
+	ModoPerdidaAleatorio    = "random"
+)
+
+// OpcionesPerdida indica qué estructura se daña y qué porcentaje de sus unidades en uso; la
+// semilla hace que la elección de unidades se repita entre ejecuciones
+type OpcionesPerdida struct {
+	Modo       string
+	Porcentaje int
+	Semilla    int64
+}
+
+// AreaPerdida resume lo que se destruyó de un área: cuántas unidades estaban en uso y cuáles
+// se perdieron
+type AreaPerdida struct {
+	Nombre     string
+	Unidad     string
+	EnUso      int
+	Destruidas []int32
+}
+
+// areaDisco describe un área de la partición dividida en unidades del mismo tamaño
+type areaDisco struct {
+	nombre string
+	unidad string
+	inicio int64
+	tamano int64
+	enUso  []int32
+	esMapa bool
+	total  int32
+}
+
+// SimularPerdida daña la partición según el modo indicado y devuelve el resumen por área. Las
+// unidades en uso se toman de los bitmaps antes de dañar nada.
+func SimularPerdida(f *os.File, sb *Superbloque, partStart int32, opciones OpcionesPerdida) ([]AreaPerdida, error) {
+	if opciones.Porcentaje < 1 || opciones.Porcentaje > 100 {
+		return nil, fmt.Errorf("el porcentaje debe estar entre 1 y 100: %d", opciones.Porcentaje)
+	}
+	defer DescartarBitmaps(f.Name())
+
+	if opciones.Modo == ModoPerdidaSuperbloque {
+		if err := ZeroRegion(f, int64(partStart), int64(binary.Size(Superbloque{}))); err != nil {
+			return nil, err
+		}
+		return []AreaPerdida{{Nombre: "superbloque principal", Unidad: "superbloques", EnUso: 1, Destruidas: []int32{0}}}, f.Sync()
+	}
+
+	areas, err := areasPerdida(f, sb)
+	if err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(opciones.Semilla))
+
+	var elegidas []areaDisco
+	switch opciones.Modo {
+	case ModoPerdidaTodo:
+		resumen := make([]AreaPerdida, 0, len(areas))
+		for _, area := range areas {
+			resumen = append(resumen, AreaPerdida{Nombre: area.nombre, Unidad: area.unidad, EnUso: len(area.enUso), Destruidas: area.enUso})
+		}
+		return resumen, CleanLossAreas(f, sb)
+	case ModoPerdidaBitmapInodos:
+		elegidas = areas[0:1]
+	case ModoPerdidaBitmapBloque:
+		elegidas = areas[1:2]
+	case ModoPerdidaInodos:
+		elegidas = areas[2:3]
+	case ModoPerdidaBloques:
+		elegidas = areas[3:4]
+	case ModoPerdidaAleatorio:
+		elegidas = areas
+	default:
+		return nil, fmt.Errorf("modo de pérdida desconocido: %s", opciones.Modo)
+	}
+
+	resumen := make([]AreaPerdida, 0, len(elegidas))
+	for _, area := range elegidas {
+		destruidas := elegirUnidades(rng, area.enUso, opciones.Porcentaje)
+		if opciones.Modo == ModoPerdidaAleatorio {
+			err = area.sobrescribir(f, rng, destruidas)
+		} else {
+			err = area.borrar(f, destruidas, len(destruidas) == len(area.enUso))
+		}
+		if err != nil {
+			return nil, err
+		}
+		resumen = append(resumen, AreaPerdida{Nombre: area.nombre, Unidad: area.unidad, EnUso: len(area.enUso), Destruidas: destruidas})
+	}
+	return resumen, f.Sync()
+}
+
+// areasPerdida devuelve los bitmaps, la tabla de inodos y los bloques con las unidades que
+// los bitmaps marcan en uso
+func areasPerdida(f *os.File, sb *Superbloque) ([]areaDisco, error) {
+	totalInodos, totalBloques := sb.TotalInodos(), sb.TotalBloques()
+
+	mapaInodos := make([]byte, (totalInodos+7)/8)
+	if _, err := f.ReadAt(mapaInodos, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, fmt.Errorf("error leyendo el bitmap de inodos: %w", err)
+	}
+	mapaBloques := make([]byte, (totalBloques+7)/8)
+	if _, err := f.ReadAt(mapaBloques, int64(sb.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error leyendo el bitmap de bloques: %w", err)
+	}
+	inodosEnUso := posicionesOcupadas(mapaInodos, totalInodos)
+	bloquesEnUso := posicionesOcupadas(mapaBloques, totalBloques)
+
+	return []areaDisco{
+		{nombre: "bitmap de inodos", unidad: "bits", inicio: int64(sb.S_bm_inode_start), enUso: inodosEnUso, esMapa: true, total: totalInodos},
+		{nombre: "bitmap de bloques", unidad: "bits", inicio: int64(sb.S_bm_block_start), enUso: bloquesEnUso, esMapa: true, total: totalBloques},
+		{nombre: "tabla de inodos", unidad: "inodos", inicio: int64(sb.S_inode_start), tamano: int64(sb.S_inode_size), enUso: inodosEnUso, total: totalInodos},
+		{nombre: "bloques", unidad: "bloques", inicio: int64(sb.S_block_start), tamano: int64(sb.S_block_size), enUso: bloquesEnUso, total: totalBloques},
+	}, nil
+}
+
+func posicionesOcupadas(mapa []byte, total int32) []int32 {
+	var posiciones []int32
+	for posicion := int32(0); posicion < total; posicion++ {
+		if ocupado(mapa, posicion) {
+			posiciones = append(posiciones, posicion)
+		}
+	}
+	return posiciones
+}
+
+// elegirUnidades toma al azar el porcentaje indicado de las unidades (redondeando hacia
+// arriba) y las devuelve ordenadas
+func elegirUnidades(rng *rand.Rand, unidades []int32, porcentaje int) []int32 {
+	cantidad := (len(unidades)*porcentaje + 99) / 100
+	elegidas := append([]int32(nil), unidades...)
+	rng.Shuffle(len(elegidas), func(i, j int) { elegidas[i], elegidas[j] = elegidas[j], elegidas[i] })
+	elegidas = elegidas[:cantidad]
+	sort.Slice(elegidas, func(i, j int) bool { return elegidas[i] < elegidas[j] })
+	return elegidas
+}
+
+// borrar pone en cero las unidades indicadas; si son todas las que estaban en uso se borra el
+// área completa
+func (a areaDisco) borrar(f *os.File, unidades []int32, completa bool) error {
+	if a.esMapa {
+		if completa {
+			return ZeroRegion(f, a.inicio, int64(a.total+7)/8)
+		}
+		mapa := make([]byte, (a.total+7)/8)
+		if _, err := f.ReadAt(mapa, a.inicio); err != nil {
+			return fmt.Errorf("error leyendo el %s: %w", a.nombre, err)
+		}
+		for _, posicion := range unidades {
+			mapa[posicion/8] &^= 1 << (posicion % 8)
+		}
+		if _, err := f.WriteAt(mapa, a.inicio); err != nil {
+			return fmt.Errorf("error escribiendo el %s: %w", a.nombre, err)
+		}
+		return nil
+	}
+
+	if completa {
+		return ZeroRegion(f, a.inicio, int64(a.total)*a.tamano)
+	}
+	for _, posicion := range unidades {
+		if err := ZeroRegion(f, a.inicio+int64(posicion)*a.tamano, a.tamano); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sobrescribir llena las unidades indicadas con bytes aleatorios; en los bitmaps se reemplaza
+// el byte que contiene el bit de cada unidad
+func (a areaDisco) sobrescribir(f *os.File, rng *rand.Rand, unidades []int32) error {
+	for _, posicion := range unidades {
+		offset, tamano := a.inicio+int64(posicion)*a.tamano, a.tamano
+		if a.esMapa {
+			offset, tamano = a.inicio+int64(posicion/8), 1
+		}
+		basura := make([]byte, tamano)
+		rng.Read(basura)
+		if _, err := f.WriteAt(basura, offset); err != nil {
+			return fmt.Errorf("error escribiendo en el %s: %w", a.nombre, err)
+		}
+	}
+	return nil
+}
@@ -61,6 +61,96 @@ func (mbr *Mbr) ObtenerParticionPorID(id string) (*Partition, error) {
 	return nil, errors.New("partición no encontrada")
 }
 
+// BuscarParticionPorID busca el ID entre las particiones primarias y luego entre las lógicas
+func (mbr *Mbr) BuscarParticionPorID(file *os.File, id string) (*Partition, error) {
+	if strings.Trim(id, "\x00 ") == "" {
+		return nil, errors.New("partición no encontrada")
+	}
+
+	if partition, err := mbr.ObtenerParticionPorID(id); err == nil {
+		return partition, nil
+	}
+
+	ebr, err := mbr.ObtenerLogicaPorID(file, id)
+	if err != nil {
+		return nil, err
+	}
+	return ebr.Particion(), nil
+}
+
+// ObtenerParticionExtendida devuelve la partición extendida del disco o nil si no tiene
+func (mbr *Mbr) ObtenerParticionExtendida() *Partition {
+	for i := range mbr.Mbr_partitions {
+		if mbr.Mbr_partitions[i].Part_type[0] == 'E' && mbr.Mbr_partitions[i].Part_s > 0 {
+			return &mbr.Mbr_partitions[i]
+		}
+	}
+	return nil
+}
+
+// ObtenerEBRs recorre la cadena de EBR de la partición extendida y devuelve los que
+// describen una partición lógica, en orden
+func (mbr *Mbr) ObtenerEBRs(file *os.File) ([]Ebr, error) {
+	extendida := mbr.ObtenerParticionExtendida()
+	if extendida == nil {
+		return nil, nil
+	}
+
+	var ebrs []Ebr
+	visitados := make(map[int32]bool)
+	for inicio := extendida.Part_start; inicio != -1; {
+		if visitados[inicio] || inicio < extendida.Part_start || inicio >= extendida.Part_start+extendida.Part_s {
+			return nil, fmt.Errorf("cadena de EBR inválida en la posición %d", inicio)
+		}
+		visitados[inicio] = true
+
+		var ebr Ebr
+		if err := ebr.Decodificar(file, int64(inicio)); err != nil {
+			return nil, err
+		}
+		if ebr.Part_s > 0 {
+			ebrs = append(ebrs, ebr)
+		}
+		inicio = ebr.Part_next
+	}
+
+	return ebrs, nil
+}
+
+// ObtenerLogicaPorNombre devuelve el EBR de la partición lógica y su posición en la cadena
+func (mbr *Mbr) ObtenerLogicaPorNombre(file *os.File, name string) (*Ebr, int, error) {
+	ebrs, err := mbr.ObtenerEBRs(file)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	inputName := strings.Trim(name, "\x00 ")
+	for i := range ebrs {
+		ebrName := strings.Trim(string(ebrs[i].Part_name[:]), "\x00 ")
+		if strings.EqualFold(ebrName, inputName) {
+			return &ebrs[i], i, nil
+		}
+	}
+	return nil, -1, errors.New("partición no encontrada")
+}
+
+// ObtenerLogicaPorID devuelve el EBR de la partición lógica montada con el ID indicado
+func (mbr *Mbr) ObtenerLogicaPorID(file *os.File, id string) (*Ebr, error) {
+	ebrs, err := mbr.ObtenerEBRs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	inputID := strings.Trim(id, "\x00 ")
+	for i := range ebrs {
+		ebrID := strings.Trim(string(ebrs[i].Part_id[:]), "\x00 ")
+		if ebrID != "" && strings.EqualFold(ebrID, inputID) {
+			return &ebrs[i], nil
+		}
+	}
+	return nil, errors.New("partición no encontrada")
+}
+
 func (mbr *Mbr) TieneParticionExtendida() bool {
 	for _, partition := range mbr.Mbr_partitions {
 		if partition.Part_type[0] == 'E' {
@@ -0,0 +1,67 @@
+package estructuras
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// MontarSistemaArchivos cuenta el montaje en el superbloque y lo marca como sucio. Si ya estaba
+// sucio, la partición no se desmontó correctamente: en EXT3 se reproduce el journal y en EXT2
+// solo se avisa. Cuando la partición queda consistente, en EXT3 se deja la marca de montaje en
+// el journal. Se usa al montar y al restaurar los montajes cuando arranca el servidor.
+func MontarSistemaArchivos(file *os.File, partStart int32, salida io.Writer) error {
+	var sb Superbloque
+	if err := sb.Decodificar(file, int64(partStart)); err != nil {
+		return fmt.Errorf("no se pudo leer el superbloque: %v", err)
+	}
+	if !sb.Formateado() {
+		return nil
+	}
+
+	sucio := sb.Sucio()
+	sb.RegistrarMontaje()
+	if err := sb.Codificar(file, int64(partStart)); err != nil {
+		return fmt.Errorf("no se pudo actualizar el superbloque: %v", err)
+	}
+	fmt.Fprintf(salida, "Montaje número %d del sistema de archivos.\n", sb.S_mnt_count)
+
+	if sb.TipoSistema() != 3 {
+		if sucio {
+			fmt.Fprintln(salida, "Advertencia: la partición no se desmontó correctamente y EXT2 no tiene journal; revísela con fsck.")
+		}
+		return nil
+	}
+
+	if sucio {
+		fmt.Fprintln(salida, "La partición no se desmontó correctamente; reproduciendo el journal...")
+		aplicadas, err := ReproducirJournal(file, &sb, partStart)
+		if err != nil {
+			return fmt.Errorf("no se pudo reproducir el journal: %v; revise la partición con fsck", err)
+		}
+		if aplicadas == "" {
+			fmt.Fprintln(salida, "Journal reproducido: las operaciones registradas ya estaban completas en el disco.")
+		} else {
+			fmt.Fprintf(salida, "Journal reproducido: se completaron las operaciones interrumpidas '%s'.\n", aplicadas)
+		}
+	}
+
+	t := &TransaccionJournal{}
+	t.Agregar(OperacionMontaje, "/", "")
+	if err := sb.RegistrarJournal(file, t); err != nil {
+		return fmt.Errorf("no se pudo marcar el montaje en el journal: %v", err)
+	}
+	return nil
+}
+
+// AvisarMontajeSoloLectura no escribe en el superbloque; si la partición no se desmontó
+// correctamente solo avisa, porque reproducir el journal la modificaría
+func AvisarMontajeSoloLectura(file *os.File, partStart int32, salida io.Writer) {
+	var sb Superbloque
+	if err := sb.Decodificar(file, int64(partStart)); err != nil || !sb.Formateado() {
+		return
+	}
+	if sb.Sucio() {
+		fmt.Fprintln(salida, "Advertencia: la partición no se desmontó correctamente; en solo lectura no se reproduce el journal.")
+	}
+}
@@ -0,0 +1,186 @@
+package estructuras
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// LongitudNombreCorto es lo que cabe directamente en B_name
+	LongitudNombreCorto = 12
+	// LongitudNombreMaxima es el límite de un nombre con el formato de nombres largos
+	LongitudNombreMaxima = 255
+
+	// CaracteristicaNombresLargos se guarda en los bits altos de S_filesystem_type;
+	// las particiones formateadas antes de existir no la tienen
+	CaracteristicaNombresLargos int32 = 1 << 8
+
+	// marcaNombreLargo no es un byte válido en UTF-8, así que no choca con ningún nombre corto
+	marcaNombreLargo byte = 0xFF
+)
+
+// Una entrada con nombre largo guarda en B_name la marca, la longitud del nombre y el
+// primer bloque de nombre (little-endian). Cada bloque de nombre usa S_block_size-4 bytes
+// para el nombre y los últimos 4 para el siguiente bloque de la cadena (-1 al final).
+
+// TipoSistema devuelve 2 o 3 sin los bits de características
+func (sb *Superbloque) TipoSistema() int32 {
+	return sb.S_filesystem_type & 0xFF
+}
+
+func (sb *Superbloque) NombresLargos() bool {
+	return sb.S_filesystem_type&CaracteristicaNombresLargos != 0
+}
+
+// ValidarNombre comprueba que el nombre se pueda guardar en la partición sin recortarlo
+func (sb *Superbloque) ValidarNombre(nombre string) error {
+	if !utf8.ValidString(nombre) {
+		return fmt.Errorf("el nombre '%s' no es UTF-8 válido", nombre)
+	}
+	if len(nombre) <= LongitudNombreCorto {
+		return nil
+	}
+	if !sb.NombresLargos() {
+		return fmt.Errorf("el nombre '%s' ocupa %d bytes y la partición solo admite %d; formatéela de nuevo con mkfs para usar nombres largos",
+			nombre, len(nombre), LongitudNombreCorto)
+	}
+	if len(nombre) > LongitudNombreMaxima {
+		return fmt.Errorf("el nombre '%s' ocupa %d bytes, el máximo es %d", nombre, len(nombre), LongitudNombreMaxima)
+	}
+	return nil
+}
+
+// ValidarNombresRuta valida cada componente de una ruta antes de crear algo en ella
+func (sb *Superbloque) ValidarNombresRuta(ruta string) error {
+	for _, nombre := range strings.Split(ruta, "/") {
+		if nombre == "" {
+			continue
+		}
+		if err := sb.ValidarNombre(nombre); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fc *FolderContent) EsNombreLargo() bool {
+	return fc.B_name[0] == marcaNombreLargo
+}
+
+// NombreEntrada devuelve el nombre completo de la entrada; si la cadena de bloques
+// de un nombre largo está dañada devuelve una cadena vacía
+func (sb *Superbloque) NombreEntrada(archivo *os.File, contenido FolderContent) string {
+	nombre, _, err := sb.LeerNombreLargo(archivo, contenido)
+	if err != nil {
+		return ""
+	}
+	return nombre
+}
+
+// LeerNombreLargo devuelve el nombre de la entrada y los bloques de nombre que ocupa
+func (sb *Superbloque) LeerNombreLargo(archivo *os.File, contenido FolderContent) (string, []int32, error) {
+	if !contenido.EsNombreLargo() {
+		return strings.Trim(string(contenido.B_name[:]), "\x00 "), nil, nil
+	}
+
+	longitud := int(contenido.B_name[1])
+	siguiente := int32(binary.LittleEndian.Uint32(contenido.B_name[2:6]))
+	porBloque := int(sb.S_block_size) - 4
+
+	nombre := make([]byte, 0, longitud)
+	var bloques []int32
+	for len(nombre) < longitud {
+		if siguiente < 0 || siguiente >= sb.TotalBloques() {
+			return "", bloques, fmt.Errorf("bloque de nombre %d fuera de rango", siguiente)
+		}
+
+		bloque := sb.NuevoBloqueArchivo()
+		if err := bloque.Decode(archivo, int64(sb.S_block_start)+int64(siguiente)*int64(sb.S_block_size)); err != nil {
+			return "", bloques, err
+		}
+		bloques = append(bloques, siguiente)
+
+		faltan := longitud - len(nombre)
+		if faltan > porBloque {
+			faltan = porBloque
+		}
+		nombre = append(nombre, bloque.B_content[:faltan]...)
+		siguiente = int32(binary.LittleEndian.Uint32(bloque.B_content[porBloque:]))
+	}
+
+	return string(nombre), bloques, nil
+}
+
+// AsignarNombreEntrada escribe el nombre en la entrada; si no cabe en B_name reserva
+// los bloques de nombre necesarios
+func (sb *Superbloque) AsignarNombreEntrada(archivo *os.File, contenido *FolderContent, nombre string) error {
+	if err := sb.ValidarNombre(nombre); err != nil {
+		return err
+	}
+
+	contenido.B_name = [12]byte{}
+	if len(nombre) <= LongitudNombreCorto {
+		copy(contenido.B_name[:], nombre)
+		return nil
+	}
+
+	porBloque := int(sb.S_block_size) - 4
+	cantidad := (len(nombre) + porBloque - 1) / porBloque
+	bloques := make([]int32, 0, cantidad)
+	// Se toman del bitmap, que los marca como usados; si no alcanzan se devuelven los
+	// que ya se habían tomado
+	for len(bloques) < cantidad {
+		indice, err := sb.FindNextFreeBlock(archivo)
+		if err != nil {
+			for _, tomado := range bloques {
+				if errLiberar := sb.UpdateBitmapBlock(archivo, tomado, false); errLiberar != nil {
+					return fmt.Errorf("error liberando bloque de nombre %d: %w", tomado, errLiberar)
+				}
+			}
+			return fmt.Errorf("no hay bloques libres para el nombre '%s': %w", nombre, err)
+		}
+		bloques = append(bloques, indice)
+	}
+	for range bloques {
+		sb.UpdateSuperblockAfterBlockAllocation()
+	}
+
+	for i, indice := range bloques {
+		bloque := sb.NuevoBloqueArchivo()
+		copy(bloque.B_content[:porBloque], nombre[i*porBloque:])
+		siguiente := int32(-1)
+		if i+1 < len(bloques) {
+			siguiente = bloques[i+1]
+		}
+		binary.LittleEndian.PutUint32(bloque.B_content[porBloque:], uint32(siguiente))
+		if err := bloque.Encode(archivo, int64(sb.S_block_start)+int64(indice)*int64(sb.S_block_size)); err != nil {
+			return err
+		}
+	}
+
+	contenido.B_name[0] = marcaNombreLargo
+	contenido.B_name[1] = byte(len(nombre))
+	binary.LittleEndian.PutUint32(contenido.B_name[2:6], uint32(bloques[0]))
+	return nil
+}
+
+// LiberarNombreEntrada libera los bloques de nombre de una entrada que se va a borrar o renombrar.
+// Solo se limpia el bitmap: bajar S_blocks_count haría que la siguiente asignación secuencial
+// cayera sobre un bloque en uso.
+func (sb *Superbloque) LiberarNombreEntrada(archivo *os.File, contenido FolderContent) error {
+	if !contenido.EsNombreLargo() {
+		return nil
+	}
+
+	// Una cadena dañada se libera hasta donde se pudo leer; fsck recupera el resto
+	_, bloques, _ := sb.LeerNombreLargo(archivo, contenido)
+	for _, indice := range bloques {
+		if err := sb.UpdateBitmapBlock(archivo, indice, false); err != nil {
+			return fmt.Errorf("error liberando bloque de nombre %d: %w", indice, err)
+		}
+	}
+	return nil
+}
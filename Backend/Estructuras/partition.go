@@ -17,6 +17,10 @@ type Partition struct {
 	Part_id          [4]byte
 }
 
+// ParticionesMontables es cuántas particiones de un disco pueden montarse: el número de partición
+// ocupa un dígito del ID de 4 bytes, así que son las 4 primarias y las primeras 5 lógicas
+const ParticionesMontables = 9
+
 func (p *Partition) CrearParticion(partStart, partSize int, partType, partFit, partName string) {
 	p.Part_status[0] = '0'
 	p.Part_start = int32(partStart)
@@ -38,6 +42,7 @@ func (p *Partition) ImprimirParticion() {
 
 func (p *Partition) MontarParticion(correlative int, id string) error {
 	p.Part_correlative = int32(correlative)
+	p.Part_id = [4]byte{}
 	copy(p.Part_id[:], id)
 	return nil
 }
@@ -6,8 +6,14 @@ import (
 	"os"
 )
 
+// PointerBlock guarda S_block_size/4 apuntadores a bloques
 type PointerBlock struct {
-	B_pointers [16]int32
+	B_pointers []int32
+}
+
+// NuevoBloqueApuntadores devuelve un bloque de apuntadores del tamaño de bloque de la partición
+func (sb *Superbloque) NuevoBloqueApuntadores() *PointerBlock {
+	return &PointerBlock{B_pointers: make([]int32, sb.S_block_size/4)}
 }
 
 func (pb *PointerBlock) ReadSimpleIndirect(file *os.File, sb *Superbloque) ([]int32, error) {
@@ -24,7 +30,7 @@ func (pb *PointerBlock) ReadDoubleIndirect(file *os.File, sb *Superbloque) ([]in
 	var blocks []int32
 	for _, pointer := range pb.B_pointers {
 		if pointer != -1 {
-			secondaryPB := &PointerBlock{}
+			secondaryPB := sb.NuevoBloqueApuntadores()
 			err := secondaryPB.Decode(file, int64(sb.S_block_start+int32(pointer)*sb.S_block_size))
 			if err != nil {
 				return nil, err
@@ -107,7 +113,7 @@ func (pb *PointerBlock) Encode(file *os.File, offset int64) error {
 	if err != nil {
 		return fmt.Errorf("error buscando la posición en el archivo: %w", err)
 	}
-	err = binary.Write(file, binary.BigEndian, *pb)
+	err = binary.Write(file, binary.BigEndian, pb.B_pointers)
 	if err != nil {
 		return fmt.Errorf("error escribiendo el PointerBlock: %w", err)
 	}
@@ -115,11 +121,14 @@ func (pb *PointerBlock) Encode(file *os.File, offset int64) error {
 }
 
 func (pb *PointerBlock) Decode(file *os.File, offset int64) error {
+	if len(pb.B_pointers) == 0 {
+		return fmt.Errorf("el bloque de apuntadores no tiene tamaño; créelo con NuevoBloqueApuntadores")
+	}
 	_, err := file.Seek(offset, 0)
 	if err != nil {
 		return fmt.Errorf("error buscando la posición en el archivo: %w", err)
 	}
-	err = binary.Read(file, binary.BigEndian, pb)
+	err = binary.Read(file, binary.BigEndian, pb.B_pointers)
 	if err != nil {
 		return fmt.Errorf("error leyendo el PointerBlock: %w", err)
 	}
@@ -130,7 +139,7 @@ func (pb *PointerBlock) ReadTripleIndirect(file *os.File, sb *Superbloque) ([]in
 	var blocks []int32
 	for _, primPointer := range pb.B_pointers {
 		if primPointer != -1 {
-			secPB := &PointerBlock{}
+			secPB := sb.NuevoBloqueApuntadores()
 			secOffset := int64(sb.S_block_start + int32(primPointer)*sb.S_block_size)
 			if err := secPB.Decode(file, secOffset); err != nil {
 				return nil, fmt.Errorf("error leyendo bloque secundario: %w", err)
@@ -138,7 +147,7 @@ func (pb *PointerBlock) ReadTripleIndirect(file *os.File, sb *Superbloque) ([]in
 
 			for _, secPointer := range secPB.B_pointers {
 				if secPointer != -1 {
-					tercPB := &PointerBlock{}
+					tercPB := sb.NuevoBloqueApuntadores()
 					tercOffset := int64(sb.S_block_start + int32(secPointer)*sb.S_block_size)
 					if err := tercPB.Decode(file, tercOffset); err != nil {
 						return nil, fmt.Errorf("error leyendo bloque terciario: %w", err)
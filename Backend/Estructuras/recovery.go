@@ -1,18 +1,20 @@
 package estructuras
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
-	utilidades "godisk/Utilidades"
 	"os"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func cleanCString(buf []byte) string {
-	return strings.TrimSpace(string(bytes.TrimRight(buf, "\x00")))
+// ResultadoReproduccion indica si una operación del journal se pudo volver a aplicar
+type ResultadoReproduccion struct {
+	Entrada EntradaJournal
+	Err     error
 }
+
 func splitPath(p string) ([]string, string) {
 	p = strings.Trim(p, "/")
 	if p == "" {
@@ -22,13 +24,9 @@ func splitPath(p string) ([]string, string) {
 	return parts[:len(parts)-1], parts[len(parts)-1]
 }
 
+// ensureRoot crea la carpeta raíz en una partición recién limpiada y deja los contadores del
+// superbloque como si solo existiera ella, para que users.txt vuelva a ser el inodo 1
 func ensureRoot(f *os.File, sb *Superbloque) error {
-	in0 := &Inodo{}
-	if err := in0.Decode(f, int64(sb.S_inode_start)); err == nil &&
-		in0.I_type[0] == '0' {
-		return nil
-	}
-
 	if err := sb.UpdateBitmapInode(f, 0, true); err != nil {
 		return err
 	}
@@ -44,17 +42,17 @@ func ensureRoot(f *os.File, sb *Superbloque) error {
 		return err
 	}
 
-	b0 := NewFolderBlock(0, 0, map[string]int32{})
+	b0 := sb.NewFolderBlock(0, 0, map[string]int32{})
 	if err := b0.Encode(f, int64(sb.S_block_start)); err != nil {
 		return err
 	}
 
 	sb.S_inodes_count = 1
 	sb.S_blocks_count = 1
-	sb.S_free_inodes_count--
-	sb.S_free_blocks_count--
-	sb.S_first_ino += sb.S_inode_size
-	sb.S_first_blo += sb.S_block_size
+	sb.S_free_inodes_count = sb.TotalInodos() - 1
+	sb.S_free_blocks_count = sb.TotalBloques() - 1
+	sb.S_first_ino = sb.S_inode_start + sb.S_inode_size
+	sb.S_first_blo = sb.S_block_start + sb.S_block_size
 	return nil
 }
 
@@ -62,65 +60,391 @@ func wipeStructures(f *os.File, sb *Superbloque) error {
 	return CleanLossAreas(f, sb)
 }
 
-func replayJournal(f *os.File, sb *Superbloque, partStart int32) error {
-	jStart := int64(partStart) + int64(binary.Size(Superbloque{}))
+func (sb *Superbloque) existeRuta(f *os.File, ruta string) bool {
+	_, err := sb.BuscarInodoPorRuta(f, ruta)
+	return err == nil
+}
+
+// crearEntrada crea la carpeta o el archivo de la ruta con permisos 664; si ya existe no hace
+// nada. El dueño lo fijan las entradas chown y chmod que siguen en el journal.
+func (sb *Superbloque) crearEntrada(f *os.File, ruta string, carpeta bool, datos []byte) error {
+	ruta = path.Clean("/" + ruta)
+	if sb.existeRuta(f, ruta) {
+		return nil
+	}
+
+	padre := path.Dir(ruta)
+	padreIndex, err := sb.BuscarInodoPorRuta(f, padre)
+	if err != nil {
+		return fmt.Errorf("no existe la carpeta '%s'", padre)
+	}
 
-	entries, err := FindValidJournalEntries(f, jStart, JOURNAL_ENTRIES)
+	index, err := sb.AssignNewInode(f)
 	if err != nil {
 		return err
 	}
 
-	for _, e := range entries {
-		op := cleanCString(e.J_content.I_operation[:])
-		path := cleanCString(e.J_content.I_path[:])
-		data := cleanCString(e.J_content.I_content[:])
+	inodo := NewEmptyInode()
+	inodo.I_perm = [3]byte{'6', '6', '4'}
+	if carpeta {
+		inodo.I_type[0] = '0'
+		bloque, err := sb.AssignNewBlock(f, inodo, 0)
+		if err != nil {
+			return err
+		}
+		folderBlock := sb.NewFolderBlock(index, padreIndex, nil)
+		if err := folderBlock.Encode(f, int64(sb.S_block_start+(bloque*sb.S_block_size))); err != nil {
+			return err
+		}
+	} else {
+		inodo.I_type[0] = '1'
+		if err := inodo.WriteData(f, sb, datos); err != nil {
+			return err
+		}
+	}
+	if err := inodo.Encode(f, sb.CalculateInodeOffset(index)); err != nil {
+		return err
+	}
 
-		if path == "" {
-			continue
+	return sb.AgregarEntradaCarpeta(f, padreIndex, path.Base(ruta), index)
+}
+
+// ReemplazarContenido libera los bloques del archivo y escribe en su lugar los datos indicados
+func (sb *Superbloque) ReemplazarContenido(f *os.File, inodeIndex int32, datos []byte) error {
+	inode := &Inodo{}
+	if err := inode.Decode(f, sb.CalculateInodeOffset(inodeIndex)); err != nil {
+		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+	}
+	if inode.I_type[0] != '1' {
+		return fmt.Errorf("el inodo %d no corresponde a un archivo", inodeIndex)
+	}
+
+	if err := inode.FreeAllBlocks(f, sb); err != nil {
+		return fmt.Errorf("error al liberar los bloques del archivo: %v", err)
+	}
+	if err := inode.WriteData(f, sb, datos); err != nil {
+		return fmt.Errorf("error al escribir el contenido del archivo: %v", err)
+	}
+	if err := inode.Encode(f, sb.CalculateInodeOffset(inodeIndex)); err != nil {
+		return fmt.Errorf("error al actualizar el inodo %d: %v", inodeIndex, err)
+	}
+	return nil
+}
+
+// escribirArchivo reemplaza el contenido del archivo de la ruta o lo crea si no existe
+func (sb *Superbloque) escribirArchivo(f *os.File, ruta string, datos []byte) error {
+	index, err := sb.BuscarInodoPorRuta(f, ruta)
+	if err != nil {
+		return sb.crearEntrada(f, ruta, false, datos)
+	}
+	return sb.ReemplazarContenido(f, index, datos)
+}
+
+// modificarInodo aplica fn al inodo de la ruta y lo guarda
+func (sb *Superbloque) modificarInodo(f *os.File, ruta string, fn func(*Inodo) error) error {
+	index, err := sb.BuscarInodoPorRuta(f, ruta)
+	if err != nil {
+		return err
+	}
+	inode := &Inodo{}
+	if err := inode.Decode(f, sb.CalculateInodeOffset(index)); err != nil {
+		return fmt.Errorf("error al deserializar el inodo %d: %v", index, err)
+	}
+	if err := fn(inode); err != nil {
+		return err
+	}
+	inode.ActualizarCtime()
+	return inode.Encode(f, sb.CalculateInodeOffset(index))
+}
+
+// contenidoJournal devuelve el contenido que el journal guarda para la ruta. En users.txt las
+// contraseñas en texto plano se guardan ocultas, así que se toman las que siguen en el disco.
+func (sb *Superbloque) contenidoJournal(f *os.File, ruta, contenido string) []byte {
+	if ruta != "/users.txt" {
+		return []byte(contenido)
+	}
+	index, err := sb.BuscarInodoPorRuta(f, ruta)
+	if err != nil {
+		return []byte(contenido)
+	}
+	inode := &Inodo{}
+	if err := inode.Decode(f, sb.CalculateInodeOffset(index)); err != nil {
+		return []byte(contenido)
+	}
+	actual, err := inode.ReadData(f, sb)
+	if err != nil {
+		return []byte(contenido)
+	}
+	return []byte(RestaurarContrasenas(contenido, string(actual)))
+}
+
+// aplicarEntradaJournal vuelve a hacer una operación del journal. Las operaciones que ya se ven
+// en el disco no se repiten.
+func aplicarEntradaJournal(f *os.File, sb *Superbloque, e EntradaJournal) error {
+	ruta := path.Clean("/" + e.Ruta)
+	parentDirs, name := splitPath(ruta)
+
+	switch e.Operacion {
+	case "mkdir":
+		return sb.crearEntrada(f, ruta, true, nil)
+
+	case "mkfile":
+		return sb.crearEntrada(f, ruta, false, []byte(e.Contenido))
+
+	case "edit":
+		index, err := sb.BuscarInodoPorRuta(f, ruta)
+		if err != nil {
+			return err
+		}
+		return sb.ReemplazarContenido(f, index, sb.contenidoJournal(f, ruta, e.Contenido))
+
+	case "rm":
+		if !sb.existeRuta(f, ruta) {
+			return nil
 		}
+		return sb.DeleteFile(f, parentDirs, name)
 
-		if op == "mkdir" && (path == "/" || path == "") {
+	case "rmdir":
+		if !sb.existeRuta(f, ruta) {
+			return nil
+		}
+		return sb.DeleteFolder(f, parentDirs, name)
+
+	case "rename":
+		nueva := path.Join(path.Dir(ruta), e.Contenido)
+		if !sb.existeRuta(f, ruta) && sb.existeRuta(f, nueva) {
+			return nil
+		}
+		padre, err := sb.BuscarInodoPorRuta(f, path.Dir(ruta))
+		if err != nil {
+			return err
+		}
+		return sb.RenombrarEntrada(f, padre, name, e.Contenido)
+
+	case "move":
+		if !sb.existeRuta(f, ruta) && sb.existeRuta(f, path.Join("/"+e.Contenido, name)) {
+			return nil
+		}
+		return sb.MoverEntrada(f, ruta, e.Contenido)
+
+	case "copy":
+		// Las entradas que crean la copia van a continuación en la misma transacción
+		return nil
+
+	case PreimagenContenido, PreimagenPermisos, PreimagenPropietario, OperacionDeshacer:
+		// Solo sirven para deshacer; las operaciones que revierten a otras van a continuación
+		return nil
+
+	case OperacionAnulada, OperacionMontaje, OperacionPuntoControl:
+		// La transacción anulada ya se saltó al leer el journal; la marca de montaje y la del
+		// punto de control no cambian nada
+		return nil
+
+	case "chmod":
+		if len(e.Contenido) != 3 {
+			return fmt.Errorf("permisos inválidos '%s'", e.Contenido)
+		}
+		return sb.modificarInodo(f, ruta, func(inode *Inodo) error {
+			copy(inode.I_perm[:], e.Contenido)
+			return nil
+		})
+
+	case "chown":
+		partes := strings.Split(e.Contenido, ",")
+		if len(partes) != 2 {
+			return fmt.Errorf("propietario inválido '%s'", e.Contenido)
+		}
+		uid, errUid := strconv.Atoi(partes[0])
+		gid, errGid := strconv.Atoi(partes[1])
+		if errUid != nil || errGid != nil {
+			return fmt.Errorf("propietario inválido '%s'", e.Contenido)
+		}
+		return sb.modificarInodo(f, ruta, func(inode *Inodo) error {
+			inode.I_uid = int32(uid)
+			inode.I_gid = int32(gid)
+			return nil
+		})
+
+	case "mkusr", "mkgrp", "rmusr", "rmgrp", "chgrp", "passwd":
+		// Las operaciones de usuarios guardan el contenido completo de users.txt
+		return sb.escribirArchivo(f, ruta, sb.contenidoJournal(f, ruta, e.Contenido))
+	}
+
+	return fmt.Errorf("operación desconocida '%s'", e.Operacion)
+}
+
+// efectoVisible indica si lo que hace la operación ya se ve en el disco. Las operaciones que no
+// cambian el disco (copy, las preimágenes, undo y abort) siempre se consideran visibles.
+func (sb *Superbloque) efectoVisible(f *os.File, e EntradaJournal) bool {
+	ruta := path.Clean("/" + e.Ruta)
+	switch e.Operacion {
+	case "mkdir", "mkfile":
+		return sb.existeRuta(f, ruta)
+	case "rm", "rmdir":
+		return !sb.existeRuta(f, ruta)
+	case "rename":
+		return !sb.existeRuta(f, ruta) && sb.existeRuta(f, path.Join(path.Dir(ruta), e.Contenido))
+	case "move":
+		return !sb.existeRuta(f, ruta) && sb.existeRuta(f, path.Join("/"+e.Contenido, path.Base(ruta)))
+	case "chmod", "chown", "edit", "mkusr", "mkgrp", "rmusr", "rmgrp", "chgrp", "passwd":
+		index, err := sb.BuscarInodoPorRuta(f, ruta)
+		if err != nil {
+			return false
+		}
+		inode := &Inodo{}
+		if err := inode.Decode(f, sb.CalculateInodeOffset(index)); err != nil {
+			return false
+		}
+		switch e.Operacion {
+		case "chmod":
+			return string(inode.I_perm[:]) == e.Contenido
+		case "chown":
+			return fmt.Sprintf("%d,%d", inode.I_uid, inode.I_gid) == e.Contenido
+		}
+		datos, err := inode.ReadData(f, sb)
+		if err != nil {
+			return false
+		}
+		if ruta == "/users.txt" {
+			return OcultarContrasenas(string(datos)) == e.Contenido
+		}
+		return string(datos) == e.Contenido
+	}
+	return true
+}
+
+// operacionesHechas devuelve las operaciones de una transacción que falló a medias cuyo efecto
+// se ve en el disco, con las preimágenes de esas rutas. La marca undo no se conserva, porque
+// una transacción deshecha a medias no cuenta como deshecha.
+func (sb *Superbloque) operacionesHechas(f *os.File, t *TransaccionJournal) *TransaccionJournal {
+	hechas := make([]bool, len(t.operaciones))
+	rutas := make(map[string]bool)
+	for i, e := range t.operaciones {
+		if esPreimagen(e.Operacion) || e.Operacion == OperacionDeshacer || e.Operacion == "copy" {
 			continue
 		}
+		if sb.efectoVisible(f, e) {
+			hechas[i] = true
+			rutas[path.Clean("/"+e.Ruta)] = true
+		}
+	}
+
+	real := &TransaccionJournal{}
+	for i, e := range t.operaciones {
+		if hechas[i] || (esPreimagen(e.Operacion) && rutas[path.Clean("/"+e.Ruta)]) {
+			real.Agregar(e.Operacion, e.Ruta, e.Contenido)
+		}
+	}
+	return real
+}
+
+func replayJournal(f *os.File, sb *Superbloque) ([]ResultadoReproduccion, error) {
+	entries, err := FindValidJournalEntries(f, sb)
+	if err != nil {
+		return nil, err
+	}
 
-		parentDirs, name := splitPath(path)
+	anuladas := transaccionesAnuladas(entries)
+	resultados := make([]ResultadoReproduccion, 0, len(entries))
+	for _, e := range entries {
+		if anuladas[e.Transaccion] || e.Operacion == OperacionMontaje || e.Operacion == OperacionPuntoControl {
+			continue
+		}
+		resultados = append(resultados, ResultadoReproduccion{Entrada: e, Err: aplicarEntradaJournal(f, sb, e)})
+	}
+	return resultados, nil
+}
 
-		switch op {
-		case "mkdir":
-			if err := sb.CrearCarpeta(f, parentDirs, name, false); err != nil {
-				return fmt.Errorf("replay mkdir %s: %w", path, err)
-			}
+// ReproducirJournal vuelve a aplicar, sin limpiar la partición, las operaciones de las
+// transacciones confirmadas después de la última marca de montaje cuyo efecto no se ve en el
+// disco. Se usa al montar una partición que no se desmontó correctamente. Devuelve las
+// operaciones aplicadas o una cadena vacía si no faltaba nada.
+func ReproducirJournal(f *os.File, sb *Superbloque, partStart int32) (string, error) {
+	entries, err := FindValidJournalEntries(f, sb)
+	if err != nil {
+		return "", err
+	}
 
-		case "mkfile":
-			chunks := utilidades.DividirCadenaEnTrozos(data)
-			if err := sb.CrearArchivo(f, parentDirs, name,
-				len(data), chunks, false); err != nil {
-				return fmt.Errorf("replay mkfile %s: %w", path, err)
-			}
+	desde := 0
+	for i, e := range entries {
+		if e.Operacion == OperacionMontaje {
+			desde = i + 1
+		}
+	}
 
-		default:
+	anuladas := transaccionesAnuladas(entries)
+	var aplicadas []string
+	for _, e := range entries[desde:] {
+		// Las transacciones se revisan en orden, así que cada operación se compara con lo que
+		// dejaron las anteriores
+		if anuladas[e.Transaccion] || sb.efectoVisible(f, e) {
+			continue
+		}
+		// Si la ruta o su carpeta ya no existen, una transacción posterior las eliminó o las
+		// movió y la operación no tiene dónde aplicarse
+		ruta := path.Clean("/" + e.Ruta)
+		creacion := e.Operacion == "mkdir" || e.Operacion == "mkfile"
+		if !sb.existeRuta(f, path.Dir(ruta)) || (!creacion && !sb.existeRuta(f, ruta)) {
+			continue
 		}
+		if err := aplicarEntradaJournal(f, sb, e); err != nil {
+			return "", fmt.Errorf("transacción %d, %s %s: %w", e.Transaccion, e.Operacion, e.Ruta, err)
+		}
+		aplicadas = append(aplicadas, e.Operacion+" "+e.Ruta)
 	}
-	return nil
+	if len(aplicadas) == 0 {
+		return "", nil
+	}
+
+	if err := sb.Codificar(f, int64(partStart)); err != nil {
+		return "", err
+	}
+	return strings.Join(aplicadas, ", "), f.Sync()
 }
 
-func RecoverFileSystem(f *os.File, sb *Superbloque, partStart int32) error {
+// RecoverFileSystem limpia las estructuras de la partición y la reconstruye aplicando en orden,
+// una por una, las operaciones que conserva el journal. Una operación que falla no detiene las
+// siguientes. Devuelve el resultado de cada operación y el número de la última transacción que
+// un punto de control descartó del journal (0 si conserva todo desde el formateo): lo que se
+// hizo hasta esa transacción solo se recupera si está en la copia de users.txt.
+func RecoverFileSystem(f *os.File, sb *Superbloque, partStart int32) ([]ResultadoReproduccion, uint32, error) {
+	entries, err := FindValidJournalEntries(f, sb)
+	if err != nil {
+		return nil, 0, err
+	}
+	descartadas := TransaccionesDescartadas(entries)
+
+	resultados, err := reconstruir(f, sb, partStart)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resultados, descartadas, nil
+}
+
+// reconstruir limpia las estructuras de la partición y vuelve a aplicar el journal completo.
+// Una operación que falla no detiene las siguientes; el resultado de cada una se devuelve para
+// informarlo.
+func reconstruir(f *os.File, sb *Superbloque, partStart int32) ([]ResultadoReproduccion, error) {
 	if err := wipeStructures(f, sb); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := ensureRoot(f, sb); err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := replayJournal(f, sb, partStart); err != nil {
-		return err
+	resultados, err := replayJournal(f, sb)
+	if err != nil {
+		return nil, err
+	}
+	if err := sb.ajustarContadores(f); err != nil {
+		return nil, err
 	}
 
 	sb.S_mtime = float64(time.Now().Unix())
 	if err := sb.Codificar(f, int64(partStart)); err != nil {
-		return err
+		return nil, err
 	}
 
-	return f.Sync()
+	return resultados, f.Sync()
 }
@@ -0,0 +1,222 @@
+package estructuras
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot describe una copia de la partición guardada en el almacén del disco. La imagen
+// va desde el superbloque hasta el final del área de bloques.
+type Snapshot struct {
+	Nombre    string    `json:"name"`
+	Particion string    `json:"partition"`
+	Id        string    `json:"id"`
+	Fecha     time.Time `json:"created_at"`
+	Usuario   string    `json:"user"`
+	Inicio    int64     `json:"start"`
+	Tamano    int64     `json:"size"`
+	Sistema   string    `json:"fs_type"`
+	Sha256    string    `json:"sha256"`
+}
+
+var nombreSnapshotValido = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// DirectorioSnapshots devuelve el almacén de snapshots de una partición: una carpeta junto al
+// disco con una subcarpeta por nombre de partición, que no cambia al volver a montarla
+func DirectorioSnapshots(disco, particion string) string {
+	return filepath.Join(disco+".snapshots", particion)
+}
+
+func ValidarNombreSnapshot(nombre string) error {
+	if !nombreSnapshotValido.MatchString(nombre) || strings.Trim(nombre, ".") == "" {
+		return fmt.Errorf("nombre de snapshot inválido '%s': use solo letras, números, '.', '_' y '-'", nombre)
+	}
+	return nil
+}
+
+func rutaImagen(directorio, nombre string) string {
+	return filepath.Join(directorio, nombre+".img")
+}
+
+func rutaMetadatos(directorio, nombre string) string {
+	return filepath.Join(directorio, nombre+".json")
+}
+
+// TamanoSnapshot devuelve los bytes desde el inicio de la partición hasta el final del área
+// de bloques
+func (sb *Superbloque) TamanoSnapshot(partStart int32) int64 {
+	return int64(sb.S_block_start) + int64(sb.TotalBloques())*int64(sb.S_block_size) - int64(partStart)
+}
+
+// CrearSnapshot copia la partición al almacén. Los metadatos se escriben al final, así que una
+// copia interrumpida no aparece en la lista.
+func CrearSnapshot(file *os.File, directorio string, snapshot Snapshot) (*Snapshot, error) {
+	if _, err := os.Stat(rutaMetadatos(directorio, snapshot.Nombre)); err == nil {
+		return nil, fmt.Errorf("ya existe un snapshot llamado '%s'", snapshot.Nombre)
+	}
+	if err := os.MkdirAll(directorio, 0755); err != nil {
+		return nil, fmt.Errorf("error creando el almacén de snapshots: %w", err)
+	}
+
+	datos := make([]byte, snapshot.Tamano)
+	if _, err := file.ReadAt(datos, snapshot.Inicio); err != nil {
+		return nil, fmt.Errorf("error leyendo la partición: %w", err)
+	}
+	suma := sha256.Sum256(datos)
+	snapshot.Sha256 = hex.EncodeToString(suma[:])
+
+	if err := escribirReemplazando(rutaImagen(directorio, snapshot.Nombre), datos); err != nil {
+		return nil, err
+	}
+	metadatos, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error serializando los metadatos del snapshot: %w", err)
+	}
+	if err := escribirReemplazando(rutaMetadatos(directorio, snapshot.Nombre), metadatos); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// escribirReemplazando escribe en un archivo temporal y lo renombra, para que el archivo final
+// quede completo o no exista
+func escribirReemplazando(ruta string, datos []byte) error {
+	temporal := ruta + ".tmp"
+	if err := os.WriteFile(temporal, datos, 0644); err != nil {
+		return fmt.Errorf("error escribiendo '%s': %w", temporal, err)
+	}
+	if err := os.Rename(temporal, ruta); err != nil {
+		os.Remove(temporal)
+		return fmt.Errorf("error guardando '%s': %w", ruta, err)
+	}
+	return nil
+}
+
+// ListarSnapshots devuelve los snapshots del almacén ordenados por fecha de creación
+func ListarSnapshots(directorio string) ([]Snapshot, error) {
+	archivos, err := filepath.Glob(filepath.Join(directorio, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0, len(archivos))
+	for _, archivo := range archivos {
+		snapshot, err := leerSnapshot(archivo)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Fecha.Before(snapshots[j].Fecha) })
+	return snapshots, nil
+}
+
+func leerSnapshot(archivo string) (*Snapshot, error) {
+	datos, err := os.ReadFile(archivo)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo '%s': %w", archivo, err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(datos, &snapshot); err != nil {
+		return nil, fmt.Errorf("metadatos de snapshot inválidos en '%s': %w", archivo, err)
+	}
+	return &snapshot, nil
+}
+
+// BuscarSnapshot devuelve los metadatos del snapshot o un error si no existe
+func BuscarSnapshot(directorio, nombre string) (*Snapshot, error) {
+	archivo := rutaMetadatos(directorio, nombre)
+	if _, err := os.Stat(archivo); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no existe el snapshot '%s'", nombre)
+	}
+	return leerSnapshot(archivo)
+}
+
+// RestaurarSnapshot vuelve a escribir la imagen en el disco. El disco se copia a un archivo
+// temporal, se escribe la imagen en la copia y la copia reemplaza al disco con un rename: si
+// algo falla a mitad de camino el disco queda como estaba.
+func RestaurarSnapshot(disco, directorio string, snapshot *Snapshot) error {
+	datos, err := os.ReadFile(rutaImagen(directorio, snapshot.Nombre))
+	if err != nil {
+		return fmt.Errorf("error leyendo la imagen del snapshot: %w", err)
+	}
+	suma := sha256.Sum256(datos)
+	if int64(len(datos)) != snapshot.Tamano || hex.EncodeToString(suma[:]) != snapshot.Sha256 {
+		return fmt.Errorf("la imagen del snapshot '%s' está dañada", snapshot.Nombre)
+	}
+
+	temporal := disco + ".restaurando"
+	if err := copiarArchivo(disco, temporal); err != nil {
+		os.Remove(temporal)
+		return err
+	}
+
+	copia, err := os.OpenFile(temporal, os.O_RDWR, 0)
+	if err != nil {
+		os.Remove(temporal)
+		return fmt.Errorf("error abriendo la copia del disco: %w", err)
+	}
+	_, err = copia.WriteAt(datos, snapshot.Inicio)
+	if err == nil {
+		err = copia.Sync()
+	}
+	if errCerrar := copia.Close(); err == nil {
+		err = errCerrar
+	}
+	if err != nil {
+		os.Remove(temporal)
+		return fmt.Errorf("error escribiendo la imagen en la copia del disco: %w", err)
+	}
+
+	if err := os.Rename(temporal, disco); err != nil {
+		os.Remove(temporal)
+		return fmt.Errorf("error reemplazando el disco: %w", err)
+	}
+	DescartarBitmaps(disco)
+	return nil
+}
+
+func copiarArchivo(origen, destino string) error {
+	entrada, err := os.Open(origen)
+	if err != nil {
+		return fmt.Errorf("error abriendo el disco: %w", err)
+	}
+	defer entrada.Close()
+
+	info, err := entrada.Stat()
+	if err != nil {
+		return fmt.Errorf("error leyendo el disco: %w", err)
+	}
+	salida, err := os.OpenFile(destino, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("error creando la copia del disco: %w", err)
+	}
+	if _, err := io.Copy(salida, entrada); err != nil {
+		salida.Close()
+		return fmt.Errorf("error copiando el disco: %w", err)
+	}
+	return salida.Close()
+}
+
+// EliminarSnapshot borra la imagen y los metadatos; la carpeta de la partición se borra si
+// queda vacía
+func EliminarSnapshot(directorio, nombre string) error {
+	if err := os.Remove(rutaMetadatos(directorio, nombre)); err != nil {
+		return fmt.Errorf("error eliminando los metadatos del snapshot: %w", err)
+	}
+	if err := os.Remove(rutaImagen(directorio, nombre)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error eliminando la imagen del snapshot: %w", err)
+	}
+	os.Remove(directorio)
+	return nil
+}
@@ -2,6 +2,7 @@ package estructuras
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	utilidades "godisk/Utilidades"
 	"os"
@@ -61,16 +62,9 @@ func (sb *Superbloque) CrearArchivoUsuarios(file *os.File) error {
 
 	sb.UpdateSuperblockAfterInodeAllocation()
 
-	rootBlock := &FolderBlock{
-		B_content: [4]FolderContent{
-			{B_name: [12]byte{'.'}, B_inodo: 0},                                                         // Apunta a sí mismo
-			{B_name: [12]byte{'.', '.'}, B_inodo: 0},                                                    // Apunta al padre
-			{B_name: [12]byte{'u', 's', 'e', 'r', 's', '.', 't', 'x', 't'}, B_inodo: sb.S_inodes_count}, // Apunta a users.txt
-			{B_name: [12]byte{'-'}, B_inodo: -1},                                                        // Vacío
-		},
-	}
+	rootBlock := sb.NewFolderBlock(0, 0, map[string]int32{"users.txt": sb.S_inodes_count})
 
-	err = utilidades.EscribirEnArchivo(file, int64(sb.S_block_start), rootBlock)
+	err = utilidades.EscribirEnArchivo(file, int64(sb.S_block_start), rootBlock.B_content)
 	if err != nil {
 		return fmt.Errorf("error al escribir el bloque raíz: %w", err)
 	}
@@ -82,27 +76,22 @@ func (sb *Superbloque) CrearArchivoUsuarios(file *os.File) error {
 
 	sb.UpdateSuperblockAfterBlockAllocation()
 
-	rootGroup := NewGroup("1", "root")
-	rootUser := NewUser("1", "root", "root", "123")
-	usersText := fmt.Sprintf("%s\n%s\n", rootGroup.ToString(), rootUser.ToString())
+	usersText, err := textoUsuariosInicial()
+	if err != nil {
+		return err
+	}
 
 	usersInode := &Inodo{
 		I_uid:   1,
 		I_gid:   1,
-		I_size:  int32(len(usersText)),
 		I_atime: float32(time.Now().Unix()),
 		I_ctime: float32(time.Now().Unix()),
 		I_mtime: float32(time.Now().Unix()),
-		I_block: [15]int32{1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, // Apunta al bloque 1 (users.txt)
-		I_type:  [1]byte{'1'},                                                         // Tipo archivo
+		I_block: [15]int32{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+		I_type:  [1]byte{'1'}, // Tipo archivo
 		I_perm:  [3]byte{'7', '7', '7'},
 	}
 
-	err = utilidades.EscribirEnArchivo(file, int64(sb.S_inode_start+int32(binary.Size(usersInode))), usersInode)
-	if err != nil {
-		return fmt.Errorf("error al escribir el inodo de users.txt: %w", err)
-	}
-
 	err = sb.UpdateBitmapInode(file, 1, true)
 	if err != nil {
 		return fmt.Errorf("error al actualizar bitmap de inodos para users.txt: %w", err)
@@ -110,21 +99,17 @@ func (sb *Superbloque) CrearArchivoUsuarios(file *os.File) error {
 
 	sb.UpdateSuperblockAfterInodeAllocation()
 
-	usersBlock := &ArchivoBloque{}
-	copy(usersBlock.B_content[:], usersText)
-
-	err = utilidades.EscribirEnArchivo(file, int64(sb.S_block_start+int32(binary.Size(usersBlock))), usersBlock)
+	// con la contraseña hasheada users.txt puede ocupar más de un bloque
+	err = usersInode.WriteData(file, sb, []byte(usersText))
 	if err != nil {
-		return fmt.Errorf("error al escribir el bloque de users.txt: %w", err)
+		return fmt.Errorf("error al escribir el contenido de users.txt: %w", err)
 	}
 
-	err = sb.UpdateBitmapBlock(file, 1, true)
+	err = utilidades.EscribirEnArchivo(file, sb.CalculateInodeOffset(1), usersInode)
 	if err != nil {
-		return fmt.Errorf("error al actualizar el bitmap de bloques para users.txt: %w", err)
+		return fmt.Errorf("error al escribir el inodo de users.txt: %w", err)
 	}
 
-	sb.UpdateSuperblockAfterBlockAllocation()
-
 	fmt.Println("Archivo users.txt creado correctamente.")
 	fmt.Println("Superbloque después de la creación de users.txt:")
 	sb.Print()
@@ -207,7 +192,7 @@ func (sb *Superbloque) PrintBlocks(path string) error {
 				break
 			}
 			if inode.I_type[0] == '0' {
-				block := &FolderBlock{}
+				block := sb.NuevoBloqueCarpeta()
 				err := utilidades.LeerDesdeArchivo(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), block)
 				if err != nil {
 					return fmt.Errorf("failed to decode folder block %d: %w", blockIndex, err)
@@ -215,7 +200,7 @@ func (sb *Superbloque) PrintBlocks(path string) error {
 				fmt.Printf("\nBloque %d:\n", blockIndex)
 				block.Print()
 			} else if inode.I_type[0] == '1' {
-				block := &ArchivoBloque{}
+				block := sb.NuevoBloqueArchivo()
 				err := utilidades.LeerDesdeArchivo(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), block)
 				if err != nil {
 					return fmt.Errorf("failed to decode file block %d: %w", blockIndex, err)
@@ -229,49 +214,49 @@ func (sb *Superbloque) PrintBlocks(path string) error {
 	return nil
 }
 
+// FindNextFreeBlock toma el siguiente bloque del tramo libre elegido con el ajuste de la partición
 func (sb *Superbloque) FindNextFreeBlock(file *os.File) (int32, error) {
-	totalBlocks := sb.S_blocks_count + sb.S_free_blocks_count // Número total de bloques
-
-	for position := int32(0); position < totalBlocks; position++ {
-		isFree, err := sb.isBlockFree(file, sb.S_bm_block_start, position)
-		if err != nil {
-			return -1, fmt.Errorf("error buscando bloque libre: %w", err)
-		}
+	bm, err := sb.bitmapsEnMemoria(file)
+	if err != nil {
+		return -1, fmt.Errorf("error buscando bloque libre: %w", err)
+	}
+	ajuste := sb.ajuste(file)
 
-		if isFree {
-			err = sb.UpdateBitmapBlock(file, position, true)
-			if err != nil {
-				return -1, fmt.Errorf("error actualizando el bitmap del bloque: %w", err)
-			}
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+	position := bm.reservarBloque(ajuste)
+	if position == -1 {
+		return -1, fmt.Errorf("no hay bloques disponibles")
+	}
 
-			fmt.Println("Indice encontrado:", position)
-			return position, nil
-		}
+	err = bm.cambiarBit(file, bm.bloques, sb.S_bm_block_start, position, true)
+	if err != nil {
+		return -1, fmt.Errorf("error actualizando el bitmap del bloque: %w", err)
 	}
 
-	return -1, fmt.Errorf("no hay bloques disponibles")
+	fmt.Println("Indice encontrado:", position)
+	return position, nil
 }
 
 func (sb *Superbloque) FindNextFreeInode(file *os.File) (int32, error) {
-	totalInodes := sb.S_inodes_count + sb.S_free_inodes_count // Número total de inodos
-
-	for position := int32(0); position < totalInodes; position++ {
-		isFree, err := sb.isInodeFree(file, sb.S_bm_inode_start, position)
-		if err != nil {
-			return -1, fmt.Errorf("error buscando inodo libre en la posición %d: %w", position, err)
-		}
+	bm, err := sb.bitmapsEnMemoria(file)
+	if err != nil {
+		return -1, fmt.Errorf("error buscando inodo libre: %w", err)
+	}
 
-		if isFree {
-			err = sb.UpdateBitmapInode(file, position, true)
-			if err != nil {
-				return -1, fmt.Errorf("error actualizando el bitmap del inodo en la posición %d: %w", position, err)
-			}
-			fmt.Printf("Inodo libre encontrado y asignado: %d\n", position)
-			return position, nil
-		}
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+	position := bm.reservarInodo()
+	if position == -1 {
+		return -1, fmt.Errorf("no hay inodos disponibles")
 	}
 
-	return -1, fmt.Errorf("no hay inodos disponibles")
+	err = bm.cambiarBit(file, bm.inodos, sb.S_bm_inode_start, position, true)
+	if err != nil {
+		return -1, fmt.Errorf("error actualizando el bitmap del inodo en la posición %d: %w", position, err)
+	}
+	fmt.Printf("Inodo libre encontrado y asignado: %d\n", position)
+	return position, nil
 }
 
 func (sb *Superbloque) AssignNewBlock(file *os.File, inode *Inodo, index int) (int32, error) {
@@ -370,13 +355,49 @@ func (sb *Superbloque) UpdateSuperblockAfterInodeDeallocation() {
 	sb.S_first_ino -= sb.S_inode_size
 }
 
-func (sb *Superbloque) JournalStart() int32 {
-	journalSize := int32(binary.Size(Journal{}))
-	start := sb.S_bm_inode_start - JOURNAL_ENTRIES*journalSize
-	return start
+// EstadoSucio se guarda en los bits altos de S_filesystem_type mientras la partición está
+// montada; si sigue puesto al montarla, la partición no se desmontó correctamente
+const EstadoSucio int32 = 1 << 9
+
+// CaracteristicaRespaldo indica que la partición guarda una copia del superbloque justo después
+// de él, antes del journal. La copia se escribe en mkfs y solo se usa su distribución, así que
+// no se actualiza después.
+const CaracteristicaRespaldo int32 = 1 << 10
+
+// PosicionRespaldo devuelve dónde está la copia del superbloque de la partición
+func PosicionRespaldo(partStart int32) int64 {
+	return int64(partStart) + int64(binary.Size(Superbloque{}))
+}
+
+// LeerRespaldo devuelve la copia del superbloque de la partición, o un error si no tiene una
+// válida
+func LeerRespaldo(file *os.File, partStart int32) (*Superbloque, error) {
+	respaldo := &Superbloque{}
+	if err := respaldo.Decodificar(file, PosicionRespaldo(partStart)); err != nil {
+		return nil, fmt.Errorf("error leyendo la copia del superbloque: %w", err)
+	}
+	if !respaldo.Formateado() || respaldo.S_filesystem_type&CaracteristicaRespaldo == 0 ||
+		int64(respaldo.S_bm_inode_start) <= PosicionRespaldo(partStart) {
+		return nil, errors.New("la partición no tiene una copia válida del superbloque")
+	}
+	return respaldo, nil
+}
+
+func (sb *Superbloque) Formateado() bool {
+	return sb.S_magic == 0xEF53
+}
+
+func (sb *Superbloque) Sucio() bool {
+	return sb.S_filesystem_type&EstadoSucio != 0
+}
+
+func (sb *Superbloque) RegistrarMontaje() {
+	sb.S_mnt_count++
+	sb.S_mtime = float64(time.Now().Unix())
+	sb.S_filesystem_type |= EstadoSucio
 }
 
-func (sb *Superbloque) JournalEnd() int32 {
-	end := sb.S_bm_inode_start
-	return end
+func (sb *Superbloque) RegistrarDesmontaje() {
+	sb.S_umtime = float64(time.Now().Unix())
+	sb.S_filesystem_type &^= EstadoSucio
 }
@@ -0,0 +1,194 @@
+package estructuras
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+)
+
+// OperacionDeshacer marca en el journal una transacción revertida con undo; su contenido es el
+// número de la transacción y a continuación van las operaciones que la revirtieron
+const OperacionDeshacer = "undo"
+
+// TransaccionDeshecha es una transacción del journal junto con las operaciones que la revierten
+type TransaccionDeshecha struct {
+	Transaccion uint32
+	Principal   EntradaJournal
+	Operaciones int
+	Inversas    []EntradaJournal
+}
+
+// PlanificarDeshacer busca las últimas cantidad transacciones que todavía no se deshicieron, de
+// la más reciente a la más antigua, y calcula sus inversas. Si alguna no se puede revertir
+// devuelve un error sin tocar la partición.
+func PlanificarDeshacer(f *os.File, sb *Superbloque, cantidad int) ([]TransaccionDeshecha, error) {
+	entries, err := FindValidJournalEntries(f, sb)
+	if err != nil {
+		return nil, err
+	}
+
+	var orden []uint32
+	grupos := make(map[uint32][]EntradaJournal)
+	// las transacciones anuladas no llegaron al disco, y ni la que las anula ni las marcas de
+	// montaje o de punto de control cambian nada
+	deshechas := transaccionesAnuladas(entries)
+	for _, e := range entries {
+		if _, existe := grupos[e.Transaccion]; !existe {
+			orden = append(orden, e.Transaccion)
+		}
+		grupos[e.Transaccion] = append(grupos[e.Transaccion], e)
+
+		// ni la transacción de undo ni la que revirtió se vuelven a deshacer
+		if e.Operacion == OperacionDeshacer {
+			deshechas[e.Transaccion] = true
+			if revertida, err := strconv.ParseUint(e.Contenido, 10, 32); err == nil {
+				deshechas[uint32(revertida)] = true
+			}
+		}
+		if e.Operacion == OperacionAnulada || e.Operacion == OperacionMontaje || e.Operacion == OperacionPuntoControl {
+			deshechas[e.Transaccion] = true
+		}
+	}
+
+	var plan []TransaccionDeshecha
+	for i := len(orden) - 1; i >= 0 && len(plan) < cantidad; i-- {
+		tx := orden[i]
+		if deshechas[tx] {
+			continue
+		}
+
+		operaciones := grupos[tx]
+		principal, total := operacionPrincipal(operaciones)
+		inversas, err := invertirTransaccion(operaciones)
+		if err != nil {
+			return nil, fmt.Errorf("no se puede deshacer la transacción %d (%s %s): %w", tx, principal.Operacion, principal.Ruta, err)
+		}
+		plan = append(plan, TransaccionDeshecha{Transaccion: tx, Principal: principal, Operaciones: total, Inversas: inversas})
+	}
+
+	if len(plan) < cantidad {
+		return nil, fmt.Errorf("el journal solo tiene %d operaciones que se pueden deshacer", len(plan))
+	}
+	return plan, nil
+}
+
+func esPreimagen(operacion string) bool {
+	return operacion == PreimagenContenido || operacion == PreimagenPermisos || operacion == PreimagenPropietario
+}
+
+// operacionPrincipal devuelve la operación que describe la transacción y cuántas tiene sin
+// contar las preimágenes. En las eliminaciones la ruta eliminada es la última, porque primero
+// se registra su contenido.
+func operacionPrincipal(operaciones []EntradaJournal) (EntradaJournal, int) {
+	var principales []EntradaJournal
+	for _, e := range operaciones {
+		if !esPreimagen(e.Operacion) {
+			principales = append(principales, e)
+		}
+	}
+	if len(principales) == 0 {
+		return operaciones[0], 0
+	}
+
+	principal := principales[0]
+	if principal.Operacion == "rm" || principal.Operacion == "rmdir" {
+		principal = principales[len(principales)-1]
+	}
+	return principal, len(principales)
+}
+
+// invertirTransaccion recorre la transacción de atrás hacia adelante y devuelve las operaciones
+// que la revierten. Las preimágenes se convierten en la operación que deja la ruta como estaba.
+func invertirTransaccion(operaciones []EntradaJournal) ([]EntradaJournal, error) {
+	preimagenes := make(map[string]bool)
+	creadas := make(map[string]bool)
+	for _, e := range operaciones {
+		ruta := path.Clean("/" + e.Ruta)
+		switch {
+		case esPreimagen(e.Operacion):
+			preimagenes[e.Operacion+" "+ruta] = true
+		case e.Operacion == "mkdir" || e.Operacion == "mkfile":
+			creadas[ruta] = true
+		}
+	}
+
+	var inversas []EntradaJournal
+	agregar := func(operacion, ruta, contenido string) {
+		inversas = append(inversas, EntradaJournal{Operacion: operacion, Ruta: ruta, Contenido: contenido})
+	}
+
+	for i := len(operaciones) - 1; i >= 0; i-- {
+		e := operaciones[i]
+		ruta := path.Clean("/" + e.Ruta)
+
+		switch e.Operacion {
+		case "mkdir":
+			agregar("rmdir", ruta, "")
+		case "mkfile":
+			if ruta == "/users.txt" {
+				return nil, errors.New("la transacción es el formateo de la partición")
+			}
+			agregar("rm", ruta, "")
+		case "rm":
+			agregar("mkfile", ruta, e.Contenido)
+		case "rmdir":
+			agregar("mkdir", ruta, "")
+		case "rename":
+			agregar("rename", path.Join(path.Dir(ruta), e.Contenido), path.Base(ruta))
+		case "move":
+			agregar("move", path.Join("/"+e.Contenido, path.Base(ruta)), path.Dir(ruta))
+		case "copy":
+			// la copia se deshace al eliminar lo que crearon las entradas mkdir y mkfile
+		case "edit", "mkusr", "mkgrp", "rmusr", "rmgrp", "chgrp", "passwd":
+			if !preimagenes[PreimagenContenido+" "+ruta] {
+				return nil, fmt.Errorf("el journal no guarda el contenido anterior de '%s'", ruta)
+			}
+		case "chmod":
+			if !creadas[ruta] && !preimagenes[PreimagenPermisos+" "+ruta] {
+				return nil, fmt.Errorf("el journal no guarda los permisos anteriores de '%s'", ruta)
+			}
+		case "chown":
+			if !creadas[ruta] && !preimagenes[PreimagenPropietario+" "+ruta] {
+				return nil, fmt.Errorf("el journal no guarda el propietario anterior de '%s'", ruta)
+			}
+		case PreimagenContenido:
+			agregar("edit", ruta, e.Contenido)
+		case PreimagenPermisos:
+			agregar("chmod", ruta, e.Contenido)
+		case PreimagenPropietario:
+			agregar("chown", ruta, e.Contenido)
+		default:
+			return nil, fmt.Errorf("la operación '%s' no se puede deshacer", e.Operacion)
+		}
+	}
+	return inversas, nil
+}
+
+// Deshacer revierte las transacciones del plan en orden. Cada una se registra en el journal
+// antes de aplicarla, precedida de su marca undo, para que recovery reconstruya el mismo árbol.
+// Si una inversa falla, la transacción se reemplaza por las inversas que sí se aplicaron, sin
+// la marca, y se devuelve el error.
+func Deshacer(f *os.File, sb *Superbloque, plan []TransaccionDeshecha) error {
+	for _, tx := range plan {
+		t := &TransaccionJournal{}
+		t.Agregar(OperacionDeshacer, tx.Principal.Ruta, strconv.FormatUint(uint64(tx.Transaccion), 10))
+		for _, e := range tx.Inversas {
+			t.Agregar(e.Operacion, e.Ruta, e.Contenido)
+		}
+
+		err := sb.AplicarConJournal(f, t, func() error {
+			for _, inversa := range tx.Inversas {
+				if err := aplicarEntradaJournal(f, sb, inversa); err != nil {
+					return fmt.Errorf("la transacción %d quedó deshecha a medias: %s %s: %w", tx.Transaccion, inversa.Operacion, inversa.Ruta, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,26 @@
 package estructuras
 
-import "fmt"
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Formato de contraseñas en users.txt: $pbkdf2$<sal>$<hash>, ambos en base64 sin relleno.
+const (
+	algoritmoContrasena   = "pbkdf2"
+	iteracionesContrasena = 10000
+	largoSalContrasena    = 12
+	largoHashContrasena   = 24
+)
+
+// ContrasenaOculta reemplaza en el journal las contraseñas en texto plano de users.txt. No es un
+// hash válido y VerificarContrasena nunca la acepta.
+const ContrasenaOculta = "$oculta$"
 
 type Usuario struct {
 	Id       string
@@ -9,10 +29,12 @@ type Usuario struct {
 	Name     string
 	Password string
 	Status   bool
+	Uid      int32
+	Gid      int32
 }
 
 func NewUser(id, group, name, password string) *Usuario {
-	return &Usuario{id, "U", group, name, password, true}
+	return &Usuario{Id: id, Tipo: "U", Group: group, Name: name, Password: password, Status: true}
 }
 
 func (u *Usuario) ToString() string {
@@ -23,3 +45,99 @@ func (u *Usuario) Eliminar() {
 	u.Id = "0"
 	u.Status = false
 }
+
+// textoUsuariosInicial devuelve el users.txt que deja mkfs: el grupo y el usuario root, con la
+// contraseña 123 ya hasheada
+func textoUsuariosInicial() (string, error) {
+	contrasena, err := HashearContrasena("123")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\n%s\n", NewGroup("1", "root").ToString(), NewUser("1", "root", "root", contrasena).ToString()), nil
+}
+
+func HashearContrasena(contrasena string) (string, error) {
+	sal := make([]byte, largoSalContrasena)
+	if _, err := rand.Read(sal); err != nil {
+		return "", fmt.Errorf("error generando la sal de la contraseña: %w", err)
+	}
+	return derivarContrasena(contrasena, sal)
+}
+
+func derivarContrasena(contrasena string, sal []byte) (string, error) {
+	hash, err := pbkdf2.Key(sha256.New, contrasena, sal, iteracionesContrasena, largoHashContrasena)
+	if err != nil {
+		return "", fmt.Errorf("error derivando la contraseña: %w", err)
+	}
+	codificar := base64.RawStdEncoding.EncodeToString
+	return fmt.Sprintf("$%s$%s$%s", algoritmoContrasena, codificar(sal), codificar(hash)), nil
+}
+
+func EsContrasenaHasheada(almacenada string) bool {
+	return strings.HasPrefix(almacenada, "$"+algoritmoContrasena+"$")
+}
+
+// OcultarContrasenas devuelve el contenido de users.txt con las contraseñas en texto plano
+// reemplazadas por ContrasenaOculta, para no guardarlas en el journal; los hashes se conservan
+func OcultarContrasenas(contenido string) string {
+	lineas := strings.Split(contenido, "\n")
+	for i, linea := range lineas {
+		campos := strings.Split(linea, ",")
+		if len(campos) == 5 && strings.TrimSpace(campos[1]) == "U" && !EsContrasenaHasheada(campos[4]) {
+			campos[4] = ContrasenaOculta
+			lineas[i] = strings.Join(campos, ",")
+		}
+	}
+	return strings.Join(lineas, "\n")
+}
+
+// RestaurarContrasenas devuelve el contenido de users.txt con cada ContrasenaOculta reemplazada
+// por la contraseña que el mismo usuario tiene en actual, si la tiene
+func RestaurarContrasenas(contenido, actual string) string {
+	contrasenas := make(map[string]string)
+	for _, linea := range strings.Split(actual, "\n") {
+		campos := strings.Split(linea, ",")
+		if len(campos) == 5 && strings.TrimSpace(campos[1]) == "U" {
+			contrasenas[campos[3]] = campos[4]
+		}
+	}
+
+	lineas := strings.Split(contenido, "\n")
+	for i, linea := range lineas {
+		campos := strings.Split(linea, ",")
+		if len(campos) != 5 || campos[4] != ContrasenaOculta {
+			continue
+		}
+		if contrasena, ok := contrasenas[campos[3]]; ok {
+			campos[4] = contrasena
+			lineas[i] = strings.Join(campos, ",")
+		}
+	}
+	return strings.Join(lineas, "\n")
+}
+
+// VerificarContrasena compara contra el hash almacenado; las contraseñas en texto
+// plano de discos anteriores se comparan directamente.
+func VerificarContrasena(almacenada, contrasena string) bool {
+	if almacenada == ContrasenaOculta {
+		return false
+	}
+	if !EsContrasenaHasheada(almacenada) {
+		return subtle.ConstantTimeCompare([]byte(almacenada), []byte(contrasena)) == 1
+	}
+
+	partes := strings.Split(almacenada, "$")
+	if len(partes) != 4 {
+		return false
+	}
+	sal, err := base64.RawStdEncoding.DecodeString(partes[2])
+	if err != nil {
+		return false
+	}
+
+	calculada, err := derivarContrasena(contrasena, sal)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(calculada), []byte(almacenada)) == 1
+}
@@ -0,0 +1,102 @@
+package estructuras
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashearContrasena(t *testing.T) {
+	hash, err := HashearContrasena("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EsContrasenaHasheada(hash) || strings.Count(hash, "$") != 3 {
+		t.Fatalf("HashearContrasena(\"123\") = %q, se esperaba $pbkdf2$<sal>$<hash>", hash)
+	}
+	if strings.Contains(hash, "123") {
+		t.Errorf("el hash %q contiene la contraseña", hash)
+	}
+
+	otro, err := HashearContrasena("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otro == hash {
+		t.Errorf("dos hashes de la misma contraseña son iguales (%q): la sal no es aleatoria", hash)
+	}
+}
+
+func TestVerificarContrasena(t *testing.T) {
+	hash, err := HashearContrasena("secreta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	partes := strings.Split(hash, "$")
+
+	casos := []struct {
+		nombre     string
+		almacenada string
+		contrasena string
+		esperado   bool
+	}{
+		{"hash correcto", hash, "secreta", true},
+		{"hash con otra contraseña", hash, "Secreta", false},
+		{"hash con contraseña vacía", hash, "", false},
+		{"texto plano correcto", "123", "123", true},
+		{"texto plano incorrecto", "123", "1234", false},
+		{"texto plano que parece hash", "123", hash, false},
+		{"oculta en el journal", ContrasenaOculta, ContrasenaOculta, false},
+		{"sin hash", "$pbkdf2$" + partes[2], "secreta", false},
+		{"partes de más", hash + "$extra", "secreta", false},
+		{"sal que no es base64", "$pbkdf2$%%%$" + partes[3], "secreta", false},
+		{"hash recortado", hash[:len(hash)-4], "secreta", false},
+		{"solo el prefijo", "$pbkdf2$", "$pbkdf2$", false},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if obtenido := VerificarContrasena(c.almacenada, c.contrasena); obtenido != c.esperado {
+				t.Errorf("VerificarContrasena(%q, %q) = %v, se esperaba %v", c.almacenada, c.contrasena, obtenido, c.esperado)
+			}
+		})
+	}
+}
+
+func TestOcultarContrasenas(t *testing.T) {
+	hash := "$pbkdf2$7fqRKJn6PZWvxoOj$Bk4X7YjyuQH0yoABZrWiWA1vN86fuE7c"
+	casos := []struct {
+		contenido string
+		esperado  string
+	}{
+		{"1,G,root\n1,U,root,root,123\n", "1,G,root\n1,U,root,root,$oculta$\n"},
+		{"1,G,root\n1,U,root,root," + hash + "\n", "1,G,root\n1,U,root,root," + hash + "\n"},
+		{"0,U,root,ana,abc\n2,U,root,luis," + hash, "0,U,root,ana,$oculta$\n2,U,root,luis," + hash},
+		{"1,G,root\n2,G,usuarios\n", "1,G,root\n2,G,usuarios\n"},
+		{"", ""},
+	}
+
+	for _, c := range casos {
+		if obtenido := OcultarContrasenas(c.contenido); obtenido != c.esperado {
+			t.Errorf("OcultarContrasenas(%q) = %q, se esperaba %q", c.contenido, obtenido, c.esperado)
+		}
+	}
+}
+
+func TestRestaurarContrasenas(t *testing.T) {
+	actual := "1,G,root\n1,U,root,root,123\n2,U,root,ana,abc\n"
+	casos := []struct {
+		contenido string
+		esperado  string
+	}{
+		{OcultarContrasenas(actual), actual},
+		// luis ya no está en el disco, así que su contraseña sigue oculta
+		{"1,U,root,root,$oculta$\n3,U,root,luis,$oculta$\n", "1,U,root,root,123\n3,U,root,luis,$oculta$\n"},
+		{"1,U,root,root,$pbkdf2$a$b\n", "1,U,root,root,$pbkdf2$a$b\n"},
+	}
+
+	for _, c := range casos {
+		if obtenido := RestaurarContrasenas(c.contenido, actual); obtenido != c.esperado {
+			t.Errorf("RestaurarContrasenas(%q) = %q, se esperaba %q", c.contenido, obtenido, c.esperado)
+		}
+	}
+}
@@ -10,13 +10,14 @@ import (
 func ReadFileBlocks(file *os.File, sb *estructuras.Superbloque, inode *estructuras.Inodo) (string, error) {
 	var contenido string
 
-	for _, blockIndex := range inode.I_block {
-		if blockIndex == -1 {
-			break
-		}
+	blockIndexes, err := inode.GetDataBlockIndexes(file, sb)
+	if err != nil {
+		return "", err
+	}
 
+	for _, blockIndex := range blockIndexes {
 		blockOffset := int64(sb.S_block_start + blockIndex*int32(sb.S_block_size))
-		var fileBlock estructuras.ArchivoBloque
+		fileBlock := sb.NuevoBloqueArchivo()
 
 		err := fileBlock.Decode(file, blockOffset)
 		if err != nil {
@@ -28,6 +29,10 @@ func ReadFileBlocks(file *os.File, sb *estructuras.Superbloque, inode *estructur
 
 	inode.ActualizarAtime()
 
+	// el último bloque puede conservar bytes de un contenido anterior más largo
+	if int(inode.I_size) < len(contenido) {
+		contenido = contenido[:inode.I_size]
+	}
 	return strings.TrimRight(contenido, "\x00"), nil
 }
 
@@ -39,34 +44,31 @@ func WriteUsersBlocks(file *os.File, sb *estructuras.Superbloque, inode *estruct
 
 	contenidoTotal := contenidoExistente + nuevoContenido
 
-	blocks, err := estructuras.SplitContent(contenidoTotal)
+	blocks, err := sb.SplitContent(contenidoTotal)
 	if err != nil {
 		return fmt.Errorf("error al dividir el contenido en bloques: %w", err)
 	}
 
-	index := 0
-
-	for _, block := range blocks {
-		if index >= len(inode.I_block) {
-			return fmt.Errorf("se alcanzó el límite máximo de bloques del inodo")
-		}
+	blockIndexes, err := inode.GetDataBlockIndexes(file, sb)
+	if err != nil {
+		return fmt.Errorf("error obteniendo los bloques de users.txt: %w", err)
+	}
 
-		if inode.I_block[index] == -1 {
-			newBlockIndex, err := sb.AssignNewBlock(file, inode, index)
-			if err != nil {
-				return fmt.Errorf("error asignando nuevo bloque: %w", err)
-			}
-			inode.I_block[index] = newBlockIndex
+	for len(blockIndexes) < len(blocks) {
+		newBlockIndex, err := inode.AddBlock(file, sb)
+		if err != nil {
+			return fmt.Errorf("error asignando nuevo bloque: %w", err)
 		}
+		blockIndexes = append(blockIndexes, newBlockIndex)
+	}
 
-		blockOffset := int64(sb.S_block_start + inode.I_block[index]*int32(sb.S_block_size))
+	for i, block := range blocks {
+		blockOffset := int64(sb.S_block_start + blockIndexes[i]*int32(sb.S_block_size))
 
 		err = block.Encode(file, blockOffset)
 		if err != nil {
-			return fmt.Errorf("error escribiendo el bloque %d: %w", inode.I_block[index], err)
+			return fmt.Errorf("error escribiendo el bloque %d: %w", blockIndexes[i], err)
 		}
-
-		index++
 	}
 
 	nuevoTamano := len(contenidoTotal)
@@ -78,6 +80,28 @@ func WriteUsersBlocks(file *os.File, sb *estructuras.Superbloque, inode *estruct
 	return nil
 }
 
+// LimpiarBloquesArchivo deja en cero los bloques de datos del inodo, incluyendo los indirectos
+func LimpiarBloquesArchivo(file *os.File, sb *estructuras.Superbloque, inode *estructuras.Inodo) error {
+	blockIndexes, err := inode.GetDataBlockIndexes(file, sb)
+	if err != nil {
+		return err
+	}
+
+	for _, blockIndex := range blockIndexes {
+		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
+		fileBlock := sb.NuevoBloqueArchivo()
+
+		fileBlock.ClearContent()
+
+		err = fileBlock.Encode(file, blockOffset)
+		if err != nil {
+			return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
+		}
+	}
+
+	return nil
+}
+
 func InsertIntoUsersFile(file *os.File, sb *estructuras.Superbloque, inode *estructuras.Inodo, entry string) error {
 	contenidoActual, err := ReadFileBlocks(file, sb, inode)
 	if err != nil {
@@ -118,33 +142,7 @@ func InsertIntoUsersFile(file *os.File, sb *estructuras.Superbloque, inode *estr
 	fmt.Println("=== Escribiendo nuevo contenido en users.txt ===")
 	fmt.Println(contenidoNuevo)
 
-	for _, blockIndex := range inode.I_block {
-		if blockIndex == -1 {
-			break
-		}
-
-		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
-		var fileBlock estructuras.ArchivoBloque
-
-		fileBlock.ClearContent()
-
-		err = fileBlock.Encode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
-		}
-	}
-
-	err = WriteUsersBlocks(file, sb, inode, contenidoNuevo)
-	if err != nil {
-		return fmt.Errorf("error escribiendo el nuevo contenido en users.txt: %w", err)
-	}
-
-	inode.I_size = int32(len(contenidoNuevo))
-
-	inode.ActualizarMtime()
-	inode.ActualizarCtime()
-
-	return nil
+	return EscribirUsuarios(file, sb, inode, "mkusr", contenidoNuevo)
 }
 
 func AddEntryToUsersFile(file *os.File, sb *estructuras.Superbloque, inode *estructuras.Inodo, entry, name, entityType string) error {
@@ -161,7 +159,11 @@ func AddEntryToUsersFile(file *os.File, sb *estructuras.Superbloque, inode *estr
 	fmt.Println("=== Escribiendo nuevo contenido en users.txt ===")
 	fmt.Println(entry)
 
-	err = WriteUsersBlocks(file, sb, inode, entry+"\n")
+	operacion := "mkusr"
+	if entityType == "G" {
+		operacion = "mkgrp"
+	}
+	err = EscribirUsuarios(file, sb, inode, operacion, contenidoActual+entry+"\n")
 	if err != nil {
 		return fmt.Errorf("error agregando entrada a users.txt: %w", err)
 	}
@@ -223,3 +225,31 @@ func findLineInUsersFile(contenido string, name, entityType string) (string, int
 
 	return "", -1, fmt.Errorf("%s '%s' no encontrado en users.txt", entityType, name)
 }
+
+// EscribirUsuarios reemplaza el contenido de users.txt y guarda su inodo. En EXT3 registra antes
+// en el journal el contenido nuevo, para poder reescribirlo al reproducir el journal, y el
+// anterior para poder deshacer la operación, los dos sin contraseñas en texto plano.
+func EscribirUsuarios(file *os.File, sb *estructuras.Superbloque, inode *estructuras.Inodo, operacion, contenido string) error {
+	t := &estructuras.TransaccionJournal{}
+	if sb.TipoSistema() == 3 {
+		anterior, err := ReadFileBlocks(file, sb, inode)
+		if err != nil {
+			return fmt.Errorf("error leyendo el contenido de users.txt: %w", err)
+		}
+		t.Agregar(estructuras.PreimagenContenido, "/users.txt", estructuras.OcultarContrasenas(anterior))
+		t.Agregar(operacion, "/users.txt", estructuras.OcultarContrasenas(contenido))
+	}
+
+	return sb.AplicarConJournal(file, t, func() error {
+		if err := LimpiarBloquesArchivo(file, sb, inode); err != nil {
+			return err
+		}
+		if err := WriteUsersBlocks(file, sb, inode, contenido); err != nil {
+			return fmt.Errorf("error guardando los cambios en users.txt: %w", err)
+		}
+		if err := inode.Encode(file, sb.CalculateInodeOffset(1)); err != nil {
+			return fmt.Errorf("error actualizando inodo de users.txt: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,134 @@
+package global
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AdministradorDiscos lleva los archivos .mia abiertos y los bloqueos de lectura/escritura
+// de cada disco y de cada partición montada.
+//
+// Los comandos que modifican el MBR o los EBR (mkdisk, fdisk, mount, ...) bloquean el disco
+// completo. Los que trabajan dentro de una partición toman el disco en modo lectura y la
+// partición en el modo que necesiten, de modo que dos particiones del mismo disco pueden
+// usarse a la vez pero nunca mientras se reescribe la tabla de particiones.
+type AdministradorDiscos struct {
+	mutex  sync.Mutex
+	discos map[string]*estadoDisco
+}
+
+type estadoDisco struct {
+	bloqueo     sync.RWMutex
+	particiones map[string]*sync.RWMutex
+	abiertos    map[*os.File]struct{}
+}
+
+// Discos es el administrador usado por todos los comandos y reportes
+var Discos = NuevoAdministradorDiscos()
+
+func NuevoAdministradorDiscos() *AdministradorDiscos {
+	return &AdministradorDiscos{discos: make(map[string]*estadoDisco)}
+}
+
+func normalizarRuta(ruta string) string {
+	if absoluta, err := filepath.Abs(ruta); err == nil {
+		return absoluta
+	}
+	return filepath.Clean(ruta)
+}
+
+// disco devuelve el estado del disco creándolo si no existe. Debe llamarse con a.mutex tomado.
+func (a *AdministradorDiscos) disco(ruta string) *estadoDisco {
+	clave := normalizarRuta(ruta)
+	estado, existe := a.discos[clave]
+	if !existe {
+		estado = &estadoDisco{
+			particiones: make(map[string]*sync.RWMutex),
+			abiertos:    make(map[*os.File]struct{}),
+		}
+		a.discos[clave] = estado
+	}
+	return estado
+}
+
+func bloquear(bloqueo *sync.RWMutex, escritura bool) func() {
+	if escritura {
+		bloqueo.Lock()
+		return bloqueo.Unlock
+	}
+	bloqueo.RLock()
+	return bloqueo.RUnlock
+}
+
+// BloquearDisco toma el disco completo y devuelve la función que lo libera
+func (a *AdministradorDiscos) BloquearDisco(ruta string, escritura bool) func() {
+	a.mutex.Lock()
+	estado := a.disco(ruta)
+	a.mutex.Unlock()
+
+	return bloquear(&estado.bloqueo, escritura)
+}
+
+// BloquearParticion toma el disco en modo lectura y la partición indicada en el modo pedido.
+// Devuelve la función que libera ambos bloqueos.
+func (a *AdministradorDiscos) BloquearParticion(ruta, id string, escritura bool) func() {
+	a.mutex.Lock()
+	estado := a.disco(ruta)
+	bloqueoParticion, existe := estado.particiones[id]
+	if !existe {
+		bloqueoParticion = &sync.RWMutex{}
+		estado.particiones[id] = bloqueoParticion
+	}
+	a.mutex.Unlock()
+
+	liberarDisco := bloquear(&estado.bloqueo, false)
+	liberarParticion := bloquear(bloqueoParticion, escritura)
+	return func() {
+		liberarParticion()
+		liberarDisco()
+	}
+}
+
+// Abrir abre el disco y registra el archivo para que el administrador lo controle.
+// Cada llamada devuelve su propio archivo, así los lectores concurrentes no comparten
+// la posición de lectura. Debe liberarse con Cerrar.
+func (a *AdministradorDiscos) Abrir(ruta string, flag int) (*os.File, error) {
+	file, err := os.OpenFile(ruta, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mutex.Lock()
+	a.disco(ruta).abiertos[file] = struct{}{}
+	a.mutex.Unlock()
+
+	return file, nil
+}
+
+// Cerrar cierra un archivo obtenido con Abrir
+func (a *AdministradorDiscos) Cerrar(file *os.File) error {
+	a.mutex.Lock()
+	delete(a.disco(file.Name()).abiertos, file)
+	a.mutex.Unlock()
+
+	return file.Close()
+}
+
+// CerrarDisco cierra los archivos que sigan abiertos sobre el disco, por ejemplo antes de eliminarlo
+func (a *AdministradorDiscos) CerrarDisco(ruta string) error {
+	a.mutex.Lock()
+	estado := a.disco(ruta)
+	abiertos := estado.abiertos
+	estado.abiertos = make(map[*os.File]struct{})
+	a.mutex.Unlock()
+
+	var primerError error
+	for file := range abiertos {
+		if err := file.Close(); err != nil && primerError == nil {
+			primerError = fmt.Errorf("error cerrando '%s': %w", file.Name(), err)
+		}
+	}
+	return primerError
+}
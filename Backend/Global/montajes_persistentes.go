@@ -0,0 +1,137 @@
+package global
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	utilidades "godisk/Utilidades"
+	"os"
+	"sync"
+)
+
+// ArchivoMontajes guarda las particiones montadas para recuperarlas al reiniciar el servidor
+var ArchivoMontajes = rutaArchivoMontajes()
+
+// mutexArchivoMontajes evita que dos montajes reescriban ArchivoMontajes a la vez
+var mutexArchivoMontajes sync.Mutex
+
+type montajeGuardado struct {
+	Id          string `json:"id"`
+	Path        string `json:"path"`
+	SoloLectura bool   `json:"read_only,omitempty"`
+}
+
+func rutaArchivoMontajes() string {
+	if ruta := os.Getenv("GODISK_MONTAJES"); ruta != "" {
+		return ruta
+	}
+	return "montajes.json"
+}
+
+// GuardarMontajes escribe las particiones montadas en ArchivoMontajes
+func GuardarMontajes() error {
+	mutexArchivoMontajes.Lock()
+	defer mutexArchivoMontajes.Unlock()
+
+	var montajes []montajeGuardado
+	for _, montaje := range Montajes() {
+		montajes = append(montajes, montajeGuardado{Id: montaje.Id, Path: montaje.Path, SoloLectura: montaje.SoloLectura})
+	}
+
+	datos, err := json.MarshalIndent(montajes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando los montajes: %w", err)
+	}
+
+	temporal := ArchivoMontajes + ".tmp"
+	if err := os.WriteFile(temporal, datos, 0644); err != nil {
+		return fmt.Errorf("error escribiendo el registro de montajes: %w", err)
+	}
+	return os.Rename(temporal, ArchivoMontajes)
+}
+
+// RestaurarMontajes carga ArchivoMontajes y vuelve a montar las particiones cuyo MBR
+// todavía tiene el mismo ID, revisando su superbloque como mount: si no se desmontaron
+// correctamente se reproduce el journal. Devuelve los IDs restaurados.
+func RestaurarMontajes() ([]string, error) {
+	datos, err := os.ReadFile(ArchivoMontajes)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el registro de montajes: %w", err)
+	}
+
+	var montajes []montajeGuardado
+	if err := json.Unmarshal(datos, &montajes); err != nil {
+		return nil, fmt.Errorf("registro de montajes inválido: %w", err)
+	}
+
+	var restaurados []string
+	for _, montaje := range montajes {
+		partStart, err := verificarMontaje(montaje.Id, montaje.Path)
+		if err != nil {
+			fmt.Printf("Se omite el montaje %s (%s): %v\n", montaje.Id, montaje.Path, err)
+			continue
+		}
+		if err := revisarSistemaArchivos(montaje, partStart); err != nil {
+			fmt.Printf("Advertencia en el montaje %s (%s): %v\n", montaje.Id, montaje.Path, err)
+		}
+
+		RegistrarMontaje(MontajeParticion{Id: montaje.Id, Path: montaje.Path, SoloLectura: montaje.SoloLectura, Recuperado: true})
+		utilidades.RegistrarLetra(montaje.Path, montaje.Id[len(montaje.Id)-1:])
+		restaurados = append(restaurados, montaje.Id)
+	}
+
+	if len(restaurados) != len(montajes) {
+		if err := GuardarMontajes(); err != nil {
+			return restaurados, err
+		}
+	}
+
+	return restaurados, nil
+}
+
+func verificarMontaje(id, path string) (int32, error) {
+	if id == "" {
+		return 0, errors.New("ID vacío")
+	}
+
+	file, err := Discos.Abrir(path, os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+	defer Discos.Cerrar(file)
+
+	var mbr estructuras.Mbr
+	if err := mbr.Decodificar(file); err != nil {
+		return 0, err
+	}
+
+	partition, err := mbr.BuscarParticionPorID(file, id)
+	if err != nil {
+		return 0, err
+	}
+	return partition.Part_start, nil
+}
+
+// revisarSistemaArchivos hace con la partición restaurada lo mismo que mount: en solo lectura
+// solo avisa si quedó sucia y si no registra el montaje y reproduce el journal si hace falta
+func revisarSistemaArchivos(montaje montajeGuardado, partStart int32) error {
+	flag := os.O_RDWR
+	if montaje.SoloLectura {
+		flag = os.O_RDONLY
+	}
+	file, err := Discos.Abrir(montaje.Path, flag)
+	if err != nil {
+		return err
+	}
+	defer Discos.Cerrar(file)
+
+	if montaje.SoloLectura {
+		estructuras.AvisarMontajeSoloLectura(file, partStart, os.Stdout)
+		return nil
+	}
+	return estructuras.MontarSistemaArchivos(file, partStart, os.Stdout)
+}
@@ -2,26 +2,82 @@ package global
 
 import (
 	"errors"
+	"fmt"
 	estructuras "godisk/Estructuras"
 	"os"
+	"sort"
+	"sync"
 )
 
 const Carnet string = "46"
 
+// MontajeParticion es una partición montada
+type MontajeParticion struct {
+	Id          string
+	Path        string
+	SoloLectura bool // montada con mount -ro
+	Recuperado  bool // restaurada desde ArchivoMontajes al iniciar
+}
+
+// Las sesiones ejecutan comandos a la vez, así que las particiones montadas solo se usan a
+// través de las funciones de abajo
 var (
-	UsuarioActual       *estructuras.Usuario = nil
-	ParticionesMontadas map[string]string    = make(map[string]string)
+	particionesMontadas = make(map[string]MontajeParticion)
+	mutexMontajes       sync.RWMutex
 )
 
+// RutaMontaje devuelve la ruta del disco de la partición montada con el ID indicado
+func RutaMontaje(id string) (string, bool) {
+	mutexMontajes.RLock()
+	defer mutexMontajes.RUnlock()
+	montaje, montada := particionesMontadas[id]
+	return montaje.Path, montada
+}
+
+// MontadaSoloLectura indica si la partición está montada con mount -ro
+func MontadaSoloLectura(id string) bool {
+	mutexMontajes.RLock()
+	defer mutexMontajes.RUnlock()
+	return particionesMontadas[id].SoloLectura
+}
+
+// RegistrarMontaje agrega la partición a las montadas o reemplaza su montaje anterior
+func RegistrarMontaje(montaje MontajeParticion) {
+	mutexMontajes.Lock()
+	defer mutexMontajes.Unlock()
+	particionesMontadas[montaje.Id] = montaje
+}
+
+func EliminarMontaje(id string) {
+	mutexMontajes.Lock()
+	defer mutexMontajes.Unlock()
+	delete(particionesMontadas, id)
+}
+
+// Montajes devuelve las particiones montadas ordenadas por ID
+func Montajes() []MontajeParticion {
+	mutexMontajes.RLock()
+	montajes := make([]MontajeParticion, 0, len(particionesMontadas))
+	for _, montaje := range particionesMontadas {
+		montajes = append(montajes, montaje)
+	}
+	mutexMontajes.RUnlock()
+
+	sort.Slice(montajes, func(i, j int) bool { return montajes[i].Id < montajes[j].Id })
+	return montajes
+}
+
 func GetMountedPartitionSuperblock(id string) (*estructuras.Superbloque, *estructuras.Partition, string, error) {
-	path := ParticionesMontadas[id]
+	path, _ := RutaMontaje(id)
 	if path == "" {
 		return nil, nil, "", errors.New("la partición no está montada")
 	}
-	file, err := os.Open(path)
+	file, err := Discos.Abrir(path, os.O_RDONLY)
 	if err != nil {
 		return nil, nil, "", err
 	}
+	defer Discos.Cerrar(file)
+
 	var mbr estructuras.Mbr
 
 	err = mbr.Decodificar(file)
@@ -29,7 +85,7 @@ func GetMountedPartitionSuperblock(id string) (*estructuras.Superbloque, *estruc
 		return nil, nil, "", err
 	}
 
-	partition, err := mbr.ObtenerParticionPorID(id)
+	partition, err := mbr.BuscarParticionPorID(file, id)
 	if partition == nil {
 		return nil, nil, "", err
 	}
@@ -40,21 +96,22 @@ func GetMountedPartitionSuperblock(id string) (*estructuras.Superbloque, *estruc
 	if err != nil {
 		return nil, nil, "", err
 	}
+	sb.RegistrarAjuste(path, partition.Part_fit[0])
 
 	return &sb, partition, path, nil
 }
 
 func ObtenerParticionMontada(id string) (*estructuras.Partition, string, error) {
-	path := ParticionesMontadas[id]
+	path, _ := RutaMontaje(id)
 	if path == "" {
 		return nil, "", errors.New("la partición no está montada")
 	}
 
-	file, err := os.Open(path)
+	file, err := Discos.Abrir(path, os.O_RDONLY)
 	if err != nil {
 		return nil, "", err
 	}
-	defer file.Close()
+	defer Discos.Cerrar(file)
 
 	var mbr estructuras.Mbr
 
@@ -63,7 +120,7 @@ func ObtenerParticionMontada(id string) (*estructuras.Partition, string, error)
 		return nil, "", err
 	}
 
-	partition, err := mbr.ObtenerParticionPorID(id)
+	partition, err := mbr.BuscarParticionPorID(file, id)
 	if partition == nil {
 		return nil, "", err
 	}
@@ -72,16 +129,16 @@ func ObtenerParticionMontada(id string) (*estructuras.Partition, string, error)
 }
 
 func GetMountedPartitionRep(id string) (*estructuras.Mbr, *estructuras.Superbloque, string, error) {
-	path := ParticionesMontadas[id]
+	path, _ := RutaMontaje(id)
 	if path == "" {
 		return nil, nil, "", errors.New("la partición no está montada")
 	}
 
-	file, err := os.Open(path)
+	file, err := Discos.Abrir(path, os.O_RDONLY)
 	if err != nil {
 		return nil, nil, "", err
 	}
-	defer file.Close()
+	defer Discos.Cerrar(file)
 
 	var mbr estructuras.Mbr
 
@@ -90,7 +147,7 @@ func GetMountedPartitionRep(id string) (*estructuras.Mbr, *estructuras.Superbloq
 		return nil, nil, "", err
 	}
 
-	partition, err := mbr.ObtenerParticionPorID(id)
+	partition, err := mbr.BuscarParticionPorID(file, id)
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -101,25 +158,20 @@ func GetMountedPartitionRep(id string) (*estructuras.Mbr, *estructuras.Superbloq
 	if err != nil {
 		return nil, nil, "", err
 	}
+	sb.RegistrarAjuste(path, partition.Part_fit[0])
 
 	return &mbr, &sb, path, nil
 }
 
-func EstaLogueado() bool {
-	return UsuarioActual != nil && UsuarioActual.Status
-}
-
-func CerrarSesion() {
-	if UsuarioActual != nil {
-		UsuarioActual.Status = false
-		UsuarioActual = nil
+// ValidarEscritura rechaza los cambios sobre una partición montada con mount -ro
+func ValidarEscritura(partitionId string) error {
+	if MontadaSoloLectura(partitionId) {
+		return fmt.Errorf("la partición %s está montada como solo lectura; desmóntela y vuelva a montarla sin -ro para modificarla", partitionId)
 	}
+	return nil
 }
 
 func ValidarAcceso(partitionId string) error {
-	if !EstaLogueado() {
-		return errors.New("no hay un usuario logueado")
-	}
 	_, _, err := ObtenerParticionMontada(partitionId)
 	if err != nil {
 		return errors.New("la partición no está montada")
@@ -0,0 +1,35 @@
+package global
+
+import estructuras "godisk/Estructuras"
+
+const (
+	PermisoLectura   byte = 4
+	PermisoEscritura byte = 2
+	PermisoEjecucion byte = 1
+)
+
+// TienePermiso evalúa los permisos UGO del inodo para el usuario de la sesión.
+// root siempre tiene acceso.
+func TienePermiso(usuario *estructuras.Usuario, inodo *estructuras.Inodo, permiso byte) bool {
+	if usuario == nil || !usuario.Status {
+		return false
+	}
+	if usuario.Name == "root" {
+		return true
+	}
+
+	var digito byte
+	switch {
+	case inodo.I_uid == usuario.Uid:
+		digito = inodo.I_perm[0]
+	case inodo.I_gid == usuario.Gid:
+		digito = inodo.I_perm[1]
+	default:
+		digito = inodo.I_perm[2]
+	}
+
+	if digito < '0' || digito > '7' {
+		return false
+	}
+	return (digito-'0')&permiso != 0
+}
@@ -0,0 +1,113 @@
+package global
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	"sync"
+	"time"
+)
+
+const DuracionSesion = 8 * time.Hour
+
+// Sesion es quien ejecuta los comandos: un cliente del servidor, identificado por Token, o la
+// consola, sin token. Cada comando recibe la sesión y login y logout cambian su Usuario.
+type Sesion struct {
+	Token     string
+	Usuario   *estructuras.Usuario
+	UltimoUso time.Time
+}
+
+var (
+	sesiones      = make(map[string]*Sesion)
+	mutexSesiones sync.Mutex
+)
+
+// EstaLogueado indica si la sesión tiene un usuario que inició sesión
+func (s *Sesion) EstaLogueado() bool {
+	return s != nil && s.Usuario != nil && s.Usuario.Status
+}
+
+func CrearSesion(usuario *estructuras.Usuario) (string, error) {
+	bytesToken := make([]byte, 32)
+	if _, err := rand.Read(bytesToken); err != nil {
+		return "", fmt.Errorf("no se pudo generar el token de sesión: %w", err)
+	}
+	token := hex.EncodeToString(bytesToken)
+
+	mutexSesiones.Lock()
+	defer mutexSesiones.Unlock()
+	sesiones[token] = &Sesion{Token: token, Usuario: usuario, UltimoUso: time.Now()}
+	persistirSesiones()
+
+	return token, nil
+}
+
+// ObtenerSesion devuelve una copia de la sesión asociada al token, si sigue vigente.
+func ObtenerSesion(token string) (Sesion, bool) {
+	if token == "" {
+		return Sesion{}, false
+	}
+
+	mutexSesiones.Lock()
+	defer mutexSesiones.Unlock()
+
+	sesion, ok := sesiones[token]
+	if !ok {
+		return Sesion{}, false
+	}
+	if time.Since(sesion.UltimoUso) > DuracionSesion {
+		delete(sesiones, token)
+		persistirSesiones()
+		return Sesion{}, false
+	}
+
+	guardar := time.Since(sesion.UltimoUso) > intervaloGuardadoSesiones
+	sesion.UltimoUso = time.Now()
+	if guardar {
+		persistirSesiones()
+	}
+	return *sesion, true
+}
+
+func ActualizarSesion(token string, usuario *estructuras.Usuario) {
+	mutexSesiones.Lock()
+	defer mutexSesiones.Unlock()
+
+	if sesion, ok := sesiones[token]; ok {
+		// cada comando pasa por aquí; el archivo solo se reescribe si login o logout cambiaron el usuario
+		cambio := sesion.Usuario != usuario
+		sesion.Usuario = usuario
+		sesion.UltimoUso = time.Now()
+		if cambio {
+			persistirSesiones()
+		}
+	}
+}
+
+func EliminarSesion(token string) {
+	mutexSesiones.Lock()
+	defer mutexSesiones.Unlock()
+	if _, ok := sesiones[token]; ok {
+		delete(sesiones, token)
+		persistirSesiones()
+	}
+}
+
+// ParticionEnUso indica si alguna sesión vigente, o la sesión que pregunta, está logueada en la
+// partición
+func ParticionEnUso(actual *Sesion, partitionId string) bool {
+	if actual.EstaLogueado() && actual.Usuario.Id == partitionId {
+		return true
+	}
+
+	mutexSesiones.Lock()
+	defer mutexSesiones.Unlock()
+	for _, sesion := range sesiones {
+		if sesion.Usuario != nil && sesion.Usuario.Status && sesion.Usuario.Id == partitionId && time.Since(sesion.UltimoUso) <= DuracionSesion {
+			return true
+		}
+	}
+	return false
+}
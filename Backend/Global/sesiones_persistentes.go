@@ -0,0 +1,122 @@
+package global
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArchivoSesiones guarda las sesiones del servidor junto a ArchivoMontajes para que un reinicio
+// no cierre la sesión de los clientes
+var ArchivoSesiones = filepath.Join(filepath.Dir(ArchivoMontajes), "sesiones.json")
+
+// intervaloGuardadoSesiones limita cada cuánto se reescribe ArchivoSesiones solo para
+// actualizar el último uso de una sesión
+const intervaloGuardadoSesiones = time.Minute
+
+// sesionGuardada es una sesión en ArchivoSesiones; el hash de la contraseña no se guarda
+type sesionGuardada struct {
+	Token     string    `json:"token"`
+	Particion string    `json:"partition"`
+	Tipo      string    `json:"type"`
+	Grupo     string    `json:"group"`
+	Nombre    string    `json:"name"`
+	Uid       int32     `json:"uid"`
+	Gid       int32     `json:"gid"`
+	UltimoUso time.Time `json:"last_use"`
+}
+
+// guardarSesiones escribe las sesiones logueadas en ArchivoSesiones; se llama con mutexSesiones
+// tomado. Los tokens dan acceso a la cuenta, así que el archivo solo lo lee el dueño.
+func guardarSesiones() error {
+	guardadas := []sesionGuardada{}
+	for _, sesion := range sesiones {
+		if !sesion.EstaLogueado() {
+			continue
+		}
+		u := sesion.Usuario
+		guardadas = append(guardadas, sesionGuardada{
+			Token:     sesion.Token,
+			Particion: u.Id,
+			Tipo:      u.Tipo,
+			Grupo:     u.Group,
+			Nombre:    u.Name,
+			Uid:       u.Uid,
+			Gid:       u.Gid,
+			UltimoUso: sesion.UltimoUso,
+		})
+	}
+
+	datos, err := json.MarshalIndent(guardadas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando las sesiones: %w", err)
+	}
+
+	temporal := ArchivoSesiones + ".tmp"
+	if err := os.WriteFile(temporal, datos, 0600); err != nil {
+		return fmt.Errorf("error escribiendo el registro de sesiones: %w", err)
+	}
+	return os.Rename(temporal, ArchivoSesiones)
+}
+
+// persistirSesiones guarda las sesiones y solo avisa si falla: la sesión sigue válida en memoria
+func persistirSesiones() {
+	if err := guardarSesiones(); err != nil {
+		fmt.Printf("Advertencia: %v\n", err)
+	}
+}
+
+// RestaurarSesiones carga ArchivoSesiones y recupera las sesiones vigentes cuya partición sigue
+// montada; se llama después de RestaurarMontajes. Devuelve cuántas sesiones recuperó.
+func RestaurarSesiones() (int, error) {
+	datos, err := os.ReadFile(ArchivoSesiones)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo el registro de sesiones: %w", err)
+	}
+
+	var guardadas []sesionGuardada
+	if err := json.Unmarshal(datos, &guardadas); err != nil {
+		return 0, fmt.Errorf("registro de sesiones inválido: %w", err)
+	}
+
+	mutexSesiones.Lock()
+	defer mutexSesiones.Unlock()
+
+	restauradas := 0
+	for _, guardada := range guardadas {
+		if guardada.Token == "" || time.Since(guardada.UltimoUso) > DuracionSesion {
+			continue
+		}
+		if _, montada := RutaMontaje(guardada.Particion); !montada {
+			continue
+		}
+		sesiones[guardada.Token] = &Sesion{
+			Token: guardada.Token,
+			Usuario: &estructuras.Usuario{
+				Id:     guardada.Particion,
+				Tipo:   guardada.Tipo,
+				Group:  guardada.Grupo,
+				Name:   guardada.Nombre,
+				Status: true,
+				Uid:    guardada.Uid,
+				Gid:    guardada.Gid,
+			},
+			UltimoUso: guardada.UltimoUso,
+		}
+		restauradas++
+	}
+
+	if restauradas != len(guardadas) {
+		if err := guardarSesiones(); err != nil {
+			return restauradas, err
+		}
+	}
+	return restauradas, nil
+}
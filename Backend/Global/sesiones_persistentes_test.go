@@ -0,0 +1,115 @@
+package global
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGuardarYRestaurarSesiones(t *testing.T) {
+	sesionesPrueba(t)
+	RegistrarMontaje(MontajeParticion{Id: "461A", Path: "/tmp/disco.mia"})
+
+	usuario := usuarioPrueba("461A", "ana")
+	token, err := CrearSesion(usuario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// la partición de esta sesión se desmonta antes del reinicio
+	if _, err := CrearSesion(usuarioPrueba("462A", "luis")); err != nil {
+		t.Fatal(err)
+	}
+	// una sesión sin login no se guarda
+	sinLogin, err := CrearSesion(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(ArchivoSesiones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permisos := info.Mode().Perm(); permisos != 0600 {
+		t.Errorf("%s tiene permisos %o, se esperaba 600", ArchivoSesiones, permisos)
+	}
+	datos, err := os.ReadFile(ArchivoSesiones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(datos), usuario.Password) {
+		t.Errorf("%s guarda el hash de la contraseña:\n%s", ArchivoSesiones, datos)
+	}
+	if strings.Contains(string(datos), sinLogin) {
+		t.Errorf("%s guarda una sesión sin login", ArchivoSesiones)
+	}
+
+	// simula el reinicio: el almacén en memoria se vacía y se carga desde el archivo
+	mutexSesiones.Lock()
+	sesiones = make(map[string]*Sesion)
+	mutexSesiones.Unlock()
+
+	restauradas, err := RestaurarSesiones()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restauradas != 1 {
+		t.Fatalf("RestaurarSesiones() = %d, se esperaba 1", restauradas)
+	}
+	sesion, ok := ObtenerSesion(token)
+	if !ok {
+		t.Fatal("la sesión de ana no se restauró")
+	}
+	u := sesion.Usuario
+	if u.Id != "461A" || u.Name != "ana" || u.Group != "root" || u.Tipo != "U" || u.Uid != 1 || u.Gid != 1 || !u.Status {
+		t.Errorf("usuario restaurado %+v, se esperaba %+v", u, usuario)
+	}
+	if u.Password != "" {
+		t.Errorf("la sesión restaurada tiene contraseña %q", u.Password)
+	}
+
+	// las sesiones descartadas se quitan del archivo
+	datos, err = os.ReadFile(ArchivoSesiones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(datos), "luis") {
+		t.Errorf("%s conserva la sesión de una partición desmontada:\n%s", ArchivoSesiones, datos)
+	}
+}
+
+func TestRestaurarSesionesVencidas(t *testing.T) {
+	sesionesPrueba(t)
+	RegistrarMontaje(MontajeParticion{Id: "461A", Path: "/tmp/disco.mia"})
+	if _, err := CrearSesion(usuarioPrueba("461A", "ana")); err != nil {
+		t.Fatal(err)
+	}
+
+	mutexSesiones.Lock()
+	for _, sesion := range sesiones {
+		sesion.UltimoUso = time.Now().Add(-DuracionSesion - time.Minute)
+	}
+	if err := guardarSesiones(); err != nil {
+		t.Fatal(err)
+	}
+	sesiones = make(map[string]*Sesion)
+	mutexSesiones.Unlock()
+
+	if restauradas, err := RestaurarSesiones(); err != nil || restauradas != 0 {
+		t.Errorf("RestaurarSesiones() = %d, %v, se esperaba 0 sesiones", restauradas, err)
+	}
+}
+
+func TestRestaurarSesionesSinArchivo(t *testing.T) {
+	sesionesPrueba(t)
+	if restauradas, err := RestaurarSesiones(); err != nil || restauradas != 0 {
+		t.Errorf("RestaurarSesiones() sin archivo = %d, %v, se esperaba 0, nil", restauradas, err)
+	}
+
+	if err := os.WriteFile(ArchivoSesiones, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestaurarSesiones(); err == nil || !strings.Contains(err.Error(), "registro de sesiones inválido") {
+		t.Errorf("RestaurarSesiones() con JSON dañado devolvió %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package global
+
+import (
+	estructuras "godisk/Estructuras"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// sesionesPrueba deja el almacén de sesiones y de montajes vacío y guarda ArchivoSesiones en un
+// directorio temporal; todo se restaura al terminar la prueba
+func sesionesPrueba(t *testing.T) {
+	t.Helper()
+	archivo := ArchivoSesiones
+	ArchivoSesiones = filepath.Join(t.TempDir(), "sesiones.json")
+
+	mutexSesiones.Lock()
+	anteriores := sesiones
+	sesiones = make(map[string]*Sesion)
+	mutexSesiones.Unlock()
+
+	mutexMontajes.Lock()
+	montajes := particionesMontadas
+	particionesMontadas = make(map[string]MontajeParticion)
+	mutexMontajes.Unlock()
+
+	t.Cleanup(func() {
+		ArchivoSesiones = archivo
+		mutexSesiones.Lock()
+		sesiones = anteriores
+		mutexSesiones.Unlock()
+		mutexMontajes.Lock()
+		particionesMontadas = montajes
+		mutexMontajes.Unlock()
+	})
+}
+
+func usuarioPrueba(particion, nombre string) *estructuras.Usuario {
+	return &estructuras.Usuario{Id: particion, Tipo: "U", Group: "root", Name: nombre, Password: "$pbkdf2$a$b", Status: true, Uid: 1, Gid: 1}
+}
+
+func TestSesionesPorCliente(t *testing.T) {
+	sesionesPrueba(t)
+	ana := usuarioPrueba("461A", "ana")
+	luis := usuarioPrueba("462A", "luis")
+
+	tokenAna, err := CrearSesion(ana)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenLuis, err := CrearSesion(luis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokenAna) != 64 || tokenAna == tokenLuis {
+		t.Fatalf("tokens %q y %q: se esperaban dos tokens distintos de 64 caracteres", tokenAna, tokenLuis)
+	}
+
+	casos := []struct {
+		token    string
+		esperado string
+	}{
+		{tokenAna, "ana"},
+		{tokenLuis, "luis"},
+	}
+	for _, c := range casos {
+		sesion, ok := ObtenerSesion(c.token)
+		if !ok || sesion.Token != c.token || sesion.Usuario.Name != c.esperado {
+			t.Errorf("ObtenerSesion(%q) = %+v, %v, se esperaba la sesión de %s", c.token, sesion, ok, c.esperado)
+		}
+	}
+	for _, token := range []string{"", "no-existe"} {
+		if _, ok := ObtenerSesion(token); ok {
+			t.Errorf("ObtenerSesion(%q) encontró una sesión", token)
+		}
+	}
+
+	// logout de un cliente no toca la sesión del otro
+	ActualizarSesion(tokenAna, nil)
+	if sesion, _ := ObtenerSesion(tokenAna); sesion.EstaLogueado() {
+		t.Errorf("la sesión de ana sigue logueada después de ActualizarSesion(nil)")
+	}
+	if sesion, _ := ObtenerSesion(tokenLuis); !sesion.EstaLogueado() {
+		t.Errorf("la sesión de luis se cerró al cerrar la de ana")
+	}
+
+	EliminarSesion(tokenLuis)
+	if _, ok := ObtenerSesion(tokenLuis); ok {
+		t.Errorf("ObtenerSesion encontró la sesión eliminada")
+	}
+}
+
+func TestSesionVencida(t *testing.T) {
+	sesionesPrueba(t)
+	token, err := CrearSesion(usuarioPrueba("461A", "ana"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mutexSesiones.Lock()
+	sesiones[token].UltimoUso = time.Now().Add(-DuracionSesion - time.Minute)
+	mutexSesiones.Unlock()
+
+	if ParticionEnUso(nil, "461A") {
+		t.Error("ParticionEnUso cuenta una sesión vencida")
+	}
+	if _, ok := ObtenerSesion(token); ok {
+		t.Error("ObtenerSesion devolvió una sesión vencida")
+	}
+	mutexSesiones.Lock()
+	_, sigue := sesiones[token]
+	mutexSesiones.Unlock()
+	if sigue {
+		t.Error("la sesión vencida sigue en el almacén")
+	}
+}
+
+func TestParticionEnUso(t *testing.T) {
+	sesionesPrueba(t)
+	if _, err := CrearSesion(usuarioPrueba("461A", "ana")); err != nil {
+		t.Fatal(err)
+	}
+	consola := &Sesion{Usuario: usuarioPrueba("463A", "root")}
+
+	casos := []struct {
+		actual    *Sesion
+		particion string
+		esperado  bool
+	}{
+		{nil, "461A", true},
+		{nil, "462A", false},
+		{consola, "463A", true},
+		{&Sesion{}, "463A", false},
+	}
+	for _, c := range casos {
+		if obtenido := ParticionEnUso(c.actual, c.particion); obtenido != c.esperado {
+			t.Errorf("ParticionEnUso(%+v, %q) = %v, se esperaba %v", c.actual, c.particion, obtenido, c.esperado)
+		}
+	}
+}
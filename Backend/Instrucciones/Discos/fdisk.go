@@ -5,9 +5,9 @@ import (
 	"errors"
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -27,69 +27,61 @@ func AnalizarFdisk(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &Fdisk{}
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-size=\d+|-unit=[bBkKmM]|-fit=[bBfFwfW]{2}|-path="[^"]+"|-path=[^\s]+|-type=[pPeElL]|-name="[^"]+"|-name=[^\s]+|-add=[+-]?\d+|-delete=(fast|full)`)
-	matches := re.FindAllString(args, -1)
+	params, err := utilidades.ParsearParametros(tokens, []string{"size", "unit", "fit", "path", "type", "name", "add", "delete"}, nil)
+	if err != nil {
+		return "", err
+	}
 
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
 
 		switch key {
-		case "-size":
+		case "size":
 			size, err := strconv.Atoi(value)
 			if err != nil || size <= 0 {
 				return "", errors.New("el tamaño debe ser un número entero positivo")
 			}
 			cmd.size = size
-		case "-unit":
+		case "unit":
 			value = strings.ToUpper(value)
 			if value != "B" && value != "K" && value != "M" {
 				return "", errors.New("la unidad debe ser B, K o M")
 			}
 			cmd.unit = value
-		case "-fit":
+		case "fit":
 			value = strings.ToUpper(value)
 			if value != "BF" && value != "FF" && value != "WF" {
 				return "", errors.New("el ajuste debe ser BF, FF o WF")
 			}
 			cmd.fit = value
-		case "-path":
+		case "path":
 			if value == "" {
 				return "", errors.New("el path no puede estar vacío")
 			}
 			cmd.path = value
-		case "-type":
+		case "type":
 			value = strings.ToUpper(value)
 			if value != "P" && value != "E" && value != "L" {
 				return "", errors.New("el tipo debe ser P, E o L")
 			}
 			cmd.typpe = value
-		case "-name":
+		case "name":
 			if value == "" {
 				return "", errors.New("el nombre no puede estar vacío")
 			}
 			cmd.name = value
-		case "-add":
+		case "add":
 			add, err := strconv.Atoi(value)
 			if err != nil {
 				return "", errors.New("el valor de -add debe ser un número entero")
 			}
 			cmd.add = add
-		case "-delete":
+		case "delete":
 			value = strings.ToLower(value)
 			if value != "fast" && value != "full" {
 				return "", errors.New("el valor de -delete debe ser 'fast' o 'full'")
 			}
 			cmd.delete = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
 		}
 	}
 
@@ -135,7 +127,7 @@ func AnalizarFdisk(tokens []string) (string, error) {
 		cmd.typpe = "P"
 	}
 
-	err := commandFdisk(cmd, &outputBuffer)
+	err = commandFdisk(cmd, &outputBuffer)
 	if err != nil {
 		return "", fmt.Errorf("error al crear la partición: %v", err)
 	}
@@ -147,11 +139,11 @@ func processDeletePartition(cmd *Fdisk, outputBuffer *bytes.Buffer) (string, err
 	fmt.Fprintf(outputBuffer, "========================== DELETE ==========================\n")
 	fmt.Fprintf(outputBuffer, "Eliminando partición con nombre '%s' usando el método %s...\n", cmd.name, cmd.delete)
 
-	file, err := os.OpenFile(cmd.path, os.O_RDWR, 0644)
+	file, err := globals.Discos.Abrir(cmd.path, os.O_RDWR)
 	if err != nil {
 		return "", fmt.Errorf("error abriendo el archivo del disco: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
 	var mbr estructuras.Mbr
 	err = mbr.Decodificar(file)
@@ -205,11 +197,11 @@ func processAddPartition(cmd *Fdisk, outputBuffer *bytes.Buffer) (string, error)
 	fmt.Fprintf(outputBuffer, "========================== ADD ==========================\n")
 	fmt.Fprintf(outputBuffer, "Modificando partición '%s', ajustando %d unidades...\n", cmd.name, cmd.add)
 
-	file, err := os.OpenFile(cmd.path, os.O_RDWR, 0644)
+	file, err := globals.Discos.Abrir(cmd.path, os.O_RDWR)
 	if err != nil {
 		return "", fmt.Errorf("error abriendo el archivo del disco: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
 	var mbr estructuras.Mbr
 	err = mbr.Decodificar(file)
@@ -287,11 +279,11 @@ func commandFdisk(fdisk *Fdisk, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintf(outputBuffer, "Creando partición con nombre '%s' y tamaño %d %s...\n", fdisk.name, fdisk.size, fdisk.unit)
 	fmt.Println("Detalles internos de la creación de partición:", fdisk.size, fdisk.unit, fdisk.fit, fdisk.path, fdisk.typpe, fdisk.name)
 
-	file, err := os.OpenFile(fdisk.path, os.O_RDWR, 0644)
+	file, err := globals.Discos.Abrir(fdisk.path, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error abriendo el archivo del disco: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
 	sizeBytes, err := utilidades.ConvertirABytes(fdisk.size, fdisk.unit)
 	if err != nil {
@@ -415,7 +407,13 @@ func createLogicalPartition(file *os.File, fdisk *Fdisk, sizeBytes int, outputBu
 
 	if lastEBR.Part_s == 0 {
 		fmt.Println("Detectado EBR inicial vacío, asignando tamaño a la nueva partición lógica.")
+		if int32(sizeBytes) > extendedPartition.Part_s {
+			return errors.New("no hay suficiente espacio en la partición extendida para una nueva partición lógica")
+		}
+		// El EBR inicial lleva el nombre de la extendida; se reemplaza por el de la lógica
 		lastEBR.Part_s = int32(sizeBytes)
+		lastEBR.Part_fit[0] = fdisk.fit[0]
+		lastEBR.Part_name = [16]byte{}
 		copy(lastEBR.Part_name[:], fdisk.name)
 
 		err = lastEBR.Codificar(file, int64(lastEBR.Part_start))
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
 	"strings"
 )
@@ -13,41 +15,67 @@ type ListPartitions struct {
 	path string
 }
 
+type PartitionInfo struct {
+	Type    string          `json:"type"`
+	Name    string          `json:"name"`
+	Start   int32           `json:"start"`
+	Size    int32           `json:"size"`
+	Status  string          `json:"status"`
+	Fit     string          `json:"fit,omitempty"`
+	Id      string          `json:"id,omitempty"`
+	Next    int32           `json:"next,omitempty"`
+	Logical []PartitionInfo `json:"logical,omitempty"`
+}
+
+type DiskPartitions struct {
+	Path       string          `json:"path"`
+	Size       int32           `json:"size"`
+	Partitions []PartitionInfo `json:"partitions"`
+}
+
 func AnalizarListPartitions(tokens []string) (string, error) {
+	salida, _, err := AnalizarListPartitionsConDatos(tokens)
+	return salida, err
+}
+
+// AnalizarListPartitionsConDatos devuelve además la lista de particiones para las respuestas JSON
+func AnalizarListPartitionsConDatos(tokens []string) (string, interface{}, error) {
 	cmd := &ListPartitions{}
 	var outputBuffer bytes.Buffer
 
-	for _, token := range tokens {
-		if strings.HasPrefix(strings.ToLower(token), "-path=") {
-			cmd.path = strings.Trim(strings.SplitN(token, "=", 2)[1], "\"")
-		}
+	params, err := utilidades.ParsearParametros(tokens, []string{"path"}, nil)
+	if err != nil {
+		return "", nil, err
 	}
+	cmd.path = params.Valor("path")
 
 	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+		return "", nil, errors.New("faltan parámetros requeridos: -path")
 	}
 
-	err := commandListPartitions(cmd, &outputBuffer)
+	disco, err := commandListPartitions(cmd, &outputBuffer)
 	if err != nil {
-		return "", fmt.Errorf("error al listar las particiones: %v", err)
+		return "", nil, fmt.Errorf("error al listar las particiones: %v", err)
 	}
 
-	return outputBuffer.String(), nil
+	return outputBuffer.String(), disco, nil
 }
 
-func commandListPartitions(listCmd *ListPartitions, outputBuffer *bytes.Buffer) error {
-	file, err := os.Open(listCmd.path)
+func commandListPartitions(listCmd *ListPartitions, outputBuffer *bytes.Buffer) (*DiskPartitions, error) {
+	file, err := globals.Discos.Abrir(listCmd.path, os.O_RDONLY)
 	if err != nil {
-		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
+		return nil, fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
 	mbr := &estructuras.Mbr{}
 	err = mbr.Decodificar(file)
 	if err != nil {
-		return fmt.Errorf("error al leer el MBR del disco: %v", err)
+		return nil, fmt.Errorf("error al leer el MBR del disco: %v", err)
 	}
 
+	disco := &DiskPartitions{Path: listCmd.path, Size: mbr.Mbr_tamano, Partitions: []PartitionInfo{}}
+
 	fmt.Fprintln(outputBuffer, "===================== LISTA DE PARTICIONES =====================")
 	fmt.Fprintf(outputBuffer, "	Disco: %s 	(Tamaño: %d 	bytes)\n", listCmd.path, mbr.Mbr_tamano)
 	fmt.Fprintln(outputBuffer, "-----------------------------------------------------------------")
@@ -70,38 +98,59 @@ func commandListPartitions(listCmd *ListPartitions, outputBuffer *bytes.Buffer)
 
 			fmt.Fprintf(outputBuffer, "%-8s %-10s %-12d %-12d %s\n", partType, partName, part.Part_start, part.Part_s, partStatus)
 
+			info := PartitionInfo{
+				Type:   partType,
+				Name:   partName,
+				Start:  part.Part_start,
+				Size:   part.Part_s,
+				Status: partStatus,
+				Fit:    strings.TrimRight(string(part.Part_fit[:]), "\x00"),
+				Id:     strings.TrimRight(string(part.Part_id[:]), "\x00"),
+			}
 			if part.Part_type[0] == 'E' {
-				listLogicalPartitions(file, part.Part_start, outputBuffer)
+				info.Logical = listLogicalPartitions(file, mbr, outputBuffer)
 			}
+			disco.Partitions = append(disco.Partitions, info)
 		}
 	}
 
 	fmt.Fprintln(outputBuffer, "=================================================================")
-	return nil
+	return disco, nil
 }
 
-func listLogicalPartitions(file *os.File, start int32, outputBuffer *bytes.Buffer) {
-	ebrStart := start
+func listLogicalPartitions(file *os.File, mbr *estructuras.Mbr, outputBuffer *bytes.Buffer) []PartitionInfo {
+	var logicas []PartitionInfo
 
 	fmt.Fprintln(outputBuffer, "  Particiones lógicas dentro de la extendida:")
-	for ebrStart != -1 {
-		ebr := &estructuras.Ebr{}
-		err := ebr.Codificar(file, int64(ebrStart))
-		if err != nil {
-			fmt.Fprintf(outputBuffer, "  Error al leer EBR en la posición %d: %v\n", ebrStart, err)
-			return
-		}
+	ebrs, err := mbr.ObtenerEBRs(file)
+	if err != nil {
+		fmt.Fprintf(outputBuffer, "  Error al leer los EBR: %v\n", err)
+		return logicas
+	}
 
+	for _, ebr := range ebrs {
 		ebrName := strings.TrimRight(string(ebr.Part_name[:]), "\x00")
-		ebrFit := string(ebr.Part_fit[:])
+		ebrFit := strings.TrimRight(string(ebr.Part_fit[:]), "\x00")
+		ebrId := strings.TrimRight(string(ebr.Part_id[:]), "\x00")
 		ebrMount := "No Montada"
-		if ebr.Part_mount[0] == '1' {
+		if ebrId != "" {
 			ebrMount = "Montada"
 		}
 
 		fmt.Fprintf(outputBuffer, "  Lógica  %-10s %-12d %-12d %-6s %-10s Next: %d\n",
 			ebrName, ebr.Part_start, ebr.Part_s, ebrFit, ebrMount, ebr.Part_next)
 
-		ebrStart = ebr.Part_next
+		logicas = append(logicas, PartitionInfo{
+			Type:   "Lógica",
+			Name:   ebrName,
+			Start:  ebr.Part_start,
+			Size:   ebr.Part_s,
+			Status: ebrMount,
+			Fit:    ebrFit,
+			Id:     ebrId,
+			Next:   ebr.Part_next,
+		})
 	}
+
+	return logicas
 }
@@ -5,11 +5,11 @@ import (
 	"errors"
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"math/rand"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -34,41 +34,34 @@ func AnalizarMkdisk(tokens []string) (string, error) {
 	cmd := &Mkdisk{}
 	var outputBuffer bytes.Buffer
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-size=\d+|-unit=[kKmM]|-fit=[bBfFwW]{2}|-path="[^"]+"|-path=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
+	params, err := utilidades.ParsearParametros(tokens, []string{"size", "unit", "fit", "path"}, nil)
+	if err != nil {
+		return "", err
+	}
 
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
 
 		switch key {
-		case "-size":
+		case "size":
 			size, err := strconv.Atoi(value)
 			if err != nil || size <= 0 {
 				return "", errors.New("el tamaño debe ser un número entero positivo")
 			}
 			cmd.Size = size
-		case "-unit":
+		case "unit":
 			value = strings.ToUpper(value)
 			if value != UnitK && value != UnitM {
 				return "", errors.New("la unidad debe ser K o M")
 			}
 			cmd.Unit = value
-		case "-fit":
+		case "fit":
 			value = strings.ToUpper(value)
 			if value != FitBF && value != FitFF && value != FitWF {
 				return "", errors.New("el ajuste debe ser BF, FF o WF")
 			}
 			cmd.Fit = value
-		case "-path":
+		case "path":
 			if value == "" {
 				return "", errors.New("el path no puede estar vacío")
 			}
@@ -76,8 +69,6 @@ func AnalizarMkdisk(tokens []string) (string, error) {
 				return "", errors.New("el archivo debe tener la extensión .mia")
 			}
 			cmd.Path = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
 		}
 	}
 
@@ -94,7 +85,7 @@ func AnalizarMkdisk(tokens []string) (string, error) {
 		cmd.Fit = FitFF
 	}
 
-	err := ejecutarMkdisk(cmd, &outputBuffer)
+	err = ejecutarMkdisk(cmd, &outputBuffer)
 	if err != nil {
 		return "", fmt.Errorf("error al crear el disco: %v", err)
 	}
@@ -135,12 +126,12 @@ func crearDisco(mkdisk *Mkdisk, sizeBytes int, outputBuffer *bytes.Buffer) error
 		return err
 	}
 
-	file, err := os.Create(mkdisk.Path)
+	file, err := globals.Discos.Abrir(mkdisk.Path, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
 	if err != nil {
 		fmt.Fprintln(outputBuffer, "Error creando archivo:", err)
 		return err
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
 	buffer := make([]byte, 1024*1024)
 	for sizeBytes > 0 {
@@ -158,12 +149,12 @@ func crearDisco(mkdisk *Mkdisk, sizeBytes int, outputBuffer *bytes.Buffer) error
 }
 
 func creacionMBR(mkdisk *Mkdisk, sizeBytes int, outputBuffer *bytes.Buffer) error {
-	file, err := os.OpenFile(mkdisk.Path, os.O_WRONLY|os.O_CREATE, 0644)
+	file, err := globals.Discos.Abrir(mkdisk.Path, os.O_WRONLY|os.O_CREATE)
 	if err != nil {
 		fmt.Fprintln(outputBuffer, "Error abriendo el archivo:", err)
 		return err
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
 	mbr := &estructuras.Mbr{
 		Mbr_tamano:         int32(sizeBytes),
@@ -10,53 +10,66 @@ import (
 	utilidades "godisk/Utilidades"
 	"math"
 	"os"
-	"regexp"
-	"strings"
+	"slices"
+	"strconv"
 	"time"
 )
 
 type MKFS struct {
-	id  string
-	typ string
-	fs  string
+	id             string
+	typ            string
+	fs             string
+	blockSize      int32
+	inodesRatio    int32
+	journalEntries int32
 }
 
 func AnalizarMkfs(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &MKFS{}
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-id=[^\s]+|-type=[^\s]+|-fs=[^\s]+`)
-	matches := re.FindAllString(args, -1)
+	params, err := utilidades.ParsearParametros(tokens, []string{"id", "type", "fs", "blocksize", "inodes_ratio", "journal_entries"}, nil)
+	if err != nil {
+		return "", err
+	}
 
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
 
 		switch key {
-		case "-id":
+		case "id":
 			if value == "" {
 				return "", errors.New("el id no puede estar vacío")
 			}
 			cmd.id = value
-		case "-type":
-			if value != "full" {
-				return "", errors.New("el tipo debe ser full")
+		case "type":
+			if value != "full" && value != "fast" {
+				return "", errors.New("el tipo debe ser full o fast")
 			}
 			cmd.typ = value
-		case "-fs":
+		case "fs":
 			if value != "2fs" && value != "3fs" {
 				return "", errors.New("el sistema de archivos debe ser 2fs o 3fs")
 			}
 			cmd.fs = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		case "blocksize":
+			size, err := strconv.Atoi(value)
+			if err != nil || !slices.Contains(estructuras.TamanosBloque, int32(size)) {
+				return "", errors.New("el tamaño de bloque debe ser 64, 128, 256, 512 o 1024")
+			}
+			cmd.blockSize = int32(size)
+		case "inodes_ratio":
+			ratio, err := strconv.Atoi(value)
+			if err != nil || ratio < 1 || ratio > 1024 {
+				return "", errors.New("inodes_ratio debe ser un entero entre 1 y 1024")
+			}
+			cmd.inodesRatio = int32(ratio)
+		case "journal_entries":
+			entries, err := strconv.Atoi(value)
+			if err != nil || entries < int(estructuras.JournalMinimo) || entries > int(estructuras.JournalMaximo) {
+				return "", fmt.Errorf("journal_entries debe ser un entero entre %d y %d", estructuras.JournalMinimo, estructuras.JournalMaximo)
+			}
+			cmd.journalEntries = int32(entries)
 		}
 	}
 
@@ -68,11 +81,23 @@ func AnalizarMkfs(tokens []string) (string, error) {
 		cmd.fs = "2fs"
 	}
 
+	if cmd.journalEntries != 0 && cmd.fs != "3fs" {
+		return "", errors.New("journal_entries solo se puede usar con -fs=3fs")
+	}
+
 	if cmd.typ == "" {
 		cmd.typ = "full"
 	}
 
-	err := commandMkfs(cmd, &outputBuffer)
+	if cmd.blockSize == 0 {
+		cmd.blockSize = estructuras.BlockSize
+	}
+
+	if cmd.inodesRatio == 0 {
+		cmd.inodesRatio = 3
+	}
+
+	err = commandMkfs(cmd, &outputBuffer)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return "", err
@@ -89,23 +114,46 @@ func commandMkfs(mkfs *MKFS, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error al obtener la partición montada con ID %s: %v", mkfs.id, err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0644)
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error abriendo el archivo de la partición en %s: %v", partitionPath, err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
 
 	fmt.Fprintf(outputBuffer, "Partición montada correctamente en %s.\n", partitionPath)
 	fmt.Println("\nPartición montada:")
 	mountedPartition.ImprimirParticion()
 
-	n := calculateN(mountedPartition, mkfs.fs)
+	n := calculateN(mountedPartition, mkfs)
 	fmt.Println("\nValor de n:", n)
+	if n < 2 {
+		return fmt.Errorf("la partición es demasiado pequeña para un bloque de %d bytes y %d bloques por inodo", mkfs.blockSize, mkfs.inodesRatio)
+	}
+	// sin -journal_entries el journal tiene un registro por inodo
+	if mkfs.fs == "3fs" && mkfs.journalEntries == 0 {
+		mkfs.journalEntries = min(n, estructuras.JournalMaximo)
+	}
 
-	superBlock := createSuperBlock(mountedPartition, n, mkfs.fs)
+	superBlock := createSuperBlock(mountedPartition, n, mkfs)
 	fmt.Println("\nSuperBlock:")
 	superBlock.Print()
 
+	inicio := time.Now()
+	var bytesEscritos int64
+
+	// full limpia desde el superbloque hasta el último bloque; fast solo escribe los metadatos
+	if mkfs.typ == "full" {
+		fin := int64(superBlock.S_block_start) + int64(superBlock.TotalBloques())*int64(superBlock.S_block_size)
+		longitud := fin - int64(mountedPartition.Part_start)
+		err = estructuras.ZeroRegion(file, int64(mountedPartition.Part_start), longitud)
+		if err != nil {
+			return fmt.Errorf("error limpiando la partición: %v", err)
+		}
+		// los metadatos se escriben dentro de la región limpiada, así que no suman bytes
+		bytesEscritos = longitud
+		fmt.Fprintln(outputBuffer, "Área de datos de la partición limpiada.")
+	}
+
 	err = superBlock.CreateBitMaps(file)
 	if err != nil {
 		return fmt.Errorf("error creando bitmaps: %v", err)
@@ -113,7 +161,7 @@ func commandMkfs(mkfs *MKFS, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "Bitmaps creados correctamente.")
 
 	if mkfs.fs == "3fs" {
-		err = superBlock.CreateUsersFileExt3(file, int64(mountedPartition.Part_start+int32(binary.Size(estructuras.Superbloque{}))))
+		err = superBlock.CreateUsersFileExt3(file, mkfs.journalEntries)
 	} else {
 		err = superBlock.CreateUsersFile(file)
 	}
@@ -126,18 +174,58 @@ func commandMkfs(mkfs *MKFS, outputBuffer *bytes.Buffer) error {
 	if err != nil {
 		return fmt.Errorf("error escribiendo el superbloque en el disco: %v", err)
 	}
+	if mkfs.fs == "3fs" {
+		err = superBlock.Codificar(file, estructuras.PosicionRespaldo(mountedPartition.Part_start))
+		if err != nil {
+			return fmt.Errorf("error escribiendo la copia del superbloque: %v", err)
+		}
+	}
 	fmt.Fprintln(outputBuffer, "Superbloque escrito correctamente en el disco.")
+	fmt.Fprintf(outputBuffer, "Tamaño de bloque: %d bytes, %d inodos y %d bloques.\n", superBlock.S_block_size, superBlock.TotalInodos(), superBlock.TotalBloques())
+	if mkfs.fs == "3fs" {
+		fmt.Fprintf(outputBuffer, "Journal de %d registros.\n", mkfs.journalEntries)
+	}
+
+	if mkfs.typ != "full" {
+		bytesEscritos = bytesMetadatos(superBlock, mkfs)
+	}
+	fmt.Fprintf(outputBuffer, "Formateo %s: %d bytes escritos en %v.\n", mkfs.typ, bytesEscritos, time.Since(inicio).Round(time.Millisecond))
 	fmt.Fprintln(outputBuffer, "===========================================================")
 
 	return nil
 }
 
-func calculateN(partition *estructuras.Partition, fs string) int32 {
+// bytesMetadatos calcula lo que escribe el formateo fast: superbloque, journal, bitmaps y los
+// inodos y bloques de la raíz y users.txt
+func bytesMetadatos(sb *estructuras.Superbloque, mkfs *MKFS) int64 {
+	total := int64(binary.Size(estructuras.Superbloque{}))
+	if mkfs.fs == "3fs" {
+		total += int64(binary.Size(estructuras.Superbloque{}))
+		total += int64(estructuras.TamanoJournal(mkfs.journalEntries))
+	}
+	total += int64((sb.TotalInodos() + 7) / 8)
+	total += int64((sb.TotalBloques() + 7) / 8)
+	total += int64(sb.S_inodes_count) * int64(sb.S_inode_size)
+	total += int64(sb.S_blocks_count) * int64(sb.S_block_size)
+	return total
+}
+
+// calculateN calcula la cantidad de inodos; por cada inodo hay inodesRatio bloques y un byte
+// de bitmap para cada uno. En EXT3 la copia del superbloque y el journal van antes de los
+// bitmaps; el journal ocupa journalEntries registros o, si no se indicó, uno por inodo.
+func calculateN(partition *estructuras.Partition, mkfs *MKFS) int32 {
+	ratio := int(mkfs.inodesRatio)
 	numerator := int(partition.Part_s) - binary.Size(estructuras.Superbloque{})
-	baseDenominator := 4 + binary.Size(estructuras.Inodo{}) + 3*binary.Size(estructuras.ArchivoBloque{})
+	baseDenominator := 1 + ratio + binary.Size(estructuras.Inodo{}) + ratio*int(mkfs.blockSize)
 	temp := 0
-	if fs == "3fs" {
-		temp = binary.Size(estructuras.Journal{})
+	if mkfs.fs == "3fs" {
+		numerator -= binary.Size(estructuras.Superbloque{})
+		if mkfs.journalEntries > 0 {
+			numerator -= int(estructuras.TamanoJournal(mkfs.journalEntries))
+		} else {
+			numerator -= int(estructuras.TamanoJournal(0))
+			temp = binary.Size(estructuras.Journal{})
+		}
 	}
 	denominator := baseDenominator + temp
 	n := math.Floor(float64(numerator) / float64(denominator))
@@ -145,39 +233,41 @@ func calculateN(partition *estructuras.Partition, fs string) int32 {
 	return int32(n)
 }
 
-func createSuperBlock(partition *estructuras.Partition, n int32, fs string) *estructuras.Superbloque {
-	journal_start, bm_inode_start, bm_block_start, inode_start, block_start := calculateStartPositions(partition, fs, n)
+func createSuperBlock(partition *estructuras.Partition, n int32, mkfs *MKFS) *estructuras.Superbloque {
+	journal_start, bm_inode_start, bm_block_start, inode_start, block_start := calculateStartPositions(partition, mkfs, n)
 
 	fmt.Println("\nInicio del SuperBlock:", partition.Part_start)
 	fmt.Println("\nFin del SuperBlock:", partition.Part_start+int32(binary.Size(estructuras.Superbloque{})))
 	fmt.Println("\nInicio del Journal:", journal_start)
-	fmt.Println("\nFin del Journal:", journal_start+int32(binary.Size(estructuras.Journal{})))
+	fmt.Println("\nFin del Journal:", bm_inode_start)
 	fmt.Println("\nInicio del Bitmap de Inodos:", bm_inode_start)
 	fmt.Println("\nFin del Bitmap de Inodos:", bm_inode_start+n)
 	fmt.Println("\nInicio del Bitmap de Bloques:", bm_block_start)
-	fmt.Println("\nFin del Bitmap de Bloques:", bm_block_start+(3*n))
+	fmt.Println("\nFin del Bitmap de Bloques:", bm_block_start+(mkfs.inodesRatio*n))
 	fmt.Println("\nInicio de Inodos:", inode_start)
 	var fsType int32
 
-	if fs == "2fs" {
+	if mkfs.fs == "2fs" {
 		fsType = 2
 	} else {
-		fsType = 3
+		// EXT3 guarda una copia del superbloque para que recovery pueda reconstruirlo
+		fsType = 3 | estructuras.CaracteristicaRespaldo
 	}
 
 	// Crear un nuevo superbloque
 	superBlock := &estructuras.Superbloque{
-		S_filesystem_type:   fsType,
+		// la partición ya está montada, así que queda sucia hasta el unmount
+		S_filesystem_type:   fsType | estructuras.CaracteristicaNombresLargos | estructuras.EstadoSucio,
 		S_inodes_count:      0,
 		S_blocks_count:      0,
 		S_free_inodes_count: int32(n),
-		S_free_blocks_count: int32(n * 3),
+		S_free_blocks_count: int32(n * mkfs.inodesRatio),
 		S_mtime:             float64(time.Now().Unix()),
 		S_umtime:            float64(time.Now().Unix()),
 		S_mnt_count:         1,
 		S_magic:             0xEF53,
 		S_inode_size:        int32(binary.Size(estructuras.Inodo{})),
-		S_block_size:        int32(binary.Size(estructuras.ArchivoBloque{})),
+		S_block_size:        mkfs.blockSize,
 		S_first_ino:         inode_start,
 		S_first_blo:         block_start,
 		S_bm_inode_start:    bm_inode_start,
@@ -188,24 +278,22 @@ func createSuperBlock(partition *estructuras.Partition, n int32, fs string) *est
 	return superBlock
 }
 
-func calculateStartPositions(partition *estructuras.Partition, fs string, n int32) (int32, int32, int32, int32, int32) {
+func calculateStartPositions(partition *estructuras.Partition, mkfs *MKFS, n int32) (int32, int32, int32, int32, int32) {
+	ratio := mkfs.inodesRatio
 	superblockSize := int32(binary.Size(estructuras.Superbloque{}))
-	journalSize := int32(binary.Size(estructuras.Journal{}))
 	inodeSize := int32(binary.Size(estructuras.Inodo{}))
 
 	journalStart := int32(0)
 	bmInodeStart := partition.Part_start + superblockSize
 	bmBlockStart := bmInodeStart + n
-	inodeStart := bmBlockStart + (3 * n)
+	inodeStart := bmBlockStart + (ratio * n)
 	blockStart := inodeStart + (inodeSize * n)
 
-	if fs == "3fs" {
-		const journalEntries = 50
-
-		journalStart = partition.Part_start + superblockSize
-		bmInodeStart = journalStart + journalEntries*journalSize
+	if mkfs.fs == "3fs" {
+		journalStart = partition.Part_start + 2*superblockSize
+		bmInodeStart = journalStart + estructuras.TamanoJournal(mkfs.journalEntries)
 		bmBlockStart = bmInodeStart + n
-		inodeStart = bmBlockStart + (3 * n)
+		inodeStart = bmBlockStart + (ratio * n)
 		blockStart = inodeStart + (inodeSize * n)
 	}
 
@@ -8,46 +8,39 @@ import (
 	global "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
 	"strings"
 )
 
 type Mount struct {
-	path string
-	name string
+	path        string
+	name        string
+	soloLectura bool
 }
 
 func AnalizarMount(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &Mount{}
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-name="[^"]+"|-name=[^\s]+`)
-	matches := re.FindAllString(args, -1)
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "name"}, []string{"ro"})
+	if err != nil {
+		return "", err
+	}
+	cmd.soloLectura = params.Banderas["ro"]
 
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
 
 		switch key {
-		case "-path":
+		case "path":
 			if value == "" {
 				return "", errors.New("el path no puede estar vacío")
 			}
 			cmd.path = value
-		case "-name":
+		case "name":
 			if value == "" {
 				return "", errors.New("el nombre no puede estar vacío")
 			}
 			cmd.name = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
 		}
 	}
 
@@ -58,7 +51,7 @@ func AnalizarMount(tokens []string) (string, error) {
 		return "", errors.New("faltan parámetros requeridos: -name")
 	}
 
-	err := commandMount(cmd, &outputBuffer)
+	err = commandMount(cmd, &outputBuffer)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return "", err
@@ -70,11 +63,11 @@ func AnalizarMount(tokens []string) (string, error) {
 func commandMount(mount *Mount, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "========================== MOUNT ==========================")
 
-	file, err := os.OpenFile(mount.path, os.O_RDWR, 0644)
+	file, err := global.Discos.Abrir(mount.path, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error abriendo el archivo del disco en el path: %s: %v", mount.path, err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
 
 	var mbr estructuras.Mbr
 	err = mbr.Decodificar(file)
@@ -82,15 +75,24 @@ func commandMount(mount *Mount, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error deserializando el MBR: %v", err)
 	}
 
+	// Las particiones lógicas se buscan en la cadena de EBR y se numeran después de las cuatro primarias
 	partition, indexPartition := mbr.ObtenerParticionPorNombre(mount.name)
+	var ebr *estructuras.Ebr
 	if partition == nil {
-		return fmt.Errorf("error: la partición '%s' no existe en el disco", mount.name)
+		var posicion int
+		ebr, posicion, err = mbr.ObtenerLogicaPorNombre(file, mount.name)
+		if ebr == nil {
+			return fmt.Errorf("error: la partición '%s' no existe en el disco", mount.name)
+		}
+		partition = ebr.Particion()
+		indexPartition = len(mbr.Mbr_partitions) + posicion
+	} else if partition.Part_type[0] == 'E' {
+		return fmt.Errorf("error: la partición '%s' es extendida y no se puede montar", mount.name)
 	}
 
-	for id, mountedPath := range global.ParticionesMontadas {
-		if mountedPath == mount.path && strings.Contains(id, mount.name) {
-			return fmt.Errorf("error: la partición '%s' ya está montada con ID: %s", mount.name, id)
-		}
+	idActual := strings.Trim(string(partition.Part_id[:]), "\x00 ")
+	if mountedPath, montada := global.RutaMontaje(idActual); montada && mountedPath == mount.path {
+		return fmt.Errorf("error: la partición '%s' ya está montada con ID: %s", mount.name, idActual)
 	}
 
 	idPartition, err := GenerateIdPartition(mount, indexPartition)
@@ -98,20 +100,43 @@ func commandMount(mount *Mount, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error generando el ID de la partición: %v", err)
 	}
 
-	global.ParticionesMontadas[idPartition] = mount.path
+	if ebr != nil {
+		ebr.MontarParticion(indexPartition, idPartition)
+		err = ebr.Codificar(file, int64(ebr.Part_start))
+		if err != nil {
+			return fmt.Errorf("error serializando el EBR de vuelta al disco: %v", err)
+		}
+	} else {
+		partition.MontarParticion(indexPartition, idPartition)
+		mbr.Mbr_partitions[indexPartition] = *partition
 
-	partition.MontarParticion(indexPartition, idPartition)
-	mbr.Mbr_partitions[indexPartition] = *partition
+		err = mbr.Codificar(file)
+		if err != nil {
+			return fmt.Errorf("error serializando el MBR de vuelta al disco: %v", err)
+		}
+	}
 
-	err = mbr.Codificar(file)
-	if err != nil {
-		return fmt.Errorf("error serializando el MBR de vuelta al disco: %v", err)
+	global.RegistrarMontaje(global.MontajeParticion{Id: idPartition, Path: mount.path, SoloLectura: mount.soloLectura})
+	if mount.soloLectura {
+		estructuras.AvisarMontajeSoloLectura(file, partition.Part_start, outputBuffer)
+	} else {
+		if err := estructuras.MontarSistemaArchivos(file, partition.Part_start, outputBuffer); err != nil {
+			fmt.Fprintf(outputBuffer, "Advertencia: %v\n", err)
+		}
+	}
+
+	if err := global.GuardarMontajes(); err != nil {
+		fmt.Fprintf(outputBuffer, "Advertencia: no se pudo guardar el registro de montajes: %v\n", err)
 	}
 
-	fmt.Fprintf(outputBuffer, "Partición '%s' montada correctamente con ID: %s\n", mount.name, idPartition)
+	modo := ""
+	if mount.soloLectura {
+		modo = " (solo lectura)"
+	}
+	fmt.Fprintf(outputBuffer, "Partición '%s' montada correctamente con ID: %s%s\n", mount.name, idPartition, modo)
 	fmt.Fprintln(outputBuffer, "\n=== Particiones Montadas ===")
-	for id, path := range global.ParticionesMontadas {
-		fmt.Fprintf(outputBuffer, "ID: %s | Path: %s\n", id, path)
+	for _, montaje := range global.Montajes() {
+		fmt.Fprintf(outputBuffer, "ID: %s | Path: %s\n", montaje.Id, montaje.Path)
 	}
 	fmt.Fprintln(outputBuffer, "============================ FIN MOUNT ========================")
 
@@ -119,6 +144,13 @@ func commandMount(mount *Mount, outputBuffer *bytes.Buffer) error {
 }
 
 func GenerateIdPartition(mount *Mount, indexPartition int) (string, error) {
+	// El ID se guarda en 4 bytes, así que el número de partición debe ser de un dígito; se revisa
+	// antes de asignarle una letra al disco
+	if indexPartition < 0 || indexPartition >= estructuras.ParticionesMontables {
+		return "", fmt.Errorf("la partición '%s' es la número %d del disco y el ID solo admite un dígito: se pueden montar las 4 primarias y las primeras %d lógicas",
+			mount.name, indexPartition+1, estructuras.ParticionesMontables-4)
+	}
+
 	lastTwoDigits := global.Carnet[len(global.Carnet)-2:]
 	letter, err := utilidades.ObtenerLetra(mount.path)
 	if err != nil {
@@ -3,26 +3,33 @@ package instrucciones
 import (
 	"fmt"
 	globales "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"strings"
 )
 
 func Mounted(parametros []string) (string, error) {
+	if _, err := utilidades.ParsearParametros(parametros, nil, nil); err != nil {
+		return "", err
+	}
+
 	var resultado strings.Builder
 	resultado.WriteString("================ MOUNTED =================\n")
 
-	var contador = 0
-	for id := range globales.ParticionesMontadas {
-		contador++
-		if len(globales.ParticionesMontadas) == contador {
-			resultado.WriteString(fmt.Sprintf("%s\n", id))
-		} else {
-			resultado.WriteString(fmt.Sprintf("%s,", id))
+	montajes := globales.Montajes()
+	for _, montaje := range montajes {
+		estado := ""
+		if montaje.SoloLectura {
+			estado += " (solo lectura)"
+		}
+		if montaje.Recuperado {
+			estado += " (recuperada)"
 		}
+		resultado.WriteString(fmt.Sprintf("%s%s\n", montaje.Id, estado))
 	}
 
 	resultado.WriteString("==================== FIN MOUNTED ====================\n")
 
-	if contador == 0 {
+	if len(montajes) == 0 {
 		resultado.WriteString("No hay particiones montadas\n")
 	}
 
@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
-	"strings"
 )
 
 type rmDisk struct {
@@ -18,29 +19,20 @@ func AnalizarRmdisk(tokens []string) (string, error) {
 
 	cmd := &rmDisk{}
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+`)
-	matches := re.FindAllString(args, -1)
+	params, err := utilidades.ParsearParametros(tokens, []string{"path"}, nil)
+	if err != nil {
+		return "", err
+	}
 
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
 
 		switch key {
-		case "-path":
+		case "path":
 			if value == "" {
 				return "", errors.New("el path no puede estar vacío")
 			}
 			cmd.path = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
 		}
 	}
 
@@ -48,7 +40,7 @@ func AnalizarRmdisk(tokens []string) (string, error) {
 		return "", errors.New("faltan parámetros requeridos: -path")
 	}
 
-	err := commandRmdisk(cmd, &outputBuffer)
+	err = commandRmdisk(cmd, &outputBuffer)
 	if err != nil {
 		return "", fmt.Errorf("error al eliminar el disco: %v", err)
 	}
@@ -66,6 +58,12 @@ func commandRmdisk(rmdisk *rmDisk, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("el archivo %s no existe", rmdisk.path)
 	}
 
+	// Cerrar los archivos que sigan abiertos sobre el disco antes de eliminarlo
+	if err := globals.Discos.CerrarDisco(rmdisk.path); err != nil {
+		return err
+	}
+	estructuras.DescartarBitmaps(rmdisk.path)
+
 	// Eliminar el archivo inmediatamente, sin preguntar
 	err := os.Remove(rmdisk.path)
 	if err != nil {
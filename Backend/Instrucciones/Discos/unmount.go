@@ -6,6 +6,7 @@ import (
 	"fmt"
 	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
 	"strings"
 )
@@ -18,17 +19,17 @@ func AnalizarUnmount(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &Unmount{}
 
-	for _, token := range tokens {
-		if strings.HasPrefix(token, "-id=") {
-			cmd.id = strings.TrimPrefix(token, "-id=")
-		}
+	params, err := utilidades.ParsearParametros(tokens, []string{"id"}, nil)
+	if err != nil {
+		return "", err
 	}
+	cmd.id = params.Valor("id")
 
 	if cmd.id == "" {
 		return "", errors.New("faltan parámetros requeridos: -id")
 	}
 
-	err := commandUnmount(cmd, &outputBuffer)
+	err = commandUnmount(cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -39,16 +40,16 @@ func AnalizarUnmount(tokens []string) (string, error) {
 func commandUnmount(unmount *Unmount, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "==================== DESMONTAJE DE PARTICIÓN ====================")
 
-	mountedPath, exists := globals.ParticionesMontadas[unmount.id]
+	mountedPath, exists := globals.RutaMontaje(unmount.id)
 	if !exists {
 		return fmt.Errorf("error: la partición con ID '%s' no se encuentra montada", unmount.id)
 	}
 
-	file, err := os.OpenFile(mountedPath, os.O_RDWR, 0644)
+	file, err := globals.Discos.Abrir(mountedPath, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error al acceder al archivo del disco: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
 	var mbr estructuras.Mbr
 	err = mbr.Decodificar(file)
@@ -61,6 +62,10 @@ func commandUnmount(unmount *Unmount, outputBuffer *bytes.Buffer) error {
 		partition := &mbr.Mbr_partitions[i]
 		partitionID := strings.TrimSpace(string(partition.Part_id[:]))
 		if partitionID == unmount.id {
+			if err := registrarDesmontajeSuperbloque(file, unmount.id, partition.Part_start); err != nil {
+				fmt.Fprintf(outputBuffer, "Advertencia: %v\n", err)
+			}
+
 			err = partition.MontarParticion(0, "")
 			if err != nil {
 				return fmt.Errorf("error al desmontar la partición: %v", err)
@@ -76,18 +81,60 @@ func commandUnmount(unmount *Unmount, outputBuffer *bytes.Buffer) error {
 		}
 	}
 
+	if !found {
+		if ebr, err := mbr.ObtenerLogicaPorID(file, unmount.id); err == nil {
+			if err := registrarDesmontajeSuperbloque(file, unmount.id, ebr.Particion().Part_start); err != nil {
+				fmt.Fprintf(outputBuffer, "Advertencia: %v\n", err)
+			}
+
+			ebr.MontarParticion(0, "")
+			err = ebr.Codificar(file, int64(ebr.Part_start))
+			if err != nil {
+				return fmt.Errorf("error al guardar cambios en el EBR: %v", err)
+			}
+			found = true
+		}
+	}
+
 	if !found {
 		return fmt.Errorf("error: partición con ID '%s' no localizada en el disco", unmount.id)
 	}
 
-	delete(globals.ParticionesMontadas, unmount.id)
+	globals.EliminarMontaje(unmount.id)
+	estructuras.DescartarBitmaps(mountedPath)
+
+	if err := globals.GuardarMontajes(); err != nil {
+		fmt.Fprintf(outputBuffer, "Advertencia: no se pudo guardar el registro de montajes: %v\n", err)
+	}
 
 	fmt.Fprintf(outputBuffer, "✓ Partición '%s' ha sido desmontada correctamente.\n", unmount.id)
 	fmt.Fprintln(outputBuffer, "\n=== Estado Actual de Particiones Montadas ===")
-	for id, path := range globals.ParticionesMontadas {
-		fmt.Fprintf(outputBuffer, "ID: %s | Ruta: %s\n", id, path)
+	for _, montaje := range globals.Montajes() {
+		fmt.Fprintf(outputBuffer, "ID: %s | Ruta: %s\n", montaje.Id, montaje.Path)
 	}
 	fmt.Fprintln(outputBuffer, "=============================================================")
 
 	return nil
 }
+
+// registrarDesmontajeSuperbloque marca el sistema de archivos como desmontado correctamente.
+// Un montaje de solo lectura no cambió el superbloque, así que no se toca.
+func registrarDesmontajeSuperbloque(file *os.File, id string, partStart int32) error {
+	if globals.MontadaSoloLectura(id) {
+		return nil
+	}
+
+	var sb estructuras.Superbloque
+	if err := sb.Decodificar(file, int64(partStart)); err != nil {
+		return fmt.Errorf("no se pudo leer el superbloque: %v", err)
+	}
+	if !sb.Formateado() {
+		return nil
+	}
+
+	sb.RegistrarDesmontaje()
+	if err := sb.Codificar(file, int64(partStart)); err != nil {
+		return fmt.Errorf("no se pudo actualizar el superbloque: %v", err)
+	}
+	return nil
+}
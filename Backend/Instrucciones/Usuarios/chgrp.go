@@ -5,8 +5,8 @@ import (
 	"fmt"
 	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -15,27 +15,26 @@ type CHGRP struct {
 	Grp  string
 }
 
-func AnalizarChgrp(tokens []string) (string, error) {
+func AnalizarChgrp(sesion *globals.Sesion, tokens []string) (string, error) {
 	var outputBuffer strings.Builder
 	cmd := &CHGRP{}
 
-	reUser := regexp.MustCompile(`-usr=[^\s]+`)
-	reGrp := regexp.MustCompile(`-grp=[^\s]+`)
-
-	matchesUser := reUser.FindString(strings.Join(tokens, " "))
-	matchesGrp := reGrp.FindString(strings.Join(tokens, " "))
+	params, err := utilidades.ParsearParametros(tokens, []string{"usr", "grp"}, nil)
+	if err != nil {
+		return "", err
+	}
 
-	if matchesUser == "" {
+	if params.Valor("usr") == "" {
 		return "", fmt.Errorf("falta el parámetro -usr")
 	}
-	if matchesGrp == "" {
+	if params.Valor("grp") == "" {
 		return "", fmt.Errorf("falta el parámetro -grp")
 	}
 
-	cmd.User = strings.SplitN(matchesUser, "=", 2)[1]
-	cmd.Grp = strings.SplitN(matchesGrp, "=", 2)[1]
+	cmd.User = params.Valor("usr")
+	cmd.Grp = params.Valor("grp")
 
-	err := commandChgrp(cmd, &outputBuffer)
+	err = commandChgrp(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -43,27 +42,27 @@ func AnalizarChgrp(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandChgrp(chgrp *CHGRP, outputBuffer *strings.Builder) error {
+func commandChgrp(sesion *globals.Sesion, chgrp *CHGRP, outputBuffer *strings.Builder) error {
 	fmt.Fprintln(outputBuffer, "======================= CHGRP =======================")
-	if !globals.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if sesion.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
-	partition, path, err := globals.ObtenerParticionMontada(globals.UsuarioActual.Id)
+	partition, path, err := globals.ObtenerParticionMontada(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
 
-	file, err := os.OpenFile(path, os.O_RDWR, 0755)
+	file, err := globals.Discos.Abrir(path, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
-	_, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	_, sb, _, err := globals.GetMountedPartitionRep(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
@@ -161,63 +160,5 @@ func ChangeUserGroup(file *os.File, sb *estructuras.Superbloque, usersInode *est
 		}
 	}
 
-	for _, blockIndex := range usersInode.I_block {
-		if blockIndex == -1 {
-			break
-		}
-
-		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
-		var fileBlock estructuras.ArchivoBloque
-
-		fileBlock.ClearContent()
-
-		err = fileBlock.Encode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
-		}
-	}
-
-	err = WriteContentToBlocks(file, sb, usersInode, nuevoContenido)
-	if err != nil {
-		return fmt.Errorf("error guardando los cambios en users.txt: %v", err)
-	}
-
-	usersInode.I_size = int32(len(strings.Join(nuevoContenido, "\n")))
-
-	usersInode.ActualizarMtime()
-	usersInode.ActualizarCtime()
-
-	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(*usersInode)))
-	err = usersInode.Encode(file, inodeOffset)
-	if err != nil {
-		return fmt.Errorf("error actualizando inodo de users.txt: %w", err)
-	}
-
-	return nil
-}
-
-func WriteContentToBlocks(file *os.File, sb *estructuras.Superbloque, usersInode *estructuras.Inodo, contenido []string) error {
-	contenidoFinal := strings.Join(contenido, "\n") + "\n"
-	data := []byte(contenidoFinal)
-
-	blockSize := int(sb.S_block_size)
-
-	for i, blockIndex := range usersInode.I_block {
-		if blockIndex == -1 {
-			break
-		}
-
-		start := i * blockSize
-		end := start + blockSize
-		if end > len(data) {
-			end = len(data)
-		}
-
-		var fileBlock estructuras.ArchivoBloque
-		copy(fileBlock.B_content[:], data[start:end])
-
-		fmt.Printf("Escribiendo bloque %d: %s\n", blockIndex, string(fileBlock.B_content[:]))
-	}
-
-	return nil
+	return globals.EscribirUsuarios(file, sb, usersInode, "chgrp", strings.Join(nuevoContenido, "\n")+"\n")
 }
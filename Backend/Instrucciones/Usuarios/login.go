@@ -6,8 +6,9 @@ import (
 	"fmt"
 	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -17,52 +18,20 @@ type LOGIN struct {
 	ID   string
 }
 
-func ParserLogin(tokens []string) (map[string]interface{}, error) {
+func ParserLogin(sesion *globals.Sesion, tokens []string) (map[string]interface{}, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &LOGIN{}
-	args := strings.Join(tokens, " ")
-
-	// Expresión regular para los parámetros
-	re := regexp.MustCompile(`-user=[^\s]+|-pass=[^\s]+|-id=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			switch strings.ToLower(kv[0]) {
-			case "-user":
-				return map[string]interface{}{
-					"status":  "error",
-					"message": "El formato del parámetro 'user' es incorrecto. Asegúrese de escribir '-user=nombre_usuario'.",
-				}, fmt.Errorf("formato de parámetro 'user' incorrecto")
-			case "-pass":
-				return map[string]interface{}{
-					"status":  "error",
-					"message": "El formato del parámetro 'pass' es incorrecto. Asegúrese de escribir '-pass=contraseña'.",
-				}, fmt.Errorf("formato de parámetro 'pass' incorrecto")
-			case "-id":
-				return map[string]interface{}{
-					"status":  "error",
-					"message": "El formato del parámetro 'id' es incorrecto. Asegúrese de escribir '-id=id_partición'.",
-				}, fmt.Errorf("formato de parámetro 'id' incorrecto")
-			default:
-				return map[string]interface{}{
-					"status":  "error",
-					"message": fmt.Sprintf("Parámetro desconocido: %s. Use solo '-user', '-pass' y '-id'.", kv[0]),
-				}, fmt.Errorf("parámetro desconocido: %s", kv[0])
-			}
-		}
 
-		key, value := strings.ToLower(kv[0]), kv[1]
-		switch key {
-		case "-user":
-			cmd.User = value
-		case "-pass":
-			cmd.Pass = value
-		case "-id":
-			cmd.ID = value
-		}
+	params, err := utilidades.ParsearParametros(tokens, []string{"user", "pass", "id"}, nil)
+	if err != nil {
+		return map[string]interface{}{
+			"status":  "error",
+			"message": fmt.Sprintf("%s. Use solo '-user', '-pass' y '-id'.", err.Error()),
+		}, err
 	}
+	cmd.User = params.Valor("user")
+	cmd.Pass = params.Valor("pass")
+	cmd.ID = params.Valor("id")
 
 	// Validar que se hayan proporcionado todos los parámetros
 	missingParams := []string{}
@@ -83,7 +52,7 @@ func ParserLogin(tokens []string) (map[string]interface{}, error) {
 	}
 
 	// Ejecutar el comando login
-	err := commandLogin(cmd, &outputBuffer)
+	err = commandLogin(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return map[string]interface{}{
 			"status":  "error",
@@ -98,18 +67,18 @@ func ParserLogin(tokens []string) (map[string]interface{}, error) {
 }
 
 // Lógica para ejecutar el login con respuesta estructurada
-func commandLogin(login *LOGIN, outputBuffer *bytes.Buffer) error {
+func commandLogin(sesion *globals.Sesion, login *LOGIN, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "===== INICIO DE LOGIN =====")
 	fmt.Fprintf(outputBuffer, "Intentando iniciar sesión con ID: %s, Usuario: %s\n", login.ID, login.User)
 
 	// Verificar si ya hay un usuario logueado
-	if globals.UsuarioActual != nil && globals.UsuarioActual.Status {
+	if sesion.EstaLogueado() {
 		return fmt.Errorf("ya hay un usuario logueado, debe cerrar sesión primero")
 	}
 
 	// Ver las particiones montadas
-	for id, path := range globals.ParticionesMontadas {
-		fmt.Fprintf(outputBuffer, "Partición montada con ID: %s | Path: %s\n", id, path)
+	for _, montaje := range globals.Montajes() {
+		fmt.Fprintf(outputBuffer, "Partición montada con ID: %s | Path: %s\n", montaje.Id, montaje.Path)
 	}
 
 	// Verificar si la partición está montada
@@ -119,18 +88,23 @@ func commandLogin(login *LOGIN, outputBuffer *bytes.Buffer) error {
 	}
 
 	// Cargar el Superblock de la partición montada
-	_, sb, _, err := globals.GetMountedPartitionRep(login.ID)
+	mbr, sb, _, err := globals.GetMountedPartitionRep(login.ID)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 	fmt.Fprintln(outputBuffer, "Superblock cargado correctamente")
 
 	// Leer el archivo users.txt (inodo 1)
-	file, err := os.Open(path)
+	soloLectura := globals.MontadaSoloLectura(login.ID)
+	flag := os.O_RDWR
+	if soloLectura {
+		flag = os.O_RDONLY
+	}
+	file, err := globals.Discos.Abrir(path, flag)
 	if err != nil {
 		return fmt.Errorf("no se puede abrir el archivo de partición: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
 	var usersInode estructuras.Inodo
 	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(usersInode)))
@@ -138,26 +112,15 @@ func commandLogin(login *LOGIN, outputBuffer *bytes.Buffer) error {
 	if err != nil {
 		return fmt.Errorf("error leyendo inodo de users.txt: %v", err)
 	}
-	usersInode.ActualizarAtime()
-
-	var contenido string
-	for _, blockIndex := range usersInode.I_block {
-		if blockIndex == -1 {
-			continue
-		}
-
-		blockOffset := int64(sb.S_block_start + blockIndex*int32(binary.Size(estructuras.ArchivoBloque{})))
-		var fileBlock estructuras.ArchivoBloque
-		err = fileBlock.Decode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error leyendo bloque de users.txt: %v", err)
-		}
-		contenido += string(fileBlock.B_content[:])
+	contenido, err := globals.ReadFileBlocks(file, sb, &usersInode)
+	if err != nil {
+		return fmt.Errorf("error leyendo users.txt: %v", err)
 	}
 
 	// Validar usuario y contraseña
+	lineas := strings.Split(strings.TrimSpace(contenido), "\n")
 	encontrado := false
-	for _, linea := range strings.Split(strings.TrimSpace(contenido), "\n") {
+	for _, linea := range lineas {
 		if linea == "" {
 			continue
 		}
@@ -165,12 +128,20 @@ func commandLogin(login *LOGIN, outputBuffer *bytes.Buffer) error {
 		datos := strings.Split(linea, ",")
 		if len(datos) == 5 && datos[1] == "U" {
 			usuario := estructuras.NewUser(datos[0], datos[2], datos[3], datos[4])
-			if usuario.Name == login.User && usuario.Password == login.Pass {
+			if usuario.Id != "0" && usuario.Name == login.User && estructuras.VerificarContrasena(usuario.Password, login.Pass) {
 				encontrado = true
-				globals.UsuarioActual = usuario
-				globals.UsuarioActual.Status = true
+				if !estructuras.EsContrasenaHasheada(usuario.Password) && !soloLectura {
+					// Discos anteriores guardan la contraseña en texto plano
+					if err := migrarContrasena(file, mbr, sb, &usersInode, login.ID, usuario.Name, login.Pass); err != nil {
+						fmt.Printf("Advertencia: no se pudo migrar la contraseña de '%s': %v\n", usuario.Name, err)
+					}
+				}
+				usuario.Uid = parsearIdUsuarios(datos[0])
+				usuario.Gid = buscarGidGrupo(lineas, usuario.Group)
+				usuario.Status = true
+				usuario.Id = login.ID
+				sesion.Usuario = usuario
 				fmt.Fprintf(outputBuffer, "Bienvenido %s, inicio de sesión exitoso.\n", usuario.Name)
-				globals.UsuarioActual.Id = login.ID
 				break
 			}
 		}
@@ -184,8 +155,44 @@ func commandLogin(login *LOGIN, outputBuffer *bytes.Buffer) error {
 	return nil
 }
 
-func AnalizarLogin(tokens []string) (string, error) {
-	result, err := ParserLogin(tokens)
+func migrarContrasena(file *os.File, mbr *estructuras.Mbr, sb *estructuras.Superbloque, usersInode *estructuras.Inodo, id, userName, pass string) error {
+	partition, err := mbr.BuscarParticionPorID(file, id)
+	if err != nil {
+		return err
+	}
+
+	hash, err := estructuras.HashearContrasena(pass)
+	if err != nil {
+		return err
+	}
+
+	if err := actualizarContrasena(file, sb, usersInode, userName, hash); err != nil {
+		return err
+	}
+
+	return sb.Codificar(file, int64(partition.Part_start))
+}
+
+func parsearIdUsuarios(valor string) int32 {
+	id, err := strconv.Atoi(strings.TrimSpace(valor))
+	if err != nil {
+		return -1
+	}
+	return int32(id)
+}
+
+func buscarGidGrupo(lineas []string, grupo string) int32 {
+	for _, linea := range lineas {
+		datos := strings.Split(linea, ",")
+		if len(datos) == 3 && datos[1] == "G" && strings.TrimSpace(datos[2]) == grupo {
+			return parsearIdUsuarios(datos[0])
+		}
+	}
+	return -1
+}
+
+func AnalizarLogin(sesion *globals.Sesion, tokens []string) (string, error) {
+	result, err := ParserLogin(sesion, tokens)
 	if err != nil {
 		return "", err
 	}
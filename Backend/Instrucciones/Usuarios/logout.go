@@ -3,20 +3,19 @@ package instrucciones
 import (
 	"bytes"
 	"fmt"
-	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
 )
 
 type LOGOUT struct{}
 
-func AnalizarLogout(tokens []string) (string, error) {
+func AnalizarLogout(sesion *globals.Sesion, tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 
-	if len(tokens) > 1 {
+	if len(tokens) > 0 {
 		return "", fmt.Errorf("el comando Logout no acepta parámetros")
 	}
 
-	err := commandLogout(&outputBuffer)
+	err := commandLogout(sesion, &outputBuffer)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return "", err
@@ -25,16 +24,16 @@ func AnalizarLogout(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandLogout(outputBuffer *bytes.Buffer) error {
-	if globals.UsuarioActual == nil || !globals.UsuarioActual.Status {
+func commandLogout(sesion *globals.Sesion, outputBuffer *bytes.Buffer) error {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
 
-	fmt.Fprintf(outputBuffer, "Cerrando sesión de usuario: %s\n", globals.UsuarioActual.Name)
+	fmt.Fprintf(outputBuffer, "Cerrando sesión de usuario: %s\n", sesion.Usuario.Name)
 
-	fmt.Printf("Cerrando sesión de usuario: %s\n", globals.UsuarioActual.Name)
+	fmt.Printf("Cerrando sesión de usuario: %s\n", sesion.Usuario.Name)
 
-	globals.UsuarioActual = &estructuras.Usuario{}
+	sesion.Usuario = nil
 
 	fmt.Fprintln(outputBuffer, "Sesión cerrada correctamente.")
 	fmt.Println("Sesión cerrada correctamente.")
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -16,25 +16,22 @@ type MKGRP struct {
 	Name string
 }
 
-func AnalizarMkgrp(tokens []string) (string, error) {
+func AnalizarMkgrp(sesion *globals.Sesion, tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 
 	cmd := &MKGRP{}
 
-	re := regexp.MustCompile(`-name=[^\s]+`)
-	matches := re.FindString(strings.Join(tokens, " "))
-
-	if matches == "" {
-		return "", fmt.Errorf("falta el parámetro -name")
+	params, err := utilidades.ParsearParametros(tokens, []string{"name"}, nil)
+	if err != nil {
+		return "", err
 	}
 
-	param := strings.SplitN(matches, "=", 2)
-	if len(param) != 2 {
-		return "", fmt.Errorf("formato incorrecto para -name")
+	if params.Valor("name") == "" {
+		return "", fmt.Errorf("falta el parámetro -name")
 	}
-	cmd.Name = param[1]
+	cmd.Name = params.Valor("name")
 
-	err := commandMkgrp(cmd, &outputBuffer)
+	err = commandMkgrp(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -42,32 +39,32 @@ func AnalizarMkgrp(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandMkgrp(mkgrp *MKGRP, outputBuffer *bytes.Buffer) error {
+func commandMkgrp(sesion *globals.Sesion, mkgrp *MKGRP, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "======================= MKGRP =======================")
-	if !globals.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if sesion.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
-	_, path, err := globals.ObtenerParticionMontada(globals.UsuarioActual.Id)
+	_, path, err := globals.ObtenerParticionMontada(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
 
-	file, err := os.OpenFile(path, os.O_RDWR, 0755)
+	file, err := globals.Discos.Abrir(path, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
-	mbr, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	mbr, sb, _, err := globals.GetMountedPartitionRep(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
-	partition, err := mbr.ObtenerParticionPorID(globals.UsuarioActual.Id)
+	partition, err := mbr.BuscarParticionPorID(file, sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo obtener la partición: %v", err)
 	}
@@ -97,12 +94,6 @@ func commandMkgrp(mkgrp *MKGRP, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error creando el grupo '%s': %v", mkgrp.Name, err)
 	}
 
-	err = usersInode.Encode(file, inodeOffset)
-	usersInode.ActualizarAtime()
-	if err != nil {
-		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
-	}
-
 	err = sb.Codificar(file, int64(partition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error guardando el Superblock: %v", err)
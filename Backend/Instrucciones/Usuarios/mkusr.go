@@ -6,9 +6,8 @@ import (
 	"fmt"
 	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
-	"strings"
 )
 
 type MKUSR struct {
@@ -24,32 +23,29 @@ func validateParamLength(param string, maxLength int, paramName string) error {
 	return nil
 }
 
-func AnalizarMkusr(tokens []string) (string, error) {
+func AnalizarMkusr(sesion *globals.Sesion, tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 
 	cmd := &MKUSR{}
 
-	reUser := regexp.MustCompile(`-user=[^\s]+`)
-	rePass := regexp.MustCompile(`-pass=[^\s]+`)
-	reGrp := regexp.MustCompile(`-grp=[^\s]+`)
-
-	matchesUser := reUser.FindString(strings.Join(tokens, " "))
-	matchesPass := rePass.FindString(strings.Join(tokens, " "))
-	matchesGrp := reGrp.FindString(strings.Join(tokens, " "))
+	params, err := utilidades.ParsearParametros(tokens, []string{"user", "pass", "grp"}, nil)
+	if err != nil {
+		return "", err
+	}
 
-	if matchesUser == "" {
+	if params.Valor("user") == "" {
 		return "", fmt.Errorf("falta el parámetro -user")
 	}
-	if matchesPass == "" {
+	if params.Valor("pass") == "" {
 		return "", fmt.Errorf("falta el parámetro -pass")
 	}
-	if matchesGrp == "" {
+	if params.Valor("grp") == "" {
 		return "", fmt.Errorf("falta el parámetro -grp")
 	}
 
-	cmd.User = strings.SplitN(matchesUser, "=", 2)[1]
-	cmd.Pass = strings.SplitN(matchesPass, "=", 2)[1]
-	cmd.Grp = strings.SplitN(matchesGrp, "=", 2)[1]
+	cmd.User = params.Valor("user")
+	cmd.Pass = params.Valor("pass")
+	cmd.Grp = params.Valor("grp")
 
 	if err := validateParamLength(cmd.User, 10, "Usuario"); err != nil {
 		return "", err
@@ -61,7 +57,7 @@ func AnalizarMkusr(tokens []string) (string, error) {
 		return "", err
 	}
 
-	err := commandMkusr(cmd, &outputBuffer)
+	err = commandMkusr(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -69,32 +65,32 @@ func AnalizarMkusr(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandMkusr(mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
+func commandMkusr(sesion *globals.Sesion, mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "======================= MKUSR =======================")
-	if !globals.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if sesion.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
-	_, path, err := globals.ObtenerParticionMontada(globals.UsuarioActual.Id)
+	_, path, err := globals.ObtenerParticionMontada(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
 
-	file, err := os.OpenFile(path, os.O_RDWR, 0755)
+	file, err := globals.Discos.Abrir(path, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
-	mbr, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	mbr, sb, _, err := globals.GetMountedPartitionRep(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
-	partition, err := mbr.ObtenerParticionPorID(globals.UsuarioActual.Id)
+	partition, err := mbr.BuscarParticionPorID(file, sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo obtener la partición: %v", err)
 	}
@@ -116,7 +112,12 @@ func commandMkusr(mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("el usuario '%s' ya existe", mkusr.User)
 	}
 
-	usuario := estructuras.NewUser(fmt.Sprintf("%d", sb.S_inodes_count+1), mkusr.Grp, mkusr.User, mkusr.Pass)
+	hash, err := estructuras.HashearContrasena(mkusr.Pass)
+	if err != nil {
+		return err
+	}
+
+	usuario := estructuras.NewUser(fmt.Sprintf("%d", sb.S_inodes_count+1), mkusr.Grp, mkusr.User, hash)
 	fmt.Println(usuario.ToString())
 
 	err = globals.InsertIntoUsersFile(file, sb, &usersInode, usuario.ToString())
@@ -124,11 +125,6 @@ func commandMkusr(mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error insertando el usuario '%s': %v", mkusr.User, err)
 	}
 
-	err = usersInode.Encode(file, inodeOffset)
-	if err != nil {
-		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
-	}
-
 	err = sb.Codificar(file, int64(partition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error guardando el Superblock: %v", err)
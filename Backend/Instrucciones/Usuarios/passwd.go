@@ -0,0 +1,141 @@
+package instrucciones
+
+import (
+	"bytes"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"os"
+	"strings"
+)
+
+type PASSWD struct {
+	User   string
+	Actual string
+	Pass   string
+}
+
+func AnalizarPasswd(sesion *globals.Sesion, tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	cmd := &PASSWD{}
+
+	params, err := utilidades.ParsearParametros(tokens, []string{"user", "pass", "actual"}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if params.Valor("pass") == "" {
+		return "", fmt.Errorf("falta el parámetro -pass")
+	}
+
+	cmd.Pass = params.Valor("pass")
+	cmd.User = params.Valor("user")
+	cmd.Actual = params.Valor("actual")
+
+	if err := validateParamLength(cmd.Pass, 10, "Contraseña"); err != nil {
+		return "", err
+	}
+
+	err = commandPasswd(sesion, cmd, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+func commandPasswd(sesion *globals.Sesion, passwd *PASSWD, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================= PASSWD =======================")
+	if !sesion.EstaLogueado() {
+		return fmt.Errorf("no hay ninguna sesión activa")
+	}
+
+	userName := sesion.Usuario.Name
+	if passwd.User != "" && passwd.User != userName {
+		if sesion.Usuario.Name != "root" {
+			return fmt.Errorf("solo el usuario root puede cambiar la contraseña de otro usuario")
+		}
+		userName = passwd.User
+	}
+
+	mbr, sb, path, err := globals.GetMountedPartitionRep(sesion.Usuario.Id)
+	if err != nil {
+		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
+	}
+
+	file, err := globals.Discos.Abrir(path, os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
+	}
+	defer globals.Discos.Cerrar(file)
+
+	partition, err := mbr.BuscarParticionPorID(file, sesion.Usuario.Id)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener la partición: %v", err)
+	}
+
+	var usersInode estructuras.Inodo
+	err = usersInode.Decode(file, sb.CalculateInodeOffset(1))
+	if err != nil {
+		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
+	}
+
+	linea, err := globals.FindInUsersFile(file, sb, &usersInode, userName, "U")
+	if err != nil {
+		return fmt.Errorf("el usuario '%s' no existe", userName)
+	}
+	usuario := crearUsuarioDesdeLinea(linea)
+	if usuario == nil || usuario.Id == "0" {
+		return fmt.Errorf("el usuario '%s' no existe o está eliminado", userName)
+	}
+
+	// Un usuario que cambia su propia contraseña debe confirmar la actual
+	if sesion.Usuario.Name != "root" && !estructuras.VerificarContrasena(usuario.Password, passwd.Actual) {
+		return fmt.Errorf("la contraseña actual es incorrecta")
+	}
+
+	hash, err := estructuras.HashearContrasena(passwd.Pass)
+	if err != nil {
+		return err
+	}
+
+	err = actualizarContrasena(file, sb, &usersInode, userName, hash)
+	if err != nil {
+		return err
+	}
+
+	err = sb.Codificar(file, int64(partition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error guardando el Superblock: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Contraseña del usuario '%s' actualizada exitosamente\n", userName)
+	fmt.Fprintln(outputBuffer, "==================== FIN PASSWD ====================")
+	return nil
+}
+
+func actualizarContrasena(file *os.File, sb *estructuras.Superbloque, usersInode *estructuras.Inodo, userName, hash string) error {
+	contenido, err := globals.ReadFileBlocks(file, sb, usersInode)
+	if err != nil {
+		return fmt.Errorf("error leyendo el contenido de users.txt: %w", err)
+	}
+
+	lineas := strings.Split(strings.TrimSpace(contenido), "\n")
+	actualizado := false
+	for i, linea := range lineas {
+		usuario := crearUsuarioDesdeLinea(strings.TrimSpace(linea))
+		if usuario != nil && usuario.Name == userName && usuario.Id != "0" {
+			usuario.Password = hash
+			lineas[i] = usuario.ToString()
+			actualizado = true
+		}
+	}
+
+	if !actualizado {
+		return fmt.Errorf("el usuario '%s' no existe o está eliminado", userName)
+	}
+
+	return globals.EscribirUsuarios(file, sb, usersInode, "passwd", limpiarYActualizarContenido(lineas))
+}
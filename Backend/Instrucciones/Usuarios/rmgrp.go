@@ -6,8 +6,8 @@ import (
 	"fmt"
 	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -15,25 +15,22 @@ type RMGRP struct {
 	Name string
 }
 
-func AnalizarRmgrp(tokens []string) (string, error) {
+func AnalizarRmgrp(sesion *globals.Sesion, tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 
 	cmd := &RMGRP{}
 
-	re := regexp.MustCompile(`-name=[^\s]+`)
-	matches := re.FindString(strings.Join(tokens, " "))
-
-	if matches == "" {
-		return "", fmt.Errorf("falta el parámetro -name")
+	params, err := utilidades.ParsearParametros(tokens, []string{"name"}, nil)
+	if err != nil {
+		return "", err
 	}
 
-	param := strings.SplitN(matches, "=", 2)
-	if len(param) != 2 {
-		return "", fmt.Errorf("formato incorrecto para -name")
+	if params.Valor("name") == "" {
+		return "", fmt.Errorf("falta el parámetro -name")
 	}
-	cmd.Name = param[1]
+	cmd.Name = params.Valor("name")
 
-	err := commandRmgrp(cmd, &outputBuffer)
+	err = commandRmgrp(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -41,32 +38,32 @@ func AnalizarRmgrp(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandRmgrp(rmgrp *RMGRP, outputBuffer *bytes.Buffer) error {
+func commandRmgrp(sesion *globals.Sesion, rmgrp *RMGRP, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "======================= RMGRP =======================")
-	if !globals.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if sesion.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
-	_, path, err := globals.ObtenerParticionMontada(globals.UsuarioActual.Id)
+	_, path, err := globals.ObtenerParticionMontada(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
 
-	file, err := os.OpenFile(path, os.O_RDWR, 0755)
+	file, err := globals.Discos.Abrir(path, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
-	mbr, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	mbr, sb, _, err := globals.GetMountedPartitionRep(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
-	partition, err := mbr.ObtenerParticionPorID(globals.UsuarioActual.Id)
+	partition, err := mbr.BuscarParticionPorID(file, sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo obtener la partición: %v", err)
 	}
@@ -88,11 +85,6 @@ func commandRmgrp(rmgrp *RMGRP, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error eliminando el grupo y usuarios asociados: %v", err)
 	}
 
-	err = usersInode.Encode(file, inodeOffset)
-	if err != nil {
-		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
-	}
-
 	err = sb.Codificar(file, int64(partition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error guardando el Superblock: %v", err)
@@ -162,25 +154,9 @@ func UpdateEntityStateOrRemoveUsers(file *os.File, sb *estructuras.Superbloque,
 	if modificado {
 		contenidoActualizado := strings.Join(lineas, "\n")
 
-		for _, blockIndex := range usersInode.I_block {
-			if blockIndex == -1 {
-				break
-			}
-
-			blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
-			var fileBlock estructuras.ArchivoBloque
-
-			fileBlock.ClearContent()
-
-			err = fileBlock.Encode(file, blockOffset)
-			if err != nil {
-				return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
-			}
-		}
-
-		err = globals.WriteUsersBlocks(file, sb, usersInode, contenidoActualizado)
+		err = globals.EscribirUsuarios(file, sb, usersInode, "rmgrp", contenidoActualizado)
 		if err != nil {
-			return fmt.Errorf("error guardando los cambios en users.txt: %v", err)
+			return err
 		}
 	} else {
 		return fmt.Errorf("%s '%s' no encontrado en users.txt", entityType, name)
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -15,25 +15,22 @@ type RMUSR struct {
 	User string
 }
 
-func AnalizarRmusr(tokens []string) (string, error) {
+func AnalizarRmusr(sesion *globals.Sesion, tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 
 	cmd := &RMUSR{}
 
-	re := regexp.MustCompile(`-usr=[^\s]+`)
-	matches := re.FindString(strings.Join(tokens, " "))
-
-	if matches == "" {
-		return "", fmt.Errorf("falta el parámetro -usr")
+	params, err := utilidades.ParsearParametros(tokens, []string{"usr"}, nil)
+	if err != nil {
+		return "", err
 	}
 
-	param := strings.SplitN(matches, "=", 2)
-	if len(param) != 2 {
-		return "", fmt.Errorf("formato incorrecto para -user")
+	if params.Valor("usr") == "" {
+		return "", fmt.Errorf("falta el parámetro -usr")
 	}
-	cmd.User = param[1]
+	cmd.User = params.Valor("usr")
 
-	err := commandRmusr(cmd, &outputBuffer)
+	err = commandRmusr(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -41,32 +38,32 @@ func AnalizarRmusr(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandRmusr(rmusr *RMUSR, outputBuffer *bytes.Buffer) error {
+func commandRmusr(sesion *globals.Sesion, rmusr *RMUSR, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "======================= RMUSR =======================")
-	if !globals.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if sesion.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
-	_, path, err := globals.ObtenerParticionMontada(globals.UsuarioActual.Id)
+	_, path, err := globals.ObtenerParticionMontada(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
 
-	file, err := os.OpenFile(path, os.O_RDWR, 0755)
+	file, err := globals.Discos.Abrir(path, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
 	}
-	defer file.Close()
+	defer globals.Discos.Cerrar(file)
 
-	mbr, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	mbr, sb, _, err := globals.GetMountedPartitionRep(sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
-	partition, err := mbr.ObtenerParticionPorID(globals.UsuarioActual.Id)
+	partition, err := mbr.BuscarParticionPorID(file, sesion.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo obtener la partición: %v", err)
 	}
@@ -88,11 +85,6 @@ func commandRmusr(rmusr *RMUSR, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error eliminando el usuario '%s': %v", rmusr.User, err)
 	}
 
-	err = usersInode.Encode(file, inodeOffset)
-	if err != nil {
-		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
-	}
-
 	err = sb.Codificar(file, int64(partition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error guardando el Superblock: %v", err)
@@ -144,7 +136,7 @@ func UpdateUserState(file *os.File, sb *estructuras.Superbloque, usersInode *est
 
 	contenidoActualizado := limpiarYActualizarContenido(lineas)
 
-	return escribirCambiosEnArchivo(file, sb, usersInode, contenidoActualizado)
+	return globals.EscribirUsuarios(file, sb, usersInode, "rmusr", contenidoActualizado)
 }
 
 func crearUsuarioDesdeLinea(linea string) *estructuras.Usuario {
@@ -164,28 +156,3 @@ func limpiarYActualizarContenido(lineas []string) string {
 	}
 	return strings.Join(contenidoActualizado, "\n") + "\n"
 }
-
-func escribirCambiosEnArchivo(file *os.File, sb *estructuras.Superbloque, usersInode *estructuras.Inodo, contenido string) error {
-	for _, blockIndex := range usersInode.I_block {
-		if blockIndex == -1 {
-			break
-		}
-
-		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
-		var fileBlock estructuras.ArchivoBloque
-
-		fileBlock.ClearContent()
-
-		err := fileBlock.Encode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
-		}
-	}
-
-	err := globals.WriteUsersBlocks(file, sb, usersInode, contenido)
-	if err != nil {
-		return fmt.Errorf("error guardando los cambios en users.txt: %v", err)
-	}
-
-	return nil
-}
@@ -16,29 +16,31 @@ type CAT struct {
 	files []string
 }
 
-func AnalizarCat(tokens []string) (string, error) {
+func AnalizarCat(sesion *global.Sesion, tokens []string) (string, error) {
 	cmd := &CAT{}
 	var outputBuffer bytes.Buffer
 
-	re := regexp.MustCompile(`-file\d+=("[^"]+"|[^\s]+)`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
-
-	if len(matches) == 0 {
-		return "", errors.New("no se especificaron archivos para leer")
+	params, err := utilidades.ParsearArgumentos(tokens)
+	if err != nil {
+		return "", err
 	}
 
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) == 2 {
-			filePath := kv[1]
-			if strings.HasPrefix(filePath, "\"") && strings.HasSuffix(filePath, "\"") {
-				filePath = strings.Trim(filePath, "\"")
-			}
-			cmd.files = append(cmd.files, filePath)
+	reArchivo := regexp.MustCompile(`^file\d+$`)
+	for clave := range params.Banderas {
+		return "", fmt.Errorf("parámetro desconocido: -%s", clave)
+	}
+	for _, clave := range params.Claves() {
+		if !reArchivo.MatchString(clave) {
+			return "", fmt.Errorf("parámetro desconocido: -%s", clave)
 		}
+		cmd.files = append(cmd.files, params.Valor(clave))
 	}
 
-	err := commandCat(cmd, &outputBuffer)
+	if len(cmd.files) == 0 {
+		return "", errors.New("no se especificaron archivos para leer")
+	}
+
+	err = commandCat(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -46,29 +48,29 @@ func AnalizarCat(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandCat(cat *CAT, outputBuffer *bytes.Buffer) error {
+func commandCat(sesion *global.Sesion, cat *CAT, outputBuffer *bytes.Buffer) error {
 	fmt.Fprint(outputBuffer, "======================= CAT =======================\n")
-	if !global.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
-	idPartition := global.UsuarioActual.Id
+	idPartition := sesion.Usuario.Id
 
 	_, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0666)
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDONLY)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
 
 	for _, filePath := range cat.files {
 		fmt.Fprintf(outputBuffer, "Leyendo archivo: %s\n", filePath)
 
-		content, err := readFileContent(filePath)
+		content, err := readFileContent(sesion, filePath)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error al leer el archivo %s: %v\n", filePath, err)
 			continue
@@ -82,18 +84,18 @@ func commandCat(cat *CAT, outputBuffer *bytes.Buffer) error {
 	return nil
 }
 
-func readFileContent(filePath string) (string, error) {
-	idPartition := global.UsuarioActual.Id
+func readFileContent(sesion *global.Sesion, filePath string) (string, error) {
+	idPartition := sesion.Usuario.Id
 	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return "", fmt.Errorf("error al obtener la partición montada: %v", err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDONLY, 0666)
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDONLY)
 	if err != nil {
 		return "", fmt.Errorf("error al abrir el archivo de partición: %v", err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
 
 	parentDirs, fileName := utilidades.ObtenerDirectoriosPadre(filePath)
 
@@ -102,6 +104,11 @@ func readFileContent(filePath string) (string, error) {
 		return "", fmt.Errorf("error al encontrar el archivo: %v", err)
 	}
 
+	err = verificarPermisoInodo(file, partitionSuperblock, sesion.Usuario, inodeIndex, filePath, global.PermisoLectura)
+	if err != nil {
+		return "", err
+	}
+
 	content, err := readFileFromInode(file, partitionSuperblock, inodeIndex)
 	if err != nil {
 		return "", fmt.Errorf("error al leer el contenido del archivo: %v", err)
@@ -128,14 +135,14 @@ func directoryExists(sb *estructuras.Superbloque, file *os.File, inodeIndex int3
 			break
 		}
 
-		block := &estructuras.FolderBlock{}
+		block := sb.NuevoBloqueCarpeta()
 		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
 		if err != nil {
 			return false, -1, fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
 		}
 
 		for _, content := range block.B_content {
-			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			contentName := sb.NombreEntrada(file, content)
 			if strings.EqualFold(contentName, dirName) && content.B_inodo != -1 {
 				fmt.Printf("Directorio o archivo '%s' encontrado en inodo %d\n", dirName, content.B_inodo)
 				return true, content.B_inodo, nil
@@ -185,22 +192,12 @@ func readFileFromInode(file *os.File, sb *estructuras.Superbloque, inodeIndex in
 		return "", fmt.Errorf("el inodo %d no corresponde a un archivo", inodeIndex)
 	}
 
-	var contentBuilder strings.Builder
-	for _, blockIndex := range inode.I_block {
-		if blockIndex == -1 {
-			break
-		}
-
-		fileBlock := &estructuras.ArchivoBloque{}
-		err := fileBlock.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
-		if err != nil {
-			return "", fmt.Errorf("error al deserializar el bloque %d: %v", blockIndex, err)
-		}
-
-		contentBuilder.WriteString(string(fileBlock.B_content[:]))
+	data, err := leerDatosArchivo(file, sb, inode)
+	if err != nil {
+		return "", err
 	}
 
-	return contentBuilder.String(), nil
+	return string(data), nil
 }
 
 func findFolderInode(file *os.File, sb *estructuras.Superbloque, parentsDir []string) (int32, error) {
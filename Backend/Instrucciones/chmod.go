@@ -0,0 +1,150 @@
+package instrucciones
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	global "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"os"
+	"path"
+	"regexp"
+)
+
+type CHMOD struct {
+	path string
+	ugo  string
+	r    bool
+}
+
+func AnalizarChmod(sesion *global.Sesion, tokens []string) (string, error) {
+	cmd := &CHMOD{}
+	var outputBuffer bytes.Buffer
+
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "ugo"}, []string{"r"})
+	if err != nil {
+		return "", err
+	}
+	cmd.path = params.Valor("path")
+	cmd.ugo = params.Valor("ugo")
+	cmd.r = params.Banderas["r"]
+
+	if cmd.path == "" || cmd.ugo == "" {
+		return "", errors.New("los parámetros -path y -ugo son obligatorios")
+	}
+	if !regexp.MustCompile(`^[0-7]{3}$`).MatchString(cmd.ugo) {
+		return "", fmt.Errorf("el parámetro -ugo debe tener tres dígitos entre 0 y 7: %s", cmd.ugo)
+	}
+
+	err = commandChmod(sesion, cmd, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+func commandChmod(sesion *global.Sesion, chmodCmd *CHMOD, outputBuffer *bytes.Buffer) error {
+	fmt.Fprint(outputBuffer, "======================= CHMOD =======================\n")
+
+	if !sesion.EstaLogueado() {
+		return fmt.Errorf("no hay un usuario logueado")
+	}
+
+	idPartition := sesion.Usuario.Id
+
+	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
+	}
+	defer global.Discos.Cerrar(file)
+
+	targetPath := path.Clean("/" + chmodCmd.path)
+	inodeIndex, err := partitionSuperblock.BuscarInodoPorRuta(file, targetPath)
+	if err != nil {
+		return fmt.Errorf("error al encontrar '%s': %v", targetPath, err)
+	}
+
+	var perm [3]byte
+	copy(perm[:], chmodCmd.ugo)
+
+	// Primero se recorren los inodos para armar la transacción y después, ya registrada en el
+	// journal, se cambian los mismos
+	planeados := make(map[string]bool)
+	t := &estructuras.TransaccionJournal{}
+	err = aplicarAInodo(file, partitionSuperblock, inodeIndex, targetPath, chmodCmd.r, func(inode *estructuras.Inodo, ruta string) (bool, error) {
+		if !esPropietario(sesion.Usuario, inode) {
+			if ruta == targetPath {
+				return false, fmt.Errorf("permiso denegado: solo root o el propietario pueden cambiar los permisos de '%s'", ruta)
+			}
+			fmt.Fprintf(outputBuffer, "Omitido '%s': no pertenece al usuario\n", ruta)
+			return false, nil
+		}
+		t.Agregar(estructuras.PreimagenPermisos, ruta, string(inode.I_perm[:]))
+		t.Agregar("chmod", ruta, chmodCmd.ugo)
+		planeados[ruta] = true
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	modified := 0
+	err = partitionSuperblock.AplicarConJournal(file, t, func() error {
+		return aplicarAInodo(file, partitionSuperblock, inodeIndex, targetPath, chmodCmd.r, func(inode *estructuras.Inodo, ruta string) (bool, error) {
+			if !planeados[ruta] {
+				return false, nil
+			}
+			inode.I_perm = perm
+			modified++
+			return true, nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outputBuffer, "Permisos de '%s' cambiados a %s (%d inodos)\n", targetPath, chmodCmd.ugo, modified)
+	fmt.Fprint(outputBuffer, "=====================================================\n")
+
+	return nil
+}
+
+func esPropietario(usuario *estructuras.Usuario, inode *estructuras.Inodo) bool {
+	return usuario.Name == "root" || inode.I_uid == usuario.Uid
+}
+
+// aplicarAInodo ejecuta fn sobre el inodo (y su contenido si recursivo) y guarda
+// el inodo cuando fn indica que fue modificado.
+func aplicarAInodo(file *os.File, sb *estructuras.Superbloque, inodeIndex int32, ruta string, recursivo bool, fn func(*estructuras.Inodo, string) (bool, error)) error {
+	inode := &estructuras.Inodo{}
+	offset := sb.CalculateInodeOffset(inodeIndex)
+	if err := inode.Decode(file, offset); err != nil {
+		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+	}
+
+	modified, err := fn(inode, ruta)
+	if err != nil {
+		return err
+	}
+	if modified {
+		inode.ActualizarCtime()
+		if err := inode.Encode(file, offset); err != nil {
+			return err
+		}
+	}
+
+	if !recursivo {
+		return nil
+	}
+
+	return recorrerCarpeta(file, sb, inodeIndex, ruta, func(childIndex int32, childPath string) error {
+		return aplicarAInodo(file, sb, childIndex, childPath, true, fn)
+	})
+}
@@ -0,0 +1,124 @@
+package instrucciones
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	global "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type CHOWN struct {
+	path    string
+	usuario string
+	r       bool
+}
+
+func AnalizarChown(sesion *global.Sesion, tokens []string) (string, error) {
+	cmd := &CHOWN{}
+	var outputBuffer bytes.Buffer
+
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "usuario"}, []string{"r"})
+	if err != nil {
+		return "", err
+	}
+	cmd.path = params.Valor("path")
+	cmd.usuario = params.Valor("usuario")
+	cmd.r = params.Banderas["r"]
+
+	if cmd.path == "" || cmd.usuario == "" {
+		return "", errors.New("los parámetros -path y -usuario son obligatorios")
+	}
+
+	err = commandChown(sesion, cmd, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+func commandChown(sesion *global.Sesion, chownCmd *CHOWN, outputBuffer *bytes.Buffer) error {
+	fmt.Fprint(outputBuffer, "======================= CHOWN =======================\n")
+
+	if !sesion.EstaLogueado() {
+		return fmt.Errorf("no hay un usuario logueado")
+	}
+
+	idPartition := sesion.Usuario.Id
+
+	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
+	}
+	defer global.Discos.Cerrar(file)
+
+	usersInode := &estructuras.Inodo{}
+	if err := usersInode.Decode(file, partitionSuperblock.CalculateInodeOffset(1)); err != nil {
+		return fmt.Errorf("error leyendo inodo de users.txt: %v", err)
+	}
+	linea, err := global.FindInUsersFile(file, partitionSuperblock, usersInode, chownCmd.usuario, "U")
+	if err != nil {
+		return fmt.Errorf("el usuario '%s' no existe", chownCmd.usuario)
+	}
+	uid, err := strconv.Atoi(strings.SplitN(linea, ",", 2)[0])
+	if err != nil || uid <= 0 {
+		return fmt.Errorf("el usuario '%s' no está activo", chownCmd.usuario)
+	}
+
+	targetPath := path.Clean("/" + chownCmd.path)
+	inodeIndex, err := partitionSuperblock.BuscarInodoPorRuta(file, targetPath)
+	if err != nil {
+		return fmt.Errorf("error al encontrar '%s': %v", targetPath, err)
+	}
+
+	// Igual que chmod: se arma la transacción, se registra y recién entonces se cambian los inodos
+	planeados := make(map[string]bool)
+	t := &estructuras.TransaccionJournal{}
+	err = aplicarAInodo(file, partitionSuperblock, inodeIndex, targetPath, chownCmd.r, func(inode *estructuras.Inodo, ruta string) (bool, error) {
+		if !esPropietario(sesion.Usuario, inode) {
+			if ruta == targetPath {
+				return false, fmt.Errorf("permiso denegado: solo root o el propietario pueden cambiar el propietario de '%s'", ruta)
+			}
+			fmt.Fprintf(outputBuffer, "Omitido '%s': no pertenece al usuario\n", ruta)
+			return false, nil
+		}
+		t.Agregar(estructuras.PreimagenPropietario, ruta, fmt.Sprintf("%d,%d", inode.I_uid, inode.I_gid))
+		t.Agregar("chown", ruta, fmt.Sprintf("%d,%d", uid, inode.I_gid))
+		planeados[ruta] = true
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	modified := 0
+	err = partitionSuperblock.AplicarConJournal(file, t, func() error {
+		return aplicarAInodo(file, partitionSuperblock, inodeIndex, targetPath, chownCmd.r, func(inode *estructuras.Inodo, ruta string) (bool, error) {
+			if !planeados[ruta] {
+				return false, nil
+			}
+			inode.I_uid = int32(uid)
+			modified++
+			return true, nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outputBuffer, "Propietario de '%s' cambiado a '%s' (%d inodos)\n", targetPath, chownCmd.usuario, modified)
+	fmt.Fprint(outputBuffer, "=====================================================\n")
+
+	return nil
+}
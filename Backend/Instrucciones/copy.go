@@ -0,0 +1,333 @@
+package instrucciones
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	global "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+type COPY struct {
+	path    string
+	destino string
+}
+
+var errSinPermisoLectura = errors.New("sin permiso de lectura")
+
+func AnalizarCopy(sesion *global.Sesion, tokens []string) (string, error) {
+	cmd := &COPY{}
+	var outputBuffer bytes.Buffer
+
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "destino"}, nil)
+	if err != nil {
+		return "", err
+	}
+	cmd.path = params.Valor("path")
+	cmd.destino = params.Valor("destino")
+
+	if cmd.path == "" || cmd.destino == "" {
+		return "", errors.New("los parámetros -path y -destino son obligatorios")
+	}
+
+	err = commandCopy(sesion, cmd, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+func commandCopy(sesion *global.Sesion, copyCmd *COPY, outputBuffer *bytes.Buffer) error {
+	fmt.Fprint(outputBuffer, "======================= COPY =======================\n")
+
+	if !sesion.EstaLogueado() {
+		return fmt.Errorf("no hay un usuario logueado")
+	}
+
+	idPartition := sesion.Usuario.Id
+
+	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
+	}
+	defer global.Discos.Cerrar(file)
+
+	origen := path.Clean("/" + copyCmd.path)
+	destino := path.Clean("/" + copyCmd.destino)
+
+	parentDirs, name := utilidades.ObtenerDirectoriosPadre(origen)
+	if name == "" {
+		return fmt.Errorf("no se puede copiar la carpeta raíz")
+	}
+	if destino == origen || strings.HasPrefix(destino+"/", origen+"/") {
+		return fmt.Errorf("no se puede copiar '%s' dentro de sí mismo", origen)
+	}
+
+	sourceIndex, err := findFileInode(file, partitionSuperblock, parentDirs, name)
+	if err != nil {
+		return fmt.Errorf("error al encontrar el origen: %v", err)
+	}
+
+	destIndex, destInode, err := buscarCarpetaDestino(file, partitionSuperblock, destino)
+	if err != nil {
+		return err
+	}
+	if !global.TienePermiso(sesion.Usuario, destInode, global.PermisoEscritura) {
+		return fmt.Errorf("no tiene permiso de escritura sobre '%s'", destino)
+	}
+
+	found, _, err := directoryExists(partitionSuperblock, file, destIndex, name)
+	if err != nil {
+		return err
+	}
+	if found {
+		return fmt.Errorf("ya existe un archivo o carpeta con el nombre '%s' en '%s'", name, destino)
+	}
+
+	// Se registra la copia completa para poder reconstruirla aunque el origen cambie después
+	copiaPath := path.Join(destino, name)
+	t := &estructuras.TransaccionJournal{}
+	if partitionSuperblock.TipoSistema() == 3 {
+		t.Agregar("copy", origen, destino)
+		err := agregarCopia(t, file, partitionSuperblock, sesion.Usuario, sourceIndex, copiaPath)
+		if errors.Is(err, errSinPermisoLectura) {
+			return fmt.Errorf("no tiene permiso de lectura sobre '%s'", origen)
+		}
+		if err != nil {
+			return fmt.Errorf("error al preparar el journal: %v", err)
+		}
+	}
+
+	var newIndex int32
+	err = partitionSuperblock.AplicarConJournal(file, t, func() error {
+		newIndex, err = copiarInodo(file, partitionSuperblock, sesion.Usuario, sourceIndex, destIndex, origen, outputBuffer)
+		if errors.Is(err, errSinPermisoLectura) {
+			return fmt.Errorf("no tiene permiso de lectura sobre '%s'", origen)
+		}
+		if err != nil {
+			return fmt.Errorf("error al copiar '%s': %v", origen, err)
+		}
+
+		if err := partitionSuperblock.AgregarEntradaCarpeta(file, destIndex, name, newIndex); err != nil {
+			return fmt.Errorf("error al registrar la copia en '%s': %v", destino, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if partitionSuperblock.TipoSistema() == 3 {
+		copiada := &estructuras.TransaccionJournal{}
+		copiada.Agregar("copy", origen, destino)
+		if err := agregarCreacion(copiada, file, partitionSuperblock, newIndex, copiaPath); err != nil {
+			return fmt.Errorf("error al revisar la copia para el journal: %v", err)
+		}
+		if !copiada.Igual(t) {
+			if err := partitionSuperblock.ReemplazarJournal(file, t, copiada); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = partitionSuperblock.Codificar(file, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error al actualizar el superbloque: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "'%s' copiado exitosamente en '%s'\n", origen, destino)
+	fmt.Fprint(outputBuffer, "=====================================================\n")
+
+	return nil
+}
+
+func buscarCarpetaDestino(file *os.File, sb *estructuras.Superbloque, destino string) (int32, *estructuras.Inodo, error) {
+	destIndex := int32(0)
+	parentDirs, name := utilidades.ObtenerDirectoriosPadre(destino)
+	if name != "" {
+		index, err := findFileInode(file, sb, parentDirs, name)
+		if err != nil {
+			return -1, nil, fmt.Errorf("error al encontrar el destino: %v", err)
+		}
+		destIndex = index
+	}
+
+	destInode := &estructuras.Inodo{}
+	if err := destInode.Decode(file, sb.CalculateInodeOffset(destIndex)); err != nil {
+		return -1, nil, fmt.Errorf("error al deserializar el inodo destino: %v", err)
+	}
+	if destInode.I_type[0] != '0' {
+		return -1, nil, fmt.Errorf("el destino '%s' no es una carpeta", destino)
+	}
+
+	return destIndex, destInode, nil
+}
+
+// copiarInodo duplica el inodo indicado (y su contenido, si es carpeta) y
+// devuelve el índice del nuevo inodo. Las entradas sin permiso de lectura se omiten.
+func copiarInodo(file *os.File, sb *estructuras.Superbloque, usuario *estructuras.Usuario, sourceIndex int32, parentIndex int32, currentPath string, outputBuffer *bytes.Buffer) (int32, error) {
+	source := &estructuras.Inodo{}
+	if err := source.Decode(file, sb.CalculateInodeOffset(sourceIndex)); err != nil {
+		return -1, fmt.Errorf("error al deserializar el inodo %d: %v", sourceIndex, err)
+	}
+	if !global.TienePermiso(usuario, source, global.PermisoLectura) {
+		return -1, errSinPermisoLectura
+	}
+
+	newIndex, err := sb.AssignNewInode(file)
+	if err != nil {
+		return -1, err
+	}
+
+	now := float32(time.Now().Unix())
+	copia := &estructuras.Inodo{
+		I_uid:   usuario.Uid,
+		I_gid:   usuario.Gid,
+		I_atime: now,
+		I_ctime: now,
+		I_mtime: now,
+		I_type:  source.I_type,
+		I_perm:  source.I_perm,
+	}
+	if copia.I_uid <= 0 {
+		copia.I_uid = source.I_uid
+		copia.I_gid = source.I_gid
+	}
+	for i := range copia.I_block {
+		copia.I_block[i] = -1
+	}
+
+	if source.I_type[0] == '1' {
+		data, err := leerDatosArchivo(file, sb, source)
+		if err != nil {
+			return -1, err
+		}
+		if err := copia.WriteData(file, sb, data); err != nil {
+			return -1, err
+		}
+		if err := copia.Encode(file, sb.CalculateInodeOffset(newIndex)); err != nil {
+			return -1, err
+		}
+		return newIndex, nil
+	}
+
+	blockIndex, err := sb.AssignNewBlock(file, copia, 0)
+	if err != nil {
+		return -1, err
+	}
+	folderBlock := sb.NewFolderBlock(newIndex, parentIndex, nil)
+	if err := folderBlock.Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size))); err != nil {
+		return -1, err
+	}
+	if err := copia.Encode(file, sb.CalculateInodeOffset(newIndex)); err != nil {
+		return -1, err
+	}
+
+	// GetDataBlockIndexes recorre los bloques de apuntadores y devuelve solo los de carpeta
+	blocks, err := source.GetDataBlockIndexes(file, sb)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, sourceBlock := range blocks {
+		block := sb.NuevoBloqueCarpeta()
+		if err := block.Decode(file, int64(sb.S_block_start+(sourceBlock*sb.S_block_size))); err != nil {
+			return -1, fmt.Errorf("error al deserializar el bloque %d: %v", sourceBlock, err)
+		}
+
+		for _, content := range block.B_content {
+			if content.B_inodo == -1 {
+				continue
+			}
+			entryName := sb.NombreEntrada(file, content)
+			if entryName == "." || entryName == ".." {
+				continue
+			}
+
+			entryPath := path.Join(currentPath, entryName)
+			childIndex, err := copiarInodo(file, sb, usuario, content.B_inodo, newIndex, entryPath, outputBuffer)
+			if errors.Is(err, errSinPermisoLectura) {
+				fmt.Fprintf(outputBuffer, "Omitido '%s': sin permiso de lectura\n", entryPath)
+				continue
+			}
+			if err != nil {
+				return -1, err
+			}
+
+			if err := sb.AgregarEntradaCarpeta(file, newIndex, entryName, childIndex); err != nil {
+				return -1, err
+			}
+		}
+	}
+
+	return newIndex, nil
+}
+
+// agregarCopia agrega a la transacción las operaciones que crean en ruta la copia que hará
+// copiarInodo: el mismo contenido y permisos, con el dueño que le pone copiarInodo y sin las
+// entradas que no se pueden leer
+func agregarCopia(t *estructuras.TransaccionJournal, file *os.File, sb *estructuras.Superbloque, usuario *estructuras.Usuario, sourceIndex int32, ruta string) error {
+	source := &estructuras.Inodo{}
+	if err := source.Decode(file, sb.CalculateInodeOffset(sourceIndex)); err != nil {
+		return fmt.Errorf("error al deserializar el inodo %d: %v", sourceIndex, err)
+	}
+	if !global.TienePermiso(usuario, source, global.PermisoLectura) {
+		return errSinPermisoLectura
+	}
+
+	if source.I_type[0] == '1' {
+		data, err := leerDatosArchivo(file, sb, source)
+		if err != nil {
+			return err
+		}
+		t.Agregar("mkfile", ruta, string(data))
+	} else {
+		t.Agregar("mkdir", ruta, "")
+	}
+
+	copia := &estructuras.Inodo{I_uid: usuario.Uid, I_gid: usuario.Gid, I_perm: source.I_perm}
+	if copia.I_uid <= 0 {
+		copia.I_uid = source.I_uid
+		copia.I_gid = source.I_gid
+	}
+	t.AgregarPropietario(ruta, copia)
+
+	return recorrerCarpeta(file, sb, sourceIndex, ruta, func(childIndex int32, childPath string) error {
+		if err := agregarCopia(t, file, sb, usuario, childIndex, childPath); !errors.Is(err, errSinPermisoLectura) {
+			return err
+		}
+		return nil
+	})
+}
+
+func leerDatosArchivo(file *os.File, sb *estructuras.Superbloque, inode *estructuras.Inodo) ([]byte, error) {
+	blocks, err := inode.GetDataBlockIndexes(file, sb)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for _, blockIndex := range blocks {
+		fileBlock := sb.NuevoBloqueArchivo()
+		if err := fileBlock.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size))); err != nil {
+			return nil, fmt.Errorf("error al deserializar el bloque %d: %v", blockIndex, err)
+		}
+		data = append(data, fileBlock.B_content[:]...)
+	}
+
+	if inode.I_size > 0 && int(inode.I_size) <= len(data) {
+		return data[:inode.I_size], nil
+	}
+	return bytes.TrimRight(data, "\x00"), nil
+}
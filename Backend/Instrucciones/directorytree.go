@@ -20,33 +20,46 @@ type DirectoryTreeService struct {
 	partitionSuperblock *estructuras.Superbloque
 	partitionPath       string
 	file                *os.File
+	liberar             func()
 }
 
-func NewDirectoryTreeService() (*DirectoryTreeService, error) {
-	if !globals.EstaLogueado() {
+// NewDirectoryTreeService abre la partición en la que inició sesión el usuario
+func NewDirectoryTreeService(usuario *estructuras.Usuario) (*DirectoryTreeService, error) {
+	if usuario == nil || !usuario.Status {
 		return nil, fmt.Errorf("operación denegada: no se ha iniciado sesión")
 	}
-	if err := globals.ValidarAcceso(globals.UsuarioActual.Id); err != nil {
+	if err := globals.ValidarAcceso(usuario.Id); err != nil {
 		return nil, fmt.Errorf("permisos insuficientes para acceder a la partición: %w", err)
 	}
-	idPartition := globals.UsuarioActual.Id
-	partitionSuperblock, _, partitionPath, err := globals.GetMountedPartitionSuperblock(idPartition)
+	idPartition := usuario.Id
+	partitionPath, montada := globals.RutaMontaje(idPartition)
+	if !montada {
+		return nil, fmt.Errorf("imposible obtener la partición montada (ID: %s): la partición no está montada", idPartition)
+	}
+
+	// El bloqueo de lectura se mantiene hasta Close para que el árbol no cambie mientras se recorre
+	liberar := globals.Discos.BloquearParticion(partitionPath, idPartition, false)
+	partitionSuperblock, _, _, err := globals.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
+		liberar()
 		return nil, fmt.Errorf("imposible obtener la partición montada (ID: %s): %w", idPartition, err)
 	}
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0666)
+	file, err := globals.Discos.Abrir(partitionPath, os.O_RDONLY)
 	if err != nil {
+		liberar()
 		return nil, fmt.Errorf("fallo al abrir el archivo de la partición en '%s': %w", partitionPath, err)
 	}
 	return &DirectoryTreeService{
 		partitionSuperblock: partitionSuperblock,
 		partitionPath:       partitionPath,
 		file:                file,
+		liberar:             liberar,
 	}, nil
 }
 
 func (dts *DirectoryTreeService) Close() {
-	dts.file.Close()
+	globals.Discos.Cerrar(dts.file)
+	dts.liberar()
 }
 
 func (dts *DirectoryTreeService) GetDirectoryTree(path string) (*DirectoryTree, error) {
@@ -100,7 +113,7 @@ func (dts *DirectoryTreeService) buildDirectoryTree(inodeIndex int32, currentPat
 			break
 		}
 
-		block := &estructuras.FolderBlock{}
+		block := dts.partitionSuperblock.NuevoBloqueCarpeta()
 		blockOffset := int64(dts.partitionSuperblock.S_block_start) + int64(blockIndex*dts.partitionSuperblock.S_block_size)
 		err := block.Decode(dts.file, blockOffset)
 		if err != nil {
@@ -111,7 +124,7 @@ func (dts *DirectoryTreeService) buildDirectoryTree(inodeIndex int32, currentPat
 				continue
 			}
 
-			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			contentName := dts.partitionSuperblock.NombreEntrada(dts.file, content)
 			if contentName == "." || contentName == ".." {
 				continue
 			}
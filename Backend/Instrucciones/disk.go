@@ -4,15 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+
+	estructuras "godisk/Estructuras"
 )
 
 type DiskCommand struct {
 	DiskManager *DiskManager
 }
 
-func NewDiskCommand() *DiskCommand {
+func NewDiskCommand(usuario *estructuras.Usuario) *DiskCommand {
 	return &DiskCommand{
-		DiskManager: NewDiskManager(),
+		DiskManager: NewDiskManager(usuario),
 	}
 }
 
@@ -13,24 +13,29 @@ import (
 type DiskManager struct {
 	disks         map[string]*os.File
 	PartitionMBRs map[string]*estructuras.Mbr
+	usuario       *estructuras.Usuario
 }
 
-func NewDiskManager() *DiskManager {
+func NewDiskManager(usuario *estructuras.Usuario) *DiskManager {
 	return &DiskManager{
 		disks:         make(map[string]*os.File),
 		PartitionMBRs: make(map[string]*estructuras.Mbr),
+		usuario:       usuario,
 	}
 }
 
 func (dm *DiskManager) LoadDisk(diskPath string) error {
-	if !globals.EstaLogueado() {
+	if dm.usuario == nil || !dm.usuario.Status {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
-	if err := globals.ValidarAcceso(globals.UsuarioActual.Id); err != nil {
+	if err := globals.ValidarAcceso(dm.usuario.Id); err != nil {
 		return fmt.Errorf("acceso denegado: %w", err)
 	}
 
-	file, err := os.OpenFile(diskPath, os.O_RDWR, 0666)
+	liberar := globals.Discos.BloquearDisco(diskPath, false)
+	defer liberar()
+
+	file, err := globals.Discos.Abrir(diskPath, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error al abrir el disco: %w", err)
 	}
@@ -38,7 +43,7 @@ func (dm *DiskManager) LoadDisk(diskPath string) error {
 	mbr := &estructuras.Mbr{}
 	err = mbr.Decodificar(file)
 	if err != nil {
-		file.Close()
+		globals.Discos.Cerrar(file)
 		return fmt.Errorf("error al leer el MBR del disco: %w", err)
 	}
 
@@ -50,7 +55,7 @@ func (dm *DiskManager) LoadDisk(diskPath string) error {
 
 func (dm *DiskManager) CloseDisk(diskPath string) error {
 	if file, exists := dm.disks[diskPath]; exists {
-		file.Close()
+		globals.Discos.Cerrar(file)
 		delete(dm.disks, diskPath)
 		delete(dm.PartitionMBRs, diskPath)
 		fmt.Printf("Disco '%s' cerrado exitosamente.\n", diskPath)
@@ -95,7 +100,7 @@ func (dm *DiskManager) GetPartitionTree(diskPath string, partitionName string) (
 		return nil, err
 	}
 
-	treeService, err := NewDirectoryTreeService()
+	treeService, err := NewDirectoryTreeService(dm.usuario)
 	if err != nil {
 		return nil, fmt.Errorf("error inicializando el servicio de árbol de directorios: %v", err)
 	}
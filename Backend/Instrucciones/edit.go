@@ -8,8 +8,7 @@ import (
 	global "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
-	"strings"
+	"path"
 )
 
 type EDIT struct {
@@ -17,35 +16,22 @@ type EDIT struct {
 	contenido string
 }
 
-func AnalizarEdit(tokens []string) (string, error) {
+func AnalizarEdit(sesion *global.Sesion, tokens []string) (string, error) {
 	cmd := &EDIT{}
 	var outputBuffer bytes.Buffer
 
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-contenido="[^"]+"|-contenido=[^\s]+`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
-
-	if len(matches) != len(tokens) || len(matches) < 2 {
-		return "", errors.New("faltan parámetros requeridos: -path o -contenido")
-	}
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-		value := strings.Trim(kv[1], "\"")
-
-		switch key {
-		case "-path":
-			cmd.path = value
-		case "-contenido":
-			cmd.contenido = value
-		}
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "contenido"}, nil)
+	if err != nil {
+		return "", err
 	}
+	cmd.path = params.Valor("path")
+	cmd.contenido = params.Valor("contenido")
 
 	if cmd.path == "" || cmd.contenido == "" {
 		return "", errors.New("los parámetros -path y -contenido son obligatorios")
 	}
 
-	err := commandEdit(cmd, &outputBuffer)
+	err = commandEdit(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -53,25 +39,25 @@ func AnalizarEdit(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandEdit(editCmd *EDIT, outputBuffer *bytes.Buffer) error {
+func commandEdit(sesion *global.Sesion, editCmd *EDIT, outputBuffer *bytes.Buffer) error {
 	fmt.Fprint(outputBuffer, "======================= EDIT =======================\n")
 
-	if !global.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
-	idPartition := global.UsuarioActual.Id
+	idPartition := sesion.Usuario.Id
 
-	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
+	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0666)
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
 
 	parentDirs, fileName := utilidades.ObtenerDirectoriosPadre(editCmd.path)
 
@@ -80,111 +66,57 @@ func commandEdit(editCmd *EDIT, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error al encontrar el archivo: %v", err)
 	}
 
-	newContent, err := os.ReadFile(editCmd.contenido)
+	err = verificarPermisoInodo(file, partitionSuperblock, sesion.Usuario, inodeIndex, editCmd.path, global.PermisoEscritura)
 	if err != nil {
-		return fmt.Errorf("error al leer el archivo de contenido '%s': %v", editCmd.contenido, err)
-	}
-
-	err = editFileContent(file, partitionSuperblock, inodeIndex, newContent)
-	if err != nil {
-		return fmt.Errorf("error al editar el contenido del archivo: %v", err)
+		return err
 	}
 
-	fmt.Fprintf(outputBuffer, "Contenido del archivo '%s' editado exitosamente\n", fileName)
-	fmt.Fprint(outputBuffer, "=================================================\n")
-
-	return nil
-}
-
-func editFileContent(file *os.File, sb *estructuras.Superbloque, inodeIndex int32, newContent []byte) error {
-	inode := &estructuras.Inodo{}
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	newContent, err := os.ReadFile(editCmd.contenido)
 	if err != nil {
-		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
-	}
-
-	if inode.I_type[0] != '1' {
-		return fmt.Errorf("el inodo %d no corresponde a un archivo", inodeIndex)
+		return fmt.Errorf("error al leer el archivo de contenido '%s': %v", editCmd.contenido, err)
 	}
 
-	for _, blockIndex := range inode.I_block {
-		if blockIndex != -1 {
-			fileBlock := &estructuras.ArchivoBloque{}
-			fileBlock.ClearContent()
-			err := fileBlock.Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
-			if err != nil {
-				return fmt.Errorf("error al limpiar el bloque %d: %v", blockIndex, err)
-			}
+	// users.txt se guarda en el journal sin contraseñas en texto plano
+	ruta := path.Clean("/" + editCmd.path)
+	contenidoJournal := func(contenido []byte) string {
+		if ruta == "/users.txt" {
+			return estructuras.OcultarContrasenas(string(contenido))
 		}
+		return string(contenido)
 	}
 
-	blocks, err := estructuras.SplitContent(string(newContent))
-	if err != nil {
-		return fmt.Errorf("error al dividir el contenido en bloques: %v", err)
+	// El contenido anterior queda en el journal para poder deshacer la edición
+	t := &estructuras.TransaccionJournal{}
+	if partitionSuperblock.TipoSistema() == 3 {
+		inode := &estructuras.Inodo{}
+		if err := inode.Decode(file, partitionSuperblock.CalculateInodeOffset(inodeIndex)); err != nil {
+			return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+		}
+		anterior, err := leerDatosArchivo(file, partitionSuperblock, inode)
+		if err != nil {
+			return fmt.Errorf("error al leer el contenido actual del archivo: %v", err)
+		}
+		t.Agregar(estructuras.PreimagenContenido, ruta, contenidoJournal(anterior))
 	}
 
-	blockCount := len(blocks)
-	for i := 0; i < blockCount; i++ {
-		if i < len(inode.I_block) {
-			blockIndex := inode.I_block[i]
-			if blockIndex == -1 {
-				blockIndex, err = sb.AssignNewBlock(file, inode, i)
-				if err != nil {
-					return fmt.Errorf("error asignando un nuevo bloque: %v", err)
-				}
-			}
-
-			err := blocks[i].Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
-			if err != nil {
-				return fmt.Errorf("error al escribir el bloque %d: %v", blockIndex, err)
-			}
-		} else {
-			pointerBlockIndex := inode.I_block[len(inode.I_block)-1]
-			if pointerBlockIndex == -1 {
-				pointerBlockIndex, err = sb.AssignNewBlock(file, inode, len(inode.I_block)-1)
-				if err != nil {
-					return fmt.Errorf("error asignando un nuevo bloque de apuntadores: %v", err)
-				}
-			}
-
-			pointerBlock := &estructuras.PointerBlock{}
-			err := pointerBlock.Decode(file, int64(sb.S_block_start+(pointerBlockIndex*sb.S_block_size)))
-			if err != nil {
-				return fmt.Errorf("error al decodificar el bloque de apuntadores: %v", err)
-			}
-
-			freeIndex, err := pointerBlock.FindFreePointer()
-			if err != nil {
-				return fmt.Errorf("no hay apuntadores libres en el bloque de apuntadores: %v", err)
-			}
-
-			newBlockIndex, err := sb.AssignNewBlock(file, inode, freeIndex)
-			if err != nil {
-				return fmt.Errorf("error asignando un nuevo bloque: %v", err)
-			}
-
-			err = pointerBlock.SetPointer(freeIndex, int64(newBlockIndex))
-			if err != nil {
-				return fmt.Errorf("error actualizando el bloque de apuntadores: %v", err)
-			}
-
-			err = pointerBlock.Encode(file, int64(sb.S_block_start+(pointerBlockIndex*sb.S_block_size)))
-			if err != nil {
-				return fmt.Errorf("error al guardar el bloque de apuntadores: %v", err)
-			}
-
-			err = blocks[i].Encode(file, int64(sb.S_block_start+(newBlockIndex*sb.S_block_size)))
-			if err != nil {
-				return fmt.Errorf("error al escribir el nuevo bloque %d: %v", newBlockIndex, err)
-			}
+	t.Agregar("edit", ruta, contenidoJournal(newContent))
+	err = partitionSuperblock.AplicarConJournal(file, t, func() error {
+		if err := partitionSuperblock.ReemplazarContenido(file, inodeIndex, newContent); err != nil {
+			return fmt.Errorf("error al editar el contenido del archivo: %v", err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	inode.I_size = int32(len(newContent))
-	err = inode.Encode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err = partitionSuperblock.Codificar(file, int64(mountedPartition.Part_start))
 	if err != nil {
-		return fmt.Errorf("error al actualizar el inodo %d: %v", inodeIndex, err)
+		return fmt.Errorf("error al actualizar el superbloque: %v", err)
 	}
 
+	fmt.Fprintf(outputBuffer, "Contenido del archivo '%s' editado exitosamente\n", fileName)
+	fmt.Fprint(outputBuffer, "=================================================\n")
+
 	return nil
 }
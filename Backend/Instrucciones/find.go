@@ -8,6 +8,7 @@ import (
 	global "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
+	"path"
 	"regexp"
 	"strings"
 )
@@ -17,60 +18,61 @@ type FIND struct {
 	name string
 }
 
-func AnalizarFind(tokens []string) (string, error) {
-	cmd := &FIND{}
-	var outputBuffer bytes.Buffer
+type FindMatch struct {
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Inode int32  `json:"inode"`
+}
 
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-name="[^"]+"|-name=[^\s]+`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
+func AnalizarFind(sesion *global.Sesion, tokens []string) (string, error) {
+	salida, _, err := AnalizarFindConDatos(sesion, tokens)
+	return salida, err
+}
 
-	if len(matches) != len(tokens) || len(matches) < 2 {
-		return "", errors.New("faltan parámetros requeridos: -path o -name")
-	}
+// AnalizarFindConDatos devuelve además las coincidencias encontradas para las respuestas JSON
+func AnalizarFindConDatos(sesion *global.Sesion, tokens []string) (string, interface{}, error) {
+	cmd := &FIND{}
+	var outputBuffer bytes.Buffer
 
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-		value := strings.Trim(kv[1], "\"")
-		switch key {
-		case "-path":
-			cmd.path = value
-		case "-name":
-			cmd.name = value
-		}
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "name"}, nil)
+	if err != nil {
+		return "", nil, err
 	}
+	cmd.path = params.Valor("path")
+	cmd.name = params.Valor("name")
 
 	if cmd.path == "" || cmd.name == "" {
-		return "", errors.New("los parámetros -path y -name son obligatorios")
+		return "", nil, errors.New("los parámetros -path y -name son obligatorios")
 	}
 
-	err := commandFind(cmd, &outputBuffer)
+	coincidencias, err := commandFind(sesion, cmd, &outputBuffer)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return outputBuffer.String(), nil
+	return outputBuffer.String(), coincidencias, nil
 }
 
-func commandFind(findCmd *FIND, outputBuffer *bytes.Buffer) error {
+func commandFind(sesion *global.Sesion, findCmd *FIND, outputBuffer *bytes.Buffer) ([]FindMatch, error) {
 	fmt.Fprint(outputBuffer, "======================= FIND =======================\n")
 
-	if !global.EstaLogueado() {
-		return fmt.Errorf("no hay un usuario logueado")
+	if !sesion.EstaLogueado() {
+		return nil, fmt.Errorf("no hay un usuario logueado")
 	}
 
-	idPartition := global.UsuarioActual.Id
+	idPartition := sesion.Usuario.Id
 
 	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
-		return fmt.Errorf("error al obtener la partición montada: %w", err)
+		return nil, fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0666)
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDONLY)
 	if err != nil {
-		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
+		return nil, fmt.Errorf("error al abrir el archivo de partición: %w", err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
 
 	var rootInodeIndex int32
 	if findCmd.path == "/" {
@@ -79,32 +81,37 @@ func commandFind(findCmd *FIND, outputBuffer *bytes.Buffer) error {
 		parentDirs, dirName := utilidades.ObtenerDirectoriosPadre(findCmd.path)
 		rootInodeIndex, err = findFileInode(file, partitionSuperblock, parentDirs, dirName)
 		if err != nil {
-			return fmt.Errorf("error al encontrar el directorio inicial: %v", err)
+			return nil, fmt.Errorf("error al encontrar el directorio inicial: %v", err)
 		}
 	}
 
 	pattern, err := wildcardToRegex(findCmd.name)
 	if err != nil {
-		return fmt.Errorf("error al convertir el patrón de búsqueda: %v", err)
+		return nil, fmt.Errorf("error al convertir el patrón de búsqueda: %v", err)
 	}
 
-	err = searchRecursive(file, partitionSuperblock, rootInodeIndex, pattern, findCmd.path, outputBuffer)
+	coincidencias := []FindMatch{}
+	err = searchRecursive(file, partitionSuperblock, sesion.Usuario, rootInodeIndex, pattern, findCmd.path, &coincidencias)
 	if err != nil {
-		return fmt.Errorf("error durante la búsqueda: %v", err)
+		return nil, fmt.Errorf("error durante la búsqueda: %v", err)
+	}
+
+	for _, coincidencia := range coincidencias {
+		fmt.Fprintf(outputBuffer, "%s\n", coincidencia.Path)
 	}
 
 	fmt.Fprint(outputBuffer, "=================================================\n")
-	return nil
+	return coincidencias, nil
 }
 
-func searchRecursive(file *os.File, sb *estructuras.Superbloque, inodeIndex int32, pattern *regexp.Regexp, currentPath string, outputBuffer *bytes.Buffer) error {
+func searchRecursive(file *os.File, sb *estructuras.Superbloque, usuario *estructuras.Usuario, inodeIndex int32, pattern *regexp.Regexp, currentPath string, coincidencias *[]FindMatch) error {
 	inode := &estructuras.Inodo{}
 	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
 	if err != nil {
 		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
 	}
 
-	if inode.I_type[0] != '0' {
+	if inode.I_type[0] != '0' || !global.TienePermiso(usuario, inode, global.PermisoLectura) {
 		return nil
 	}
 
@@ -113,7 +120,7 @@ func searchRecursive(file *os.File, sb *estructuras.Superbloque, inodeIndex int3
 			break
 		}
 
-		block := &estructuras.FolderBlock{}
+		block := sb.NuevoBloqueCarpeta()
 		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
 		if err != nil {
 			return fmt.Errorf("error al deserializar el bloque %d: %v", blockIndex, err)
@@ -124,15 +131,28 @@ func searchRecursive(file *os.File, sb *estructuras.Superbloque, inodeIndex int3
 				continue
 			}
 
-			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			contentName := sb.NombreEntrada(file, content)
 			if contentName == "." || contentName == ".." {
 				continue
 			}
 			if pattern.MatchString(contentName) {
-				fmt.Fprintf(outputBuffer, "%s/%s\n", currentPath, contentName)
+				child := &estructuras.Inodo{}
+				if err := child.Decode(file, int64(sb.S_inode_start+(content.B_inodo*sb.S_inode_size))); err != nil {
+					return fmt.Errorf("error al deserializar el inodo %d: %v", content.B_inodo, err)
+				}
+				tipo := "archivo"
+				if child.I_type[0] == '0' {
+					tipo = "carpeta"
+				}
+				*coincidencias = append(*coincidencias, FindMatch{
+					Path:  path.Join(currentPath, contentName),
+					Name:  contentName,
+					Type:  tipo,
+					Inode: content.B_inodo,
+				})
 			}
 			newInodeIndex := content.B_inodo
-			err = searchRecursive(file, sb, newInodeIndex, pattern, currentPath+"/"+contentName, outputBuffer)
+			err = searchRecursive(file, sb, usuario, newInodeIndex, pattern, currentPath+"/"+contentName, coincidencias)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,117 @@
+package instrucciones
+
+import (
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	global "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"os"
+	"strings"
+)
+
+type FsckCommand struct {
+	Id     string `json:"id"`
+	Repair bool   `json:"repair"`
+}
+
+func AnalizarFsck(args []string) (string, error) {
+	salida, _, err := AnalizarFsckConDatos(args)
+	return salida, err
+}
+
+// AnalizarFsckConDatos devuelve además la lista de hallazgos para las respuestas JSON
+func AnalizarFsckConDatos(args []string) (string, interface{}, error) {
+	params, err := utilidades.ParsearParametros(args, []string{"id"}, []string{"repair"})
+	if err != nil {
+		return "", nil, err
+	}
+
+	cmd := &FsckCommand{Id: params.Valor("id"), Repair: params.Banderas["repair"]}
+	if cmd.Id == "" {
+		return "", nil, errors.New("el parámetro id es obligatorio")
+	}
+
+	resultado, err := cmd.Execute()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return generarTablaFsck(cmd, resultado), resultado, nil
+}
+
+func (cmd *FsckCommand) Execute() (*estructuras.ResultadoFsck, error) {
+	sb, partition, path, err := global.GetMountedPartitionSuperblock(cmd.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo la partición: %w", err)
+	}
+
+	flag := os.O_RDONLY
+	if cmd.Repair {
+		flag = os.O_RDWR
+	}
+	file, err := global.Discos.Abrir(path, flag)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo el archivo: %w", err)
+	}
+	defer global.Discos.Cerrar(file)
+
+	resultado, err := estructuras.VerificarSistemaArchivos(file, sb, cmd.Repair)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Repair {
+		if err := sb.Codificar(file, int64(partition.Part_start)); err != nil {
+			return nil, fmt.Errorf("error guardando el superbloque: %w", err)
+		}
+	}
+
+	return resultado, nil
+}
+
+func generarTablaFsck(cmd *FsckCommand, resultado *estructuras.ResultadoFsck) string {
+	var tb strings.Builder
+	tb.WriteString("======================= FSCK =======================\n")
+	fmt.Fprintf(&tb, "Partición: %s\n", cmd.Id)
+	fmt.Fprintf(&tb, "Inodos en uso: %d de %d\n", resultado.InodosUsados, resultado.InodosTotales)
+	fmt.Fprintf(&tb, "Bloques en uso: %d de %d\n", resultado.BloquesUsados, resultado.BloquesTotales)
+
+	if len(resultado.Hallazgos) == 0 {
+		tb.WriteString("El sistema de archivos es consistente\n")
+		tb.WriteString("==================== FIN FSCK ====================\n")
+		return tb.String()
+	}
+
+	header := fmt.Sprintf("%-4s | %-22s | %-6s | %-6s | %-20s | %-9s | %s\n",
+		"NO.", "TIPO", "INODO", "BLOQUE", "RUTA", "REPARADO", "DESCRIPCIÓN")
+	tb.WriteString("\n" + header)
+	tb.WriteString(strings.Repeat("-", len(header)-1) + "\n")
+
+	reparados := 0
+	for i, h := range resultado.Hallazgos {
+		reparado := "no"
+		if h.Reparado {
+			reparado = "sí"
+			reparados++
+		}
+		fmt.Fprintf(&tb, "%-4d | %-22s | %-6s | %-6s | %-20s | %-9s | %s\n",
+			i+1, h.Tipo, valorFsck(h.Inodo), valorFsck(h.Bloque), h.Ruta, reparado, h.Descripcion)
+	}
+
+	fmt.Fprintf(&tb, "\nSe encontraron %d problemas", len(resultado.Hallazgos))
+	if cmd.Repair {
+		fmt.Fprintf(&tb, ", %d reparados", reparados)
+	} else {
+		tb.WriteString("; use -repair para corregirlos")
+	}
+	tb.WriteString("\n==================== FIN FSCK ====================\n")
+	return tb.String()
+}
+
+func valorFsck(valor int32) string {
+	if valor < 0 {
+		return "-"
+	}
+	return fmt.Sprint(valor)
+}
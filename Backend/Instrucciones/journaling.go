@@ -1,12 +1,11 @@
 package instrucciones
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	estructuras "godisk/Estructuras"
 	global "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
 	"strings"
 	"time"
@@ -17,107 +16,122 @@ type JournalingCommand struct {
 }
 
 type JournalEntry struct {
-	Operation string `json:"operation"`
-	Path      string `json:"path"`
-	Content   string `json:"content"`
-	Date      string `json:"date"`
+	Sequence    uint32 `json:"sequence"`
+	Transaction uint32 `json:"transaction"`
+	Operation   string `json:"operation"`
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Date        string `json:"date"`
 }
 
-func cleanCString(buf []byte) string {
-	return strings.TrimSpace(
-		string(bytes.TrimRight(buf, "\x00")),
-	)
+func (cmd *JournalingCommand) Execute() (interface{}, error) {
+	entries, err := cmd.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return "No hay entradas de journal para mostrar", nil
+	}
+
+	return cmd.GenerateJournalingTable(entries)
 }
 
-func (cmd *JournalingCommand) Execute() (interface{}, error) {
+// Entries devuelve las entradas válidas del journal de la partición
+func (cmd *JournalingCommand) Entries() ([]JournalEntry, error) {
 	if cmd.Id == "" {
 		return nil, errors.New("el parámetro id es obligatorio")
 	}
 
-	sb, partition, path, err := global.GetMountedPartitionSuperblock(cmd.Id)
+	sb, _, path, err := global.GetMountedPartitionSuperblock(cmd.Id)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo la partición: %w", err)
 	}
 
-	if sb.S_filesystem_type != 3 {
+	if sb.TipoSistema() != 3 {
 		return nil, errors.New("la partición no es de tipo EXT3, no tiene journaling")
 	}
 
-	file, err := os.OpenFile(path, os.O_RDONLY, 0666)
+	file, err := global.Discos.Abrir(path, os.O_RDONLY)
 	if err != nil {
 		return nil, fmt.Errorf("error abriendo el archivo: %w", err)
 	}
-	defer file.Close()
-
-	journalStart := int64(partition.Part_start) + int64(binary.Size(estructuras.Superbloque{}))
-
-	fmt.Printf("Leyendo journal desde posición %d\n", journalStart)
+	defer global.Discos.Cerrar(file)
 
-	entries, err := estructuras.FindValidJournalEntries(file, journalStart, estructuras.JOURNAL_ENTRIES)
+	estado, err := estructuras.EstadoJournal(file, sb)
 	if err != nil {
-		return nil, fmt.Errorf("error buscando entradas de journal: %w", err)
+		return nil, fmt.Errorf("error leyendo el journal: %w", err)
 	}
+	fmt.Printf("Journal: %d de %d registros usados, siguiente secuencia %d\n", estado.J_used, estado.J_entries, estado.J_sequence)
 
-	if len(entries) == 0 {
-		return "No hay entradas de journal para mostrar", nil
+	entries, err := estructuras.FindValidJournalEntries(file, sb)
+	if err != nil {
+		return nil, fmt.Errorf("error buscando entradas de journal: %w", err)
 	}
 
-	var result []JournalEntry
+	result := []JournalEntry{}
 	for _, entry := range entries {
-		operation := cleanCString(entry.J_content.I_operation[:])
-		path := cleanCString(entry.J_content.I_path[:])
-		content := cleanCString(entry.J_content.I_content[:])
-
-		date := time.Unix(int64(entry.J_content.I_date), 0)
-		dateStr := date.Format(time.RFC3339)
-
 		result = append(result, JournalEntry{
-			Operation: operation,
-			Path:      path,
-			Content:   content,
-			Date:      dateStr,
+			Sequence:    entry.Secuencia,
+			Transaction: entry.Transaccion,
+			Operation:   entry.Operacion,
+			Path:        entry.Ruta,
+			Content:     entry.Contenido,
+			Date:        entry.Fecha.Format(time.RFC3339),
 		})
 	}
 
 	fmt.Printf("Se encontraron %d entradas válidas de journal\n", len(result))
-	return cmd.GenerateJournalingTable(result)
+	return result, nil
 }
 
 func AnalizarJournaling(args []string) (interface{}, error) {
-	cmd := &JournalingCommand{}
+	cmd, err := parsearJournaling(args)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(args) == 0 {
-		return nil, errors.New("no se proporcionaron parámetros para el comando journaling")
+	return cmd.Execute()
+}
+
+// AnalizarJournalingConDatos devuelve además las entradas del journal para las respuestas JSON
+func AnalizarJournalingConDatos(args []string) (string, interface{}, error) {
+	cmd, err := parsearJournaling(args)
+	if err != nil {
+		return "", nil, err
 	}
 
-	for _, arg := range args {
-		if !strings.HasPrefix(arg, "-") {
-			continue
-		}
+	entries, err := cmd.Entries()
+	if err != nil {
+		return "", nil, err
+	}
 
-		parts := strings.SplitN(strings.TrimPrefix(arg, "-"), "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("formato de parámetro incorrecto: %s", arg)
-		}
+	if len(entries) == 0 {
+		return "No hay entradas de journal para mostrar", entries, nil
+	}
 
-		param, value := strings.ToLower(parts[0]), parts[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = value[1 : len(value)-1]
-		}
+	tabla, err := cmd.GenerateJournalingTable(entries)
+	return tabla, entries, err
+}
 
-		switch param {
-		case "id":
-			cmd.Id = value
-		default:
-			return nil, fmt.Errorf("parámetro desconocido: %s", param)
-		}
+func parsearJournaling(args []string) (*JournalingCommand, error) {
+	cmd := &JournalingCommand{}
+
+	if len(args) == 0 {
+		return nil, errors.New("no se proporcionaron parámetros para el comando journaling")
 	}
 
+	params, err := utilidades.ParsearParametros(args, []string{"id"}, nil)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Id = params.Valor("id")
+
 	if cmd.Id == "" {
 		return nil, errors.New("el parámetro id es obligatorio")
 	}
 
-	return cmd.Execute()
+	return cmd, nil
 }
 
 func (cmd *JournalingCommand) GenerateJournalingTable(entries []JournalEntry) (string, error) {
@@ -130,8 +144,8 @@ func (cmd *JournalingCommand) GenerateJournalingTable(entries []JournalEntry) (s
 		dateWidth = 46
 	)
 
-	header := fmt.Sprintf("%-5s | %-10s | %-*s | %-*s | %s\n",
-		"NO.", "OPERACIÓN", pathWidth, "RUTA", dateWidth, "FECHA", "CONTENIDO")
+	header := fmt.Sprintf("%-5s | %-5s | %-10s | %-*s | %-*s | %s\n",
+		"SEC.", "TX", "OPERACIÓN", pathWidth, "RUTA", dateWidth, "FECHA", "CONTENIDO")
 	divider := strings.Repeat("-", len(header)-1) + "\n"
 
 	var tb strings.Builder
@@ -140,8 +154,8 @@ func (cmd *JournalingCommand) GenerateJournalingTable(entries []JournalEntry) (s
 	tb.WriteString(header)
 	tb.WriteString(divider)
 
-	for i, e := range entries {
-		content := e.Content
+	for _, e := range entries {
+		content := strings.ReplaceAll(e.Content, "\n", " ")
 		if len(content) > 40 {
 			content = content[:37] + "..."
 		}
@@ -151,8 +165,9 @@ func (cmd *JournalingCommand) GenerateJournalingTable(entries []JournalEntry) (s
 			date = t.Format("02/01/2006 15:04:05")
 		}
 
-		row := fmt.Sprintf("%-5d | %-10s | %-*s | %-*s | %s\n",
-			i+1,
+		row := fmt.Sprintf("%-5d | %-5d | %-10s | %-*s | %-*s | %s\n",
+			e.Sequence,
+			e.Transaction,
 			e.Operation,
 			pathWidth, e.Path,
 			dateWidth, date,
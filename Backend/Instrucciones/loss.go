@@ -2,61 +2,164 @@ package instrucciones
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	estructuras "godisk/Estructuras"
 	global "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
 )
 
+// máximo de unidades destruidas que se listan por área en el resumen
+const maxUnidadesResumen = 20
+
+type Loss struct {
+	id       string
+	opciones estructuras.OpcionesPerdida
+}
+
 func AnalizarLoss(tokens []string) (string, error) {
 	var output bytes.Buffer
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-id=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-	if len(matches) != len(tokens) {
-		for _, token := range tokens {
-			if !re.MatchString(token) {
-				return "", fmt.Errorf("parámetro inválido: %s", token)
-			}
-		}
+	cmd := &Loss{opciones: estructuras.OpcionesPerdida{Modo: estructuras.ModoPerdidaTodo, Semilla: 1}}
+
+	params, err := utilidades.ParsearParametros(tokens, []string{"id", "mode", "percent", "seed"}, nil)
+	if err != nil {
+		return "", err
 	}
-	var id string
-	for _, m := range matches {
-		parts := strings.SplitN(m, "=", 2)
-		if strings.ToLower(parts[0]) == "-id" && len(parts) == 2 {
-			id = strings.Trim(parts[1], `"`)
+
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
+
+		switch key {
+		case "id":
+			cmd.id = value
+		case "mode":
+			modo := strings.ToLower(value)
+			switch modo {
+			case estructuras.ModoPerdidaTodo, estructuras.ModoPerdidaBitmapInodos, estructuras.ModoPerdidaBitmapBloque,
+				estructuras.ModoPerdidaInodos, estructuras.ModoPerdidaBloques, estructuras.ModoPerdidaSuperbloque,
+				estructuras.ModoPerdidaAleatorio:
+				cmd.opciones.Modo = modo
+			default:
+				return "", errors.New("el modo debe ser all, bm_inode, bm_block, inodes, blocks, superblock o random")
+			}
+		case "percent":
+			porcentaje, err := strconv.Atoi(value)
+			if err != nil || porcentaje < 1 || porcentaje > 100 {
+				return "", errors.New("percent debe ser un entero entre 1 y 100")
+			}
+			cmd.opciones.Porcentaje = porcentaje
+		case "seed":
+			semilla, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return "", errors.New("seed debe ser un número entero")
+			}
+			cmd.opciones.Semilla = semilla
 		}
 	}
-	if id == "" {
+
+	if cmd.id == "" {
 		return "", fmt.Errorf("falta parámetro requerido: -id")
 	}
-	if err := LossPartition(id); err != nil {
+
+	// el superbloque es una sola estructura: se destruye completa o no se destruye
+	if cmd.opciones.Modo == estructuras.ModoPerdidaSuperbloque && cmd.opciones.Porcentaje != 0 {
+		return "", errors.New("el modo superblock no admite -percent")
+	}
+	if cmd.opciones.Porcentaje == 0 {
+		cmd.opciones.Porcentaje = 100
+		if cmd.opciones.Modo == estructuras.ModoPerdidaAleatorio {
+			cmd.opciones.Porcentaje = 10
+		}
+	}
+
+	if err := LossPartition(cmd, &output); err != nil {
 		return "", err
 	}
-	output.WriteString(fmt.Sprintf("Simulación de pérdida completada en partición %s", id))
 	return output.String(), nil
 }
 
-func LossPartition(id string) error {
-	sb, part, path, err := global.GetMountedPartitionSuperblock(id)
+func LossPartition(cmd *Loss, output *bytes.Buffer) error {
+	sb, part, path, respaldo, err := superbloqueParticion(cmd.id)
 	if err != nil {
-		return fmt.Errorf("no existe montaje %s: %w", id, err)
+		return err
 	}
 
-	f, err := os.OpenFile(path, os.O_RDWR, 0666)
+	f, err := global.Discos.Abrir(path, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("abrir %s: %w", path, err)
 	}
-	defer f.Close()
+	defer global.Discos.Cerrar(f)
 
-	if err = sb.Decodificar(f, int64(part.Part_start)); err != nil {
-		return fmt.Errorf("leer superbloque: %w", err)
+	if respaldo {
+		fmt.Fprintln(output, "El superbloque principal ya está dañado; se usa la copia de respaldo para ubicar las estructuras.")
 	}
 
-	if err = estructuras.CleanLossAreas(f, sb); err != nil {
+	areas, err := estructuras.SimularPerdida(f, sb, part.Part_start, cmd.opciones)
+	if err != nil {
 		return err
 	}
+
+	fmt.Fprintf(output, "Simulación de pérdida completada en partición %s\n", cmd.id)
+	fmt.Fprintf(output, "Modo: %s | Porcentaje: %d%% | Semilla: %d\n", cmd.opciones.Modo, cmd.opciones.Porcentaje, cmd.opciones.Semilla)
+	if cmd.opciones.Modo != estructuras.ModoPerdidaSuperbloque {
+		for _, area := range areas {
+			fmt.Fprintf(output, "- %s: %d de %d %s en uso destruidos%s\n", area.Nombre, len(area.Destruidas), area.EnUso, area.Unidad, listarUnidades(area.Destruidas))
+		}
+	} else {
+		fmt.Fprintln(output, "- superbloque principal: destruido")
+		if _, err := estructuras.LeerRespaldo(f, part.Part_start); err != nil {
+			fmt.Fprintf(output, "Sin respaldo: %v\n", err)
+		} else {
+			fmt.Fprintln(output, "La partición tiene una copia del superbloque; recovery la usará.")
+		}
+	}
 	return nil
 }
+
+func listarUnidades(unidades []int32) string {
+	if len(unidades) == 0 {
+		return ""
+	}
+	partes := make([]string, 0, maxUnidadesResumen)
+	for i, unidad := range unidades {
+		if i == maxUnidadesResumen {
+			partes = append(partes, fmt.Sprintf("... (%d más)", len(unidades)-i))
+			break
+		}
+		partes = append(partes, strconv.Itoa(int(unidad)))
+	}
+	return ": " + strings.Join(partes, ", ")
+}
+
+// superbloqueParticion devuelve el superbloque de una partición montada. Si el principal está
+// dañado usa la copia de respaldo de EXT3 e indica que se tomó de ahí.
+func superbloqueParticion(id string) (*estructuras.Superbloque, *estructuras.Partition, string, bool, error) {
+	sb, part, path, err := global.GetMountedPartitionSuperblock(id)
+	if err == nil && sb.Formateado() {
+		return sb, part, path, false, nil
+	}
+
+	part, path, errParticion := global.ObtenerParticionMontada(id)
+	if errParticion != nil {
+		return nil, nil, "", false, fmt.Errorf("no existe montaje %s: %w", id, errParticion)
+	}
+
+	f, errParticion := global.Discos.Abrir(path, os.O_RDONLY)
+	if errParticion != nil {
+		return nil, nil, "", false, fmt.Errorf("abrir %s: %w", path, errParticion)
+	}
+	defer global.Discos.Cerrar(f)
+
+	respaldo, errRespaldo := estructuras.LeerRespaldo(f, part.Part_start)
+	if errRespaldo != nil {
+		if err != nil {
+			return nil, nil, "", false, fmt.Errorf("leer superbloque: %w", err)
+		}
+		return nil, nil, "", false, fmt.Errorf("el superbloque de la partición %s está dañado: %v", id, errRespaldo)
+	}
+	respaldo.RegistrarAjuste(path, part.Part_fit[0])
+	return respaldo, part, path, true, nil
+}
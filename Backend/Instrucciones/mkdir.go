@@ -8,8 +8,7 @@ import (
 	globales "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
-	"strings"
+	"path"
 )
 
 type MKDIR struct {
@@ -17,47 +16,21 @@ type MKDIR struct {
 	p    bool
 }
 
-func AnalizarMkdir(parametros []string) (string, error) {
+func AnalizarMkdir(sesion *globales.Sesion, parametros []string) (string, error) {
 	cmd := &MKDIR{}
 	var outputBuffer bytes.Buffer
-	args := strings.Join(parametros, " ")
-	re := regexp.MustCompile(`-path=[^\s]+|-p`)
-	matches := re.FindAllString(args, -1)
-
-	if len(matches) != len(parametros) {
-		for _, token := range parametros {
-			if !re.MatchString(token) {
-				return "", fmt.Errorf("parámetro inválido: %s", token)
-			}
-		}
-	}
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-
-		switch key {
-		case "-path":
-			if len(kv) != 2 {
-				return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-			}
-			value := kv[1]
-			if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-				value = strings.Trim(value, "\"")
-			}
-			cmd.ruta = value
-		case "-p":
-			cmd.p = true
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
-		}
+	params, err := utilidades.ParsearParametros(parametros, []string{"path"}, []string{"p"})
+	if err != nil {
+		return "", err
 	}
+	cmd.ruta = params.Valor("path")
+	cmd.p = params.Banderas["p"]
 
 	if cmd.ruta == "" {
 		return "", errors.New("faltan parámetros requeridos: -path")
 	}
 
-	err := ejecutarMkdir(cmd, &outputBuffer)
+	err = ejecutarMkdir(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -65,14 +38,14 @@ func AnalizarMkdir(parametros []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func ejecutarMkdir(mkdir *MKDIR, outputBuffer *bytes.Buffer) error {
+func ejecutarMkdir(sesion *globales.Sesion, mkdir *MKDIR, outputBuffer *bytes.Buffer) error {
 
-	if !globales.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		fmt.Println("No hay ninguna sesión activa")
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
 
-	idParticion := globales.UsuarioActual.Id
+	idParticion := sesion.Usuario.Id
 
 	particionSuperbloque, particionMontada, rutaPartition, err := globales.GetMountedPartitionSuperblock(idParticion)
 
@@ -80,23 +53,42 @@ func ejecutarMkdir(mkdir *MKDIR, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
-	archivo, err := os.OpenFile(rutaPartition, os.O_RDWR, 0666)
+	archivo, err := globales.Discos.Abrir(rutaPartition, os.O_RDWR)
 
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
 	}
 
-	defer archivo.Close()
+	defer globales.Discos.Cerrar(archivo)
 
 	fmt.Printf("Creando directorio: %s\n", mkdir.ruta)
 
-	err = CrearDirectorio(mkdir.ruta, mkdir.p, particionSuperbloque, archivo, particionMontada)
+	if err := particionSuperbloque.ValidarNombresRuta(mkdir.ruta); err != nil {
+		return err
+	}
 
+	carpetaPadre := carpetaExistente(archivo, particionSuperbloque, path.Dir(path.Clean("/"+mkdir.ruta)))
+	err = verificarPermisoRuta(archivo, particionSuperbloque, sesion.Usuario, carpetaPadre, globales.PermisoEscritura)
 	if err != nil {
-		return fmt.Errorf("error al crear el directorio: %w", err)
+		return err
 	}
+	nuevas := rutasInexistentes(archivo, particionSuperbloque, mkdir.ruta)
+	t := &estructuras.TransaccionJournal{}
+	agregarCreacionPrevista(t, sesion.Usuario, nuevas, nil)
+
+	return crearConJournal(archivo, particionSuperbloque, nuevas, t, func() error {
+		primerInodoNuevo := particionSuperbloque.S_inodes_count
+		err := CrearDirectorio(mkdir.ruta, mkdir.p, particionSuperbloque, archivo, particionMontada)
+		if err != nil {
+			return fmt.Errorf("error al crear el directorio: %w", err)
+		}
 
-	return nil
+		err = asignarPropietario(archivo, particionSuperbloque, sesion.Usuario, primerInodoNuevo)
+		if err != nil {
+			return fmt.Errorf("error al asignar el propietario: %w", err)
+		}
+		return nil
+	})
 }
 
 func CrearDirectorio(dirRuta string, crearPadres bool, superbloque *estructuras.Superbloque, archivo *os.File, particionMontada *estructuras.Partition) error {
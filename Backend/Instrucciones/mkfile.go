@@ -9,9 +9,7 @@ import (
 	utilidades "godisk/Utilidades"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
-	"strings"
 )
 
 type MKFILE struct {
@@ -21,55 +19,36 @@ type MKFILE struct {
 	cont string
 }
 
-func AnalizarMkfile(tokens []string) (string, error) {
+func AnalizarMkfile(sesion *global.Sesion, tokens []string) (string, error) {
 	cmd := &MKFILE{}
 	var outputBuffer bytes.Buffer
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-r|-size=\d+|-cont="[^"]+"|-cont=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	if len(matches) != len(tokens) {
-		for _, token := range tokens {
-			if !re.MatchString(token) {
-				return "", fmt.Errorf("parámetro inválido: %s", token)
-			}
-		}
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "size", "cont"}, []string{"r"})
+	if err != nil {
+		return "", err
 	}
+	cmd.r = params.Banderas["r"]
 
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-		var value string
-		if len(kv) == 2 {
-			value = kv[1]
-		}
-
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
 
 		switch key {
-		case "-path":
+		case "path":
 			if value == "" {
 				return "", errors.New("el path no puede estar vacío")
 			}
 			cmd.path = value
-		case "-r":
-			cmd.r = true
-		case "-size":
+		case "size":
 			size, err := strconv.Atoi(value)
 			if err != nil || size < 0 {
 				return "", errors.New("el tamaño debe ser un número entero no negativo")
 			}
 			cmd.size = size
-		case "-cont":
+		case "cont":
 			if value == "" {
 				return "", errors.New("el contenido no puede estar vacío")
 			}
 			cmd.cont = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
 		}
 	}
 
@@ -85,7 +64,7 @@ func AnalizarMkfile(tokens []string) (string, error) {
 		cmd.cont = ""
 	}
 
-	err := commandMkfile(cmd, &outputBuffer)
+	err = commandMkfile(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -93,12 +72,12 @@ func AnalizarMkfile(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandMkfile(mkfile *MKFILE, outputBuffer *bytes.Buffer) error {
-	if !global.EstaLogueado() {
+func commandMkfile(sesion *global.Sesion, mkfile *MKFILE, outputBuffer *bytes.Buffer) error {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
-	idPartition := global.UsuarioActual.Id
+	idPartition := sesion.Usuario.Id
 
 	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
@@ -109,33 +88,58 @@ func commandMkfile(mkfile *MKFILE, outputBuffer *bytes.Buffer) error {
 		mkfile.cont = generateContent(mkfile.size)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0666)
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
 
 	fmt.Fprintln(outputBuffer, "======================= MKFILE =======================")
 	fmt.Fprintf(outputBuffer, "Creando archivo: %s\n", mkfile.path)
 
+	if err := partitionSuperblock.ValidarNombresRuta(mkfile.path); err != nil {
+		return err
+	}
+
 	dirPath, _ := GetDirectoryAndFile(mkfile.path)
 
+	err = verificarPermisoRuta(file, partitionSuperblock, sesion.Usuario, carpetaExistente(file, partitionSuperblock, dirPath), global.PermisoEscritura)
+	if err != nil {
+		return err
+	}
+	nuevas := rutasInexistentes(file, partitionSuperblock, mkfile.path)
+
 	fmt.Fprintf(outputBuffer, "Verificando la existencia del directorio: %s\n", dirPath)
 	exists, _, err := directoryExists(partitionSuperblock, file, 0, dirPath)
 	if err != nil {
 		return fmt.Errorf("error al verificar directorio: %w", err)
 	}
 
-	if mkfile.r && !exists {
-		err = CrearDirectorio(dirPath, mkfile.r, partitionSuperblock, file, mountedPartition)
+	t := &estructuras.TransaccionJournal{}
+	agregarCreacionPrevista(t, sesion.Usuario, nuevas, &mkfile.cont)
+
+	err = crearConJournal(file, partitionSuperblock, nuevas, t, func() error {
+		firstNewInode := partitionSuperblock.S_inodes_count
+		if mkfile.r && !exists {
+			err := CrearDirectorio(dirPath, mkfile.r, partitionSuperblock, file, mountedPartition)
+			if err != nil {
+				return fmt.Errorf("error al crear directorios intermedios: %w", err)
+			}
+		}
+
+		err := createFile(mkfile.path, mkfile.size, mkfile.cont, partitionSuperblock, file, mountedPartition, outputBuffer, mkfile.r)
 		if err != nil {
-			return fmt.Errorf("error al crear directorios intermedios: %w", err)
+			return fmt.Errorf("error al crear el archivo: %w", err)
 		}
-	}
 
-	err = createFile(mkfile.path, mkfile.size, mkfile.cont, partitionSuperblock, file, mountedPartition, outputBuffer, mkfile.r)
+		err = asignarPropietario(file, partitionSuperblock, sesion.Usuario, firstNewInode)
+		if err != nil {
+			return fmt.Errorf("error al asignar el propietario: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error al crear el archivo: %w", err)
+		return err
 	}
 
 	fmt.Fprintf(outputBuffer, "Archivo %s creado exitosamente\n", mkfile.path)
@@ -156,7 +160,7 @@ func createFile(filePath string, size int, content string, sb *estructuras.Super
 	fmt.Fprintf(outputBuffer, "Creando archivo en la ruta: %s\n", filePath)
 
 	parentDirs, destDir := utilidades.ObtenerDirectoriosPadre(filePath)
-	chunks := utilidades.DividirCadenaEnTrozos(content)
+	chunks := utilidades.DividirCadenaEnTrozos(content, int(sb.S_block_size))
 	fmt.Fprintf(outputBuffer, "Contenido generado: %v\n", chunks)
 
 	err := sb.CrearArchivo(file, parentDirs, destDir, size, chunks, r)
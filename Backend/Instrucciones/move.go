@@ -0,0 +1,101 @@
+package instrucciones
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	global "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"os"
+	"path"
+)
+
+type MOVE struct {
+	path    string
+	destino string
+}
+
+func AnalizarMove(sesion *global.Sesion, tokens []string) (string, error) {
+	cmd := &MOVE{}
+	var outputBuffer bytes.Buffer
+
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "destino"}, nil)
+	if err != nil {
+		return "", err
+	}
+	cmd.path = params.Valor("path")
+	cmd.destino = params.Valor("destino")
+
+	if cmd.path == "" || cmd.destino == "" {
+		return "", errors.New("los parámetros -path y -destino son obligatorios")
+	}
+
+	err = commandMove(sesion, cmd, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+func commandMove(sesion *global.Sesion, moveCmd *MOVE, outputBuffer *bytes.Buffer) error {
+	fmt.Fprint(outputBuffer, "======================= MOVE =======================\n")
+
+	if !sesion.EstaLogueado() {
+		return fmt.Errorf("no hay un usuario logueado")
+	}
+
+	idPartition := sesion.Usuario.Id
+
+	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
+	}
+	defer global.Discos.Cerrar(file)
+
+	origen := path.Clean("/" + moveCmd.path)
+	destino := path.Clean("/" + moveCmd.destino)
+
+	for _, ruta := range []string{path.Dir(origen), destino} {
+		index, err := partitionSuperblock.BuscarInodoPorRuta(file, ruta)
+		if err != nil {
+			return fmt.Errorf("error al encontrar '%s': %v", ruta, err)
+		}
+
+		inode := &estructuras.Inodo{}
+		if err := inode.Decode(file, partitionSuperblock.CalculateInodeOffset(index)); err != nil {
+			return fmt.Errorf("error al deserializar el inodo %d: %v", index, err)
+		}
+		if !global.TienePermiso(sesion.Usuario, inode, global.PermisoEscritura) {
+			return fmt.Errorf("no tiene permiso de escritura sobre '%s'", ruta)
+		}
+	}
+
+	t := &estructuras.TransaccionJournal{}
+	t.Agregar("move", origen, destino)
+	err = partitionSuperblock.AplicarConJournal(file, t, func() error {
+		if err := partitionSuperblock.MoverEntrada(file, origen, destino); err != nil {
+			return fmt.Errorf("error al mover '%s': %v", origen, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = partitionSuperblock.Codificar(file, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error al actualizar el superbloque: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "'%s' movido exitosamente a '%s'\n", origen, destino)
+	fmt.Fprint(outputBuffer, "=====================================================\n")
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package instrucciones
+
+import (
+	"fmt"
+	estructuras "godisk/Estructuras"
+	global "godisk/Global"
+	"os"
+	"path"
+)
+
+func nombrePermiso(permiso byte) string {
+	switch permiso {
+	case global.PermisoLectura:
+		return "lectura"
+	case global.PermisoEscritura:
+		return "escritura"
+	default:
+		return "ejecución"
+	}
+}
+
+func verificarPermisoInodo(file *os.File, sb *estructuras.Superbloque, usuario *estructuras.Usuario, inodeIndex int32, ruta string, permiso byte) error {
+	inode := &estructuras.Inodo{}
+	if err := inode.Decode(file, sb.CalculateInodeOffset(inodeIndex)); err != nil {
+		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+	}
+	if !global.TienePermiso(usuario, inode, permiso) {
+		return fmt.Errorf("permiso denegado: no tiene permiso de %s sobre '%s'", nombrePermiso(permiso), ruta)
+	}
+	return nil
+}
+
+func verificarPermisoRuta(file *os.File, sb *estructuras.Superbloque, usuario *estructuras.Usuario, ruta string, permiso byte) error {
+	inodeIndex, err := sb.BuscarInodoPorRuta(file, ruta)
+	if err != nil {
+		return err
+	}
+	return verificarPermisoInodo(file, sb, usuario, inodeIndex, ruta, permiso)
+}
+
+// verificarPermisoArbol exige el permiso sobre el inodo y, si es carpeta, sobre todo su contenido.
+func verificarPermisoArbol(file *os.File, sb *estructuras.Superbloque, usuario *estructuras.Usuario, inodeIndex int32, ruta string, permiso byte) error {
+	if err := verificarPermisoInodo(file, sb, usuario, inodeIndex, ruta, permiso); err != nil {
+		return err
+	}
+
+	return recorrerCarpeta(file, sb, inodeIndex, ruta, func(childIndex int32, childPath string) error {
+		return verificarPermisoArbol(file, sb, usuario, childIndex, childPath, permiso)
+	})
+}
+
+// carpetaExistente devuelve la carpeta existente más profunda de la ruta indicada.
+func carpetaExistente(file *os.File, sb *estructuras.Superbloque, ruta string) string {
+	actual := path.Clean("/" + ruta)
+	for actual != "/" {
+		if _, err := sb.BuscarInodoPorRuta(file, actual); err == nil {
+			return actual
+		}
+		actual = path.Dir(actual)
+	}
+	return actual
+}
+
+// recorrerCarpeta invoca fn por cada entrada (excepto '.' y '..') de la carpeta indicada.
+func recorrerCarpeta(file *os.File, sb *estructuras.Superbloque, inodeIndex int32, ruta string, fn func(int32, string) error) error {
+	inode := &estructuras.Inodo{}
+	if err := inode.Decode(file, sb.CalculateInodeOffset(inodeIndex)); err != nil {
+		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+	}
+	if inode.I_type[0] != '0' {
+		return nil
+	}
+
+	blocks, err := inode.GetDataBlockIndexes(file, sb)
+	if err != nil {
+		return err
+	}
+
+	for _, blockIndex := range blocks {
+		block := sb.NuevoBloqueCarpeta()
+		if err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size))); err != nil {
+			return fmt.Errorf("error al deserializar el bloque %d: %v", blockIndex, err)
+		}
+
+		for _, content := range block.B_content {
+			contentName := sb.NombreEntrada(file, content)
+			if content.B_inodo == -1 || contentName == "." || contentName == ".." {
+				continue
+			}
+			if err := fn(content.B_inodo, path.Join(ruta, contentName)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// asignarPropietario marca como del usuario los inodos creados a partir de desde.
+func asignarPropietario(file *os.File, sb *estructuras.Superbloque, usuario *estructuras.Usuario, desde int32) error {
+	if usuario.Uid <= 0 {
+		return nil
+	}
+
+	for i := desde; i < sb.S_inodes_count; i++ {
+		inode := &estructuras.Inodo{}
+		offset := sb.CalculateInodeOffset(i)
+		if err := inode.Decode(file, offset); err != nil {
+			return fmt.Errorf("error al deserializar el inodo %d: %v", i, err)
+		}
+		if inode.I_type[0] != '0' && inode.I_type[0] != '1' {
+			continue
+		}
+
+		inode.I_uid = usuario.Uid
+		inode.I_gid = usuario.Gid
+		if err := inode.Encode(file, offset); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,22 +1,24 @@
 package instrucciones
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	estructuras "godisk/Estructuras"
 	global "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"strings"
 )
 
 type RecoveryCmd struct{ Id string }
 
 func AnalizarRecovery(args []string) (string, error) {
 	cmd := &RecoveryCmd{}
-	for _, a := range args {
-		if strings.HasPrefix(strings.ToLower(a), "-id=") {
-			cmd.Id = strings.SplitN(a, "=", 2)[1]
-		}
+	params, err := utilidades.ParsearParametros(args, []string{"id"}, nil)
+	if err != nil {
+		return "", err
 	}
+	cmd.Id = params.Valor("id")
 	if cmd.Id == "" {
 		return "", errors.New("falta parámetro -id")
 	}
@@ -24,23 +26,50 @@ func AnalizarRecovery(args []string) (string, error) {
 }
 
 func (c *RecoveryCmd) Execute() (string, error) {
-	sb, part, path, err := global.GetMountedPartitionSuperblock(c.Id)
+	sb, part, path, respaldo, err := superbloqueParticion(c.Id)
 	if err != nil {
 		return "", err
 	}
-	if sb.S_filesystem_type != 3 {
-		return "", errors.New("la partición no es EXT3 (sisn journaling)")
+	if sb.TipoSistema() != 3 {
+		return "", errors.New("la partición no es EXT3 (sin journaling)")
 	}
 
-	f, err := os.OpenFile(path, os.O_RDWR, 0666)
+	f, err := global.Discos.Abrir(path, os.O_RDWR)
 	if err != nil {
 		return "", err
 	}
-	defer f.Close()
+	defer global.Discos.Cerrar(f)
 
-	if err := estructuras.RecoverFileSystem(f, sb, part.Part_start); err != nil {
+	var salida bytes.Buffer
+	if respaldo {
+		// la partición sigue montada, así que el superbloque reconstruido queda marcado como en uso
+		sb.S_filesystem_type |= estructuras.EstadoSucio
+		fmt.Fprintln(&salida, "El superbloque principal está dañado; se reconstruye a partir de la copia de respaldo.")
+	}
+
+	resultados, descartadas, err := estructuras.RecoverFileSystem(f, sb, part.Part_start)
+	if err != nil {
 		return "", err
 	}
 
-	return "Recuperación exitosa: el sistema se restauró usando el journal", nil
+	fallidas := 0
+	fmt.Fprintf(&salida, "%-4s | %-8s | %-30s | %s\n", "TX", "OPERACIÓN", "RUTA", "RESULTADO")
+	for _, r := range resultados {
+		resultado := "ok"
+		if r.Err != nil {
+			resultado = "error: " + r.Err.Error()
+			fallidas++
+		}
+		fmt.Fprintf(&salida, "%-4d | %-8s | %-30s | %s\n", r.Entrada.Transaccion, r.Entrada.Operacion, r.Entrada.Ruta, resultado)
+	}
+
+	if fallidas > 0 || descartadas > 0 {
+		fmt.Fprintf(&salida, "Recuperación parcial: se aplicaron %d de %d operaciones del journal\n", len(resultados)-fallidas, len(resultados))
+		if descartadas > 0 {
+			fmt.Fprintf(&salida, "El journal ya no conserva las transacciones hasta la %d; lo que hicieron no se recuperó, salvo la copia de users.txt del punto de control\n", descartadas)
+		}
+	} else {
+		fmt.Fprintf(&salida, "Recuperación exitosa: se aplicaron las %d operaciones del journal\n", len(resultados))
+	}
+	return salida.String(), nil
 }
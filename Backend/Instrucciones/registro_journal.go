@@ -0,0 +1,123 @@
+package instrucciones
+
+import (
+	"fmt"
+	estructuras "godisk/Estructuras"
+	"os"
+	"path"
+)
+
+// rutasInexistentes devuelve la ruta y sus carpetas padre que todavía no existen, de la menos a
+// la más profunda
+func rutasInexistentes(file *os.File, sb *estructuras.Superbloque, ruta string) []string {
+	var rutas []string
+	for actual := path.Clean("/" + ruta); actual != "/"; actual = path.Dir(actual) {
+		if _, err := sb.BuscarInodoPorRuta(file, actual); err == nil {
+			break
+		}
+		rutas = append([]string{actual}, rutas...)
+	}
+	return rutas
+}
+
+// agregarCreacionPrevista agrega las operaciones que crean las rutas nuevas como las dejan
+// mkdir y mkfile: todas son carpetas salvo la última si datos no es nil, con permisos 664 y el
+// dueño que les pone asignarPropietario
+func agregarCreacionPrevista(t *estructuras.TransaccionJournal, usuario *estructuras.Usuario, nuevas []string, datos *string) {
+	propietario := "1,1"
+	if usuario.Uid > 0 {
+		propietario = fmt.Sprintf("%d,%d", usuario.Uid, usuario.Gid)
+	}
+
+	for i, ruta := range nuevas {
+		if i == len(nuevas)-1 && datos != nil {
+			t.Agregar("mkfile", ruta, *datos)
+		} else {
+			t.Agregar("mkdir", ruta, "")
+		}
+		t.Agregar("chown", ruta, propietario)
+		t.Agregar("chmod", ruta, "664")
+	}
+}
+
+// crearConJournal registra en el journal la creación prevista antes de hacerla. Después compara
+// lo previsto con lo que quedó creado desde la primera de las rutas nuevas que exista (las demás
+// quedan dentro de ella) y, si no coincide, reemplaza la transacción por lo creado.
+func crearConJournal(file *os.File, sb *estructuras.Superbloque, nuevas []string, prevista *estructuras.TransaccionJournal, crear func() error) error {
+	if err := sb.AplicarConJournal(file, prevista, crear); err != nil {
+		return err
+	}
+	if sb.TipoSistema() != 3 {
+		return nil
+	}
+
+	creada := &estructuras.TransaccionJournal{}
+	for _, ruta := range nuevas {
+		index, err := sb.BuscarInodoPorRuta(file, ruta)
+		if err != nil {
+			continue
+		}
+		if err := agregarCreacion(creada, file, sb, index, ruta); err != nil {
+			return fmt.Errorf("error al revisar lo creado para el journal: %v", err)
+		}
+		break
+	}
+	if creada.Igual(prevista) {
+		return nil
+	}
+	return sb.ReemplazarJournal(file, prevista, creada)
+}
+
+// agregarCreacion agrega a la transacción las operaciones que vuelven a crear el inodo (y su
+// contenido, si es carpeta) con el mismo dueño y permisos
+func agregarCreacion(t *estructuras.TransaccionJournal, file *os.File, sb *estructuras.Superbloque, inodeIndex int32, ruta string) error {
+	inode := &estructuras.Inodo{}
+	if err := inode.Decode(file, sb.CalculateInodeOffset(inodeIndex)); err != nil {
+		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+	}
+
+	if inode.I_type[0] == '0' {
+		t.Agregar("mkdir", ruta, "")
+	} else {
+		data, err := leerDatosArchivo(file, sb, inode)
+		if err != nil {
+			return err
+		}
+		t.Agregar("mkfile", ruta, string(data))
+	}
+	t.AgregarPropietario(ruta, inode)
+
+	return recorrerCarpeta(file, sb, inodeIndex, ruta, func(childIndex int32, childPath string) error {
+		return agregarCreacion(t, file, sb, childIndex, childPath)
+	})
+}
+
+// agregarEliminacion agrega a la transacción las operaciones que eliminan el inodo, empezando
+// por el contenido de las carpetas. Cada una va precedida del dueño y los permisos del inodo, y
+// los archivos guardan su contenido, para poder deshacerlas.
+func agregarEliminacion(t *estructuras.TransaccionJournal, file *os.File, sb *estructuras.Superbloque, inodeIndex int32, ruta string) error {
+	inode := &estructuras.Inodo{}
+	if err := inode.Decode(file, sb.CalculateInodeOffset(inodeIndex)); err != nil {
+		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+	}
+
+	if inode.I_type[0] != '0' {
+		data, err := leerDatosArchivo(file, sb, inode)
+		if err != nil {
+			return err
+		}
+		t.AgregarPreimagen(ruta, inode)
+		t.Agregar("rm", ruta, string(data))
+		return nil
+	}
+
+	err := recorrerCarpeta(file, sb, inodeIndex, ruta, func(childIndex int32, childPath string) error {
+		return agregarEliminacion(t, file, sb, childIndex, childPath)
+	})
+	if err != nil {
+		return err
+	}
+	t.AgregarPreimagen(ruta, inode)
+	t.Agregar("rmdir", ruta, "")
+	return nil
+}
@@ -8,62 +8,83 @@ import (
 	global "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
-	"strings"
+	"path"
 )
 
 type REMOVE struct {
 	path string
 }
 
-func AnalizarRemove(tokens []string) (string, error) {
+func AnalizarRemove(sesion *global.Sesion, tokens []string) (string, error) {
 	cmd := &REMOVE{}
 	var outputBuffer bytes.Buffer
 
-	re := regexp.MustCompile(`-path=("[^"]+"|[^\s]+)`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
-	if len(matches) == 0 {
-		return "", errors.New("no se especificó una ruta para eliminar")
+	params, err := utilidades.ParsearParametros(tokens, []string{"path"}, nil)
+	if err != nil {
+		return "", err
 	}
-
-	kv := strings.SplitN(matches[0], "=", 2)
-	if len(kv) == 2 {
-		cmd.path = kv[1]
-		if strings.HasPrefix(cmd.path, "\"") && strings.HasSuffix(cmd.path, "\"") {
-			cmd.path = strings.Trim(cmd.path, "\"")
-		}
+	if params.Valor("path") == "" {
+		return "", errors.New("no se especificó una ruta para eliminar")
 	}
+	cmd.path = params.Valor("path")
 
-	err := commandRemove(cmd, &outputBuffer)
+	err = commandRemove(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
 
 	return outputBuffer.String(), nil
 }
-func commandRemove(removeCmd *REMOVE, outputBuffer *bytes.Buffer) error {
+func commandRemove(sesion *global.Sesion, removeCmd *REMOVE, outputBuffer *bytes.Buffer) error {
 	fmt.Fprint(outputBuffer, "====================== REMOVE ======================\n")
 
-	if !global.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
-	idPartition := global.UsuarioActual.Id
+	idPartition := sesion.Usuario.Id
 	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0666)
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
 
-	err = removeFileOrDirectory(removeCmd.path, partitionSuperblock, file)
+	targetPath := path.Clean("/" + removeCmd.path)
+	err = verificarPermisoRuta(file, partitionSuperblock, sesion.Usuario, path.Dir(targetPath), global.PermisoEscritura)
+	if err != nil {
+		return err
+	}
+	targetIndex, err := partitionSuperblock.BuscarInodoPorRuta(file, targetPath)
 	if err != nil {
 		return fmt.Errorf("error al eliminar archivo o carpeta: %v", err)
 	}
+	err = verificarPermisoArbol(file, partitionSuperblock, sesion.Usuario, targetIndex, targetPath, global.PermisoEscritura)
+	if err != nil {
+		return err
+	}
+
+	// Se arma antes de eliminar para guardar el contenido de los archivos
+	t := &estructuras.TransaccionJournal{}
+	if partitionSuperblock.TipoSistema() == 3 {
+		if err := agregarEliminacion(t, file, partitionSuperblock, targetIndex, targetPath); err != nil {
+			return fmt.Errorf("error al preparar el journal: %v", err)
+		}
+	}
+
+	err = partitionSuperblock.AplicarConJournal(file, t, func() error {
+		if err := removeFileOrDirectory(removeCmd.path, partitionSuperblock, file); err != nil {
+			return fmt.Errorf("error al eliminar archivo o carpeta: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
 	err = partitionSuperblock.Codificar(file, int64(mountedPartition.Part_start))
 	if err != nil {
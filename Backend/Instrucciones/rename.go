@@ -8,8 +8,7 @@ import (
 	global "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
-	"strings"
+	"path"
 )
 
 type RENAME struct {
@@ -17,34 +16,22 @@ type RENAME struct {
 	name string
 }
 
-func AnalizarRename(tokens []string) (string, error) {
+func AnalizarRename(sesion *global.Sesion, tokens []string) (string, error) {
 	cmd := &RENAME{}
 	var outputBuffer bytes.Buffer
 
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-name="[^"]+"|-name=[^\s]+`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
-
-	if len(matches) != len(tokens) || len(matches) < 2 {
-		return "", errors.New("faltan parámetros requeridos: -path o -name")
-	}
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-		value := strings.Trim(kv[1], "\"")
-
-		switch key {
-		case "-path":
-			cmd.path = value
-		case "-name":
-			cmd.name = value
-		}
+	params, err := utilidades.ParsearParametros(tokens, []string{"path", "name"}, nil)
+	if err != nil {
+		return "", err
 	}
+	cmd.path = params.Valor("path")
+	cmd.name = params.Valor("name")
 
 	if cmd.path == "" || cmd.name == "" {
 		return "", errors.New("los parámetros -path y -name son obligatorios")
 	}
 
-	err := commandRename(cmd, &outputBuffer)
+	err = commandRename(sesion, cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -52,25 +39,30 @@ func AnalizarRename(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandRename(renameCmd *RENAME, outputBuffer *bytes.Buffer) error {
+func commandRename(sesion *global.Sesion, renameCmd *RENAME, outputBuffer *bytes.Buffer) error {
 	fmt.Fprint(outputBuffer, "======================= RENAME =======================\n")
 
-	if !global.EstaLogueado() {
+	if !sesion.EstaLogueado() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
-	idPartition := global.UsuarioActual.Id
+	idPartition := sesion.Usuario.Id
 
-	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
+	partitionSuperblock, partition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
-	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0666)
+	file, err := global.Discos.Abrir(partitionPath, os.O_RDWR)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
+
+	err = verificarPermisoRuta(file, partitionSuperblock, sesion.Usuario, renameCmd.path, global.PermisoEscritura)
+	if err != nil {
+		return err
+	}
 
 	parentDirs, oldName := utilidades.ObtenerDirectoriosPadre(renameCmd.path)
 
@@ -78,26 +70,22 @@ func commandRename(renameCmd *RENAME, outputBuffer *bytes.Buffer) error {
 	if err != nil {
 		return fmt.Errorf("error al encontrar el directorio padre: %v", err)
 	}
-	folderBlock := &estructuras.FolderBlock{}
-	err = folderBlock.Decode(file, int64(partitionSuperblock.S_block_start+(inodeIndex*partitionSuperblock.S_block_size)))
-	if err != nil {
-		return fmt.Errorf("error al deserializar el bloque de carpeta: %v", err)
-	}
 
-	for _, content := range folderBlock.B_content {
-		if strings.EqualFold(strings.Trim(string(content.B_name[:]), "\x00 "), renameCmd.name) {
-			return fmt.Errorf("ya existe un archivo o carpeta con el nombre '%s'", renameCmd.name)
+	t := &estructuras.TransaccionJournal{}
+	t.Agregar("rename", path.Clean("/"+renameCmd.path), renameCmd.name)
+	err = partitionSuperblock.AplicarConJournal(file, t, func() error {
+		if err := partitionSuperblock.RenombrarEntrada(file, inodeIndex, oldName, renameCmd.name); err != nil {
+			return fmt.Errorf("error al renombrar el archivo o carpeta: %v", err)
 		}
-	}
-
-	err = folderBlock.RenameInFolderBlock(oldName, renameCmd.name)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error al renombrar el archivo o carpeta: %v", err)
+		return err
 	}
 
-	err = folderBlock.Encode(file, int64(partitionSuperblock.S_block_start+(inodeIndex*partitionSuperblock.S_block_size)))
+	err = partitionSuperblock.Codificar(file, int64(partition.Part_start))
 	if err != nil {
-		return fmt.Errorf("error al guardar el bloque de carpeta modificado: %v", err)
+		return fmt.Errorf("error al guardar el superbloque: %v", err)
 	}
 
 	fmt.Fprintf(outputBuffer, "Nombre cambiado exitosamente de '%s' a '%s'\n", oldName, renameCmd.name)
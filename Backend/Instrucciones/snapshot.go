@@ -0,0 +1,179 @@
+package instrucciones
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	global "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"os"
+	"strings"
+	"time"
+)
+
+type SnapshotCmd struct {
+	id     string
+	action string
+	name   string
+}
+
+func AnalizarSnapshot(sesion *global.Sesion, tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &SnapshotCmd{}
+
+	params, err := utilidades.ParsearParametros(tokens, []string{"id", "action", "name"}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
+
+		switch key {
+		case "id":
+			cmd.id = value
+		case "action":
+			cmd.action = strings.ToLower(value)
+			if cmd.action != "create" && cmd.action != "list" && cmd.action != "restore" && cmd.action != "delete" {
+				return "", errors.New("la acción debe ser create, list, restore o delete")
+			}
+		case "name":
+			if err := estructuras.ValidarNombreSnapshot(value); err != nil {
+				return "", err
+			}
+			cmd.name = value
+		}
+	}
+
+	if cmd.id == "" {
+		return "", errors.New("faltan parámetros requeridos: -id")
+	}
+	if cmd.action == "" {
+		return "", errors.New("faltan parámetros requeridos: -action")
+	}
+	if cmd.name == "" && cmd.action != "list" {
+		return "", errors.New("faltan parámetros requeridos: -name")
+	}
+
+	err = commandSnapshot(sesion, cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+func commandSnapshot(sesion *global.Sesion, cmd *SnapshotCmd, outputBuffer *bytes.Buffer) error {
+	part, path, err := global.ObtenerParticionMontada(cmd.id)
+	if err != nil {
+		return fmt.Errorf("error obteniendo la partición %s: %w", cmd.id, err)
+	}
+	directorio := estructuras.DirectorioSnapshots(path, strings.Trim(string(part.Part_name[:]), "\x00 "))
+
+	fmt.Fprintln(outputBuffer, "======================= SNAPSHOT =======================")
+	switch cmd.action {
+	case "create":
+		err = crearSnapshot(sesion, cmd, part, path, directorio, outputBuffer)
+	case "list":
+		err = listarSnapshots(cmd, directorio, outputBuffer)
+	case "restore":
+		err = restaurarSnapshot(sesion, cmd, part, path, directorio, outputBuffer)
+	case "delete":
+		if _, err = estructuras.BuscarSnapshot(directorio, cmd.name); err == nil {
+			err = estructuras.EliminarSnapshot(directorio, cmd.name)
+		}
+		if err == nil {
+			fmt.Fprintf(outputBuffer, "Snapshot '%s' eliminado.\n", cmd.name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(outputBuffer, "==================== FIN SNAPSHOT ====================")
+	return nil
+}
+
+func crearSnapshot(sesion *global.Sesion, cmd *SnapshotCmd, part *estructuras.Partition, path, directorio string, outputBuffer *bytes.Buffer) error {
+	file, err := global.Discos.Abrir(path, os.O_RDONLY)
+	if err != nil {
+		return fmt.Errorf("error abriendo el disco: %w", err)
+	}
+	defer global.Discos.Cerrar(file)
+
+	var sb estructuras.Superbloque
+	if err := sb.Decodificar(file, int64(part.Part_start)); err != nil {
+		return fmt.Errorf("error leyendo el superbloque: %w", err)
+	}
+	if !sb.Formateado() {
+		return errors.New("la partición no tiene un sistema de archivos; formatéela con mkfs antes de crear un snapshot")
+	}
+	tamano := sb.TamanoSnapshot(part.Part_start)
+	if tamano <= 0 || tamano > int64(part.Part_s) {
+		return errors.New("el superbloque indica un tamaño fuera de la partición; revísela con fsck")
+	}
+
+	usuario := "-"
+	if sesion.EstaLogueado() {
+		usuario = sesion.Usuario.Name
+	}
+
+	snapshot, err := estructuras.CrearSnapshot(file, directorio, estructuras.Snapshot{
+		Nombre:    cmd.name,
+		Particion: strings.Trim(string(part.Part_name[:]), "\x00 "),
+		Id:        cmd.id,
+		Fecha:     time.Now(),
+		Usuario:   usuario,
+		Inicio:    int64(part.Part_start),
+		Tamano:    tamano,
+		Sistema:   fmt.Sprintf("EXT%d", sb.TipoSistema()),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outputBuffer, "Snapshot '%s' creado: %d bytes de la partición %s (%s) guardados en %s\n",
+		snapshot.Nombre, snapshot.Tamano, snapshot.Particion, snapshot.Sistema, directorio)
+	return nil
+}
+
+func listarSnapshots(cmd *SnapshotCmd, directorio string, outputBuffer *bytes.Buffer) error {
+	snapshots, err := estructuras.ListarSnapshots(directorio)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Fprintf(outputBuffer, "La partición %s no tiene snapshots.\n", cmd.id)
+		return nil
+	}
+
+	fmt.Fprintf(outputBuffer, "%-20s | %-19s | %-10s | %-12s | %s\n", "NOMBRE", "FECHA", "USUARIO", "TAMAÑO", "SISTEMA")
+	fmt.Fprintln(outputBuffer, strings.Repeat("-", 78))
+	for _, s := range snapshots {
+		fmt.Fprintf(outputBuffer, "%-20s | %-19s | %-10s | %-12d | %s\n", s.Nombre, s.Fecha.Format("2006-01-02 15:04:05"), s.Usuario, s.Tamano, s.Sistema)
+	}
+	return nil
+}
+
+func restaurarSnapshot(sesion *global.Sesion, cmd *SnapshotCmd, part *estructuras.Partition, path, directorio string, outputBuffer *bytes.Buffer) error {
+	snapshot, err := estructuras.BuscarSnapshot(directorio, cmd.name)
+	if err != nil {
+		return err
+	}
+	if global.ParticionEnUso(sesion, cmd.id) {
+		return fmt.Errorf("hay una sesión activa en la partición %s; cierre la sesión antes de restaurar el snapshot", cmd.id)
+	}
+	// la partición pudo haberse eliminado y vuelto a crear en otra posición o con otro tamaño
+	if snapshot.Inicio != int64(part.Part_start) || snapshot.Tamano > int64(part.Part_s) {
+		return fmt.Errorf("el snapshot '%s' no corresponde a la posición ni al tamaño actual de la partición", cmd.name)
+	}
+
+	if err := estructuras.RestaurarSnapshot(path, directorio, snapshot); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outputBuffer, "Snapshot '%s' del %s restaurado en la partición %s.\n",
+		snapshot.Nombre, snapshot.Fecha.Format("2006-01-02 15:04:05"), cmd.id)
+	return nil
+}
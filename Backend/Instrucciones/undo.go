@@ -0,0 +1,112 @@
+package instrucciones
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	estructuras "godisk/Estructuras"
+	global "godisk/Global"
+	utilidades "godisk/Utilidades"
+	"os"
+	"strconv"
+)
+
+type Undo struct {
+	id       string
+	cantidad int
+}
+
+func AnalizarUndo(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &Undo{cantidad: 1}
+
+	params, err := utilidades.ParsearParametros(tokens, []string{"id", "n"}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
+
+		switch key {
+		case "id":
+			cmd.id = value
+		case "n":
+			cantidad, err := strconv.Atoi(value)
+			if err != nil || cantidad < 1 {
+				return "", errors.New("n debe ser un entero mayor que cero")
+			}
+			cmd.cantidad = cantidad
+		}
+	}
+
+	if cmd.id == "" {
+		return "", errors.New("faltan parámetros requeridos: -id")
+	}
+
+	err = commandUndo(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+func commandUndo(cmd *Undo, outputBuffer *bytes.Buffer) error {
+	sb, part, path, err := global.GetMountedPartitionSuperblock(cmd.id)
+	if err != nil {
+		return fmt.Errorf("error obteniendo la partición %s: %w", cmd.id, err)
+	}
+	if !sb.Formateado() {
+		return errors.New("la partición no tiene un sistema de archivos")
+	}
+	if sb.TipoSistema() != 3 {
+		return errors.New("la partición no es EXT3; undo necesita el journal")
+	}
+
+	file, err := global.Discos.Abrir(path, os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("error abriendo el disco: %w", err)
+	}
+	defer global.Discos.Cerrar(file)
+
+	plan, err := estructuras.PlanificarDeshacer(file, sb, cmd.cantidad)
+	if err != nil {
+		return err
+	}
+
+	errDeshacer := estructuras.Deshacer(file, sb, plan)
+	if err := sb.Codificar(file, int64(part.Part_start)); err != nil {
+		return fmt.Errorf("error al actualizar el superbloque: %v", err)
+	}
+	if errDeshacer != nil {
+		return fmt.Errorf("%v; revise la partición con fsck", errDeshacer)
+	}
+
+	fmt.Fprintln(outputBuffer, "======================= UNDO =======================")
+	fmt.Fprintf(outputBuffer, "%-4s | %-12s | %-30s | %s\n", "TX", "OPERACIÓN", "RUTA", "DESHECHA CON")
+	for _, tx := range plan {
+		descripcion := tx.Principal.Operacion
+		if tx.Operaciones > 1 {
+			descripcion = fmt.Sprintf("%s (+%d)", descripcion, tx.Operaciones-1)
+		}
+		if len(tx.Inversas) == 0 {
+			fmt.Fprintf(outputBuffer, "%-4d | %-12s | %-30s | %s\n", tx.Transaccion, descripcion, tx.Principal.Ruta, "-")
+		}
+		for i, inversa := range tx.Inversas {
+			aplicada := inversa.Operacion + " " + inversa.Ruta
+			if inversa.Operacion == "rename" || inversa.Operacion == "move" {
+				aplicada += " " + inversa.Contenido
+			}
+			if i == 0 {
+				fmt.Fprintf(outputBuffer, "%-4d | %-12s | %-30s | %s\n", tx.Transaccion, descripcion, tx.Principal.Ruta, aplicada)
+			} else {
+				fmt.Fprintf(outputBuffer, "%-4s | %-12s | %-30s | %s\n", "", "", "", aplicada)
+			}
+		}
+	}
+	fmt.Fprintf(outputBuffer, "Operaciones del journal deshechas: %d\n", len(plan))
+	fmt.Fprintln(outputBuffer, "====================================================")
+	return nil
+}
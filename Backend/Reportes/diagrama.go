@@ -0,0 +1,761 @@
+package reportes
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Distancias del diagrama en puntos (1/72 de pulgada), las mismas unidades que usa Graphviz
+const (
+	margenLienzo      = 8.0
+	separacionNodos   = 24.0
+	separacionNiveles = 40.0
+	tamanoFuenteDot   = 14.0
+	largoFlecha       = 10.0
+)
+
+type tipoElemento int
+
+const (
+	elementoRectangulo tipoElemento = iota
+	elementoElipse
+	elementoTexto
+	elementoLinea
+	elementoPoligono
+)
+
+type punto struct{ x, y float64 }
+
+// elemento es una primitiva del lienzo; la dibujan igual el escritor SVG y el rasterizador PNG.
+// Los rectángulos y elipses usan x, y, ancho y alto; el texto usa x, y como línea base.
+type elemento struct {
+	tipo        tipoElemento
+	x, y        float64
+	ancho, alto float64
+	puntos      []punto
+	relleno     string
+	trazo       string
+	discontinua bool
+	texto       string
+	negrita     bool
+	tamano      float64
+	ancla       string
+}
+
+type lienzo struct {
+	ancho, alto float64
+	fondo       string
+	elementos   []elemento
+}
+
+func (l *lienzo) rectangulo(x, y, ancho, alto float64, relleno, trazo string) {
+	l.elementos = append(l.elementos, elemento{tipo: elementoRectangulo, x: x, y: y, ancho: ancho, alto: alto, relleno: relleno, trazo: trazo})
+}
+
+// figura es un nodo ya medido; dibujar lo coloca con la esquina superior izquierda en x, y
+type figura struct {
+	ancho, alto float64
+	dibujar     func(l *lienzo, x, y float64)
+}
+
+type lineaTexto struct {
+	texto string
+	ancla string
+}
+
+// separarLineasDot reparte el texto en líneas con la alineación de cada una, según los escapes
+// de DOT: \n centra, \l alinea a la izquierda y \r a la derecha
+func separarLineasDot(texto, id string) []lineaTexto {
+	var lineas []lineaTexto
+	var actual strings.Builder
+	runas := []rune(texto)
+	for i := 0; i < len(runas); i++ {
+		if runas[i] != '\\' || i+1 == len(runas) {
+			actual.WriteRune(runas[i])
+			continue
+		}
+		i++
+		switch runas[i] {
+		case 'n', 'l', 'r':
+			ancla := map[rune]string{'n': "middle", 'l': "start", 'r': "end"}[runas[i]]
+			lineas = append(lineas, lineaTexto{actual.String(), ancla})
+			actual.Reset()
+		case 'N':
+			actual.WriteString(id)
+		default:
+			actual.WriteRune(runas[i])
+		}
+	}
+	if actual.Len() > 0 || len(lineas) == 0 {
+		lineas = append(lineas, lineaTexto{actual.String(), "middle"})
+	}
+	return lineas
+}
+
+// dibujarLineas escribe las líneas dentro de la caja indicada respetando la alineación
+func dibujarLineas(l *lienzo, m *medidorTexto, lineas []lineaTexto, negrita bool, tamano, x, y, ancho, alto, margen float64) {
+	altoTexto := float64(len(lineas)) * m.altoLinea(tamano)
+	base := y + (alto-altoTexto)/2 + m.ascenso(tamano)
+	for i, linea := range lineas {
+		e := elemento{tipo: elementoTexto, texto: linea.texto, negrita: negrita, tamano: tamano, ancla: linea.ancla,
+			y: base + float64(i)*m.altoLinea(tamano), relleno: "black"}
+		switch linea.ancla {
+		case "start":
+			e.x = x + margen
+		case "end":
+			e.x = x + ancho - margen
+		default:
+			e.x = x + ancho/2
+		}
+		l.elementos = append(l.elementos, e)
+	}
+}
+
+func anchoLineas(m *medidorTexto, lineas []lineaTexto, negrita bool, tamano float64) float64 {
+	ancho := 0.0
+	for _, linea := range lineas {
+		ancho = math.Max(ancho, m.anchoTexto(linea.texto, negrita, tamano))
+	}
+	return ancho
+}
+
+func tamanoFuente(n *nodoDot) float64 {
+	if tamano, err := strconv.ParseFloat(n.atributo("fontsize", ""), 64); err == nil && tamano > 0 {
+		return tamano
+	}
+	return tamanoFuenteDot
+}
+
+// figuraNodo mide el nodo según su forma y su etiqueta
+func figuraNodo(n *nodoDot, m *medidorTexto, horizontal bool) figura {
+	if n.atributo("style", "") == "invis" {
+		return figura{dibujar: func(*lienzo, float64, float64) {}}
+	}
+
+	etiqueta := n.atributo("label", `\N`)
+	forma := strings.ToLower(n.atributo("shape", "ellipse"))
+	tamano := tamanoFuente(n)
+
+	if n.html {
+		return figuraHTML(etiqueta, m, tamano)
+	}
+	if forma == "record" || forma == "mrecord" {
+		return figuraRecord(n, etiqueta, m, tamano, horizontal)
+	}
+
+	lineas := separarLineasDot(etiqueta, n.id)
+	anchoTexto := anchoLineas(m, lineas, false, tamano)
+	altoTexto := float64(len(lineas)) * m.altoLinea(tamano)
+
+	relleno := ""
+	if strings.Contains(n.atributo("style", ""), "filled") {
+		relleno = n.atributo("fillcolor", n.atributo("color", "lightgrey"))
+	}
+	trazo := n.atributo("color", "black")
+
+	switch forma {
+	case "plaintext", "plain", "none":
+		return figura{ancho: anchoTexto + 16, alto: altoTexto + 8, dibujar: func(l *lienzo, x, y float64) {
+			dibujarLineas(l, m, lineas, false, tamano, x, y, anchoTexto+16, altoTexto+8, 8)
+		}}
+	case "box", "rect", "rectangle", "square":
+		ancho, alto := math.Max(anchoTexto+16, 54), math.Max(altoTexto+8, 36)
+		return figura{ancho: ancho, alto: alto, dibujar: func(l *lienzo, x, y float64) {
+			l.rectangulo(x, y, ancho, alto, relleno, trazo)
+			dibujarLineas(l, m, lineas, false, tamano, x, y, ancho, alto, 8)
+		}}
+	default:
+		ancho, alto := math.Max((anchoTexto+16)*math.Sqrt2, 54), math.Max((altoTexto+8)*math.Sqrt2, 36)
+		return figura{ancho: ancho, alto: alto, dibujar: func(l *lienzo, x, y float64) {
+			l.elementos = append(l.elementos, elemento{tipo: elementoElipse, x: x, y: y, ancho: ancho, alto: alto, relleno: relleno, trazo: trazo})
+			dibujarLineas(l, m, lineas, false, tamano, x, y, ancho, alto, 8)
+		}}
+	}
+}
+
+// campoRecord es un campo de una etiqueta record: texto o una lista de campos que se apila en
+// la dirección contraria a la del padre
+type campoRecord struct {
+	lineas      []lineaTexto
+	hijos       []*campoRecord
+	ancho, alto float64
+}
+
+func analizarCamposRecord(runas []rune, pos *int, id string) []*campoRecord {
+	var campos []*campoRecord
+	for *pos <= len(runas) {
+		campo := &campoRecord{}
+		for *pos < len(runas) && runas[*pos] == ' ' {
+			*pos++
+		}
+		if *pos < len(runas) && runas[*pos] == '{' {
+			*pos++
+			campo.hijos = analizarCamposRecord(runas, pos, id)
+			*pos++
+			for *pos < len(runas) && runas[*pos] != '|' && runas[*pos] != '}' {
+				*pos++
+			}
+		} else {
+			var texto strings.Builder
+			for ; *pos < len(runas) && runas[*pos] != '|' && runas[*pos] != '}'; *pos++ {
+				// los escapes de línea se conservan para separarLineasDot
+				if runas[*pos] == '\\' && *pos+1 < len(runas) {
+					*pos++
+					if !strings.ContainsRune("{}|<> ", runas[*pos]) {
+						texto.WriteRune('\\')
+					}
+				}
+				texto.WriteRune(runas[*pos])
+			}
+			campo.lineas = separarLineasDot(strings.TrimSpace(texto.String()), id)
+		}
+		campos = append(campos, campo)
+
+		if *pos >= len(runas) || runas[*pos] == '}' {
+			return campos
+		}
+		*pos++
+	}
+	return campos
+}
+
+func medirCampoRecord(c *campoRecord, m *medidorTexto, tamano float64, horizontal bool) {
+	if c.hijos == nil {
+		c.ancho = anchoLineas(m, c.lineas, false, tamano) + 16
+		c.alto = float64(len(c.lineas))*m.altoLinea(tamano) + 8
+		return
+	}
+	c.ancho, c.alto = 0, 0
+	for _, hijo := range c.hijos {
+		medirCampoRecord(hijo, m, tamano, !horizontal)
+		if horizontal {
+			c.ancho += hijo.ancho
+			c.alto = math.Max(c.alto, hijo.alto)
+		} else {
+			c.alto += hijo.alto
+			c.ancho = math.Max(c.ancho, hijo.ancho)
+		}
+	}
+}
+
+// dibujarCampoRecord reparte el espacio sobrante entre los hijos y dibuja cada campo de texto
+func dibujarCampoRecord(l *lienzo, m *medidorTexto, c *campoRecord, tamano float64, horizontal bool, x, y, ancho, alto float64, relleno, trazo string) {
+	if c.hijos == nil {
+		l.rectangulo(x, y, ancho, alto, relleno, trazo)
+		dibujarLineas(l, m, c.lineas, false, tamano, x, y, ancho, alto, 8)
+		return
+	}
+	sobrante := alto - c.alto
+	if horizontal {
+		sobrante = ancho - c.ancho
+	}
+	extra := sobrante / float64(len(c.hijos))
+	for _, hijo := range c.hijos {
+		if horizontal {
+			dibujarCampoRecord(l, m, hijo, tamano, false, x, y, hijo.ancho+extra, alto, relleno, trazo)
+			x += hijo.ancho + extra
+		} else {
+			dibujarCampoRecord(l, m, hijo, tamano, true, x, y, ancho, hijo.alto+extra, relleno, trazo)
+			y += hijo.alto + extra
+		}
+	}
+}
+
+func figuraRecord(n *nodoDot, etiqueta string, m *medidorTexto, tamano float64, horizontal bool) figura {
+	pos := 0
+	raiz := &campoRecord{hijos: analizarCamposRecord([]rune(etiqueta), &pos, n.id)}
+	medirCampoRecord(raiz, m, tamano, horizontal)
+
+	relleno := ""
+	if strings.Contains(n.atributo("style", ""), "filled") {
+		relleno = n.atributo("fillcolor", "lightgrey")
+	}
+	trazo := n.atributo("color", "black")
+	return figura{ancho: raiz.ancho, alto: raiz.alto, dibujar: func(l *lienzo, x, y float64) {
+		dibujarCampoRecord(l, m, raiz, tamano, horizontal, x, y, raiz.ancho, raiz.alto, relleno, trazo)
+	}}
+}
+
+type celdaHTML struct {
+	lineas   []lineaTexto
+	negrita  bool
+	columnas int
+	fondo    string
+	ancla    string
+	ancho    float64
+	alto     float64
+}
+
+type tablaHTML struct {
+	filas      [][]*celdaHTML
+	fondo      string
+	relleno    float64
+	espaciado  float64
+	borde      float64
+	bordeCelda float64
+}
+
+func atributoXML(inicio xml.StartElement, nombre string) (string, bool) {
+	for _, atributo := range inicio.Attr {
+		if strings.EqualFold(atributo.Name.Local, nombre) {
+			return atributo.Value, true
+		}
+	}
+	return "", false
+}
+
+func numeroXML(inicio xml.StartElement, nombre string, defecto float64) float64 {
+	if valor, ok := atributoXML(inicio, nombre); ok {
+		if numero, err := strconv.ParseFloat(valor, 64); err == nil {
+			return numero
+		}
+	}
+	return defecto
+}
+
+// analizarTablaHTML lee una etiqueta HTML de Graphviz. Si no contiene una tabla, el texto se
+// devuelve como una tabla de una celda sin bordes.
+func analizarTablaHTML(etiqueta string) *tablaHTML {
+	decodificador := xml.NewDecoder(strings.NewReader("<etiqueta>" + etiqueta + "</etiqueta>"))
+	decodificador.Strict = false
+	decodificador.AutoClose = xml.HTMLAutoClose
+	decodificador.Entity = xml.HTMLEntity
+
+	tabla := &tablaHTML{relleno: 2, espaciado: 2, borde: 1, bordeCelda: -1}
+	hayTabla := false
+	suelta := &celdaHTML{columnas: 1, ancla: "middle"}
+	var celda *celdaHTML
+	texto := ""
+	negrita := 0
+
+	cerrarLinea := func(destino *celdaHTML) {
+		destino.lineas = append(destino.lineas, lineaTexto{strings.TrimSpace(texto), destino.ancla})
+		texto = ""
+	}
+
+	for {
+		token, err := decodificador.Token()
+		if err == io.EOF || err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "table":
+				if !hayTabla {
+					hayTabla = true
+					tabla.fondo, _ = atributoXML(t, "bgcolor")
+					tabla.relleno = numeroXML(t, "cellpadding", tabla.relleno)
+					tabla.espaciado = numeroXML(t, "cellspacing", tabla.espaciado)
+					tabla.borde = numeroXML(t, "border", tabla.borde)
+					tabla.bordeCelda = numeroXML(t, "cellborder", tabla.bordeCelda)
+				}
+			case "tr":
+				tabla.filas = append(tabla.filas, nil)
+			case "td":
+				celda = &celdaHTML{columnas: int(numeroXML(t, "colspan", 1)), ancla: "middle"}
+				celda.fondo, _ = atributoXML(t, "bgcolor")
+				if alineacion, _ := atributoXML(t, "align"); strings.EqualFold(alineacion, "left") {
+					celda.ancla = "start"
+				} else if strings.EqualFold(alineacion, "right") {
+					celda.ancla = "end"
+				}
+				if celda.columnas < 1 {
+					celda.columnas = 1
+				}
+				texto = ""
+			case "b":
+				negrita++
+			case "br":
+				if celda != nil {
+					cerrarLinea(celda)
+				} else {
+					cerrarLinea(suelta)
+				}
+			}
+		case xml.EndElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "td":
+				if celda != nil && len(tabla.filas) > 0 {
+					cerrarLinea(celda)
+					tabla.filas[len(tabla.filas)-1] = append(tabla.filas[len(tabla.filas)-1], celda)
+				}
+				celda = nil
+			case "b":
+				negrita--
+			}
+		case xml.CharData:
+			if celda != nil || !hayTabla {
+				texto += string(t)
+				if negrita > 0 {
+					if celda != nil {
+						celda.negrita = true
+					} else {
+						suelta.negrita = true
+					}
+				}
+			}
+		}
+	}
+
+	if !hayTabla {
+		cerrarLinea(suelta)
+		return &tablaHTML{filas: [][]*celdaHTML{{suelta}}, relleno: 2}
+	}
+	if tabla.bordeCelda < 0 {
+		tabla.bordeCelda = tabla.borde
+	}
+	return tabla
+}
+
+func figuraHTML(etiqueta string, m *medidorTexto, tamano float64) figura {
+	tabla := analizarTablaHTML(etiqueta)
+
+	columnas := 0
+	for _, fila := range tabla.filas {
+		total := 0
+		for _, celda := range fila {
+			total += celda.columnas
+		}
+		columnas = max(columnas, total)
+	}
+	if columnas == 0 {
+		return figura{dibujar: func(*lienzo, float64, float64) {}}
+	}
+
+	anchos := make([]float64, columnas)
+	altos := make([]float64, len(tabla.filas))
+	interior := 2 * (tabla.relleno + tabla.bordeCelda)
+	for i, fila := range tabla.filas {
+		for _, celda := range fila {
+			celda.ancho = anchoLineas(m, celda.lineas, celda.negrita, tamano) + interior
+			celda.alto = float64(len(celda.lineas))*m.altoLinea(tamano) + interior
+			altos[i] = math.Max(altos[i], celda.alto)
+		}
+	}
+
+	// primero las celdas de una columna y después las que abarcan varias, repartiendo lo que falte
+	for _, varias := range []bool{false, true} {
+		for _, fila := range tabla.filas {
+			columna := 0
+			for _, celda := range fila {
+				fin := min(columna+celda.columnas, columnas)
+				if (celda.columnas > 1) == varias {
+					disponible := float64(fin-columna-1) * tabla.espaciado
+					for c := columna; c < fin; c++ {
+						disponible += anchos[c]
+					}
+					if falta := celda.ancho - disponible; falta > 0 {
+						for c := columna; c < fin; c++ {
+							anchos[c] += falta / float64(fin-columna)
+						}
+					}
+				}
+				columna = fin
+			}
+		}
+	}
+
+	ancho := 2*tabla.borde + float64(columnas+1)*tabla.espaciado
+	for _, a := range anchos {
+		ancho += a
+	}
+	alto := 2*tabla.borde + float64(len(altos)+1)*tabla.espaciado
+	for _, a := range altos {
+		alto += a
+	}
+
+	return figura{ancho: ancho, alto: alto, dibujar: func(l *lienzo, x, y float64) {
+		trazoTabla := ""
+		if tabla.borde > 0 {
+			trazoTabla = "black"
+		}
+		if tabla.fondo != "" || trazoTabla != "" {
+			l.rectangulo(x, y, ancho, alto, tabla.fondo, trazoTabla)
+		}
+		trazoCelda := ""
+		if tabla.bordeCelda > 0 {
+			trazoCelda = "black"
+		}
+
+		filaY := y + tabla.borde + tabla.espaciado
+		for i, fila := range tabla.filas {
+			celdaX := x + tabla.borde + tabla.espaciado
+			columna := 0
+			for _, celda := range fila {
+				fin := min(columna+celda.columnas, columnas)
+				anchoCelda := float64(fin-columna-1) * tabla.espaciado
+				for c := columna; c < fin; c++ {
+					anchoCelda += anchos[c]
+				}
+				if celda.fondo != "" || trazoCelda != "" {
+					l.rectangulo(celdaX, filaY, anchoCelda, altos[i], celda.fondo, trazoCelda)
+				}
+				dibujarLineas(l, m, celda.lineas, celda.negrita, tamano, celdaX, filaY, anchoCelda, altos[i], tabla.relleno+tabla.bordeCelda)
+				celdaX += anchoCelda + tabla.espaciado
+				columna = fin
+			}
+			filaY += altos[i] + tabla.espaciado
+		}
+	}}
+}
+
+// diagramarGrafo ubica los nodos por niveles, como hace dot: los ciclos se rompen invirtiendo
+// las aristas de retorno, cada nodo queda un nivel después del más lejano de sus predecesores
+// y el orden dentro de cada nivel se ajusta con el baricentro de sus vecinos
+func diagramarGrafo(g *grafoDot, m *medidorTexto) *lienzo {
+	horizontal := strings.EqualFold(g.atributos["rankdir"], "LR") || strings.EqualFold(g.atributos["rankdir"], "RL")
+
+	figuras := make(map[*nodoDot]figura, len(g.nodos))
+	for _, n := range g.nodos {
+		figuras[n] = figuraNodo(n, m, !horizontal)
+	}
+
+	salientes := make(map[*nodoDot][]*nodoDot)
+	for _, a := range g.aristas {
+		if a.origen != a.destino {
+			salientes[a.origen] = append(salientes[a.origen], a.destino)
+		}
+	}
+
+	// búsqueda en profundidad: orden de descubrimiento y orden topológico sin aristas de retorno
+	const (
+		sinVisitar = iota
+		enPila
+		terminado
+	)
+	estado := make(map[*nodoDot]int)
+	descubrimiento := make(map[*nodoDot]int)
+	retorno := make(map[[2]*nodoDot]bool)
+	var topologico []*nodoDot
+	var visitar func(n *nodoDot)
+	visitar = func(n *nodoDot) {
+		estado[n] = enPila
+		descubrimiento[n] = len(descubrimiento)
+		for _, destino := range salientes[n] {
+			switch estado[destino] {
+			case sinVisitar:
+				visitar(destino)
+			case enPila:
+				retorno[[2]*nodoDot{n, destino}] = true
+			}
+		}
+		estado[n] = terminado
+		topologico = append(topologico, n)
+	}
+	for _, n := range g.nodos {
+		if estado[n] == sinVisitar {
+			visitar(n)
+		}
+	}
+
+	nivel := make(map[*nodoDot]int)
+	for i := len(topologico) - 1; i >= 0; i-- {
+		n := topologico[i]
+		for _, destino := range salientes[n] {
+			if !retorno[[2]*nodoDot{n, destino}] && nivel[destino] < nivel[n]+1 {
+				nivel[destino] = nivel[n] + 1
+			}
+		}
+	}
+
+	maxNivel := 0
+	for _, n := range g.nodos {
+		maxNivel = max(maxNivel, nivel[n])
+	}
+	niveles := make([][]*nodoDot, maxNivel+1)
+	for _, n := range g.nodos {
+		niveles[nivel[n]] = append(niveles[nivel[n]], n)
+	}
+
+	vecinos := make(map[*nodoDot][]*nodoDot)
+	for _, a := range g.aristas {
+		if a.origen != a.destino {
+			vecinos[a.origen] = append(vecinos[a.origen], a.destino)
+			vecinos[a.destino] = append(vecinos[a.destino], a.origen)
+		}
+	}
+
+	posicion := make(map[*nodoDot]float64)
+	for _, nodos := range niveles {
+		sort.SliceStable(nodos, func(i, j int) bool { return descubrimiento[nodos[i]] < descubrimiento[nodos[j]] })
+		for i, n := range nodos {
+			posicion[n] = float64(i)
+		}
+	}
+	ordenarNivel := func(nodos []*nodoDot, referencia int) {
+		baricentro := make(map[*nodoDot]float64, len(nodos))
+		for _, n := range nodos {
+			suma, cantidad := 0.0, 0
+			for _, v := range vecinos[n] {
+				if nivel[v] == referencia {
+					suma += posicion[v]
+					cantidad++
+				}
+			}
+			baricentro[n] = posicion[n]
+			if cantidad > 0 {
+				baricentro[n] = suma / float64(cantidad)
+			}
+		}
+		sort.SliceStable(nodos, func(i, j int) bool { return baricentro[nodos[i]] < baricentro[nodos[j]] })
+		for i, n := range nodos {
+			posicion[n] = float64(i)
+		}
+	}
+	for iteracion := 0; iteracion < 4; iteracion++ {
+		for i := 1; i < len(niveles); i++ {
+			ordenarNivel(niveles[i], i-1)
+		}
+		for i := len(niveles) - 2; i >= 0; i-- {
+			ordenarNivel(niveles[i], i+1)
+		}
+	}
+
+	// medidas sobre el eje de los niveles y sobre el eje transversal
+	largo := func(n *nodoDot) float64 {
+		if horizontal {
+			return figuras[n].ancho
+		}
+		return figuras[n].alto
+	}
+	transversal := func(n *nodoDot) float64 {
+		if horizontal {
+			return figuras[n].alto
+		}
+		return figuras[n].ancho
+	}
+
+	centro := make(map[*nodoDot]float64)
+	inicioNivel := make([]float64, len(niveles))
+	grosorNivel := make([]float64, len(niveles))
+	acumulado := 0.0
+	for i, nodos := range niveles {
+		for _, n := range nodos {
+			grosorNivel[i] = math.Max(grosorNivel[i], largo(n))
+		}
+		inicioNivel[i] = acumulado
+		acumulado += grosorNivel[i] + separacionNiveles
+
+		// cada nodo busca el promedio de sus vecinos ya ubicados sin pisar al anterior
+		fin := math.Inf(-1)
+		desplazamiento, conDeseo := 0.0, 0
+		for _, n := range nodos {
+			suma, cantidad := 0.0, 0
+			for _, v := range vecinos[n] {
+				if nivel[v] < i {
+					suma += centro[v]
+					cantidad++
+				}
+			}
+			inicio := fin + separacionNodos
+			if math.IsInf(fin, -1) {
+				inicio = 0
+			}
+			if cantidad > 0 {
+				deseado := suma/float64(cantidad) - transversal(n)/2
+				if deseado > inicio || math.IsInf(fin, -1) {
+					inicio = deseado
+				}
+				desplazamiento += inicio - deseado
+				conDeseo++
+			}
+			centro[n] = inicio + transversal(n)/2
+			fin = inicio + transversal(n)
+		}
+		if conDeseo > 0 {
+			for _, n := range nodos {
+				centro[n] -= desplazamiento / float64(conDeseo)
+			}
+		}
+	}
+
+	minimo := math.Inf(1)
+	for _, n := range g.nodos {
+		minimo = math.Min(minimo, centro[n]-transversal(n)/2)
+	}
+
+	// esquinas de cada nodo en el lienzo
+	posiciones := make(map[*nodoDot]punto, len(g.nodos))
+	l := &lienzo{fondo: g.atributos["bgcolor"]}
+	for _, n := range g.nodos {
+		i := nivel[n]
+		a := margenLienzo + centro[n] - minimo - transversal(n)/2
+		b := margenLienzo + inicioNivel[i] + (grosorNivel[i]-largo(n))/2
+		if horizontal {
+			posiciones[n] = punto{b, a}
+		} else {
+			posiciones[n] = punto{a, b}
+		}
+		l.ancho = math.Max(l.ancho, posiciones[n].x+figuras[n].ancho+margenLienzo)
+		l.alto = math.Max(l.alto, posiciones[n].y+figuras[n].alto+margenLienzo)
+	}
+
+	// las aristas van debajo de los nodos
+	for _, a := range g.aristas {
+		dibujarArista(l, a, posiciones, figuras)
+	}
+	for _, n := range g.nodos {
+		figuras[n].dibujar(l, posiciones[n].x, posiciones[n].y)
+	}
+	return l
+}
+
+// recortarEnCaja devuelve el punto donde el segmento que sale del centro de la caja hacia
+// destino cruza su borde
+func recortarEnCaja(centro, destino punto, ancho, alto float64) punto {
+	dx, dy := destino.x-centro.x, destino.y-centro.y
+	if ancho == 0 || alto == 0 || (dx == 0 && dy == 0) {
+		return centro
+	}
+	t := math.Inf(1)
+	if dx != 0 {
+		t = math.Min(t, (ancho/2)/math.Abs(dx))
+	}
+	if dy != 0 {
+		t = math.Min(t, (alto/2)/math.Abs(dy))
+	}
+	t = math.Min(t, 1)
+	return punto{centro.x + dx*t, centro.y + dy*t}
+}
+
+func dibujarArista(l *lienzo, a *aristaDot, posiciones map[*nodoDot]punto, figuras map[*nodoDot]figura) {
+	estilo := a.atributo("style", "")
+	if a.origen == a.destino || estilo == "invis" {
+		return
+	}
+	fo, fd := figuras[a.origen], figuras[a.destino]
+	co := punto{posiciones[a.origen].x + fo.ancho/2, posiciones[a.origen].y + fo.alto/2}
+	cd := punto{posiciones[a.destino].x + fd.ancho/2, posiciones[a.destino].y + fd.alto/2}
+	inicio := recortarEnCaja(co, cd, fo.ancho, fo.alto)
+	fin := recortarEnCaja(cd, co, fd.ancho, fd.alto)
+
+	dx, dy := fin.x-inicio.x, fin.y-inicio.y
+	distancia := math.Hypot(dx, dy)
+	if distancia < 1 {
+		return
+	}
+	ux, uy := dx/distancia, dy/distancia
+	color := a.atributo("color", "black")
+
+	if a.atributo("arrowhead", "normal") != "none" {
+		escala := 1.0
+		if valor, err := strconv.ParseFloat(a.atributo("arrowsize", "1"), 64); err == nil && valor > 0 {
+			escala = valor
+		}
+		flecha := math.Min(largoFlecha*escala, distancia)
+		base := punto{fin.x - ux*flecha, fin.y - uy*flecha}
+		mitad := flecha * 0.35
+		l.elementos = append(l.elementos, elemento{tipo: elementoPoligono, relleno: color, puntos: []punto{
+			fin, {base.x - uy*mitad, base.y + ux*mitad}, {base.x + uy*mitad, base.y - ux*mitad},
+		}})
+		fin = base
+	}
+
+	l.elementos = append(l.elementos, elemento{tipo: elementoLinea, trazo: color,
+		discontinua: estilo == "dashed" || estilo == "dotted", puntos: []punto{inicio, fin}})
+}
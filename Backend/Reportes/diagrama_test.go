@@ -0,0 +1,174 @@
+package reportes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSepararLineasDot(t *testing.T) {
+	casos := []struct {
+		texto  string
+		lineas []lineaTexto
+	}{
+		{"", []lineaTexto{{"", "middle"}}},
+		{"hola", []lineaTexto{{"hola", "middle"}}},
+		{`uno\ndos`, []lineaTexto{{"uno", "middle"}, {"dos", "middle"}}},
+		{`izq\lder\rcentro\n`, []lineaTexto{{"izq", "start"}, {"der", "end"}, {"centro", "middle"}}},
+		{`nodo \N`, []lineaTexto{{"nodo n1", "middle"}}},
+		{`50\%\`, []lineaTexto{{`50%\`, "middle"}}},
+	}
+	for _, caso := range casos {
+		if lineas := separarLineasDot(caso.texto, "n1"); !reflect.DeepEqual(lineas, caso.lineas) {
+			t.Errorf("separarLineasDot(%q) = %v, se esperaba %v", caso.texto, lineas, caso.lineas)
+		}
+	}
+}
+
+// describirCampos resume los campos record: el texto de cada línea o, para un grupo, sus hijos
+func describirCampos(campos []*campoRecord) []any {
+	var resultado []any
+	for _, campo := range campos {
+		if campo.hijos != nil {
+			resultado = append(resultado, describirCampos(campo.hijos))
+			continue
+		}
+		var textos []string
+		for _, linea := range campo.lineas {
+			textos = append(textos, linea.texto)
+		}
+		resultado = append(resultado, textos)
+	}
+	return resultado
+}
+
+func TestAnalizarCamposRecord(t *testing.T) {
+	casos := []struct {
+		etiqueta string
+		campos   []any
+	}{
+		{"MBR", []any{[]string{"MBR"}}},
+		{"a|b| c ", []any{[]string{"a"}, []string{"b"}, []string{"c"}}},
+		{
+			`{MBR}|{Primaria p1\n25.00%}`,
+			[]any{[]any{[]string{"MBR"}}, []any{[]string{"Primaria p1", "25.00%"}}},
+		},
+		{
+			`{Extendida|{EBR|Lógica|Libre}}|x`,
+			[]any{[]any{[]string{"Extendida"}, []any{[]string{"EBR"}, []string{"Lógica"}, []string{"Libre"}}}, []string{"x"}},
+		},
+		{`<f0> campo\|con\{escapes\}`, []any{[]string{"<f0> campo|con{escapes}"}}},
+		{"a||b", []any{[]string{"a"}, []string{""}, []string{"b"}}},
+	}
+	for _, caso := range casos {
+		pos := 0
+		campos := describirCampos(analizarCamposRecord([]rune(caso.etiqueta), &pos, "n"))
+		if !reflect.DeepEqual(campos, caso.campos) {
+			t.Errorf("analizarCamposRecord(%q) = %v, se esperaba %v", caso.etiqueta, campos, caso.campos)
+		}
+	}
+}
+
+func TestAnalizarTablaHTML(t *testing.T) {
+	tabla := analizarTablaHTML(`
+		<table border="0" cellborder="1" cellspacing="0" cellpadding="4" bgcolor="#FFFDE7">
+			<tr><td colspan="2" bgcolor="#4CAF50"><b>INODO 1</b></td></tr>
+			<tr><td align="left">i_uid</td><td align="right">1</td></tr>
+			<tr><td>uno<br/>dos</td><td colspan="0">&lt;vacío&gt;</td></tr>
+		</table>`)
+
+	if tabla.borde != 0 || tabla.bordeCelda != 1 || tabla.espaciado != 0 || tabla.relleno != 4 || tabla.fondo != "#FFFDE7" {
+		t.Errorf("atributos de la tabla = %+v", *tabla)
+	}
+	type celdaEsperada struct {
+		lineas   []lineaTexto
+		negrita  bool
+		columnas int
+		fondo    string
+	}
+	esperadas := [][]celdaEsperada{
+		{{[]lineaTexto{{"INODO 1", "middle"}}, true, 2, "#4CAF50"}},
+		{{[]lineaTexto{{"i_uid", "start"}}, false, 1, ""}, {[]lineaTexto{{"1", "end"}}, false, 1, ""}},
+		{{[]lineaTexto{{"uno", "middle"}, {"dos", "middle"}}, false, 1, ""}, {[]lineaTexto{{"<vacío>", "middle"}}, false, 1, ""}},
+	}
+	if len(tabla.filas) != len(esperadas) {
+		t.Fatalf("%d filas, se esperaban %d", len(tabla.filas), len(esperadas))
+	}
+	for i, fila := range tabla.filas {
+		if len(fila) != len(esperadas[i]) {
+			t.Fatalf("fila %d: %d celdas, se esperaban %d", i, len(fila), len(esperadas[i]))
+		}
+		for j, celda := range fila {
+			obtenida := celdaEsperada{celda.lineas, celda.negrita, celda.columnas, celda.fondo}
+			if !reflect.DeepEqual(obtenida, esperadas[i][j]) {
+				t.Errorf("celda %d,%d = %+v, se esperaba %+v", i, j, obtenida, esperadas[i][j])
+			}
+		}
+	}
+}
+
+func TestAnalizarTablaHTMLSinTabla(t *testing.T) {
+	tabla := analizarTablaHTML("<b>título</b><br/>detalle")
+	if len(tabla.filas) != 1 || len(tabla.filas[0]) != 1 {
+		t.Fatalf("se esperaba una tabla de una celda y se obtuvo %d filas", len(tabla.filas))
+	}
+	celda := tabla.filas[0][0]
+	lineas := []lineaTexto{{"título", "middle"}, {"detalle", "middle"}}
+	if !reflect.DeepEqual(celda.lineas, lineas) || !celda.negrita || tabla.borde != 0 || tabla.bordeCelda != 0 {
+		t.Errorf("celda = %+v, tabla = %+v", *celda, *tabla)
+	}
+}
+
+func TestRecortarEnCaja(t *testing.T) {
+	centro := punto{50, 50}
+	casos := []struct {
+		destino     punto
+		ancho, alto float64
+		esperado    punto
+	}{
+		{punto{150, 50}, 40, 20, punto{70, 50}},
+		{punto{50, -50}, 40, 20, punto{50, 40}},
+		{punto{150, 150}, 40, 20, punto{60, 60}},
+		{punto{55, 50}, 40, 20, punto{55, 50}},
+		{punto{50, 50}, 40, 20, punto{50, 50}},
+		{punto{150, 50}, 0, 0, punto{50, 50}},
+	}
+	for _, caso := range casos {
+		if obtenido := recortarEnCaja(centro, caso.destino, caso.ancho, caso.alto); obtenido != caso.esperado {
+			t.Errorf("recortarEnCaja(%v, %v, %v, %v) = %v, se esperaba %v", centro, caso.destino, caso.ancho, caso.alto, obtenido, caso.esperado)
+		}
+	}
+}
+
+// La capa de cada nodo depende de sus predecesores; los ciclos no deben dejar nodos fuera
+func TestDiagramarGrafoNiveles(t *testing.T) {
+	grafo, err := analizarDot(`digraph { node [shape=box]; a -> b -> c -> a; a -> d; d -> c }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	medidor, err := nuevoMedidorTexto(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := diagramarGrafo(grafo, medidor)
+
+	alturas := map[string]float64{}
+	for _, e := range l.elementos {
+		if e.tipo == elementoTexto {
+			alturas[e.texto] = e.y
+		}
+	}
+	if len(alturas) != 4 {
+		t.Fatalf("se dibujaron %d etiquetas, se esperaban 4", len(alturas))
+	}
+	if !(alturas["a"] < alturas["b"] && alturas["a"] < alturas["d"] && alturas["b"] < alturas["c"] && alturas["d"] < alturas["c"]) {
+		t.Errorf("niveles fuera de orden: %v", alturas)
+	}
+	if alturas["b"] != alturas["d"] {
+		t.Errorf("b y d deberían compartir nivel: %v", alturas)
+	}
+	for _, e := range l.elementos {
+		if e.tipo == elementoRectangulo && (e.x < 0 || e.y < 0 || e.x+e.ancho > l.ancho || e.y+e.alto > l.alto) {
+			t.Errorf("el nodo en %v,%v se sale del lienzo %vx%v", e.x, e.y, l.ancho, l.alto)
+		}
+	}
+}
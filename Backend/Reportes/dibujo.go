@@ -0,0 +1,341 @@
+package reportes
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// Los PNG y JPG se generan a 96 ppp como los de Graphviz; si el lienzo supera maxPixelesPNG se
+// reduce la escala para no agotar la memoria del servidor
+const (
+	escalaPNG     = 96.0 / 72.0
+	maxPixelesPNG = 25_000_000
+)
+
+var (
+	cargaFuentes  sync.Once
+	fuenteNormal  *opentype.Font
+	fuenteNegrita *opentype.Font
+	errFuentes    error
+)
+
+type claveCara struct {
+	negrita bool
+	tamano  float64
+}
+
+// medidorTexto mide y dibuja texto con las fuentes Go; escala convierte puntos en píxeles. Las
+// caras de opentype no se pueden compartir entre goroutines, así que cada reporte crea el suyo.
+type medidorTexto struct {
+	escala float64
+	caras  map[claveCara]font.Face
+}
+
+func nuevoMedidorTexto(escala float64) (*medidorTexto, error) {
+	cargaFuentes.Do(func() {
+		if fuenteNormal, errFuentes = opentype.Parse(goregular.TTF); errFuentes == nil {
+			fuenteNegrita, errFuentes = opentype.Parse(gobold.TTF)
+		}
+	})
+	if errFuentes != nil {
+		return nil, fmt.Errorf("error al cargar las fuentes del renderizador: %v", errFuentes)
+	}
+	return &medidorTexto{escala: escala, caras: make(map[claveCara]font.Face)}, nil
+}
+
+func (m *medidorTexto) cara(negrita bool, tamano float64) font.Face {
+	clave := claveCara{negrita, tamano}
+	if cara, ok := m.caras[clave]; ok {
+		return cara
+	}
+	fuente := fuenteNormal
+	if negrita {
+		fuente = fuenteNegrita
+	}
+	cara, err := opentype.NewFace(fuente, &opentype.FaceOptions{Size: tamano, DPI: 72 * m.escala, Hinting: font.HintingNone})
+	if err != nil {
+		cara = basicfont.Face7x13
+	}
+	m.caras[clave] = cara
+	return cara
+}
+
+func (m *medidorTexto) anchoTexto(texto string, negrita bool, tamano float64) float64 {
+	return float64(font.MeasureString(m.cara(negrita, tamano), texto)) / 64 / m.escala
+}
+
+func (m *medidorTexto) altoLinea(tamano float64) float64 {
+	return float64(m.cara(false, tamano).Metrics().Height) / 64 / m.escala
+}
+
+func (m *medidorTexto) ascenso(tamano float64) float64 {
+	return float64(m.cara(false, tamano).Metrics().Ascent) / 64 / m.escala
+}
+
+var coloresConNombre = map[string]color.RGBA{
+	"black":     {0, 0, 0, 255},
+	"white":     {255, 255, 255, 255},
+	"red":       {255, 0, 0, 255},
+	"green":     {0, 128, 0, 255},
+	"blue":      {0, 0, 255, 255},
+	"yellow":    {255, 255, 0, 255},
+	"orange":    {255, 165, 0, 255},
+	"gray":      {192, 192, 192, 255},
+	"grey":      {192, 192, 192, 255},
+	"lightgray": {211, 211, 211, 255},
+	"lightgrey": {211, 211, 211, 255},
+}
+
+// parsearColor acepta #RRGGBB, #RRGGBBAA y los nombres de coloresConNombre; "none",
+// "transparent" y la cadena vacía no pintan nada
+func parsearColor(valor string) (color.RGBA, bool) {
+	valor = strings.ToLower(strings.TrimSpace(valor))
+	if c, ok := coloresConNombre[valor]; ok {
+		return c, true
+	}
+	if !strings.HasPrefix(valor, "#") || (len(valor) != 7 && len(valor) != 9) {
+		return color.RGBA{}, false
+	}
+	n, err := strconv.ParseUint(valor[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	if len(valor) == 7 {
+		n = n<<8 | 0xFF
+	}
+	return color.RGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, true
+}
+
+func colorSVG(valor string) string {
+	c, ok := parsearColor(valor)
+	if !ok {
+		return `"none"`
+	}
+	if c.A < 255 {
+		return fmt.Sprintf(`"#%02x%02x%02x%02x"`, c.R, c.G, c.B, c.A)
+	}
+	return fmt.Sprintf(`"#%02x%02x%02x"`, c.R, c.G, c.B)
+}
+
+func puntosSVG(puntos []punto) string {
+	partes := make([]string, len(puntos))
+	for i, p := range puntos {
+		partes[i] = fmt.Sprintf("%.2f,%.2f", p.x, p.y)
+	}
+	return strings.Join(partes, " ")
+}
+
+func escribirSVG(w io.Writer, l *lienzo) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	fmt.Fprintf(b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0fpt\" height=\"%.0fpt\" viewBox=\"0 0 %.2f %.2f\">\n", l.ancho, l.alto, l.ancho, l.alto)
+	fondo := l.fondo
+	if fondo == "" {
+		fondo = "white"
+	}
+	fmt.Fprintf(b, "<rect width=\"100%%\" height=\"100%%\" fill=%s/>\n", colorSVG(fondo))
+
+	for _, e := range l.elementos {
+		switch e.tipo {
+		case elementoRectangulo:
+			fmt.Fprintf(b, "<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=%s stroke=%s/>\n",
+				e.x, e.y, e.ancho, e.alto, colorSVG(e.relleno), colorSVG(e.trazo))
+		case elementoElipse:
+			fmt.Fprintf(b, "<ellipse cx=\"%.2f\" cy=\"%.2f\" rx=\"%.2f\" ry=\"%.2f\" fill=%s stroke=%s/>\n",
+				e.x+e.ancho/2, e.y+e.alto/2, e.ancho/2, e.alto/2, colorSVG(e.relleno), colorSVG(e.trazo))
+		case elementoTexto:
+			peso := "normal"
+			if e.negrita {
+				peso = "bold"
+			}
+			fmt.Fprintf(b, "<text x=\"%.2f\" y=\"%.2f\" text-anchor=\"%s\" font-family=\"Go, Helvetica, Arial, sans-serif\" font-size=\"%.2f\" font-weight=\"%s\" fill=%s>",
+				e.x, e.y, e.ancla, e.tamano, peso, colorSVG(e.relleno))
+			xml.EscapeText(b, []byte(e.texto))
+			fmt.Fprintln(b, "</text>")
+		case elementoLinea:
+			guiones := ""
+			if e.discontinua {
+				guiones = ` stroke-dasharray="5,3"`
+			}
+			fmt.Fprintf(b, "<polyline points=\"%s\" fill=\"none\" stroke=%s%s/>\n", puntosSVG(e.puntos), colorSVG(e.trazo), guiones)
+		case elementoPoligono:
+			fmt.Fprintf(b, "<polygon points=\"%s\" fill=%s stroke=%s/>\n", puntosSVG(e.puntos), colorSVG(e.relleno), colorSVG(e.relleno))
+		}
+	}
+
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
+
+// rasterizador dibuja las primitivas del lienzo sobre una imagen RGBA
+type rasterizador struct {
+	imagen *image.RGBA
+	escala float64
+	medida *medidorTexto
+	trazo  vector.Rasterizer
+}
+
+// rellenar pinta los contornos con la regla de devanado distinto de cero: un contorno interior
+// recorrido al revés deja un hueco, así se dibujan los bordes de las elipses
+func (r *rasterizador) rellenar(contornos [][]punto, c color.RGBA) {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, contorno := range contornos {
+		for _, p := range contorno {
+			minX, minY = math.Min(minX, p.x*r.escala), math.Min(minY, p.y*r.escala)
+			maxX, maxY = math.Max(maxX, p.x*r.escala), math.Max(maxY, p.y*r.escala)
+		}
+	}
+	caja := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1)
+	if caja.Intersect(r.imagen.Bounds()) != caja || caja.Empty() {
+		return
+	}
+
+	r.trazo.Reset(caja.Dx(), caja.Dy())
+	for _, contorno := range contornos {
+		for i, p := range contorno {
+			x, y := float32(p.x*r.escala-float64(caja.Min.X)), float32(p.y*r.escala-float64(caja.Min.Y))
+			if i == 0 {
+				r.trazo.MoveTo(x, y)
+			} else {
+				r.trazo.LineTo(x, y)
+			}
+		}
+		r.trazo.ClosePath()
+	}
+	r.trazo.Draw(r.imagen, caja, image.NewUniform(c), image.Point{})
+}
+
+func (r *rasterizador) rectangulo(x, y, ancho, alto float64, c color.RGBA) {
+	caja := image.Rect(int(math.Round(x*r.escala)), int(math.Round(y*r.escala)), int(math.Round((x+ancho)*r.escala)), int(math.Round((y+alto)*r.escala)))
+	draw.Draw(r.imagen, caja, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// segmento devuelve el rectángulo de un punto de grosor que cubre la línea de a a b
+func segmento(a, b punto) []punto {
+	largo := math.Hypot(b.x-a.x, b.y-a.y)
+	if largo == 0 {
+		return nil
+	}
+	nx, ny := -(b.y-a.y)/largo*0.5, (b.x-a.x)/largo*0.5
+	return []punto{{a.x + nx, a.y + ny}, {b.x + nx, b.y + ny}, {b.x - nx, b.y - ny}, {a.x - nx, a.y - ny}}
+}
+
+func contornoElipse(e elemento, recorte float64, alReves bool) []punto {
+	const lados = 72
+	puntos := make([]punto, lados)
+	for i := range puntos {
+		angulo := 2 * math.Pi * float64(i) / lados
+		if alReves {
+			angulo = -angulo
+		}
+		puntos[i] = punto{e.x + e.ancho/2 + (e.ancho/2-recorte)*math.Cos(angulo), e.y + e.alto/2 + (e.alto/2-recorte)*math.Sin(angulo)}
+	}
+	return puntos
+}
+
+func (r *rasterizador) dibujar(e elemento) {
+	switch e.tipo {
+	case elementoRectangulo:
+		if c, ok := parsearColor(e.relleno); ok {
+			r.rectangulo(e.x, e.y, e.ancho, e.alto, c)
+		}
+		if c, ok := parsearColor(e.trazo); ok {
+			grosor := 1 / r.escala
+			r.rectangulo(e.x, e.y, e.ancho, grosor, c)
+			r.rectangulo(e.x, e.y+e.alto-grosor, e.ancho, grosor, c)
+			r.rectangulo(e.x, e.y, grosor, e.alto, c)
+			r.rectangulo(e.x+e.ancho-grosor, e.y, grosor, e.alto, c)
+		}
+	case elementoElipse:
+		if c, ok := parsearColor(e.relleno); ok {
+			r.rellenar([][]punto{contornoElipse(e, 0, false)}, c)
+		}
+		if c, ok := parsearColor(e.trazo); ok {
+			r.rellenar([][]punto{contornoElipse(e, 0, false), contornoElipse(e, 1, true)}, c)
+		}
+	case elementoLinea:
+		c, ok := parsearColor(e.trazo)
+		if !ok {
+			return
+		}
+		for i := 0; i+1 < len(e.puntos); i++ {
+			a, b := e.puntos[i], e.puntos[i+1]
+			if !e.discontinua {
+				r.rellenar([][]punto{segmento(a, b)}, c)
+				continue
+			}
+			largo := math.Hypot(b.x-a.x, b.y-a.y)
+			for d := 0.0; d < largo; d += 8 {
+				fin := math.Min(d+5, largo)
+				desde := punto{a.x + (b.x-a.x)*d/largo, a.y + (b.y-a.y)*d/largo}
+				hasta := punto{a.x + (b.x-a.x)*fin/largo, a.y + (b.y-a.y)*fin/largo}
+				r.rellenar([][]punto{segmento(desde, hasta)}, c)
+			}
+		}
+	case elementoPoligono:
+		if c, ok := parsearColor(e.relleno); ok {
+			r.rellenar([][]punto{e.puntos}, c)
+		}
+	case elementoTexto:
+		c, ok := parsearColor(e.relleno)
+		if !ok {
+			return
+		}
+		cara := r.medida.cara(e.negrita, e.tamano)
+		x := e.x * r.escala
+		switch e.ancla {
+		case "middle":
+			x -= float64(font.MeasureString(cara, e.texto)) / 64 / 2
+		case "end":
+			x -= float64(font.MeasureString(cara, e.texto)) / 64
+		}
+		escritor := font.Drawer{Dst: r.imagen, Src: image.NewUniform(c), Face: cara,
+			Dot: fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(e.y * r.escala * 64)}}
+		escritor.DrawString(e.texto)
+	}
+}
+
+// rasterizarLienzo dibuja el lienzo en una imagen para codificarla como PNG o JPG
+func rasterizarLienzo(l *lienzo) (*image.RGBA, error) {
+	escala := escalaPNG
+	if pixeles := l.ancho * l.alto * escala * escala; pixeles > maxPixelesPNG {
+		escala *= math.Sqrt(maxPixelesPNG / pixeles)
+	}
+
+	medida, err := nuevoMedidorTexto(escala)
+	if err != nil {
+		return nil, err
+	}
+	r := &rasterizador{
+		imagen: image.NewRGBA(image.Rect(0, 0, int(math.Ceil(l.ancho*escala)), int(math.Ceil(l.alto*escala)))),
+		escala: escala,
+		medida: medida,
+	}
+
+	fondo, ok := parsearColor(l.fondo)
+	if !ok {
+		fondo = coloresConNombre["white"]
+	}
+	draw.Draw(r.imagen, r.imagen.Bounds(), image.NewUniform(fondo), image.Point{}, draw.Src)
+	for _, e := range l.elementos {
+		r.dibujar(e)
+	}
+	return r.imagen, nil
+}
@@ -0,0 +1,329 @@
+package reportes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// grafoDot es el subconjunto de DOT que generan los reportes: atributos del grafo, nodos con
+// etiquetas de texto, record o tablas HTML, y aristas dirigidas
+type grafoDot struct {
+	atributos map[string]string
+	nodos     []*nodoDot
+	indice    map[string]*nodoDot
+	aristas   []*aristaDot
+}
+
+type nodoDot struct {
+	id        string
+	atributos map[string]string
+	html      bool
+}
+
+type aristaDot struct {
+	origen    *nodoDot
+	destino   *nodoDot
+	atributos map[string]string
+}
+
+func (n *nodoDot) atributo(clave, defecto string) string {
+	if valor, ok := n.atributos[clave]; ok && valor != "" {
+		return valor
+	}
+	return defecto
+}
+
+func (a *aristaDot) atributo(clave, defecto string) string {
+	if valor, ok := a.atributos[clave]; ok && valor != "" {
+		return valor
+	}
+	return defecto
+}
+
+type tipoTokenDot int
+
+const (
+	tokenFin tipoTokenDot = iota
+	tokenId
+	tokenCadena
+	tokenHTML
+	tokenSimbolo
+)
+
+type tokenDot struct {
+	tipo  tipoTokenDot
+	valor string
+}
+
+func separarTokensDot(contenido string) ([]tokenDot, error) {
+	var tokens []tokenDot
+	runas := []rune(contenido)
+
+	for i := 0; i < len(runas); {
+		r := runas[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runas) && runas[i+1] == '/', r == '#':
+			for i < len(runas) && runas[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runas) && runas[i+1] == '*':
+			fin := i + 2
+			for fin+1 < len(runas) && !(runas[fin] == '*' && runas[fin+1] == '/') {
+				fin++
+			}
+			if fin+1 >= len(runas) {
+				return nil, errors.New("comentario sin cerrar en el archivo DOT")
+			}
+			i = fin + 2
+		case r == '"':
+			var valor strings.Builder
+			i++
+			for ; i < len(runas) && runas[i] != '"'; i++ {
+				if runas[i] == '\\' && i+1 < len(runas) && runas[i+1] == '"' {
+					i++
+				}
+				valor.WriteRune(runas[i])
+			}
+			if i >= len(runas) {
+				return nil, errors.New("cadena sin cerrar en el archivo DOT")
+			}
+			i++
+			tokens = append(tokens, tokenDot{tokenCadena, valor.String()})
+		case r == '<':
+			nivel := 0
+			inicio := i
+			for ; i < len(runas); i++ {
+				if runas[i] == '<' {
+					nivel++
+				} else if runas[i] == '>' {
+					nivel--
+					if nivel == 0 {
+						break
+					}
+				}
+			}
+			if i >= len(runas) {
+				return nil, errors.New("etiqueta HTML sin cerrar en el archivo DOT")
+			}
+			tokens = append(tokens, tokenDot{tokenHTML, string(runas[inicio+1 : i])})
+			i++
+		case r == '-' && i+1 < len(runas) && (runas[i+1] == '>' || runas[i+1] == '-'):
+			tokens = append(tokens, tokenDot{tokenSimbolo, string(runas[i : i+2])})
+			i += 2
+		case strings.ContainsRune("{}[]=;,:", r):
+			tokens = append(tokens, tokenDot{tokenSimbolo, string(r)})
+			i++
+		case r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			inicio := i
+			for i < len(runas) && (runas[i] == '_' || runas[i] == '.' || unicode.IsLetter(runas[i]) || unicode.IsDigit(runas[i]) || (i == inicio && runas[i] == '-')) {
+				i++
+			}
+			tokens = append(tokens, tokenDot{tokenId, string(runas[inicio:i])})
+		default:
+			return nil, fmt.Errorf("carácter inesperado '%c' en el archivo DOT", r)
+		}
+	}
+	return append(tokens, tokenDot{tipo: tokenFin}), nil
+}
+
+type analizadorDot struct {
+	tokens        []tokenDot
+	pos           int
+	grafo         *grafoDot
+	nodoDefecto   map[string]string
+	aristaDefecto map[string]string
+}
+
+// analizarDot convierte el contenido de un archivo DOT en un grafoDot
+func analizarDot(contenido string) (*grafoDot, error) {
+	tokens, err := separarTokensDot(contenido)
+	if err != nil {
+		return nil, err
+	}
+	a := &analizadorDot{
+		tokens:        tokens,
+		grafo:         &grafoDot{atributos: map[string]string{}, indice: map[string]*nodoDot{}},
+		nodoDefecto:   map[string]string{},
+		aristaDefecto: map[string]string{},
+	}
+
+	if a.esId("strict") {
+		a.pos++
+	}
+	if !a.esId("digraph") && !a.esId("graph") {
+		return nil, errors.New("el archivo DOT debe comenzar con digraph o graph")
+	}
+	a.pos++
+	if a.actual().tipo == tokenId || a.actual().tipo == tokenCadena {
+		a.pos++
+	}
+	if err := a.esperar("{"); err != nil {
+		return nil, err
+	}
+
+	for !a.esSimbolo("}") {
+		if a.actual().tipo == tokenFin {
+			return nil, errors.New("falta '}' al final del archivo DOT")
+		}
+		if err := a.sentencia(); err != nil {
+			return nil, err
+		}
+	}
+	return a.grafo, nil
+}
+
+func (a *analizadorDot) actual() tokenDot {
+	return a.tokens[a.pos]
+}
+
+func (a *analizadorDot) esSimbolo(simbolo string) bool {
+	return a.actual().tipo == tokenSimbolo && a.actual().valor == simbolo
+}
+
+func (a *analizadorDot) esId(palabra string) bool {
+	return a.actual().tipo == tokenId && strings.EqualFold(a.actual().valor, palabra)
+}
+
+func (a *analizadorDot) esperar(simbolo string) error {
+	if !a.esSimbolo(simbolo) {
+		return fmt.Errorf("se esperaba '%s' en el archivo DOT y se encontró '%s'", simbolo, a.actual().valor)
+	}
+	a.pos++
+	return nil
+}
+
+func (a *analizadorDot) identificador() (tokenDot, error) {
+	token := a.actual()
+	if token.tipo != tokenId && token.tipo != tokenCadena && token.tipo != tokenHTML {
+		return token, fmt.Errorf("se esperaba un identificador en el archivo DOT y se encontró '%s'", token.valor)
+	}
+	a.pos++
+	return token, nil
+}
+
+func (a *analizadorDot) sentencia() error {
+	if a.esSimbolo(";") {
+		a.pos++
+		return nil
+	}
+	if a.esId("subgraph") || a.esSimbolo("{") {
+		return errors.New("el renderizador interno no admite subgrafos")
+	}
+
+	for _, clase := range []string{"node", "edge", "graph"} {
+		if a.esId(clase) && a.tokens[a.pos+1].tipo == tokenSimbolo && a.tokens[a.pos+1].valor == "[" {
+			a.pos++
+			atributos, _, err := a.listaAtributos()
+			if err != nil {
+				return err
+			}
+			destino := map[string]map[string]string{"node": a.nodoDefecto, "edge": a.aristaDefecto, "graph": a.grafo.atributos}[clase]
+			for clave, valor := range atributos {
+				destino[clave] = valor
+			}
+			return nil
+		}
+	}
+
+	primero, err := a.identificador()
+	if err != nil {
+		return err
+	}
+
+	if a.esSimbolo("=") {
+		a.pos++
+		valor, err := a.identificador()
+		if err != nil {
+			return err
+		}
+		a.grafo.atributos[primero.valor] = valor.valor
+		return nil
+	}
+
+	ids := []string{primero.valor}
+	for a.esSimbolo("->") || a.esSimbolo("--") {
+		a.pos++
+		siguiente, err := a.identificador()
+		if err != nil {
+			return err
+		}
+		ids = append(ids, siguiente.valor)
+	}
+
+	atributos, html, err := a.listaAtributos()
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 1 {
+		nodo := a.nodo(ids[0])
+		for clave, valor := range atributos {
+			nodo.atributos[clave] = valor
+		}
+		if _, ok := atributos["label"]; ok {
+			nodo.html = html
+		}
+		return nil
+	}
+
+	for i := 0; i+1 < len(ids); i++ {
+		arista := &aristaDot{origen: a.nodo(ids[i]), destino: a.nodo(ids[i+1]), atributos: map[string]string{}}
+		for clave, valor := range a.aristaDefecto {
+			arista.atributos[clave] = valor
+		}
+		for clave, valor := range atributos {
+			arista.atributos[clave] = valor
+		}
+		a.grafo.aristas = append(a.grafo.aristas, arista)
+	}
+	return nil
+}
+
+// listaAtributos lee uno o varios bloques [clave=valor, ...]; indica si la etiqueta es HTML
+func (a *analizadorDot) listaAtributos() (map[string]string, bool, error) {
+	atributos := map[string]string{}
+	html := false
+	for a.esSimbolo("[") {
+		a.pos++
+		for !a.esSimbolo("]") {
+			clave, err := a.identificador()
+			if err != nil {
+				return nil, false, err
+			}
+			valor := tokenDot{tokenId, "true"}
+			if a.esSimbolo("=") {
+				a.pos++
+				if valor, err = a.identificador(); err != nil {
+					return nil, false, err
+				}
+			}
+			atributos[clave.valor] = valor.valor
+			if clave.valor == "label" {
+				html = valor.tipo == tokenHTML
+			}
+			if a.esSimbolo(",") || a.esSimbolo(";") {
+				a.pos++
+			}
+		}
+		a.pos++
+	}
+	return atributos, html, nil
+}
+
+// nodo devuelve el nodo con ese id y lo crea con los atributos por defecto si no existe
+func (a *analizadorDot) nodo(id string) *nodoDot {
+	if nodo, ok := a.grafo.indice[id]; ok {
+		return nodo
+	}
+	nodo := &nodoDot{id: id, atributos: map[string]string{}}
+	for clave, valor := range a.nodoDefecto {
+		nodo.atributos[clave] = valor
+	}
+	a.grafo.nodos = append(a.grafo.nodos, nodo)
+	a.grafo.indice[id] = nodo
+	return nodo
+}
@@ -0,0 +1,173 @@
+package reportes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalizarDot(t *testing.T) {
+	type nodoEsperado struct {
+		id        string
+		atributos map[string]string
+		html      bool
+	}
+	casos := []struct {
+		nombre    string
+		dot       string
+		atributos map[string]string
+		nodos     []nodoEsperado
+		aristas   []string
+	}{
+		{
+			nombre: "vacío",
+			dot:    `digraph {}`,
+		},
+		{
+			nombre:    "strict, nombre entre comillas y atributos del grafo",
+			dot:       `strict digraph "G" { rankdir=LR; graph [bgcolor="#FAFAFA"] }`,
+			atributos: map[string]string{"rankdir": "LR", "bgcolor": "#FAFAFA"},
+		},
+		{
+			nombre: "atributos por defecto solo para los nodos creados después",
+			dot: `digraph G {
+				a;
+				node [shape=box, fontsize=12];
+				b [label="B"];
+			}`,
+			nodos: []nodoEsperado{
+				{id: "a", atributos: map[string]string{}},
+				{id: "b", atributos: map[string]string{"shape": "box", "fontsize": "12", "label": "B"}},
+			},
+		},
+		{
+			nombre: "cadena de aristas con atributos por defecto y propios",
+			dot: `digraph G {
+				edge [color=red];
+				a -> b -> c [style=dashed];
+			}`,
+			nodos: []nodoEsperado{
+				{id: "a", atributos: map[string]string{}},
+				{id: "b", atributos: map[string]string{}},
+				{id: "c", atributos: map[string]string{}},
+			},
+			aristas: []string{"a->b color=red style=dashed", "b->c color=red style=dashed"},
+		},
+		{
+			nombre: "etiqueta HTML con tablas anidadas",
+			dot:    `digraph { t [label=<<table><tr><td>x &lt; y</td></tr></table>>] }`,
+			nodos: []nodoEsperado{
+				{id: "t", atributos: map[string]string{"label": "<table><tr><td>x &lt; y</td></tr></table>"}, html: true},
+			},
+		},
+		{
+			nombre: "comentarios, comillas escapadas y atributo sin valor",
+			dot: `// reporte
+			digraph G {
+				# línea de preprocesador
+				/* bloque
+				   de varias líneas */
+				n [label="dice \"hola\"", fixedsize];
+			}`,
+			nodos: []nodoEsperado{
+				{id: "n", atributos: map[string]string{"label": `dice "hola"`, "fixedsize": "true"}},
+			},
+		},
+		{
+			nombre: "la etiqueta de texto posterior reemplaza a la HTML",
+			dot:    `digraph { n [label=<<b>x</b>>]; n [label="x"] }`,
+			nodos: []nodoEsperado{
+				{id: "n", atributos: map[string]string{"label": "x"}},
+			},
+		},
+		{
+			nombre: "grafo no dirigido",
+			dot:    `graph { a -- b }`,
+			nodos: []nodoEsperado{
+				{id: "a", atributos: map[string]string{}},
+				{id: "b", atributos: map[string]string{}},
+			},
+			aristas: []string{"a->b"},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			grafo, err := analizarDot(caso.dot)
+			if err != nil {
+				t.Fatalf("analizarDot: %v", err)
+			}
+			atributos := caso.atributos
+			if atributos == nil {
+				atributos = map[string]string{}
+			}
+			if !reflect.DeepEqual(grafo.atributos, atributos) {
+				t.Errorf("atributos del grafo = %v, se esperaba %v", grafo.atributos, atributos)
+			}
+
+			if len(grafo.nodos) != len(caso.nodos) {
+				t.Fatalf("%d nodos, se esperaban %d", len(grafo.nodos), len(caso.nodos))
+			}
+			for i, esperado := range caso.nodos {
+				n := grafo.nodos[i]
+				if n.id != esperado.id || n.html != esperado.html || !reflect.DeepEqual(n.atributos, esperado.atributos) {
+					t.Errorf("nodo %d = {%s %v %v}, se esperaba %v", i, n.id, n.atributos, n.html, esperado)
+				}
+				if grafo.indice[n.id] != n {
+					t.Errorf("el índice no apunta al nodo %s", n.id)
+				}
+			}
+
+			var aristas []string
+			for _, a := range grafo.aristas {
+				aristas = append(aristas, describirArista(a))
+			}
+			if !reflect.DeepEqual(aristas, caso.aristas) {
+				t.Errorf("aristas = %q, se esperaba %q", aristas, caso.aristas)
+			}
+		})
+	}
+}
+
+// describirArista escribe la arista como "origen->destino" seguido de sus atributos en orden
+func describirArista(a *aristaDot) string {
+	partes := []string{a.origen.id + "->" + a.destino.id}
+	for _, clave := range []string{"color", "style"} {
+		if valor, ok := a.atributos[clave]; ok {
+			partes = append(partes, clave+"="+valor)
+		}
+	}
+	return strings.Join(partes, " ")
+}
+
+func TestAnalizarDotErrores(t *testing.T) {
+	casos := []struct {
+		nombre string
+		dot    string
+		error  string
+	}{
+		{"sin encabezado", `{ a -> b }`, "debe comenzar con digraph o graph"},
+		{"sin llave de cierre", `digraph G { a -> b`, "falta '}'"},
+		{"sin llave de apertura", `digraph G a -> b }`, "se esperaba '{'"},
+		{"subgrafo", `digraph G { subgraph cluster_0 { a } }`, "no admite subgrafos"},
+		{"subgrafo anónimo", `digraph G { { a b } }`, "no admite subgrafos"},
+		{"cadena sin cerrar", `digraph G { a [label="hola] }`, "cadena sin cerrar"},
+		{"HTML sin cerrar", `digraph G { a [label=<<b>hola</b>] }`, "etiqueta HTML sin cerrar"},
+		{"comentario sin cerrar", `digraph G { a /* b }`, "comentario sin cerrar"},
+		{"carácter inesperado", `digraph G { a @ b }`, "carácter inesperado '@'"},
+		{"arista sin destino", `digraph G { a -> ; }`, "se esperaba un identificador"},
+		{"atributo sin valor tras el igual", `digraph G { a [label=] }`, "se esperaba un identificador"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			_, err := analizarDot(caso.dot)
+			if err == nil {
+				t.Fatalf("analizarDot(%q) no devolvió error", caso.dot)
+			}
+			if !strings.Contains(err.Error(), caso.error) {
+				t.Errorf("error = %q, se esperaba que contuviera %q", err, caso.error)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package reportes
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// kappaElipse es la distancia de los puntos de control de una curva de Bézier que aproxima un
+// cuarto de elipse
+const kappaElipse = 0.5522847498
+
+// documentoPDF arma un PDF de una página con los objetos numerados desde 1
+type documentoPDF struct {
+	objetos [][]byte
+}
+
+func (d *documentoPDF) agregar(contenido string) int {
+	d.objetos = append(d.objetos, []byte(contenido))
+	return len(d.objetos)
+}
+
+func (d *documentoPDF) agregarFlujo(diccionario string, datos []byte) int {
+	var comprimido bytes.Buffer
+	z := zlib.NewWriter(&comprimido)
+	z.Write(datos)
+	z.Close()
+
+	var objeto bytes.Buffer
+	fmt.Fprintf(&objeto, "<< %s /Filter /FlateDecode /Length %d >>\nstream\n", diccionario, comprimido.Len())
+	objeto.Write(comprimido.Bytes())
+	objeto.WriteString("\nendstream")
+	d.objetos = append(d.objetos, objeto.Bytes())
+	return len(d.objetos)
+}
+
+func (d *documentoPDF) escribir(w io.Writer, raiz int) error {
+	var salida bytes.Buffer
+	salida.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	posiciones := make([]int, len(d.objetos))
+	for i, objeto := range d.objetos {
+		posiciones[i] = salida.Len()
+		fmt.Fprintf(&salida, "%d 0 obj\n", i+1)
+		salida.Write(objeto)
+		salida.WriteString("\nendobj\n")
+	}
+	inicioXref := salida.Len()
+	fmt.Fprintf(&salida, "xref\n0 %d\n0000000000 65535 f \n", len(d.objetos)+1)
+	for _, posicion := range posiciones {
+		fmt.Fprintf(&salida, "%010d 00000 n \n", posicion)
+	}
+	fmt.Fprintf(&salida, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objetos)+1, raiz, inicioXref)
+	_, err := w.Write(salida.Bytes())
+	return err
+}
+
+// agregarFuente incrusta una fuente TrueType con la codificación WinAnsi; los anchos salen de
+// la misma fuente con la que se midió el diagrama
+func (d *documentoPDF) agregarFuente(nombre string, ttf []byte, f *opentype.Font) (int, error) {
+	var buf sfnt.Buffer
+	unidades := f.UnitsPerEm()
+	ppem := fixed.I(int(unidades))
+	escala := func(v fixed.Int26_6) int {
+		return int(math.Round(float64(v) / 64 * 1000 / float64(unidades)))
+	}
+
+	anchos := make([]string, 0, 224)
+	for c := 32; c <= 255; c++ {
+		ancho := 0
+		if indice, err := f.GlyphIndex(&buf, rune(c)); err == nil && indice != 0 {
+			if avance, err := f.GlyphAdvance(&buf, indice, ppem, font.HintingNone); err == nil {
+				ancho = escala(avance)
+			}
+		}
+		anchos = append(anchos, fmt.Sprint(ancho))
+	}
+
+	limites, err := f.Bounds(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	metricas, err := f.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+
+	archivo := d.agregarFlujo(fmt.Sprintf("/Length1 %d", len(ttf)), ttf)
+	descriptor := d.agregar(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		nombre, escala(limites.Min.X), -escala(limites.Max.Y), escala(limites.Max.X), -escala(limites.Min.Y),
+		escala(metricas.Ascent), -escala(metricas.Descent), escala(metricas.CapHeight), archivo))
+	return d.agregar(fmt.Sprintf("<< /Type /Font /Subtype /TrueType /BaseFont /%s /FirstChar 32 /LastChar 255 /Widths [%s] /Encoding /WinAnsiEncoding /FontDescriptor %d 0 R >>",
+		nombre, strings.Join(anchos, " "), descriptor)), nil
+}
+
+// textoPDF convierte el texto a WinAnsi y escapa los caracteres especiales de las cadenas PDF;
+// lo que no está en Latin-1 se reemplaza por '?'
+func textoPDF(texto string) string {
+	var b strings.Builder
+	for _, r := range texto {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '\t':
+			b.WriteByte(' ')
+		case (r >= 0x20 && r < 0x7F) || (r >= 0xA0 && r <= 0xFF):
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func colorPDF(valor, operador string) (string, bool) {
+	c, ok := parsearColor(valor)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%.3f %.3f %.3f %s\n", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, operador), true
+}
+
+// escribirPDF dibuja el lienzo en una página del mismo tamaño; PDF mide desde abajo, así que las
+// coordenadas verticales se invierten
+func escribirPDF(w io.Writer, l *lienzo, m *medidorTexto) error {
+	alto := l.alto
+	y := func(v float64) float64 { return alto - v }
+
+	var c bytes.Buffer
+	fondo := l.fondo
+	if fondo == "" {
+		fondo = "white"
+	}
+	if relleno, ok := colorPDF(fondo, "rg"); ok {
+		fmt.Fprintf(&c, "%s0 0 %.2f %.2f re f\n", relleno, l.ancho, l.alto)
+	}
+	c.WriteString("1 w\n")
+
+	usaNegrita := false
+	for _, e := range l.elementos {
+		switch e.tipo {
+		case elementoRectangulo:
+			if relleno, ok := colorPDF(e.relleno, "rg"); ok {
+				fmt.Fprintf(&c, "%s%.2f %.2f %.2f %.2f re f\n", relleno, e.x, y(e.y+e.alto), e.ancho, e.alto)
+			}
+			if trazo, ok := colorPDF(e.trazo, "RG"); ok {
+				fmt.Fprintf(&c, "%s%.2f %.2f %.2f %.2f re S\n", trazo, e.x+0.5, y(e.y+e.alto)+0.5, e.ancho-1, e.alto-1)
+			}
+		case elementoElipse:
+			cx, cy, rx, ry := e.x+e.ancho/2, y(e.y+e.alto/2), e.ancho/2, e.alto/2
+			kx, ky := rx*kappaElipse, ry*kappaElipse
+			trazado := fmt.Sprintf("%.2f %.2f m %.2f %.2f %.2f %.2f %.2f %.2f c %.2f %.2f %.2f %.2f %.2f %.2f c %.2f %.2f %.2f %.2f %.2f %.2f c %.2f %.2f %.2f %.2f %.2f %.2f c h\n",
+				cx+rx, cy,
+				cx+rx, cy+ky, cx+kx, cy+ry, cx, cy+ry,
+				cx-kx, cy+ry, cx-rx, cy+ky, cx-rx, cy,
+				cx-rx, cy-ky, cx-kx, cy-ry, cx, cy-ry,
+				cx+kx, cy-ry, cx+rx, cy-ky, cx+rx, cy)
+			if relleno, ok := colorPDF(e.relleno, "rg"); ok {
+				fmt.Fprintf(&c, "%s%sf\n", relleno, trazado)
+			}
+			if trazo, ok := colorPDF(e.trazo, "RG"); ok {
+				fmt.Fprintf(&c, "%s%sS\n", trazo, trazado)
+			}
+		case elementoLinea:
+			trazo, ok := colorPDF(e.trazo, "RG")
+			if !ok || len(e.puntos) < 2 {
+				continue
+			}
+			c.WriteString(trazo)
+			if e.discontinua {
+				c.WriteString("[5 3] 0 d\n")
+			}
+			for i, p := range e.puntos {
+				operador := "l"
+				if i == 0 {
+					operador = "m"
+				}
+				fmt.Fprintf(&c, "%.2f %.2f %s\n", p.x, y(p.y), operador)
+			}
+			c.WriteString("S\n")
+			if e.discontinua {
+				c.WriteString("[] 0 d\n")
+			}
+		case elementoPoligono:
+			relleno, ok := colorPDF(e.relleno, "rg")
+			if !ok || len(e.puntos) < 3 {
+				continue
+			}
+			c.WriteString(relleno)
+			for i, p := range e.puntos {
+				operador := "l"
+				if i == 0 {
+					operador = "m"
+				}
+				fmt.Fprintf(&c, "%.2f %.2f %s\n", p.x, y(p.y), operador)
+			}
+			c.WriteString("h f\n")
+		case elementoTexto:
+			relleno, ok := colorPDF(e.relleno, "rg")
+			if !ok {
+				continue
+			}
+			x := e.x
+			switch e.ancla {
+			case "middle":
+				x -= m.anchoTexto(e.texto, e.negrita, e.tamano) / 2
+			case "end":
+				x -= m.anchoTexto(e.texto, e.negrita, e.tamano)
+			}
+			fuente := "F1"
+			if e.negrita {
+				fuente = "F2"
+				usaNegrita = true
+			}
+			fmt.Fprintf(&c, "BT\n%s/%s %.2f Tf\n%.2f %.2f Td\n(%s) Tj\nET\n", relleno, fuente, e.tamano, x, y(e.y), textoPDF(e.texto))
+		}
+	}
+
+	d := &documentoPDF{}
+	fuentes := ""
+	normal, err := d.agregarFuente("GoRegular", goregular.TTF, fuenteNormal)
+	if err != nil {
+		return fmt.Errorf("error al incrustar la fuente: %v", err)
+	}
+	fuentes += fmt.Sprintf("/F1 %d 0 R", normal)
+	if usaNegrita {
+		negrita, err := d.agregarFuente("GoBold", gobold.TTF, fuenteNegrita)
+		if err != nil {
+			return fmt.Errorf("error al incrustar la fuente: %v", err)
+		}
+		fuentes += fmt.Sprintf(" /F2 %d 0 R", negrita)
+	}
+
+	contenido := d.agregarFlujo("", c.Bytes())
+	paginas := len(d.objetos) + 2
+	pagina := d.agregar(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+		paginas, l.ancho, l.alto, fuentes, contenido))
+	d.agregar(fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", pagina))
+	raiz := d.agregar(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", paginas))
+	return d.escribir(w, raiz)
+}
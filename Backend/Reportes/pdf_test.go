@@ -0,0 +1,158 @@
+package reportes
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var (
+	expresionStartxref = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	expresionTrailer   = regexp.MustCompile(`trailer\n<< /Size (\d+) /Root (\d+) 0 R >>`)
+	expresionLength    = regexp.MustCompile(`/Length (\d+) >>\nstream\n`)
+)
+
+// objetosPDF valida el encabezado, la tabla xref y el trailer del PDF y devuelve el contenido de
+// cada objeto indexado por su número
+func objetosPDF(t *testing.T, datos []byte) map[int][]byte {
+	t.Helper()
+	if !bytes.HasPrefix(datos, []byte("%PDF-1.4\n")) {
+		t.Fatalf("el PDF no empieza con %%PDF-1.4: %q", datos[:min(len(datos), 16)])
+	}
+	coincidencia := expresionStartxref.FindSubmatch(datos)
+	if coincidencia == nil {
+		t.Fatalf("el PDF no termina con startxref y %%%%EOF")
+	}
+	inicioXref, _ := strconv.Atoi(string(coincidencia[1]))
+	if inicioXref >= len(datos) || !bytes.HasPrefix(datos[inicioXref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d no apunta a la tabla xref", inicioXref)
+	}
+
+	var cantidad int
+	xref := datos[inicioXref:]
+	if _, err := fmt.Sscanf(string(xref), "xref\n0 %d\n", &cantidad); err != nil {
+		t.Fatalf("encabezado de xref inválido: %v", err)
+	}
+	entradas := xref[bytes.IndexByte(xref[5:], '\n')+6:]
+	// cada entrada ocupa exactamente 20 bytes, incluido el fin de línea de dos caracteres
+	if len(entradas) < 20*cantidad || string(entradas[:20]) != "0000000000 65535 f \n" {
+		t.Fatalf("la entrada 0 de xref es inválida: %q", entradas[:min(len(entradas), 20)])
+	}
+
+	objetos := make(map[int][]byte)
+	for i := 1; i < cantidad; i++ {
+		entrada := string(entradas[20*i : 20*(i+1)])
+		var posicion, generacion int
+		var uso string
+		if _, err := fmt.Sscanf(entrada, "%010d %05d %1s", &posicion, &generacion, &uso); err != nil || uso != "n" || entrada[18:] != " \n" {
+			t.Fatalf("entrada %d de xref inválida: %q", i, entrada)
+		}
+		encabezado := []byte(fmt.Sprintf("%d 0 obj\n", i))
+		if !bytes.HasPrefix(datos[posicion:], encabezado) {
+			t.Fatalf("la entrada %d de xref apunta a %q", i, datos[posicion:min(len(datos), posicion+16)])
+		}
+		cuerpo := datos[posicion+len(encabezado):]
+		fin := bytes.Index(cuerpo, []byte("\nendobj\n"))
+		if fin < 0 {
+			t.Fatalf("el objeto %d no termina con endobj", i)
+		}
+		objetos[i] = cuerpo[:fin]
+	}
+
+	trailer := expresionTrailer.FindSubmatch(xref)
+	if trailer == nil {
+		t.Fatal("falta el trailer")
+	}
+	if tamano, _ := strconv.Atoi(string(trailer[1])); tamano != cantidad {
+		t.Errorf("/Size %d no coincide con las %d entradas de xref", tamano, cantidad)
+	}
+	raiz, _ := strconv.Atoi(string(trailer[2]))
+	if !bytes.Contains(objetos[raiz], []byte("/Type /Catalog")) {
+		t.Errorf("/Root %d no es el catálogo: %q", raiz, objetos[raiz])
+	}
+	return objetos
+}
+
+// flujoPDF devuelve los datos descomprimidos de un objeto stream y comprueba su /Length
+func flujoPDF(t *testing.T, numero int, objeto []byte) []byte {
+	t.Helper()
+	coincidencia := expresionLength.FindSubmatchIndex(objeto)
+	if coincidencia == nil {
+		t.Fatalf("el objeto %d no es un stream", numero)
+	}
+	largo, _ := strconv.Atoi(string(objeto[coincidencia[2]:coincidencia[3]]))
+	datos := objeto[coincidencia[1]:]
+	if string(datos[min(largo, len(datos)):]) != "\nendstream" {
+		t.Fatalf("/Length %d del objeto %d no coincide con el stream de %d bytes", largo, numero, len(datos)-len("\nendstream"))
+	}
+	z, err := zlib.NewReader(bytes.NewReader(datos[:largo]))
+	if err != nil {
+		t.Fatalf("objeto %d: %v", numero, err)
+	}
+	descomprimido, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatalf("objeto %d: %v", numero, err)
+	}
+	return descomprimido
+}
+
+func TestReportesPDF(t *testing.T) {
+	titulos := map[string]string{
+		"mbr":      "(REPORTE MBR) Tj",
+		"disk":     "(Reporte DISK) Tj",
+		"bm_inode": "(BITMAP DE INODOS) Tj",
+		"file":     "(nota.txt) Tj",
+	}
+	for _, reporte := range reportesPrueba(t) {
+		t.Run(reporte.nombre, func(t *testing.T) {
+			objetos := objetosPDF(t, generarReporte(t, reporte, FormatoPDF))
+
+			var contenido []byte
+			paginas := 0
+			for numero, objeto := range objetos {
+				if bytes.Contains(objeto, []byte("stream\n")) {
+					datos := flujoPDF(t, numero, objeto)
+					if bytes.Contains(objeto, []byte("<<  /Filter")) {
+						contenido = datos
+					}
+				}
+				if bytes.Contains(objeto, []byte("/Type /Page ")) {
+					paginas++
+					if !bytes.Contains(objeto, []byte("/MediaBox [0 0 ")) {
+						t.Errorf("la página no tiene MediaBox: %q", objeto)
+					}
+				}
+			}
+			if paginas != 1 {
+				t.Errorf("%d páginas, se esperaba 1", paginas)
+			}
+			if contenido == nil {
+				t.Fatal("no se encontró el contenido de la página")
+			}
+			if titulo, ok := titulos[reporte.nombre]; ok && !bytes.Contains(contenido, []byte(titulo)) {
+				t.Errorf("el contenido de la página no dibuja %q", titulo)
+			}
+		})
+	}
+}
+
+func TestTextoPDF(t *testing.T) {
+	casos := map[string]string{
+		"hola":               "hola",
+		`(a) \ b`:            `\(a\) \\ b`,
+		"tab\tuado":          "tab uado",
+		"PARTICIÓN":          "PARTICI\xd3N",
+		"ñ → ü":              "\xf1 ? \xfc",
+		"línea\nnueva":       "l\xednea?nueva",
+		"\u00a0no separable": "\xa0no separable",
+	}
+	for texto, esperado := range casos {
+		if obtenido := textoPDF(texto); obtenido != esperado {
+			t.Errorf("textoPDF(%q) = %q, se esperaba %q", texto, obtenido, esperado)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package reportes
+
+import (
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Renderizadores de los reportes gráficos. Sin uno explícito se usa Graphviz si dot está en el
+// PATH y el renderizador interno si no.
+const (
+	RenderizadorGraphviz = "graphviz"
+	RenderizadorInterno  = "builtin"
+)
+
+// renderizarDot convierte la descripción DOT en una imagen png, jpg, svg o pdf
+func renderizarDot(dot, salida, formato, renderizador string) error {
+	if renderizador == "" {
+		renderizador = RenderizadorInterno
+		if _, err := exec.LookPath("dot"); err == nil {
+			renderizador = RenderizadorGraphviz
+		}
+	}
+
+	if renderizador == RenderizadorGraphviz {
+		cmd := exec.Command("dot", "-T"+formato, "-o", salida)
+		cmd.Stdin = strings.NewReader(dot)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("error al ejecutar Graphviz: %v", err)
+		}
+		return nil
+	}
+	return renderizarInterno(dot, salida, formato)
+}
+
+func renderizarInterno(dot, salida, formato string) error {
+	grafo, err := analizarDot(dot)
+	if err != nil {
+		return fmt.Errorf("error al interpretar el archivo DOT: %v", err)
+	}
+	medidor, err := nuevoMedidorTexto(1)
+	if err != nil {
+		return err
+	}
+	diagrama := diagramarGrafo(grafo, medidor)
+
+	archivo, err := os.Create(salida)
+	if err != nil {
+		return fmt.Errorf("error al crear la imagen: %v", err)
+	}
+	switch formato {
+	case FormatoSVG:
+		err = escribirSVG(archivo, diagrama)
+	case FormatoPDF:
+		err = escribirPDF(archivo, diagrama, medidor)
+	default:
+		imagen, errImagen := rasterizarLienzo(diagrama)
+		if errImagen != nil {
+			err = errImagen
+		} else if formato == FormatoJPG {
+			err = jpeg.Encode(archivo, imagen, &jpeg.Options{Quality: 90})
+		} else {
+			err = png.Encode(archivo, imagen)
+		}
+	}
+	if errCerrar := archivo.Close(); err == nil {
+		err = errCerrar
+	}
+	if err != nil {
+		return fmt.Errorf("error al escribir la imagen: %v", err)
+	}
+	return nil
+}
@@ -5,8 +5,8 @@ import (
 	"errors"
 	"fmt"
 	global "godisk/Global"
+	utilidades "godisk/Utilidades"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -15,47 +15,53 @@ type REP struct {
 	path         string
 	name         string
 	path_file_ls string
+	renderer     string
+	format       string
 }
 
 func AnalizarRep(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 
 	cmd := &REP{}
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-id=[^\s]+|-path="[^"]+"|-path=[^\s]+|-name=[^\s]+|-path_file_ls="[^"]+"|-path_file_ls=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	params, err := utilidades.ParsearParametros(tokens, []string{"id", "path", "name", "path_file_ls", "renderer", "format"}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range params.Claves() {
+		value := params.Valor(key)
 
 		switch key {
-		case "-id":
+		case "id":
 			if value == "" {
 				return "", errors.New("el id no puede estar vacío")
 			}
 			cmd.id = value
-		case "-path":
+		case "path":
 			if value == "" {
 				return "", errors.New("el path no puede estar vacío")
 			}
 			cmd.path = value
-		case "-name":
+		case "name":
 			validNames := []string{"mbr", "disk", "inode", "block", "bm_inode", "bm_block", "sb", "file", "ls", "tree"}
 			if !contains(validNames, value) {
 				return "", errors.New("nombre inválido, debe ser uno de los siguientes: mbr, disk, inode, block, bm_inode, bm_block, sb, file, ls")
 			}
 			cmd.name = value
-		case "-path_file_ls":
+		case "path_file_ls":
 			cmd.path_file_ls = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		case "renderer":
+			value = strings.ToLower(value)
+			if value != RenderizadorGraphviz && value != RenderizadorInterno {
+				return "", errors.New("renderizador inválido, debe ser graphviz o builtin")
+			}
+			cmd.renderer = value
+		case "format":
+			formato := normalizarFormato(value)
+			if formato == "" {
+				return "", errors.New("formato inválido, debe ser uno de los siguientes: png, jpg, svg, pdf, dot, txt, json")
+			}
+			cmd.format = formato
 		}
 	}
 
@@ -63,7 +69,7 @@ func AnalizarRep(tokens []string) (string, error) {
 		return "", errors.New("faltan parámetros requeridos: -id, -path, -name")
 	}
 
-	err := commandRep(cmd, &outputBuffer)
+	err = commandRep(cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -86,80 +92,90 @@ func commandRep(rep *REP, outputBuffer *bytes.Buffer) error {
 		return err
 	}
 
-	file, err := os.Open(mountedDiskPath)
+	file, err := global.Discos.Abrir(mountedDiskPath, os.O_RDONLY)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
-	defer file.Close()
+	defer global.Discos.Cerrar(file)
+
+	// Los bitmaps, file y ls siguen siendo texto si la ruta no indica otro formato
+	formatoPorDefecto := FormatoPNG
+	if contains([]string{"bm_inode", "bm_block", "file", "ls"}, rep.name) {
+		formatoPorDefecto = FormatoTXT
+	}
+	opciones := OpcionesSalida{
+		Formato:      formatoReporte(rep.path, rep.format, formatoPorDefecto),
+		Renderizador: rep.renderer,
+	}
 
 	fmt.Fprintf(outputBuffer, "Generando reporte '%s'...\n", rep.name)
 	fmt.Printf("Generando reporte '%s'...\n", rep.name)
 
 	switch rep.name {
 	case "mbr":
-		err = ReporteMBR(mountedMbr, rep.path, file)
+		err = ReporteMBR(mountedMbr, rep.path, file, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte MBR: %v\n", err)
 			fmt.Printf("Error generando reporte MBR: %v\n", err)
 			return err
 		}
 	case "disk":
-		err = ReporteDisk(mountedMbr, rep.path, mountedDiskPath)
+		err = ReporteDisk(mountedMbr, rep.path, mountedDiskPath, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte del disco: %v\n", err)
 			fmt.Printf("Error generando reporte del disco: %v\n", err)
 			return err
 		}
 	case "inode":
-		err = ReporteInodo(mountedSb, mountedDiskPath, rep.path)
+		err = ReporteInodo(mountedSb, mountedDiskPath, rep.path, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte de inodos: %v\n", err)
 			fmt.Printf("Error generando reporte de inodos: %v\n", err)
 			return err
 		}
 	case "block":
-		err = ReporteBloque(mountedSb, mountedDiskPath, rep.path)
+		err = ReporteBloque(mountedSb, mountedDiskPath, rep.path, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte de bloques: %v\n", err)
 			fmt.Printf("Error generando reporte de bloques: %v\n", err)
 			return err
 		}
 	case "bm_inode":
-		err = ReporteBMInodo(mountedSb, mountedDiskPath, rep.path)
+		err = ReporteBMInodo(mountedSb, mountedDiskPath, rep.path, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte de bitmap de inodos: %v\n", err)
 			fmt.Printf("Error generando reporte de bitmap de inodos: %v\n", err)
 			return err
 		}
 	case "bm_block":
-		err = ReporteBMBloque(mountedSb, mountedDiskPath, rep.path)
+		err = ReporteBMBloque(mountedSb, mountedDiskPath, rep.path, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte de bitmap de bloques: %v\n", err)
 			fmt.Printf("Error generando reporte de bitmap de bloques: %v\n", err)
 		}
 	case "sb":
-		err = ReporteSb(mountedSb, mountedDiskPath, rep.path)
+		err = ReporteSb(mountedSb, mountedDiskPath, rep.path, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte del superbloque: %v\n", err)
 			fmt.Printf("Error generando reporte del superbloque: %v\n", err)
 			return err
 		}
 	case "file":
-		err = ReporteFile(mountedSb, mountedDiskPath, rep.path, rep.path_file_ls)
+		err = ReporteFile(mountedSb, mountedDiskPath, rep.path, rep.path_file_ls, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte de archivo: %v\n", err)
 			fmt.Printf("Error generando reporte de archivo: %v\n", err)
 			return err
 		}
 	case "tree":
-		err = ReporteTree(mountedSb, mountedDiskPath, rep.path)
+		err = ReporteTree(mountedSb, mountedDiskPath, rep.path, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte de árbol: %v\n", err)
 			fmt.Printf("Error generando reporte de árbol: %v\n", err)
 			return err
 		}
 	case "ls":
-		err = ReporteLS(mountedSb, mountedDiskPath, rep.path, rep.path_file_ls)
+		err = ReporteLS(mountedSb, mountedDiskPath, rep.path, rep.path_file_ls, opciones)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error generando reporte LS: %v\n", err)
 			fmt.Printf("Error generando reporte LS: %v\n", err)
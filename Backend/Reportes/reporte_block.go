@@ -3,33 +3,47 @@ package reportes
 import (
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"html"
 	"os"
-	"os/exec"
 	"strings"
 )
 
-func ReporteBloque(superbloque *estructuras.Superbloque, rutaDisco string, ruta string) error {
+// datosBloque es un bloque en la forma JSON de los reportes block y tree: las carpetas llevan
+// sus entradas y los archivos su contenido
+type datosBloque struct {
+	Indice    int32           `json:"index"`
+	Tipo      string          `json:"type"`
+	Contenido string          `json:"content,omitempty"`
+	Entradas  []entradaBloque `json:"entries,omitempty"`
+}
+
+// entradaBloque es una entrada de un bloque de carpeta; inode es -1 si no está asignada
+type entradaBloque struct {
+	Posicion int    `json:"position"`
+	Nombre   string `json:"name"`
+	Inodo    int32  `json:"inode"`
+}
+
+func ReporteBloque(superbloque *estructuras.Superbloque, rutaDisco string, ruta string, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(ruta)
 
 	if err != nil {
 		return fmt.Errorf("error al crear directorios: %v", err)
 	}
 
-	archivo, err := os.Open(rutaDisco)
+	archivo, err := globals.Discos.Abrir(rutaDisco, os.O_RDONLY)
 
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
 
-	defer archivo.Close()
-
-	dotFileName, outputImage := utilidades.ObtenerNombresArchivo(ruta)
+	defer globals.Discos.Cerrar(archivo)
 
 	dotContent := iniciarDotGraph()
 
-	dotContent, conexiones, err := generarGrafoBloque(dotContent, superbloque, archivo)
+	dotContent, conexiones, bloques, err := generarGrafoBloque(dotContent, superbloque, archivo)
 
 	if err != nil {
 		return err
@@ -38,41 +52,25 @@ func ReporteBloque(superbloque *estructuras.Superbloque, rutaDisco string, ruta
 	dotContent += conexiones
 	dotContent += "}"
 
-	err = escribirDotFile(dotFileName, dotContent)
-	if err != nil {
-		return err
-	}
-
-	err = ejecutarGraphviz(dotFileName, outputImage)
+	err = escribirReporte(ruta, opciones, contenidoReporte{dot: dotContent, datos: map[string]any{"blocks": bloques}})
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Imagen de los bloques generada:", outputImage)
+	fmt.Println("Imagen de los bloques generada:", ruta)
 	return nil
 }
 
-func GenerarImagenBloque(dotFileName string, outputImage string) error {
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz: %v", err)
-	}
-
-	return nil
-}
-
-func generarGrafoBloque(dotContent string, superbloque *estructuras.Superbloque, archivo *os.File) (string, string, error) {
+func generarGrafoBloque(dotContent string, superbloque *estructuras.Superbloque, archivo *os.File) (string, string, []datosBloque, error) {
 	visitedBlocks := make(map[int32]bool)
 	var conexiones string
+	bloques := []datosBloque{}
 
 	for i := int32(0); i < superbloque.S_inodes_count; i++ {
 		inodo := &estructuras.Inodo{}
 		err := inodo.Decode(archivo, int64(superbloque.S_inode_start+(i*superbloque.S_inode_size)))
 		if err != nil {
-			return "", "", fmt.Errorf("error al deserializar el inodo %d: %v", i, err)
+			return "", "", nil, fmt.Errorf("error al deserializar el inodo %d: %v", i, err)
 		}
 
 		if inodo.I_uid == -1 || inodo.I_uid == 0 {
@@ -82,33 +80,44 @@ func generarGrafoBloque(dotContent string, superbloque *estructuras.Superbloque,
 		for _, block := range inodo.I_block {
 			if block != -1 {
 				if !visitedBlocks[block] {
-					dotContent, conexiones, err = generarEtiquetaBloque(dotContent, conexiones, block, inodo, superbloque, archivo, visitedBlocks)
+					var bloque *datosBloque
+					dotContent, conexiones, bloque, err = generarEtiquetaBloque(dotContent, conexiones, block, inodo, superbloque, archivo, visitedBlocks)
 					if err != nil {
-						return "", "", err
+						return "", "", nil, err
+					}
+					if bloque != nil {
+						bloques = append(bloques, *bloque)
 					}
 					visitedBlocks[block] = true
 				}
 			}
 		}
 	}
-	return dotContent, conexiones, nil
+	return dotContent, conexiones, bloques, nil
 }
 
-func generarEtiquetaBloque(dotContent, conexiones string, indiceBloque int32, inodo *estructuras.Inodo, superbloque *estructuras.Superbloque, archivo *os.File, visitedBlocks map[int32]bool) (string, string, error) {
+// generarEtiquetaBloque agrega el nodo del bloque y devuelve sus datos, o nil si el bloque no
+// aparece en el grafo
+func generarEtiquetaBloque(dotContent, conexiones string, indiceBloque int32, inodo *estructuras.Inodo, superbloque *estructuras.Superbloque, archivo *os.File, visitedBlocks map[int32]bool) (string, string, *datosBloque, error) {
 	bloqueOffset := int64(superbloque.S_block_start + (indiceBloque * superbloque.S_block_size))
+	var datos *datosBloque
 
 	if inodo.I_type[0] == '0' {
-		bloqueFolder := &estructuras.FolderBlock{}
+		bloqueFolder := superbloque.NuevoBloqueCarpeta()
 		err := bloqueFolder.Decode(archivo, bloqueOffset)
 		if err != nil {
-			return "", "", fmt.Errorf("error al decodificar bloque de carpeta %d: %w", indiceBloque, err)
+			return "", "", nil, fmt.Errorf("error al decodificar bloque de carpeta %d: %w", indiceBloque, err)
 		}
 
 		label := fmt.Sprintf("BLOQUE DE CARPETA %d", indiceBloque)
 		hasValidConnections := false
+		carpeta := &datosBloque{Indice: indiceBloque, Tipo: "folder"}
 
 		for i, content := range bloqueFolder.B_content {
-			name := limpiarNombreBloque(content.B_name)
+			name := limpiarNombreBloque(superbloque, archivo, content)
+			if i > 1 {
+				carpeta.Entradas = append(carpeta.Entradas, entradaBloque{i + 1, name, content.B_inodo})
+			}
 
 			name = html.EscapeString(name)
 
@@ -127,18 +136,20 @@ func generarEtiquetaBloque(dotContent, conexiones string, indiceBloque int32, in
 
 		if hasValidConnections {
 			dotContent += fmt.Sprintf("block%d [label=\"%s\", shape=box, style=filled, fillcolor=\"#FFFDE7\", color=\"#EEEEEE\"];\n", indiceBloque, label)
+			datos = carpeta
 		}
 
 	} else if inodo.I_type[0] == '1' {
-		bloqueFile := &estructuras.ArchivoBloque{}
+		bloqueFile := superbloque.NuevoBloqueArchivo()
 		err := bloqueFile.Decode(archivo, bloqueOffset)
 		if err != nil {
-			return "", "", fmt.Errorf("error al decodificar bloque de archivo %d: %w", indiceBloque, err)
+			return "", "", nil, fmt.Errorf("error al decodificar bloque de archivo %d: %w", indiceBloque, err)
 		}
 
 		content := limpiarContenidoBloque(bloqueFile.GetContent())
 
 		if len(strings.TrimSpace(content)) > 0 {
+			datos = &datosBloque{Indice: indiceBloque, Tipo: "file", Contenido: bloqueFile.GetContent()}
 			label := fmt.Sprintf("BLOQUE DE ARCHIVO %d\\n%s", indiceBloque, content)
 			dotContent += fmt.Sprintf("block%d [label=\"%s\", shape=box, style=filled, fillcolor=\"#FFFDE7\", color=\"#EEEEEE\"];\n", indiceBloque, label)
 
@@ -154,7 +165,7 @@ func generarEtiquetaBloque(dotContent, conexiones string, indiceBloque int32, in
 		conexiones += fmt.Sprintf("block%d -> block%d [color=\"#FF7043\"];\n", parentBlock, indiceBloque)
 	}
 
-	return dotContent, conexiones, nil
+	return dotContent, conexiones, datos, nil
 }
 
 func encontrarBloquePadre(inodo *estructuras.Inodo, bloqueActual int32) int32 {
@@ -179,19 +190,13 @@ func encontrarSiguienteBloqueValido(inodo *estructuras.Inodo, bloqueActual int32
 	return -1
 }
 
-func limpiarNombreBloque(nameArray [12]byte) string {
-	return strings.TrimRight(string(nameArray[:]), "\x00")
+func limpiarNombreBloque(superbloque *estructuras.Superbloque, archivo *os.File, content estructuras.FolderContent) string {
+	if content.EsNombreLargo() {
+		return superbloque.NombreEntrada(archivo, content)
+	}
+	return strings.TrimRight(string(content.B_name[:]), "\x00")
 }
 
 func limpiarContenidoBloque(content string) string {
 	return strings.ReplaceAll(content, "\n", "\\n")
 }
-
-func ejecutarGraphviz(dotFileName string, outputImage string) error {
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error executing Graphviz: %v", err)
-	}
-	return nil
-}
@@ -4,22 +4,23 @@ import (
 	"encoding/binary"
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
 	"strings"
 )
 
-func ReporteBMBloque(superbloque *estructuras.Superbloque, rutaDisco string, rutaSalida string) error {
+func ReporteBMBloque(superbloque *estructuras.Superbloque, rutaDisco string, rutaSalida string, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(rutaSalida)
 	if err != nil {
 		return fmt.Errorf("error creando carpetas padre: %v", err)
 	}
 
-	archivo, err := os.Open(rutaDisco)
+	archivo, err := globals.Discos.Abrir(rutaDisco, os.O_RDONLY)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
-	defer archivo.Close()
+	defer globals.Discos.Cerrar(archivo)
 
 	totalBlocks := superbloque.S_blocks_count + superbloque.S_free_blocks_count
 
@@ -56,15 +57,9 @@ func ReporteBMBloque(superbloque *estructuras.Superbloque, rutaDisco string, rut
 		}
 	}
 
-	archivoTXT, err := os.Create(rutaSalida)
+	err = escribirReporte(rutaSalida, opciones, reporteBitmap("BITMAP DE BLOQUES", totalBlocks, bitmapContent.String()))
 	if err != nil {
-		return fmt.Errorf("error al crear el archivo de reporte: %v", err)
-	}
-	defer archivoTXT.Close()
-
-	_, err = archivoTXT.WriteString(bitmapContent.String())
-	if err != nil {
-		return fmt.Errorf("error al escribir en el archivo de reporte: %v", err)
+		return err
 	}
 
 	fmt.Println("Reporte del bitmap de bloques generado correctamente:", rutaSalida)
@@ -4,25 +4,26 @@ import (
 	"encoding/binary"
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
 	"strings"
 )
 
-func ReporteBMInodo(superbloque *estructuras.Superbloque, rutaDisco string, rutaSalida string) error {
+func ReporteBMInodo(superbloque *estructuras.Superbloque, rutaDisco string, rutaSalida string, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(rutaSalida)
 
 	if err != nil {
 		return fmt.Errorf("error creando carpetas padre: %v", err)
 	}
 
-	archivo, err := os.Open(rutaDisco)
+	archivo, err := globals.Discos.Abrir(rutaDisco, os.O_RDONLY)
 
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
 
-	defer archivo.Close()
+	defer globals.Discos.Cerrar(archivo)
 
 	totalInodos := superbloque.S_inodes_count + superbloque.S_free_inodes_count
 
@@ -59,18 +60,28 @@ func ReporteBMInodo(superbloque *estructuras.Superbloque, rutaDisco string, ruta
 		}
 	}
 
-	archivoTXT, err := os.Create(rutaSalida)
+	err = escribirReporte(rutaSalida, opciones, reporteBitmap("BITMAP DE INODOS", totalInodos, contenidoBitmap.String()))
 	if err != nil {
-		return fmt.Errorf("error al crear el archivo de reporte: %v", err)
-	}
-	defer archivoTXT.Close()
-
-	_, err = archivoTXT.WriteString(contenidoBitmap.String())
-	if err != nil {
-		return fmt.Errorf("error al escribir en el archivo de reporte: %v", err)
+		return err
 	}
 
 	fmt.Println("Reporte del bitmap de inodos generado correctamente:", rutaSalida)
 
 	return nil
 }
+
+// reporteBitmap arma las formas de un reporte de bitmap: el texto de siempre, con 20 bits por
+// línea, una tabla con esas mismas líneas y en JSON el total, los usados y el bitmap completo
+func reporteBitmap(titulo string, total int32, texto string) contenidoReporte {
+	lineas := strings.Split(strings.TrimSuffix(texto, "\n"), "\n")
+	filas := make([][]string, 0, len(lineas))
+	for _, linea := range lineas {
+		filas = append(filas, []string{linea})
+	}
+	bitmap := strings.ReplaceAll(texto, "\n", "")
+	return contenidoReporte{
+		dot:   dotDeTabla(titulo, nil, filas),
+		texto: texto,
+		datos: map[string]any{"total": total, "used": strings.Count(bitmap, "1"), "bitmap": bitmap},
+	}
+}
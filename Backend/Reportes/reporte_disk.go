@@ -3,26 +3,39 @@ package reportes
 import (
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"os/exec"
 	"strings"
 )
 
-func ReporteDisk(mbr *estructuras.Mbr, path string, diskPath string) error {
+// segmentoDisco es una división del reporte disk en JSON; la extendida lleva dentro sus EBR,
+// lógicas y espacio libre
+type segmentoDisco struct {
+	Tipo       string          `json:"type"`
+	Nombre     string          `json:"name,omitempty"`
+	Tamano     int32           `json:"size,omitempty"`
+	Porcentaje float64         `json:"percent,omitempty"`
+	Segmentos  []segmentoDisco `json:"segments,omitempty"`
+}
+
+type datosDisco struct {
+	Tamano    int32           `json:"disk_size"`
+	Segmentos []segmentoDisco `json:"segments"`
+}
+
+func ReporteDisk(mbr *estructuras.Mbr, path string, diskPath string, opciones OpcionesSalida) error {
 
 	err := utilidades.CrearDirectoriosPadre(path)
 	if err != nil {
 		return fmt.Errorf("error al crear directorios: %v", err)
 	}
 
-	file, err := os.Open(diskPath)
+	file, err := globals.Discos.Abrir(diskPath, os.O_RDONLY)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
-	defer file.Close()
-
-	dotFileName, outputImage := utilidades.ObtenerNombresArchivo(path)
+	defer globals.Discos.Cerrar(file)
 
 	dotContent := `digraph G {
 		fontname="Helvetica,Arial,sans-serif"
@@ -40,6 +53,7 @@ func ReporteDisk(mbr *estructuras.Mbr, path string, diskPath string) error {
 	usedSize := int32(0)
 
 	dotContent += "{MBR}"
+	datos := datosDisco{Tamano: totalSize, Segmentos: []segmentoDisco{{Tipo: "mbr"}}}
 
 	for _, part := range mbr.Mbr_partitions {
 		if part.Part_s > 0 {
@@ -49,11 +63,13 @@ func ReporteDisk(mbr *estructuras.Mbr, path string, diskPath string) error {
 			partName := strings.TrimRight(string(part.Part_name[:]), "\x00")
 			if part.Part_type[0] == 'P' {
 				dotContent += fmt.Sprintf("|{Primaria %s\\n%.2f%%}", partName, percentage)
+				datos.Segmentos = append(datos.Segmentos, segmentoDisco{Tipo: "primary", Nombre: partName, Tamano: part.Part_s, Porcentaje: percentage})
 			} else if part.Part_type[0] == 'E' {
 				dotContent += fmt.Sprintf("|{Extendida %.2f%%|{", percentage)
 				ebrStart := part.Part_start
 				ebrCount := 0
 				ebrUsedSize := int32(0)
+				extendida := segmentoDisco{Tipo: "extended", Nombre: partName, Tamano: part.Part_s, Porcentaje: percentage}
 				for ebrStart != -1 {
 					ebr := &estructuras.Ebr{}
 					err := ebr.Decodificar(file, int64(ebrStart))
@@ -69,6 +85,9 @@ func ReporteDisk(mbr *estructuras.Mbr, path string, diskPath string) error {
 						dotContent += "|"
 					}
 					dotContent += fmt.Sprintf("{EBR|Lógica %s\\n%.2f%%}", ebrName, ebrPercentage)
+					extendida.Segmentos = append(extendida.Segmentos,
+						segmentoDisco{Tipo: "ebr"},
+						segmentoDisco{Tipo: "logical", Nombre: ebrName, Tamano: ebr.Part_s, Porcentaje: ebrPercentage})
 
 					ebrStart = ebr.Part_next
 					ebrCount++
@@ -78,9 +97,11 @@ func ReporteDisk(mbr *estructuras.Mbr, path string, diskPath string) error {
 				if extendedFreeSize > 0 {
 					extendedFreePercentage := (float64(extendedFreeSize) / float64(totalSize)) * 100
 					dotContent += fmt.Sprintf("|Libre %.2f%%", extendedFreePercentage)
+					extendida.Segmentos = append(extendida.Segmentos, segmentoDisco{Tipo: "free", Tamano: extendedFreeSize, Porcentaje: extendedFreePercentage})
 				}
 
 				dotContent += "}}"
+				datos.Segmentos = append(datos.Segmentos, extendida)
 			}
 		}
 	}
@@ -89,6 +110,7 @@ func ReporteDisk(mbr *estructuras.Mbr, path string, diskPath string) error {
 	if freeSize > 0 {
 		freePercentage := (float64(freeSize) / float64(totalSize)) * 100
 		dotContent += fmt.Sprintf("|Libre %.2f%%", freePercentage)
+		datos.Segmentos = append(datos.Segmentos, segmentoDisco{Tipo: "free", Tamano: freeSize, Porcentaje: freePercentage})
 	}
 
 	dotContent += `"];
@@ -96,23 +118,11 @@ func ReporteDisk(mbr *estructuras.Mbr, path string, diskPath string) error {
 		title -> dsk [style=invis];
 	}`
 
-	dotFile, err := os.Create(dotFileName)
-	if err != nil {
-		return fmt.Errorf("error al crear el archivo DOT: %v", err)
-	}
-	defer dotFile.Close()
-
-	_, err = dotFile.WriteString(dotContent)
-	if err != nil {
-		return fmt.Errorf("error al escribir en el archivo DOT: %v", err)
-	}
-
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	err = cmd.Run()
+	err = escribirReporte(path, opciones, contenidoReporte{dot: dotContent, datos: datos})
 	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz: %v", err)
+		return err
 	}
 
-	fmt.Println("Reporte de disco generado:", outputImage)
+	fmt.Println("Reporte de disco generado:", path)
 	return nil
 }
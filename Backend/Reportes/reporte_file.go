@@ -3,25 +3,26 @@ package reportes
 import (
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-func ReporteFile(superbloque *estructuras.Superbloque, rutaDisco string, ruta string, rutaArchivo string) error {
+func ReporteFile(superbloque *estructuras.Superbloque, rutaDisco string, ruta string, rutaArchivo string, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(ruta)
 	if err != nil {
 		return fmt.Errorf("error al crear directorios: %v", err)
 	}
 
-	archivo, err := os.Open(rutaDisco)
+	archivo, err := globals.Discos.Abrir(rutaDisco, os.O_RDONLY)
 
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
 
-	defer archivo.Close()
+	defer globals.Discos.Cerrar(archivo)
 
 	inodoIndice, err := encontrarArchivoInodo(superbloque, archivo, rutaArchivo)
 
@@ -35,20 +36,22 @@ func ReporteFile(superbloque *estructuras.Superbloque, rutaDisco string, ruta st
 		return fmt.Errorf("error al leer el contenido del archivo: %v", err)
 	}
 
-	reporteArchivo, err := os.Create(ruta)
+	_, nombreArchivo := filepath.Split(rutaArchivo)
+	contenido := strings.TrimRight(fileContent, "\x00")
+	reportContent := fmt.Sprintf("Nombre del archivo: %s\n\nContenido del archivo:\n%s", nombreArchivo, contenido)
 
-	if err != nil {
-		return fmt.Errorf("error al crear el archivo de reporte: %v", err)
+	filas := [][]string{}
+	for _, linea := range strings.Split(contenido, "\n") {
+		filas = append(filas, []string{linea})
 	}
 
-	defer reporteArchivo.Close()
-
-	_, nombreArchivo := filepath.Split(rutaArchivo)
-	reportContent := fmt.Sprintf("Nombre del archivo: %s\n\nContenido del archivo:\n%s", nombreArchivo, fileContent)
-
-	_, err = reporteArchivo.WriteString(reportContent)
+	err = escribirReporte(ruta, opciones, contenidoReporte{
+		dot:   dotDeTabla(nombreArchivo, nil, filas),
+		texto: reportContent,
+		datos: map[string]any{"name": nombreArchivo, "path": rutaArchivo, "content": contenido},
+	})
 	if err != nil {
-		return fmt.Errorf("error al escribir en el archivo de reporte: %v", err)
+		return err
 	}
 
 	fmt.Println("Reporte del archivo generado:", ruta)
@@ -99,11 +102,13 @@ func leerContenidoArchivo(superbloque *estructuras.Superbloque, archivoDisco *os
 		return "", fmt.Errorf("error al leer el inodo del archivo: %v", err)
 	}
 
+	indicesBloques, err := inodo.GetDataBlockIndexes(archivoDisco, superbloque)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener los bloques del archivo: %v", err)
+	}
+
 	var contenido string
-	for _, indiceBloque := range inodo.I_block {
-		if indiceBloque == -1 {
-			continue
-		}
+	for _, indiceBloque := range indicesBloques {
 
 		bloque, err := leerArchivoBloque(superbloque, archivoDisco, indiceBloque)
 		if err != nil {
@@ -129,7 +134,7 @@ func leerInodo(superblock *estructuras.Superbloque, archivoDisco *os.File, inode
 }
 
 func leerArchivoBloque(superblock *estructuras.Superbloque, archivoDisco *os.File, indiceBloque int32) (*estructuras.ArchivoBloque, error) {
-	bloque := &estructuras.ArchivoBloque{}
+	bloque := superblock.NuevoBloqueArchivo()
 	offset := int64(superblock.S_block_start + indiceBloque*superblock.S_block_size)
 	err := bloque.Decode(archivoDisco, offset)
 	if err != nil {
@@ -144,7 +149,7 @@ func encontrarInodoEnDirectorio(inodo *estructuras.Inodo, archivoDisco *os.File,
 			continue
 		}
 
-		bloque := &estructuras.FolderBlock{}
+		bloque := superbloque.NuevoBloqueCarpeta()
 		offset := int64(superbloque.S_block_start + indiceBloque*superbloque.S_block_size)
 		err := bloque.Decode(archivoDisco, offset)
 		if err != nil {
@@ -152,7 +157,7 @@ func encontrarInodoEnDirectorio(inodo *estructuras.Inodo, archivoDisco *os.File,
 		}
 
 		for _, contenido := range bloque.B_content {
-			nombreContenido := strings.Trim(string(contenido.B_name[:]), "\x00 ")
+			nombreContenido := superbloque.NombreEntrada(archivoDisco, contenido)
 			if nombreContenido == nombre {
 				return true, contenido.B_inodo
 			}
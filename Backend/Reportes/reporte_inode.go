@@ -3,27 +3,54 @@ package reportes
 import (
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"os/exec"
 	"time"
 )
 
-func ReporteInodo(superbloque *estructuras.Superbloque, rutaDisco string, ruta string) error {
+// datosInodo es un inodo en la forma JSON de los reportes inode y tree
+type datosInodo struct {
+	Indice int32     `json:"index"`
+	Uid    int32     `json:"i_uid"`
+	Gid    int32     `json:"i_gid"`
+	Size   int32     `json:"i_size"`
+	Atime  string    `json:"i_atime"`
+	Ctime  string    `json:"i_ctime"`
+	Mtime  string    `json:"i_mtime"`
+	Type   string    `json:"i_type"`
+	Perm   string    `json:"i_perm"`
+	Block  [15]int32 `json:"i_block"`
+}
+
+func nuevoDatosInodo(indiceInodo int32, inodo *estructuras.Inodo) datosInodo {
+	return datosInodo{
+		Indice: indiceInodo,
+		Uid:    inodo.I_uid,
+		Gid:    inodo.I_gid,
+		Size:   inodo.I_size,
+		Atime:  time.Unix(int64(inodo.I_atime), 0).Format(time.RFC3339),
+		Ctime:  time.Unix(int64(inodo.I_ctime), 0).Format(time.RFC3339),
+		Mtime:  time.Unix(int64(inodo.I_mtime), 0).Format(time.RFC3339),
+		Type:   string(inodo.I_type[:]),
+		Perm:   string(inodo.I_perm[:]),
+		Block:  inodo.I_block,
+	}
+}
+
+func ReporteInodo(superbloque *estructuras.Superbloque, rutaDisco string, ruta string, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(ruta)
 	if err != nil {
 		return fmt.Errorf("error al crear directorios: %v", err)
 	}
 
-	archivo, err := os.Open(rutaDisco)
+	archivo, err := globals.Discos.Abrir(rutaDisco, os.O_RDONLY)
 
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
 
-	defer archivo.Close()
-
-	dotFileName, outputImage := utilidades.ObtenerNombresArchivo(ruta)
+	defer globals.Discos.Cerrar(archivo)
 
 	dotContent := iniciarDotGraph()
 
@@ -31,24 +58,19 @@ func ReporteInodo(superbloque *estructuras.Superbloque, rutaDisco string, ruta s
 		return fmt.Errorf("no hay inodos en el sistema")
 	}
 
-	dotContent, err = generarGrafoInodo(dotContent, superbloque, archivo)
+	dotContent, inodos, err := generarGrafoInodo(dotContent, superbloque, archivo)
 	if err != nil {
 		return err
 	}
 
 	dotContent += "}"
 
-	err = escribirDotFile(dotFileName, dotContent)
-	if err != nil {
-		return err
-	}
-
-	err = generarImagenInodo(dotFileName, outputImage)
+	err = escribirReporte(ruta, opciones, contenidoReporte{dot: dotContent, datos: map[string]any{"inodes": inodos}})
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Imagen de los inodos generada:", outputImage)
+	fmt.Println("Imagen de los inodos generada:", ruta)
 	return nil
 }
 
@@ -68,12 +90,13 @@ func iniciarDotGraph() string {
 	`
 }
 
-func generarGrafoInodo(dotContent string, superbloque *estructuras.Superbloque, archivo *os.File) (string, error) {
+func generarGrafoInodo(dotContent string, superbloque *estructuras.Superbloque, archivo *os.File) (string, []datosInodo, error) {
+	inodos := []datosInodo{}
 	for i := int32(0); i < superbloque.S_inodes_count; i++ {
 		inodo := &estructuras.Inodo{}
 		err := inodo.Decode(archivo, int64(superbloque.S_inode_start+(i*superbloque.S_inode_size)))
 		if err != nil {
-			return "", fmt.Errorf("error al deserializar el inodo %d: %v", i, err)
+			return "", nil, fmt.Errorf("error al deserializar el inodo %d: %v", i, err)
 		}
 
 		if inodo.I_uid == -1 || inodo.I_uid == 0 {
@@ -81,12 +104,13 @@ func generarGrafoInodo(dotContent string, superbloque *estructuras.Superbloque,
 		}
 
 		dotContent += generarTablaInodo(i, inodo)
+		inodos = append(inodos, nuevoDatosInodo(i, inodo))
 
 		if i < superbloque.S_inodes_count-1 {
 			dotContent += fmt.Sprintf("inodo%d -> inodo%d [color=\"#FF7043\"];\n", i, i+1)
 		}
 	}
-	return dotContent, nil
+	return dotContent, inodos, nil
 }
 
 func generarTablaInodo(indiceInodo int32, inodo *estructuras.Inodo) string {
@@ -160,13 +184,3 @@ func escribirDotFile(dotFileName string, dotContent string) error {
 
 	return nil
 }
-
-func generarImagenInodo(dotFileName string, outputImage string) error {
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz: %v", err)
-	}
-
-	return nil
-}
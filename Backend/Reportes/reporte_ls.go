@@ -3,53 +3,72 @@ package reportes
 import (
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
 	"strings"
 	"time"
 )
 
-func ReporteLS(superbloque *estructuras.Superbloque, rutaDisco string, rutaReporte string, rutaCarpeta string) error {
+func ReporteLS(superbloque *estructuras.Superbloque, rutaDisco string, rutaReporte string, rutaCarpeta string, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(rutaReporte)
 	if err != nil {
 		return fmt.Errorf("error al crear directorios: %v", err)
 	}
 
-	archivo, err := os.Open(rutaDisco)
+	archivo, err := globals.Discos.Abrir(rutaDisco, os.O_RDONLY)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
-	defer archivo.Close()
+	defer globals.Discos.Cerrar(archivo)
 
 	inodoIndice, err := encontrarCarpetaInodo(superbloque, archivo, rutaCarpeta)
 	if err != nil {
 		return fmt.Errorf("error al buscar el inodo de la carpeta: %v", err)
 	}
 
-	tabla, err := generarTablaLS(superbloque, archivo, inodoIndice)
+	tabla, entradas, err := generarTablaLS(superbloque, archivo, inodoIndice)
 	if err != nil {
 		return fmt.Errorf("error al leer el contenido de la carpeta: %v", err)
 	}
 
-	reporteArchivo, err := os.Create(rutaReporte)
-	if err != nil {
-		return fmt.Errorf("error al crear el archivo de reporte: %v", err)
+	filas := make([][]string, 0, len(entradas))
+	for _, entrada := range entradas {
+		filas = append(filas, []string{entrada.Permisos, entrada.Owner, entrada.Grupo, fmt.Sprint(entrada.Size), entrada.Fecha, entrada.Hora, entrada.Tipo, entrada.Nombre})
 	}
-	defer reporteArchivo.Close()
+	encabezados := []string{"Permisos", "Owner", "Grupo", "Size (en Bytes)", "Fecha", "Hora", "Tipo", "Name"}
 
-	_, err = reporteArchivo.WriteString(tabla)
+	err = escribirReporte(rutaReporte, opciones, contenidoReporte{
+		dot:   dotDeTabla("LS "+rutaCarpeta, encabezados, filas),
+		texto: tabla,
+		datos: map[string]any{"path": rutaCarpeta, "entries": entradas},
+	})
 	if err != nil {
-		return fmt.Errorf("error al escribir en el archivo de reporte: %v", err)
+		return err
 	}
 
 	fmt.Println("Reporte LS generado:", rutaReporte)
 	return nil
 }
-func generarTablaLS(superbloque *estructuras.Superbloque, archivoDisco *os.File, indiceInodoCarpeta int32) (string, error) {
+
+// entradaLS es una fila del reporte ls, también su forma JSON
+type entradaLS struct {
+	Permisos string `json:"permissions"`
+	Owner    string `json:"owner"`
+	Grupo    string `json:"group"`
+	Size     int32  `json:"size"`
+	Fecha    string `json:"date"`
+	Hora     string `json:"time"`
+	Tipo     string `json:"type"`
+	Nombre   string `json:"name"`
+}
+
+func generarTablaLS(superbloque *estructuras.Superbloque, archivoDisco *os.File, indiceInodoCarpeta int32) (string, []entradaLS, error) {
 	inodoCarpeta, err := leerInodoLS(superbloque, archivoDisco, indiceInodoCarpeta)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
+	entradas := []entradaLS{}
 	var tabla strings.Builder
 	tabla.WriteString("| Permisos | Owner | Grupo | Size (en Bytes) | Fecha | Hora | Tipo | Name |\n")
 	tabla.WriteString("|----------|-------|-------|-----------------|-------|------|------|------|\n")
@@ -57,14 +76,14 @@ func generarTablaLS(superbloque *estructuras.Superbloque, archivoDisco *os.File,
 		if indiceBloque == -1 {
 			continue
 		}
-		bloque := &estructuras.FolderBlock{}
+		bloque := superbloque.NuevoBloqueCarpeta()
 		offset := int64(superbloque.S_block_start + indiceBloque*superbloque.S_block_size)
 		err := bloque.Decode(archivoDisco, offset)
 		if err != nil {
 			continue
 		}
 		for _, entry := range bloque.B_content {
-			nombre := strings.Trim(string(entry.B_name[:]), "\x00 ")
+			nombre := superbloque.NombreEntrada(archivoDisco, entry)
 			if nombre == "" || nombre == "." || nombre == ".." {
 				continue
 			}
@@ -88,9 +107,10 @@ func generarTablaLS(superbloque *estructuras.Superbloque, archivoDisco *os.File,
 			}
 			tabla.WriteString(fmt.Sprintf("| %s | %s | %s | %d | %s | %s | %s | %s |\n",
 				permisos, owner, grupo, size, fecha, hora, tipo, nombre))
+			entradas = append(entradas, entradaLS{permisos, owner, grupo, size, fecha, hora, tipo, nombre})
 		}
 	}
-	return tabla.String(), nil
+	return tabla.String(), entradas, nil
 }
 
 func obtenerOwner(uid int32) string {
@@ -129,14 +149,14 @@ func leerContenidoCarpeta(superbloque *estructuras.Superbloque, archivoDisco *os
 		if indiceBloque == -1 {
 			continue
 		}
-		bloque := &estructuras.FolderBlock{}
+		bloque := superbloque.NuevoBloqueCarpeta()
 		offset := int64(superbloque.S_block_start + indiceBloque*superbloque.S_block_size)
 		err := bloque.Decode(archivoDisco, offset)
 		if err != nil {
 			continue
 		}
 		for _, entry := range bloque.B_content {
-			nombre := strings.Trim(string(entry.B_name[:]), "\x00 ")
+			nombre := superbloque.NombreEntrada(archivoDisco, entry)
 			if nombre != "" && nombre != "." && nombre != ".." {
 				contenido.WriteString(fmt.Sprintf("- %s\n", nombre))
 			}
@@ -160,14 +180,14 @@ func buscarInodoEnDirectorioLS(inodo *estructuras.Inodo, archivoDisco *os.File,
 		if indiceBloque == -1 {
 			continue
 		}
-		bloque := &estructuras.FolderBlock{}
+		bloque := superbloque.NuevoBloqueCarpeta()
 		offset := int64(superbloque.S_block_start + indiceBloque*superbloque.S_block_size)
 		err := bloque.Decode(archivoDisco, offset)
 		if err != nil {
 			continue
 		}
 		for _, contenido := range bloque.B_content {
-			nombreContenido := strings.Trim(string(contenido.B_name[:]), "\x00 ")
+			nombreContenido := superbloque.NombreEntrada(archivoDisco, contenido)
 			if nombreContenido == nombre {
 				return true, contenido.B_inodo
 			}
@@ -5,18 +5,57 @@ import (
 	estructuras "godisk/Estructuras"
 	utilidades "godisk/Utilidades"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 )
 
-func ReporteMBR(mbr *estructuras.Mbr, path string, file *os.File) error {
+// datosMBR es la forma JSON del reporte mbr, con los mismos campos que la tabla
+type datosMBR struct {
+	Tamano        int32             `json:"mbr_tamano"`
+	FechaCreacion string            `json:"mbr_fecha_creacion"`
+	DiskSignature int32             `json:"mbr_disk_signature"`
+	Particiones   []particionMBR    `json:"partitions"`
+	NoAsignado    []espacioLibreMBR `json:"unallocated"`
+}
+
+type particionMBR struct {
+	Numero int      `json:"number"`
+	Status string   `json:"part_status"`
+	Type   string   `json:"part_type"`
+	Fit    string   `json:"part_fit"`
+	Start  int32    `json:"part_start"`
+	Size   int32    `json:"part_size"`
+	Name   string   `json:"part_name"`
+	Ebrs   []ebrMBR `json:"ebrs,omitempty"`
+}
+
+type ebrMBR struct {
+	Inicio int32  `json:"start"`
+	Fit    string `json:"ebr_fit"`
+	Start  int32  `json:"ebr_start"`
+	Size   int32  `json:"ebr_size"`
+	Next   int32  `json:"ebr_next"`
+	Name   string `json:"ebr_name"`
+}
+
+type espacioLibreMBR struct {
+	Start int32 `json:"start"`
+	Size  int32 `json:"size"`
+}
+
+func ReporteMBR(mbr *estructuras.Mbr, path string, file *os.File, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(path)
 	if err != nil {
 		return err
 	}
 
-	dotFileName, outputImage := utilidades.ObtenerNombresArchivo(path)
+	datos := datosMBR{
+		Tamano:        mbr.Mbr_tamano,
+		FechaCreacion: time.Unix(int64(mbr.Mbr_fecha_creacion), 0).Format(time.RFC3339),
+		DiskSignature: mbr.Mbr_dsk_signature,
+		Particiones:   []particionMBR{},
+		NoAsignado:    []espacioLibreMBR{},
+	}
 
 	primaryColor := "#FFDDC1"
 	extendedColor := "#C1E1C1"
@@ -44,6 +83,7 @@ func ReporteMBR(mbr *estructuras.Mbr, path string, file *os.File) error {
 				dotContent += fmt.Sprintf(`
                     <tr><td colspan="2" bgcolor="%s"><b>ESPACIO NO ASIGNADO (Tamaño: %d bytes)</b></td></tr>
                 `, unallocatedColor, unallocatedSize)
+				datos.NoAsignado = append(datos.NoAsignado, espacioLibreMBR{allocatedSize, unallocatedSize})
 				allocatedSize += unallocatedSize
 			}
 
@@ -76,6 +116,16 @@ func ReporteMBR(mbr *estructuras.Mbr, path string, file *os.File) error {
 				rowColor, rowColor, part.Part_s,
 				rowColor, rowColor, partName)
 
+			particion := particionMBR{
+				Numero: i + 1,
+				Status: string(partStatus),
+				Type:   string(partType),
+				Fit:    string(partFit),
+				Start:  part.Part_start,
+				Size:   part.Part_s,
+				Name:   partName,
+			}
+
 			allocatedSize += part.Part_s
 
 			if partType == 'E' {
@@ -107,6 +157,7 @@ func ReporteMBR(mbr *estructuras.Mbr, path string, file *os.File) error {
 						ebrColor, ebrColor, ebr.Part_s,
 						ebrColor, ebrColor, ebr.Part_next,
 						ebrColor, ebrColor, ebrName)
+					particion.Ebrs = append(particion.Ebrs, ebrMBR{ebrStart, string(ebrFit), ebr.Part_start, ebr.Part_s, ebr.Part_next, ebrName})
 
 					if ebr.Part_s > 0 {
 						dotContent += fmt.Sprintf(`
@@ -118,6 +169,7 @@ func ReporteMBR(mbr *estructuras.Mbr, path string, file *os.File) error {
 					ebrStart = ebr.Part_next
 				}
 			}
+			datos.Particiones = append(datos.Particiones, particion)
 		}
 	}
 
@@ -126,27 +178,16 @@ func ReporteMBR(mbr *estructuras.Mbr, path string, file *os.File) error {
 		dotContent += fmt.Sprintf(`
             <tr><td colspan="2" bgcolor="%s"><b>ESPACIO NO ASIGNADO (Tamaño: %d bytes)</b></td></tr>
         `, unallocatedColor, unallocatedSize)
+		datos.NoAsignado = append(datos.NoAsignado, espacioLibreMBR{allocatedSize, unallocatedSize})
 	}
 
 	dotContent += "</table>>] }"
 
-	file, err = os.Create(dotFileName)
-	if err != nil {
-		return fmt.Errorf("error al crear el archivo: %v", err)
-	}
-	defer file.Close()
-
-	_, err = file.WriteString(dotContent)
+	err = escribirReporte(path, opciones, contenidoReporte{dot: dotContent, datos: datos})
 	if err != nil {
-		return fmt.Errorf("error al escribir en el archivo: %v", err)
-	}
-
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	err = cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz: %v", err)
+		return err
 	}
 
-	fmt.Println("Imagen de la tabla generada:", outputImage)
+	fmt.Println("Imagen de la tabla generada:", path)
 	return nil
 }
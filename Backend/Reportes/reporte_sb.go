@@ -4,50 +4,70 @@ import (
 	"fmt"
 	estructuras "godisk/Estructuras"
 	utilidades "godisk/Utilidades"
-	"os/exec"
 	"time"
 )
 
-func ReporteSb(superbloque *estructuras.Superbloque, rutaDisco string, ruta string) error {
+// datosSuperbloque es la forma JSON del reporte sb, con los campos que muestra la tabla
+type datosSuperbloque struct {
+	InodesCount     int32  `json:"s_inodes_count"`
+	BlocksCount     int32  `json:"s_blocks_count"`
+	FreeInodesCount int32  `json:"s_free_inodes_count"`
+	FreeBlocksCount int32  `json:"s_free_blocks_count"`
+	InodeSize       int32  `json:"s_inode_size"`
+	BlockSize       int32  `json:"s_block_size"`
+	FirstIno        int32  `json:"s_first_ino"`
+	FirstBlo        int32  `json:"s_first_blo"`
+	BmInodeStart    int32  `json:"s_bm_inode_start"`
+	BmBlockStart    int32  `json:"s_bm_block_start"`
+	Mtime           string `json:"s_mtime"`
+	Umtime          string `json:"s_umtime"`
+	MntCount        int32  `json:"s_mnt_count"`
+	Estado          string `json:"state"`
+}
+
+func nuevoDatosSuperbloque(superbloque *estructuras.Superbloque) datosSuperbloque {
+	estado := "Desmontado correctamente"
+	if superbloque.Sucio() {
+		estado = "Montado (sucio)"
+	}
+	return datosSuperbloque{
+		InodesCount:     superbloque.S_inodes_count,
+		BlocksCount:     superbloque.S_blocks_count,
+		FreeInodesCount: superbloque.S_free_inodes_count,
+		FreeBlocksCount: superbloque.S_free_blocks_count,
+		InodeSize:       superbloque.S_inode_size,
+		BlockSize:       superbloque.S_block_size,
+		FirstIno:        superbloque.S_first_ino,
+		FirstBlo:        superbloque.S_first_blo,
+		BmInodeStart:    superbloque.S_bm_inode_start,
+		BmBlockStart:    superbloque.S_bm_block_start,
+		Mtime:           time.Unix(int64(superbloque.S_mtime), 0).Format(time.RFC3339),
+		Umtime:          time.Unix(int64(superbloque.S_umtime), 0).Format(time.RFC3339),
+		MntCount:        superbloque.S_mnt_count,
+		Estado:          estado,
+	}
+}
+
+func ReporteSb(superbloque *estructuras.Superbloque, rutaDisco string, ruta string, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(ruta)
 	if err != nil {
 		return fmt.Errorf("error al crear directorios: %v", err)
 	}
 
-	dotFileName, outputImage := utilidades.ObtenerNombresArchivo(ruta)
-
-	dotContent := iniciarGrafoDotParaSuperbloque(superbloque)
+	datos := nuevoDatosSuperbloque(superbloque)
+	dotContent := iniciarGrafoDotParaSuperbloque(datos)
 
-	err = escribirDotFile(dotFileName, dotContent)
+	err = escribirReporte(ruta, opciones, contenidoReporte{dot: dotContent, datos: datos})
 	if err != nil {
 		return err
 	}
 
-	err = generarImagenSuperbloque(dotFileName, outputImage)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("Imagen del Superbloque generada:", outputImage)
+	fmt.Println("Imagen del Superbloque generada:", ruta)
 
 	return nil
 }
 
-func generarImagenSuperbloque(dotFileName string, outputImage string) error {
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz para generar la imagen del Superbloque: %v", err)
-	}
-
-	return nil
-}
-
-func iniciarGrafoDotParaSuperbloque(superbloque *estructuras.Superbloque) string {
-	mtime := time.Unix(int64(superbloque.S_mtime), 0).Format(time.RFC3339)
-	umtime := time.Unix(int64(superbloque.S_umtime), 0).Format(time.RFC3339)
-
+func iniciarGrafoDotParaSuperbloque(datos datosSuperbloque) string {
 	dotContent := `
 		digraph G {
 			fontname="Helvetica,Arial,sans-serif"
@@ -69,25 +89,29 @@ func iniciarGrafoDotParaSuperbloque(superbloque *estructuras.Superbloque) string
 					<tr><td><b>Primer Bloque Libre</b></td><td>%d</td></tr>
 					<tr><td><b>Inicio Bitmap de Inodos</b></td><td>%d</td></tr>
 					<tr><td><b>Inicio Bitmap de Bloques</b></td><td>%d</td></tr>
-					<tr><td><b>Última Modificación</b></td><td>%s</td></tr>
 					<tr><td><b>Último Montaje</b></td><td>%s</td></tr>
+					<tr><td><b>Último Desmontaje</b></td><td>%s</td></tr>
+					<tr><td><b>Cantidad de Montajes</b></td><td>%d</td></tr>
+					<tr><td><b>Estado</b></td><td>%s</td></tr>
 				</table>>];
 		}
 	`
 
 	dotContent = fmt.Sprintf(dotContent,
-		superbloque.S_inodes_count,
-		superbloque.S_blocks_count,
-		superbloque.S_free_inodes_count,
-		superbloque.S_free_blocks_count,
-		superbloque.S_inode_size,
-		superbloque.S_block_size,
-		superbloque.S_first_ino,
-		superbloque.S_first_blo,
-		superbloque.S_bm_inode_start,
-		superbloque.S_bm_block_start,
-		mtime,
-		umtime,
+		datos.InodesCount,
+		datos.BlocksCount,
+		datos.FreeInodesCount,
+		datos.FreeBlocksCount,
+		datos.InodeSize,
+		datos.BlockSize,
+		datos.FirstIno,
+		datos.FirstBlo,
+		datos.BmInodeStart,
+		datos.BmBlockStart,
+		datos.Mtime,
+		datos.Umtime,
+		datos.MntCount,
+		datos.Estado,
 	)
 
 	return dotContent
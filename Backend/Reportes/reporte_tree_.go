@@ -3,47 +3,48 @@ package reportes
 import (
 	"fmt"
 	estructuras "godisk/Estructuras"
+	globals "godisk/Global"
 	utilidades "godisk/Utilidades"
 	"os"
-	"os/exec"
+	"sort"
 	"strings"
 	"time"
 )
 
-func ReporteTree(superbloque *estructuras.Superbloque, rutaDisco string, ruta string) error {
+// datosTree es la forma JSON del reporte tree; las conexiones salen de i_block y de las
+// entradas de las carpetas
+type datosTree struct {
+	Inodos  []datosInodo  `json:"inodes"`
+	Bloques []datosBloque `json:"blocks"`
+}
+
+func ReporteTree(superbloque *estructuras.Superbloque, rutaDisco string, ruta string, opciones OpcionesSalida) error {
 	err := utilidades.CrearDirectoriosPadre(ruta)
 	if err != nil {
 		return fmt.Errorf("error al crear directorios: %v", err)
 	}
 
-	archivo, err := os.Open(rutaDisco)
+	archivo, err := globals.Discos.Abrir(rutaDisco, os.O_RDONLY)
 	if err != nil {
 		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
 	}
-	defer archivo.Close()
-
-	dotFileName, outputImage := utilidades.ObtenerNombresArchivo(ruta)
+	defer globals.Discos.Cerrar(archivo)
 
 	dotContent := iniciarDotTreeGraph()
 
-	dotContent, err = generarGrafoTree(dotContent, superbloque, archivo)
+	dotContent, datos, err := generarGrafoTree(dotContent, superbloque, archivo)
 	if err != nil {
 		return err
 	}
 
 	dotContent += "}"
 
-	err = escribirArchivoTexto(dotFileName, dotContent)
-	if err != nil {
-		return err
-	}
-
-	err = generarImagenTree(dotFileName, outputImage)
+	err = escribirReporte(ruta, opciones, contenidoReporte{dot: dotContent, datos: datos})
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Imagen del árbol EXT2 generada:", outputImage)
+	fmt.Println("Imagen del árbol EXT2 generada:", ruta)
 	return nil
 }
 
@@ -63,25 +64,37 @@ func iniciarDotTreeGraph() string {
 	`
 }
 
-func generarGrafoTree(dotContent string, superbloque *estructuras.Superbloque, archivo *os.File) (string, error) {
+func generarGrafoTree(dotContent string, superbloque *estructuras.Superbloque, archivo *os.File) (string, datosTree, error) {
 	conexiones := ""
+	datos := datosTree{Inodos: []datosInodo{}, Bloques: []datosBloque{}}
+	bloquesVistos := make(map[int32]bool)
+	// se recorren en orden de índice para que el reporte salga igual en cada ejecución
+	var indices []int32
 	inodos := make(map[int32]*estructuras.Inodo)
 	for i := int32(0); i < superbloque.S_inodes_count; i++ {
 		inodo := &estructuras.Inodo{}
 		err := inodo.Decode(archivo, int64(superbloque.S_inode_start+(i*superbloque.S_inode_size)))
 		if err == nil && inodo.I_uid != -1 && inodo.I_uid != 0 {
 			inodos[i] = inodo
+			indices = append(indices, i)
 			dotContent += generarTablaInodoTree(i, inodo)
+			datos.Inodos = append(datos.Inodos, nuevoDatosInodo(i, inodo))
 		}
 	}
-	for i, inodo := range inodos {
+	for _, i := range indices {
+		inodo := inodos[i]
 		for _, block := range inodo.I_block {
 			if block != -1 {
-				dotContent += generarNodoBloqueTree(block, inodo, superbloque, archivo)
+				nodo, bloque := generarNodoBloqueTree(block, inodo, superbloque, archivo)
+				dotContent += nodo
+				if bloque != nil && !bloquesVistos[block] {
+					bloquesVistos[block] = true
+					datos.Bloques = append(datos.Bloques, *bloque)
+				}
 				conexiones += fmt.Sprintf("inodo%d -> block%d [color=\"#4676D2\"]\n", i, block)
 				if inodo.I_type[0] == '0' {
 					bloqueOffset := int64(superbloque.S_block_start + (block * superbloque.S_block_size))
-					bloqueFolder := &estructuras.FolderBlock{}
+					bloqueFolder := superbloque.NuevoBloqueCarpeta()
 					err := bloqueFolder.Decode(archivo, bloqueOffset)
 					if err == nil {
 						for j, content := range bloqueFolder.B_content {
@@ -94,12 +107,13 @@ func generarGrafoTree(dotContent string, superbloque *estructuras.Superbloque, a
 			}
 		}
 	}
-	for i, inodo := range inodos {
+	for _, i := range indices {
+		inodo := inodos[i]
 		if inodo.I_type[0] == '0' {
 			for _, block := range inodo.I_block {
 				if block != -1 {
 					bloqueOffset := int64(superbloque.S_block_start + (block * superbloque.S_block_size))
-					bloqueFolder := &estructuras.FolderBlock{}
+					bloqueFolder := superbloque.NuevoBloqueCarpeta()
 					err := bloqueFolder.Decode(archivo, bloqueOffset)
 					if err == nil {
 						for j, content := range bloqueFolder.B_content {
@@ -114,7 +128,8 @@ func generarGrafoTree(dotContent string, superbloque *estructuras.Superbloque, a
 		}
 	}
 	dotContent += conexiones
-	return dotContent, nil
+	sort.Slice(datos.Bloques, func(a, b int) bool { return datos.Bloques[a].Indice < datos.Bloques[b].Indice })
+	return dotContent, datos, nil
 }
 
 func generarTablaInodoTree(indiceInodo int32, inodo *estructuras.Inodo) string {
@@ -126,18 +141,21 @@ func generarTablaInodoTree(indiceInodo int32, inodo *estructuras.Inodo) string {
 	return table
 }
 
-func generarNodoBloqueTree(indiceBloque int32, inodo *estructuras.Inodo, superbloque *estructuras.Superbloque, archivo *os.File) string {
+func generarNodoBloqueTree(indiceBloque int32, inodo *estructuras.Inodo, superbloque *estructuras.Superbloque, archivo *os.File) (string, *datosBloque) {
 	bloqueOffset := int64(superbloque.S_block_start + (indiceBloque * superbloque.S_block_size))
 	var dot string
+	var datos *datosBloque
 	if inodo.I_type[0] == '0' {
-		bloqueFolder := &estructuras.FolderBlock{}
+		bloqueFolder := superbloque.NuevoBloqueCarpeta()
 		err := bloqueFolder.Decode(archivo, bloqueOffset)
 		if err != nil {
-			return ""
+			return "", nil
 		}
 		label := fmt.Sprintf("BLOQUE DE CARPETA %d", indiceBloque)
+		datos = &datosBloque{Indice: indiceBloque, Tipo: "folder"}
 		for i, content := range bloqueFolder.B_content {
-			name := limpiarNombreBloqueTree(content.B_name)
+			name := limpiarNombreBloqueTree(superbloque, archivo, content)
+			datos.Entradas = append(datos.Entradas, entradaBloque{i + 1, name, content.B_inodo})
 			if content.B_inodo != -1 {
 				label += fmt.Sprintf("\\nContenido %d: %s (Inodo %d)", i+1, name, content.B_inodo)
 			} else {
@@ -147,27 +165,31 @@ func generarNodoBloqueTree(indiceBloque int32, inodo *estructuras.Inodo, superbl
 		label = reemplazarNuevasLineas(label)
 		dot += fmt.Sprintf("block%d [label=\"%s\", shape=box, style=filled, fillcolor=\"#FFFDE7\", color=\"#EEEEEE\"]\n", indiceBloque, label)
 	} else if inodo.I_type[0] == '1' {
-		bloqueFile := &estructuras.ArchivoBloque{}
+		bloqueFile := superbloque.NuevoBloqueArchivo()
 		err := bloqueFile.Decode(archivo, bloqueOffset)
 		if err != nil {
-			return ""
+			return "", nil
 		}
 		content := limpiarContenidoBloqueTree(bloqueFile.GetContent())
 		if len(content) > 0 {
+			datos = &datosBloque{Indice: indiceBloque, Tipo: "file", Contenido: content}
 			content = reemplazarNuevasLineas(content)
 			label := fmt.Sprintf("BLOQUE DE ARCHIVO %d\\n%s", indiceBloque, content)
 			dot += fmt.Sprintf("block%d [label=\"%s\", shape=box, style=filled, fillcolor=\"#FFFDE7\", color=\"#EEEEEE\"]\n", indiceBloque, label)
 		}
 	}
-	return dot
+	return dot, datos
 }
 
 func reemplazarNuevasLineas(s string) string {
 	return strings.ReplaceAll(s, "\n", "\\n")
 }
 
-func limpiarNombreBloqueTree(nameArray [12]byte) string {
-	name := string(nameArray[:])
+func limpiarNombreBloqueTree(superbloque *estructuras.Superbloque, archivo *os.File, content estructuras.FolderContent) string {
+	if content.EsNombreLargo() {
+		return superbloque.NombreEntrada(archivo, content)
+	}
+	name := string(content.B_name[:])
 	for i := range name {
 		if name[i] == '\x00' {
 			return name[:i]
@@ -180,15 +202,6 @@ func limpiarContenidoBloqueTree(content string) string {
 	return content
 }
 
-func generarImagenTree(dotFileName string, outputImage string) error {
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz: %v", err)
-	}
-	return nil
-}
-
 func escribirArchivoTexto(nombreArchivo string, contenido string) error {
 	archivo, err := os.Create(nombreArchivo)
 	if err != nil {
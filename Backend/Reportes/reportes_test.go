@@ -0,0 +1,230 @@
+package reportes
+
+import (
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	estructuras "godisk/Estructuras"
+)
+
+// go test ./Reportes -update reescribe los archivos de testdata con la salida actual
+var actualizar = flag.Bool("update", false, "reescribe los archivos .golden de testdata")
+
+// fechaPrueba reemplaza todas las fechas del disco de prueba para que las salidas no cambien
+const fechaPrueba = 1700000000
+
+// usuariosPrueba reemplaza el users.txt de mkfs, cuya sal es aleatoria; la contraseña es 123
+const usuariosPrueba = "1,G,root\n1,U,root,root,$pbkdf2$7fqRKJn6PZWvxoOj$Bk4X7YjyuQH0yoABZrWiWA1vN86fuE7c\n"
+
+func TestMain(m *testing.M) {
+	// las fechas de los reportes se escriben en la zona local
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+// discoPrueba crea un disco con una primaria, una extendida con una lógica y espacio libre
+func discoPrueba(t *testing.T) (string, *estructuras.Mbr) {
+	t.Helper()
+	particion := func(tipo byte, inicio, tamano int32, nombre string) estructuras.Partition {
+		p := estructuras.Partition{Part_status: [1]byte{'0'}, Part_type: [1]byte{tipo}, Part_fit: [1]byte{'F'}, Part_start: inicio, Part_s: tamano, Part_correlative: -1}
+		copy(p.Part_name[:], nombre)
+		return p
+	}
+	mbr := &estructuras.Mbr{Mbr_tamano: 4000, Mbr_fecha_creacion: fechaPrueba, Mbr_dsk_signature: 1234, Dsk_fit: [1]byte{'F'}}
+	mbr.Mbr_partitions[0] = particion('P', 200, 1000, "datos")
+	mbr.Mbr_partitions[1] = particion('E', 1200, 1600, "ext")
+
+	ruta := filepath.Join(t.TempDir(), "disco.mia")
+	archivo, err := os.Create(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archivo.Close()
+	if err := archivo.Truncate(int64(mbr.Mbr_tamano)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mbr.Codificar(archivo); err != nil {
+		t.Fatal(err)
+	}
+	ebr := &estructuras.Ebr{}
+	ebr.EstablecerEBR('W', 400, 1200+int32(binary.Size(estructuras.Ebr{})), -1, "logica")
+	if err := ebr.Codificar(archivo, 1200); err != nil {
+		t.Fatal(err)
+	}
+	return ruta, mbr
+}
+
+// particionPrueba crea una partición EXT2 que empieza en el byte 0 con /docs/nota.txt y deja
+// todas las fechas en fechaPrueba
+func particionPrueba(t *testing.T) (string, *estructuras.Superbloque) {
+	t.Helper()
+	const n = 16
+	tamanoInodo := int32(binary.Size(estructuras.Inodo{}))
+	inicioBmInodos := int32(binary.Size(estructuras.Superbloque{}))
+	inicioBmBloques := inicioBmInodos + n
+	inicioInodos := inicioBmBloques + 3*n
+	inicioBloques := inicioInodos + tamanoInodo*n
+
+	sb := &estructuras.Superbloque{
+		S_filesystem_type:   2 | estructuras.CaracteristicaNombresLargos,
+		S_free_inodes_count: n,
+		S_free_blocks_count: 3 * n,
+		S_mtime:             fechaPrueba,
+		S_umtime:            fechaPrueba,
+		S_mnt_count:         1,
+		S_magic:             0xEF53,
+		S_inode_size:        tamanoInodo,
+		S_block_size:        estructuras.BlockSize,
+		S_first_ino:         inicioInodos,
+		S_first_blo:         inicioBloques,
+		S_bm_inode_start:    inicioBmInodos,
+		S_bm_block_start:    inicioBmBloques,
+		S_inode_start:       inicioInodos,
+		S_block_start:       inicioBloques,
+	}
+
+	ruta := filepath.Join(t.TempDir(), "particion.mia")
+	archivo, err := os.Create(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { estructuras.DescartarBitmaps(ruta) })
+	defer archivo.Close()
+	if err := archivo.Truncate(int64(inicioBloques) + 3*n*int64(estructuras.BlockSize)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateBitMaps(archivo); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateUsersFile(archivo); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.ReemplazarContenido(archivo, 1, []byte(usuariosPrueba)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CrearCarpeta(archivo, []string{"docs"}, "docs", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CrearArchivo(archivo, []string{"docs"}, "nota.txt", 11, []string{"hola\nmundo\n"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int32(0); i < sb.S_inodes_count; i++ {
+		inodo := &estructuras.Inodo{}
+		posicion := int64(sb.S_inode_start + i*sb.S_inode_size)
+		if err := inodo.Decode(archivo, posicion); err != nil {
+			t.Fatal(err)
+		}
+		inodo.I_atime, inodo.I_ctime, inodo.I_mtime = fechaPrueba, fechaPrueba, fechaPrueba
+		if err := inodo.Encode(archivo, posicion); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ruta, sb
+}
+
+// compararGolden compara la salida con testdata/nombre.golden, o la guarda ahí con -update
+func compararGolden(t *testing.T, nombre string, obtenido []byte) {
+	t.Helper()
+	ruta := filepath.Join("testdata", nombre+".golden")
+	if *actualizar {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(ruta, obtenido, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	esperado, err := os.ReadFile(ruta)
+	if err != nil {
+		t.Fatalf("%v (go test ./Reportes -update lo genera)", err)
+	}
+	if string(obtenido) != string(esperado) {
+		t.Errorf("%s no coincide con %s:\n%s", nombre, ruta, obtenido)
+	}
+}
+
+// reportePrueba genera un tipo de reporte sobre el disco o la partición de prueba
+type reportePrueba struct {
+	nombre  string
+	generar func(ruta string, opciones OpcionesSalida) error
+}
+
+// reportesPrueba arma un disco y una partición de prueba y devuelve un generador por cada
+// nombre de rep
+func reportesPrueba(t *testing.T) []reportePrueba {
+	t.Helper()
+	rutaDisco, mbr := discoPrueba(t)
+	rutaParticion, sb := particionPrueba(t)
+
+	return []reportePrueba{
+		{"mbr", func(ruta string, opciones OpcionesSalida) error {
+			archivo, err := os.Open(rutaDisco)
+			if err != nil {
+				return err
+			}
+			defer archivo.Close()
+			return ReporteMBR(mbr, ruta, archivo, opciones)
+		}},
+		{"disk", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteDisk(mbr, ruta, rutaDisco, opciones)
+		}},
+		{"sb", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteSb(sb, rutaParticion, ruta, opciones)
+		}},
+		{"inode", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteInodo(sb, rutaParticion, ruta, opciones)
+		}},
+		{"block", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteBloque(sb, rutaParticion, ruta, opciones)
+		}},
+		{"tree", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteTree(sb, rutaParticion, ruta, opciones)
+		}},
+		{"bm_inode", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteBMInodo(sb, rutaParticion, ruta, opciones)
+		}},
+		{"bm_block", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteBMBloque(sb, rutaParticion, ruta, opciones)
+		}},
+		{"file", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteFile(sb, rutaParticion, ruta, "/docs/nota.txt", opciones)
+		}},
+		{"ls", func(ruta string, opciones OpcionesSalida) error {
+			return ReporteLS(sb, rutaParticion, ruta, "/docs", opciones)
+		}},
+	}
+}
+
+// generarReporte escribe el reporte en un directorio temporal con el renderizador interno y
+// devuelve el archivo generado
+func generarReporte(t *testing.T, reporte reportePrueba, formato string) []byte {
+	t.Helper()
+	ruta := filepath.Join(t.TempDir(), reporte.nombre+"."+formato)
+	if err := reporte.generar(ruta, OpcionesSalida{Formato: formato, Renderizador: RenderizadorInterno}); err != nil {
+		t.Fatalf("rep %s: %v", reporte.nombre, err)
+	}
+	salida, err := os.ReadFile(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return salida
+}
+
+// TestReportesGolden genera cada reporte como texto y como SVG con el renderizador interno; el
+// SVG fija la forma de los nodos y el diagrama por niveles, y el texto la lectura de etiquetas
+func TestReportesGolden(t *testing.T) {
+	for _, reporte := range reportesPrueba(t) {
+		for _, formato := range []string{FormatoTXT, FormatoSVG} {
+			nombre := reporte.nombre + "." + formato
+			t.Run(nombre, func(t *testing.T) {
+				compararGolden(t, nombre, generarReporte(t, reporte, formato))
+			})
+		}
+	}
+}
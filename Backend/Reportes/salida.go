@@ -0,0 +1,296 @@
+package reportes
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	utilidades "godisk/Utilidades"
+)
+
+// Formatos de salida de rep
+const (
+	FormatoPNG  = "png"
+	FormatoJPG  = "jpg"
+	FormatoSVG  = "svg"
+	FormatoPDF  = "pdf"
+	FormatoDOT  = "dot"
+	FormatoTXT  = "txt"
+	FormatoJSON = "json"
+)
+
+var formatosReporte = []string{FormatoPNG, FormatoJPG, FormatoSVG, FormatoPDF, FormatoDOT, FormatoTXT, FormatoJSON}
+
+// OpcionesSalida indica en qué formato se escribe un reporte y, para las imágenes, con qué
+// renderizador
+type OpcionesSalida struct {
+	Formato      string
+	Renderizador string
+}
+
+// normalizarFormato devuelve el formato escrito en minúsculas, con jpeg como sinónimo de jpg,
+// o "" si no es uno de formatosReporte
+func normalizarFormato(formato string) string {
+	formato = strings.ToLower(strings.TrimPrefix(formato, "."))
+	if formato == "jpeg" {
+		formato = FormatoJPG
+	}
+	if !contains(formatosReporte, formato) {
+		return ""
+	}
+	return formato
+}
+
+// formatoReporte elige el formato del reporte: el de -format si se indicó, si no el de la
+// extensión de la ruta y, si la extensión no es un formato conocido, el del reporte
+func formatoReporte(ruta, formato, porDefecto string) string {
+	if formato != "" {
+		return formato
+	}
+	if desdeRuta := normalizarFormato(filepath.Ext(ruta)); desdeRuta != "" {
+		return desdeRuta
+	}
+	return porDefecto
+}
+
+// contenidoReporte reúne las formas de un reporte: la descripción DOT de la que salen las
+// imágenes, el texto y los datos para JSON. Si texto está vacío se arma desde el DOT.
+type contenidoReporte struct {
+	dot   string
+	texto string
+	datos any
+}
+
+// escribirReporte guarda el reporte en ruta con el formato pedido. Las imágenes dejan además el
+// archivo .dot junto a la salida, como siempre hicieron los reportes.
+func escribirReporte(ruta string, opciones OpcionesSalida, contenido contenidoReporte) error {
+	switch opciones.Formato {
+	case FormatoTXT:
+		texto := contenido.texto
+		if texto == "" {
+			var err error
+			if texto, err = textoDesdeDot(contenido.dot); err != nil {
+				return err
+			}
+		}
+		return escribirArchivoTexto(ruta, texto)
+	case FormatoJSON:
+		datos, err := json.MarshalIndent(contenido.datos, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error al serializar el reporte: %v", err)
+		}
+		return escribirArchivoTexto(ruta, string(datos)+"\n")
+	case FormatoDOT:
+		return escribirArchivoTexto(ruta, contenido.dot)
+	}
+
+	dotFileName, outputImage := utilidades.ObtenerNombresArchivo(ruta)
+	if dotFileName != outputImage {
+		if err := escribirDotFile(dotFileName, contenido.dot); err != nil {
+			return err
+		}
+	}
+	return renderizarDot(contenido.dot, outputImage, opciones.Formato, opciones.Renderizador)
+}
+
+// textoDesdeDot arma la versión de texto de un reporte gráfico: cada tabla HTML como tabla de
+// texto, los record como lista con sangría y al final las conexiones entre nodos
+func textoDesdeDot(dot string) (string, error) {
+	grafo, err := analizarDot(dot)
+	if err != nil {
+		return "", fmt.Errorf("error al interpretar el archivo DOT: %v", err)
+	}
+
+	var b strings.Builder
+	visibles := make(map[*nodoDot]bool)
+	for _, n := range grafo.nodos {
+		if n.atributo("style", "") == "invis" {
+			continue
+		}
+		visibles[n] = true
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		etiqueta := n.atributo("label", `\N`)
+		switch forma := strings.ToLower(n.atributo("shape", "")); {
+		case n.html:
+			escribirTablaTexto(&b, analizarTablaHTML(etiqueta))
+		case forma == "record" || forma == "mrecord":
+			pos := 0
+			escribirRecordTexto(&b, &campoRecord{hijos: analizarCamposRecord([]rune(etiqueta), &pos, n.id)}, 0)
+		default:
+			for _, linea := range separarLineasDot(etiqueta, n.id) {
+				b.WriteString(strings.TrimRight(linea.texto, " ") + "\n")
+			}
+		}
+	}
+
+	var conexiones []string
+	vistas := make(map[string]bool)
+	for _, a := range grafo.aristas {
+		conexion := a.origen.id + " -> " + a.destino.id
+		if visibles[a.origen] && visibles[a.destino] && a.atributo("style", "") != "invis" && !vistas[conexion] {
+			vistas[conexion] = true
+			conexiones = append(conexiones, conexion)
+		}
+	}
+	if len(conexiones) > 0 {
+		b.WriteString("\nConexiones:\n")
+		for _, conexion := range conexiones {
+			b.WriteString("  " + conexion + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func textoCelda(celda *celdaHTML) string {
+	partes := make([]string, 0, len(celda.lineas))
+	for _, linea := range celda.lineas {
+		if linea.texto != "" {
+			partes = append(partes, linea.texto)
+		}
+	}
+	return strings.Join(partes, " ")
+}
+
+// escribirTablaTexto dibuja la tabla con bordes de texto; las celdas que abarcan varias
+// columnas quedan centradas entre líneas divisorias
+func escribirTablaTexto(b *strings.Builder, tabla *tablaHTML) {
+	columnas := 0
+	for _, fila := range tabla.filas {
+		total := 0
+		for _, celda := range fila {
+			total += celda.columnas
+		}
+		columnas = max(columnas, total)
+	}
+	if columnas == 0 {
+		return
+	}
+
+	anchos := make([]int, columnas)
+	for _, varias := range []bool{false, true} {
+		for _, fila := range tabla.filas {
+			columna := 0
+			for _, celda := range fila {
+				fin := min(columna+celda.columnas, columnas)
+				if (celda.columnas > 1) == varias {
+					disponible := 3 * (fin - columna - 1)
+					for c := columna; c < fin; c++ {
+						disponible += anchos[c]
+					}
+					for falta := utf8.RuneCountInString(textoCelda(celda)) - disponible; falta > 0; falta-- {
+						anchos[columna+falta%(fin-columna)]++
+					}
+				}
+				columna = fin
+			}
+		}
+	}
+
+	separador := "+"
+	for _, ancho := range anchos {
+		separador += strings.Repeat("-", ancho+2) + "+"
+	}
+	separador += "\n"
+
+	anterior := false
+	for i, fila := range tabla.filas {
+		abarca := false
+		for _, celda := range fila {
+			abarca = abarca || celda.columnas > 1
+		}
+		if i == 0 || abarca || anterior {
+			b.WriteString(separador)
+		}
+		anterior = abarca
+
+		b.WriteString("|")
+		columna := 0
+		for _, celda := range fila {
+			fin := min(columna+celda.columnas, columnas)
+			ancho := 3 * (fin - columna - 1)
+			for c := columna; c < fin; c++ {
+				ancho += anchos[c]
+			}
+			texto := textoCelda(celda)
+			relleno := ancho - utf8.RuneCountInString(texto)
+			izquierda := 0
+			if celda.columnas > 1 {
+				izquierda = relleno / 2
+			}
+			b.WriteString(" " + strings.Repeat(" ", izquierda) + texto + strings.Repeat(" ", relleno-izquierda) + " |")
+			columna = fin
+		}
+		for ; columna < columnas; columna++ {
+			b.WriteString(strings.Repeat(" ", anchos[columna]+2) + "|")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(separador)
+}
+
+// escribirRecordTexto escribe cada campo en una línea; los grupos que siguen a un campo de texto
+// de su mismo grupo, como las lógicas de una extendida, llevan un nivel más de sangría
+func escribirRecordTexto(b *strings.Builder, campo *campoRecord, nivel int) {
+	hayTexto := false
+	for _, hijo := range campo.hijos {
+		if hijo.hijos != nil {
+			siguiente := nivel
+			if hayTexto {
+				siguiente++
+			}
+			escribirRecordTexto(b, hijo, siguiente)
+			continue
+		}
+		partes := make([]string, 0, len(hijo.lineas))
+		for _, linea := range hijo.lineas {
+			partes = append(partes, linea.texto)
+		}
+		b.WriteString(strings.Repeat("  ", nivel) + strings.Join(partes, " ") + "\n")
+		hayTexto = true
+	}
+}
+
+// dotDeTabla describe como tabla HTML los reportes que nacen como texto (bitmaps, file y ls), para
+// que también puedan pedirse como imagen
+func dotDeTabla(titulo string, encabezados []string, filas [][]string) string {
+	columnas := max(len(encabezados), 1)
+	for _, fila := range filas {
+		columnas = max(columnas, len(fila))
+	}
+
+	var b strings.Builder
+	b.WriteString(`digraph G {
+		fontname="Helvetica,Arial,sans-serif"
+		node [fontname="Helvetica,Arial,sans-serif", shape=plaintext, fontsize=12];
+		bgcolor="#FAFAFA";
+
+		tabla [label=<
+			<table border="0" cellborder="1" cellspacing="0" cellpadding="4" bgcolor="#FFFDE7">
+`)
+	fmt.Fprintf(&b, "\t\t\t\t<tr><td colspan=\"%d\" bgcolor=\"#4CAF50\" align=\"center\"><b>%s</b></td></tr>\n", columnas, html.EscapeString(titulo))
+	if len(encabezados) > 0 {
+		b.WriteString("\t\t\t\t<tr>")
+		for _, encabezado := range encabezados {
+			fmt.Fprintf(&b, "<td bgcolor=\"#FF9800\"><b>%s</b></td>", html.EscapeString(encabezado))
+		}
+		b.WriteString("</tr>\n")
+	}
+	for _, fila := range filas {
+		b.WriteString("\t\t\t\t<tr>")
+		for i, celda := range fila {
+			extra := ""
+			if i == len(fila)-1 && len(fila) < columnas {
+				extra = fmt.Sprintf(" colspan=\"%d\"", columnas-len(fila)+1)
+			}
+			fmt.Fprintf(&b, "<td align=\"left\"%s>%s</td>", extra, html.EscapeString(celda))
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("\t\t\t</table>>];\n\t}\n")
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package reportes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNormalizarFormato(t *testing.T) {
+	casos := map[string]string{
+		"png":  FormatoPNG,
+		".PNG": FormatoPNG,
+		"jpeg": FormatoJPG,
+		"JPG":  FormatoJPG,
+		"svg":  FormatoSVG,
+		"pdf":  FormatoPDF,
+		"dot":  FormatoDOT,
+		"txt":  FormatoTXT,
+		"Json": FormatoJSON,
+		"":     "",
+		"bmp":  "",
+		"gif":  "",
+		"html": "",
+		"tx t": "",
+	}
+	for formato, esperado := range casos {
+		if obtenido := normalizarFormato(formato); obtenido != esperado {
+			t.Errorf("normalizarFormato(%q) = %q, se esperaba %q", formato, obtenido, esperado)
+		}
+	}
+}
+
+func TestFormatoReporte(t *testing.T) {
+	casos := []struct {
+		ruta, formato, porDefecto, esperado string
+	}{
+		{"/r/mbr.svg", "", FormatoPNG, FormatoSVG},
+		{"/r/mbr.jpeg", "", FormatoPNG, FormatoJPG},
+		{"/r/mbr.png", FormatoJSON, FormatoPNG, FormatoJSON},
+		{"/r/mbr", "", FormatoPNG, FormatoPNG},
+		{"/r/mbr.bmp", "", FormatoPNG, FormatoPNG},
+		{"/r/bitmap.report", "", FormatoTXT, FormatoTXT},
+	}
+	for _, caso := range casos {
+		if obtenido := formatoReporte(caso.ruta, caso.formato, caso.porDefecto); obtenido != caso.esperado {
+			t.Errorf("formatoReporte(%q, %q, %q) = %q, se esperaba %q", caso.ruta, caso.formato, caso.porDefecto, obtenido, caso.esperado)
+		}
+	}
+}
+
+// Un -format desconocido se rechaza antes de buscar la partición y sin escribir nada
+func TestAnalizarRepRechazaFormatoDesconocido(t *testing.T) {
+	for _, formato := range []string{"bmp", "gif", "html", "", "png2"} {
+		t.Run(formato, func(t *testing.T) {
+			ruta := filepath.Join(t.TempDir(), "reporte.png")
+			_, err := AnalizarRep([]string{"-id=991A", "-path=" + ruta, "-name=mbr", "-format=" + formato})
+			if err == nil || !strings.Contains(err.Error(), "formato inválido") {
+				t.Fatalf("AnalizarRep con -format=%s devolvió %v, se esperaba formato inválido", formato, err)
+			}
+			if _, err := os.Stat(ruta); !os.IsNotExist(err) {
+				t.Errorf("se creó %s pese al formato inválido", ruta)
+			}
+		})
+	}
+}
+
+// clavesJSON devuelve las claves del objeto ordenadas
+func clavesJSON(objeto map[string]any) []string {
+	claves := make([]string, 0, len(objeto))
+	for clave := range objeto {
+		claves = append(claves, clave)
+	}
+	sort.Strings(claves)
+	return claves
+}
+
+// TestReportesJSON comprueba las claves de primer nivel de cada reporte y, en los que tienen
+// listas, las del primer elemento
+func TestReportesJSON(t *testing.T) {
+	claves := []string{"i_atime", "i_block", "i_ctime", "i_gid", "i_mtime", "i_perm", "i_size", "i_type", "i_uid", "index"}
+	formas := map[string]struct {
+		claves    []string
+		lista     string
+		elementos []string
+	}{
+		"mbr":      {[]string{"mbr_disk_signature", "mbr_fecha_creacion", "mbr_tamano", "partitions", "unallocated"}, "partitions", []string{"number", "part_fit", "part_name", "part_size", "part_start", "part_status", "part_type"}},
+		"disk":     {[]string{"disk_size", "segments"}, "segments", []string{"type"}},
+		"sb":       {[]string{"s_block_size", "s_blocks_count", "s_bm_block_start", "s_bm_inode_start", "s_first_blo", "s_first_ino", "s_free_blocks_count", "s_free_inodes_count", "s_inode_size", "s_inodes_count", "s_mnt_count", "s_mtime", "s_umtime", "state"}, "", nil},
+		"inode":    {[]string{"inodes"}, "inodes", claves},
+		"block":    {[]string{"blocks"}, "blocks", []string{"entries", "index", "type"}},
+		"tree":     {[]string{"blocks", "inodes"}, "inodes", claves},
+		"bm_inode": {[]string{"bitmap", "total", "used"}, "", nil},
+		"bm_block": {[]string{"bitmap", "total", "used"}, "", nil},
+		"file":     {[]string{"content", "name", "path"}, "", nil},
+		"ls":       {[]string{"entries", "path"}, "entries", []string{"date", "group", "name", "owner", "permissions", "size", "time", "type"}},
+	}
+
+	for _, reporte := range reportesPrueba(t) {
+		t.Run(reporte.nombre, func(t *testing.T) {
+			forma, ok := formas[reporte.nombre]
+			if !ok {
+				t.Fatalf("falta la forma JSON esperada de %s", reporte.nombre)
+			}
+			var objeto map[string]any
+			if err := json.Unmarshal(generarReporte(t, reporte, FormatoJSON), &objeto); err != nil {
+				t.Fatalf("el reporte no es un objeto JSON: %v", err)
+			}
+			if obtenidas := clavesJSON(objeto); !reflect.DeepEqual(obtenidas, forma.claves) {
+				t.Errorf("claves = %v, se esperaba %v", obtenidas, forma.claves)
+			}
+			if forma.lista == "" {
+				return
+			}
+			lista, ok := objeto[forma.lista].([]any)
+			if !ok || len(lista) == 0 {
+				t.Fatalf("%s = %v, se esperaba una lista no vacía", forma.lista, objeto[forma.lista])
+			}
+			primero, ok := lista[0].(map[string]any)
+			if !ok {
+				t.Fatalf("%s[0] = %v, se esperaba un objeto", forma.lista, lista[0])
+			}
+			if obtenidas := clavesJSON(primero); !reflect.DeepEqual(obtenidas, forma.elementos) {
+				t.Errorf("claves de %s[0] = %v, se esperaba %v", forma.lista, obtenidas, forma.elementos)
+			}
+		})
+	}
+}
+
+// Los bitmaps cuentan en JSON los mismos bits que muestra el texto
+func TestReporteBitmapJSON(t *testing.T) {
+	contenido := reporteBitmap("BITMAP", 24, "11110000000000000000\n1010\n")
+	datos, err := json.Marshal(contenido.datos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperado := `{"bitmap":"111100000000000000001010","total":24,"used":6}`
+	if string(datos) != esperado {
+		t.Errorf("JSON = %s, se esperaba %s", datos, esperado)
+	}
+}
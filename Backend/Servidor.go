@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	analizador "godisk/Analizador"
+	estructuras "godisk/Estructuras"
 	globals "godisk/Global"
 	instrucciones_gen "godisk/Instrucciones"
 	instrucciones "godisk/Instrucciones/Usuarios"
+	utilidades "godisk/Utilidades"
 	"log"
 	"net/http"
 	"os"
@@ -24,14 +26,32 @@ func analizar(c *gin.Context) {
 
 	command := string(body)
 
-	lines := strings.Split(command, "\n")
+	lines := utilidades.UnirLineas(command)
 
 	var results []string
 	var errors []string
+	detalles := []*analizador.Resultado{}
+	formatoJSON := respuestaJSON(c)
+
+	token := tokenDeSolicitud(c)
+	sesion, tieneSesion := globals.ObtenerSesion(token)
+
+	for _, line := range lines {
+		lineNumber := line.Numero
+		if formatoJSON {
+			detalle := analizador.AnalizarDetallado(&sesion, line.Texto)
+			if detalle == nil {
+				continue
+			}
+			detalle.Linea = lineNumber
+			detalles = append(detalles, detalle)
+			if !detalle.Ok {
+				errors = append(errors, detalle.Error)
+			}
+			continue
+		}
 
-	for i, line := range lines {
-		lineNumber := i + 1
-		result, err := analizador.Analizador(line)
+		result, err := analizador.Analizador(&sesion, line.Texto)
 
 		if err != nil {
 			if err.Error() != "" {
@@ -42,17 +62,44 @@ func analizar(c *gin.Context) {
 		}
 	}
 
+	var usuarioFinal *estructuras.Usuario
+	if sesion.EstaLogueado() {
+		usuarioFinal = sesion.Usuario
+	}
+
 	response := gin.H{
 		"Lineas en total":     len(lines),
 		"Lineas procesadas":   len(results),
 		"Errores encontrados": len(errors),
 	}
+	if formatoJSON {
+		response = gin.H{
+			"lines":   len(lines),
+			"results": detalles,
+			"errors":  len(errors),
+		}
+	}
+
+	// El script pudo haber ejecutado login o logout
+	switch {
+	case tieneSesion && usuarioFinal == nil:
+		globals.EliminarSesion(token)
+		borrarCookieSesion(c)
+	case tieneSesion:
+		globals.ActualizarSesion(token, usuarioFinal)
+	case usuarioFinal != nil:
+		nuevoToken, err := globals.CrearSesion(usuarioFinal)
+		if err == nil {
+			establecerCookieSesion(c, nuevoToken)
+			response["token"] = nuevoToken
+		}
+	}
 
 	if len(results) > 0 {
 		response["Resultados"] = results
 	}
 
-	if len(errors) > 0 {
+	if len(errors) > 0 && !formatoJSON {
 		response["Errores"] = errors
 	}
 
@@ -64,6 +111,15 @@ func analizar(c *gin.Context) {
 	c.JSON(statusCode, response)
 }
 
+// respuestaJSON indica si el cliente pidió la respuesta estructurada con ?format=json o con el header Accept
+func respuestaJSON(c *gin.Context) bool {
+	if formato := c.Query("format"); formato != "" {
+		return strings.EqualFold(formato, "json")
+	}
+	accept := strings.Split(c.GetHeader("Accept"), ",")[0]
+	return strings.TrimSpace(strings.Split(accept, ";")[0]) == "application/json"
+}
+
 // LoginRequest estructura para la petición de login
 type LoginRequest struct {
 	User string `json:"user" binding:"required"`
@@ -75,9 +131,34 @@ type LoginRequest struct {
 type LoginResponse struct {
 	Status  string    `json:"status"`
 	Message string    `json:"message"`
+	Token   string    `json:"token,omitempty"`
 	User    *UserData `json:"user,omitempty"`
 }
 
+const cookieSesion = "godisk_session"
+
+// tokenDeSolicitud obtiene el token de sesión del header Authorization o de la cookie
+func tokenDeSolicitud(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	token, err := c.Cookie(cookieSesion)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+func establecerCookieSesion(c *gin.Context, token string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cookieSesion, token, int(globals.DuracionSesion.Seconds()), "/", "", false, true)
+}
+
+func borrarCookieSesion(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cookieSesion, "", -1, "/", "", false, true)
+}
+
 // UserData estructura para datos del usuario logueado
 type UserData struct {
 	ID          string `json:"id"`
@@ -98,8 +179,8 @@ func loginHandler(c *gin.Context) {
 		return
 	}
 
-	// Verificar si ya hay un usuario logueado
-	if globals.UsuarioActual != nil && globals.UsuarioActual.Status {
+	// Verificar si este cliente ya tiene una sesión activa
+	if _, ok := globals.ObtenerSesion(tokenDeSolicitud(c)); ok {
 		c.JSON(http.StatusConflict, LoginResponse{
 			Status:  "error",
 			Message: "Ya hay un usuario logueado. Debe cerrar sesión primero.",
@@ -114,8 +195,15 @@ func loginHandler(c *gin.Context) {
 		fmt.Sprintf("-id=%s", loginReq.ID),
 	}
 
-	// Usar el parser existente
-	result, err := instrucciones.ParserLogin(tokens)
+	// Usar el parser existente con una sesión nueva. Como en /analizar, login bloquea la
+	// partición para escritura porque puede migrar la contraseña en users.txt
+	liberar := func() {}
+	if ruta, montada := globals.RutaMontaje(loginReq.ID); montada {
+		liberar = globals.Discos.BloquearParticion(ruta, loginReq.ID, true)
+	}
+	sesion := &globals.Sesion{}
+	result, err := instrucciones.ParserLogin(sesion, tokens)
+	liberar()
 	if err != nil {
 		errorMessage := err.Error()
 		if result != nil && result["message"] != nil {
@@ -128,30 +216,44 @@ func loginHandler(c *gin.Context) {
 		return
 	}
 
-	// Si el login fue exitoso, devolver los datos del usuario
-	if globals.UsuarioActual != nil && globals.UsuarioActual.Status {
-		c.JSON(http.StatusOK, LoginResponse{
-			Status:  "success",
-			Message: "Login exitoso",
-			User: &UserData{
-				ID:          globals.UsuarioActual.Id,
-				Name:        globals.UsuarioActual.Name,
-				Group:       globals.UsuarioActual.Group,
-				Status:      globals.UsuarioActual.Status,
-				PartitionID: loginReq.ID,
-			},
-		})
-	} else {
+	usuario := sesion.Usuario
+	if usuario == nil {
 		c.JSON(http.StatusInternalServerError, LoginResponse{
 			Status:  "error",
 			Message: "Error interno del servidor durante el login",
 		})
+		return
 	}
+
+	token, err := globals.CrearSesion(usuario)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, LoginResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+		return
+	}
+	establecerCookieSesion(c, token)
+
+	// Si el login fue exitoso, devolver los datos del usuario
+	c.JSON(http.StatusOK, LoginResponse{
+		Status:  "success",
+		Message: "Login exitoso",
+		Token:   token,
+		User: &UserData{
+			ID:          usuario.Id,
+			Name:        usuario.Name,
+			Group:       usuario.Group,
+			Status:      usuario.Status,
+			PartitionID: loginReq.ID,
+		},
+	})
 }
 
 // Handler para logout
 func logoutHandler(c *gin.Context) {
-	if globals.UsuarioActual == nil || !globals.UsuarioActual.Status {
+	token := tokenDeSolicitud(c)
+	if _, ok := globals.ObtenerSesion(token); !ok {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
 			"message": "No hay un usuario logueado",
@@ -160,7 +262,8 @@ func logoutHandler(c *gin.Context) {
 	}
 
 	// Cerrar sesión
-	globals.CerrarSesion()
+	globals.EliminarSesion(token)
+	borrarCookieSesion(c)
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
@@ -170,11 +273,12 @@ func logoutHandler(c *gin.Context) {
 
 // Handler para verificar la sesión actual
 func sessionHandler(c *gin.Context) {
-	if globals.EstaLogueado() {
+	sesion, ok := globals.ObtenerSesion(tokenDeSolicitud(c))
+	if ok && sesion.Usuario != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"logged_in": true,
-			"user":      globals.UsuarioActual.Name,
-			"id":        globals.UsuarioActual.Id,
+			"user":      sesion.Usuario.Name,
+			"id":        sesion.Usuario.Id,
 		})
 	} else {
 		c.JSON(http.StatusOK, gin.H{
@@ -184,7 +288,8 @@ func sessionHandler(c *gin.Context) {
 }
 
 func directoryTreeHandler(c *gin.Context) {
-	if !globals.EstaLogueado() {
+	sesion, ok := globals.ObtenerSesion(tokenDeSolicitud(c))
+	if !ok || sesion.Usuario == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "Usuario no logueado",
@@ -193,7 +298,7 @@ func directoryTreeHandler(c *gin.Context) {
 	}
 
 	// Crear el servicio de árbol de directorios
-	dirService, err := instrucciones_gen.NewDirectoryTreeService()
+	dirService, err := instrucciones_gen.NewDirectoryTreeService(sesion.Usuario)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -227,6 +332,22 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Recuperar las particiones que estaban montadas antes de reiniciar el servidor
+	restaurados, err := globals.RestaurarMontajes()
+	if err != nil {
+		log.Printf("No se pudieron restaurar los montajes: %v", err)
+	} else if len(restaurados) > 0 {
+		log.Printf("Montajes restaurados: %s", strings.Join(restaurados, ", "))
+	}
+
+	// Recuperar las sesiones de los clientes en las particiones que siguen montadas
+	sesiones, err := globals.RestaurarSesiones()
+	if err != nil {
+		log.Printf("No se pudieron restaurar las sesiones: %v", err)
+	} else if sesiones > 0 {
+		log.Printf("Sesiones restauradas: %d", sesiones)
+	}
+
 	router := gin.Default()
 
 	// Usar middleware de CORS oficial de Gin (configuración directa similar al ejemplo de Fiber)
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 func ConvertirABytes(tamano int, unidad string) (int, error) {
@@ -31,7 +32,13 @@ var rutaALetra = make(map[string]string)
 
 var siguienteIndiceLetra = 0
 
+// mutexLetras protege las letras de los discos, porque se pueden montar varios a la vez
+var mutexLetras sync.Mutex
+
 func ObtenerLetra(ruta string) (string, error) {
+	mutexLetras.Lock()
+	defer mutexLetras.Unlock()
+
 	if _, existe := rutaALetra[ruta]; !existe {
 		if siguienteIndiceLetra < len(abecedario) {
 			rutaALetra[ruta] = abecedario[siguienteIndiceLetra]
@@ -44,7 +51,22 @@ func ObtenerLetra(ruta string) (string, error) {
 	return rutaALetra[ruta], nil
 }
 
+// RegistrarLetra asocia a la ruta una letra asignada previamente, por ejemplo al restaurar montajes
+func RegistrarLetra(ruta, letra string) {
+	mutexLetras.Lock()
+	defer mutexLetras.Unlock()
+
+	rutaALetra[ruta] = letra
+	for i, l := range abecedario {
+		if l == letra && i >= siguienteIndiceLetra {
+			siguienteIndiceLetra = i + 1
+		}
+	}
+}
+
 func EliminarLetra(ruta string) {
+	mutexLetras.Lock()
+	defer mutexLetras.Unlock()
 	delete(rutaALetra, ruta)
 }
 
@@ -108,10 +130,10 @@ func EliminarElemento[T any](slice []T, indice int) []T {
 	return append(slice[:indice], slice[indice+1:]...)
 }
 
-func DividirCadenaEnTrozos(cadena string) []string {
+func DividirCadenaEnTrozos(cadena string, tamano int) []string {
 	var trozos []string
-	for i := 0; i < len(cadena); i += 64 {
-		fin := i + 64
+	for i := 0; i < len(cadena); i += tamano {
+		fin := i + tamano
 		if fin > len(cadena) {
 			fin = len(cadena)
 		}
@@ -0,0 +1,199 @@
+package utilidades
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Comando es el resultado de analizar los argumentos de una línea: parámetros (-clave=valor) y banderas (-r)
+type Comando struct {
+	Parametros map[string]string
+	Banderas   map[string]bool
+	orden      []string
+}
+
+// LineaLogica es una línea del script después de unir las continuaciones con '\'
+type LineaLogica struct {
+	Numero int
+	Texto  string
+}
+
+// UnirLineas separa el script en líneas lógicas. Una línea que termina en '\' continúa en la siguiente.
+func UnirLineas(texto string) []LineaLogica {
+	var lineas []LineaLogica
+	var actual strings.Builder
+	inicio := 0
+
+	for i, linea := range strings.Split(texto, "\n") {
+		linea = strings.TrimRight(linea, "\r")
+		if actual.Len() == 0 {
+			inicio = i + 1
+		}
+
+		recortada := strings.TrimRight(linea, " \t")
+		barras := len(recortada) - len(strings.TrimRight(recortada, "\\"))
+		if barras%2 == 1 {
+			actual.WriteString(recortada[:len(recortada)-1])
+			actual.WriteString(" ")
+			continue
+		}
+
+		actual.WriteString(linea)
+		lineas = append(lineas, LineaLogica{Numero: inicio, Texto: actual.String()})
+		actual.Reset()
+	}
+
+	if actual.Len() > 0 {
+		lineas = append(lineas, LineaLogica{Numero: inicio, Texto: actual.String()})
+	}
+
+	return lineas
+}
+
+// Tokenizar divide una línea en tokens respetando comillas simples y dobles y escapes con '\'.
+// Un '#' al inicio de un token comienza un comentario que llega hasta el final de la línea.
+// Las comillas se eliminan del token: -path="/a b" produce -path=/a b.
+func Tokenizar(linea string) ([]string, error) {
+	var tokens []string
+	var actual strings.Builder
+	enToken := false
+	comilla := rune(0)
+	escape := false
+
+	for _, c := range linea {
+		switch {
+		case escape:
+			actual.WriteRune(c)
+			escape = false
+		case comilla == '\'':
+			if c == '\'' {
+				comilla = 0
+			} else {
+				actual.WriteRune(c)
+			}
+		case comilla == '"':
+			switch c {
+			case '"':
+				comilla = 0
+			case '\\':
+				escape = true
+			default:
+				actual.WriteRune(c)
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			if enToken {
+				tokens = append(tokens, actual.String())
+				actual.Reset()
+				enToken = false
+			}
+		case c == '#' && !enToken:
+			return tokens, nil
+		case c == '"' || c == '\'':
+			comilla = c
+			enToken = true
+		case c == '\\':
+			escape = true
+			enToken = true
+		default:
+			actual.WriteRune(c)
+			enToken = true
+		}
+	}
+
+	if comilla != 0 {
+		return nil, fmt.Errorf("comillas sin cerrar: falta %c", comilla)
+	}
+	if escape {
+		return nil, fmt.Errorf("la línea termina con un '\\' sin carácter a escapar")
+	}
+	if enToken {
+		tokens = append(tokens, actual.String())
+	}
+
+	return tokens, nil
+}
+
+// ParsearArgumentos convierte tokens ya separados en parámetros y banderas. Los nombres se guardan en minúsculas y sin '-'.
+func ParsearArgumentos(tokens []string) (*Comando, error) {
+	cmd := &Comando{
+		Parametros: make(map[string]string),
+		Banderas:   make(map[string]bool),
+	}
+
+	for _, token := range tokens {
+		if !strings.HasPrefix(token, "-") || len(token) == 1 {
+			return nil, fmt.Errorf("argumento inesperado: '%s'", token)
+		}
+
+		clave, valor, tieneValor := strings.Cut(token[1:], "=")
+		clave = strings.ToLower(clave)
+		if clave == "" {
+			return nil, fmt.Errorf("argumento inesperado: '%s'", token)
+		}
+
+		if _, existe := cmd.Parametros[clave]; existe || cmd.Banderas[clave] {
+			return nil, fmt.Errorf("parámetro duplicado: -%s", clave)
+		}
+
+		cmd.orden = append(cmd.orden, clave)
+		if tieneValor {
+			cmd.Parametros[clave] = valor
+		} else {
+			cmd.Banderas[clave] = true
+		}
+	}
+
+	return cmd, nil
+}
+
+// Validar rechaza parámetros y banderas que el comando no reconoce
+func (cmd *Comando) Validar(parametros []string, banderas []string) error {
+	for _, clave := range cmd.orden {
+		_, esParametro := cmd.Parametros[clave]
+		switch {
+		case esParametro && slices.Contains(banderas, clave):
+			return fmt.Errorf("la bandera -%s no recibe valor", clave)
+		case !esParametro && slices.Contains(parametros, clave):
+			return fmt.Errorf("el parámetro -%s requiere un valor", clave)
+		case !slices.Contains(parametros, clave) && !slices.Contains(banderas, clave):
+			return fmt.Errorf("parámetro desconocido: -%s", clave)
+		}
+	}
+
+	return nil
+}
+
+// Claves devuelve los nombres de los parámetros con valor en el orden en que aparecen en la línea
+func (cmd *Comando) Claves() []string {
+	var claves []string
+	for _, clave := range cmd.orden {
+		if _, esParametro := cmd.Parametros[clave]; esParametro {
+			claves = append(claves, clave)
+		}
+	}
+	return claves
+}
+
+// Valor devuelve el valor del parámetro o una cadena vacía si no se indicó
+func (cmd *Comando) Valor(nombre string) string {
+	return cmd.Parametros[nombre]
+}
+
+// Tiene indica si el parámetro fue indicado, aunque su valor esté vacío
+func (cmd *Comando) Tiene(nombre string) bool {
+	_, existe := cmd.Parametros[nombre]
+	return existe
+}
+
+// ParsearParametros convierte los tokens de un comando y valida que solo use los parámetros y banderas indicados
+func ParsearParametros(tokens []string, parametros []string, banderas []string) (*Comando, error) {
+	cmd, err := ParsearArgumentos(tokens)
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Validar(parametros, banderas); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
@@ -0,0 +1,130 @@
+package utilidades
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnirLineas(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		texto    string
+		esperado []LineaLogica
+	}{
+		{"una línea", "mkdir -path=/a", []LineaLogica{{1, "mkdir -path=/a"}}},
+		{"fin de línea de Windows", "mkdir -path=/a\r\nlogout\r\n", []LineaLogica{{1, "mkdir -path=/a"}, {2, "logout"}, {3, ""}}},
+		{"continuación", "mkdisk -size=5 \\\n  -path=/a.mia", []LineaLogica{{1, "mkdisk -size=5    -path=/a.mia"}}},
+		{"continuación con espacios al final", "mkdisk \\  \n-size=5\nlogout", []LineaLogica{{1, "mkdisk  -size=5"}, {3, "logout"}}},
+		{"varias continuaciones", "a \\\nb \\\nc\nd", []LineaLogica{{1, "a  b  c"}, {4, "d"}}},
+		{"barra escapada no continúa", "mkfile -path=/a\\\\\nlogout", []LineaLogica{{1, "mkfile -path=/a\\\\"}, {2, "logout"}}},
+		{"continuación al final del script", "mkdir \\", []LineaLogica{{1, "mkdir  "}}},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if obtenido := UnirLineas(c.texto); !reflect.DeepEqual(obtenido, c.esperado) {
+				t.Errorf("UnirLineas(%q) = %q, se esperaba %q", c.texto, obtenido, c.esperado)
+			}
+		})
+	}
+}
+
+func TestTokenizar(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		linea    string
+		esperado []string
+		err      string
+	}{
+		{"espacios y tabuladores", "mkdir\t -p   -path=/a ", []string{"mkdir", "-p", "-path=/a"}, ""},
+		{"comillas dobles", `mkdir -path="/mis documentos/a"`, []string{"mkdir", "-path=/mis documentos/a"}, ""},
+		{"comillas simples", `mkdir -path='/mis documentos'`, []string{"mkdir", "-path=/mis documentos"}, ""},
+		{"comilla escapada", `mkfile -cont="di \"hola\""`, []string{"mkfile", `-cont=di "hola"`}, ""},
+		{"barra escapada entre comillas", `mkfile -cont="a\\b"`, []string{"mkfile", `-cont=a\b`}, ""},
+		{"sin escapes entre comillas simples", `mkfile -cont='a\"b'`, []string{"mkfile", `-cont=a\"b`}, ""},
+		{"espacio escapado", `mkdir -path=/a\ b`, []string{"mkdir", "-path=/a b"}, ""},
+		{"comillas vacías", `mkfile -cont=""`, []string{"mkfile", "-cont="}, ""},
+		{"comentario", "mkdir -path=/a # crea /a", []string{"mkdir", "-path=/a"}, ""},
+		{"línea de comentario", "# solo un comentario", nil, ""},
+		{"# entre comillas", `mkfile -cont="a # b"`, []string{"mkfile", "-cont=a # b"}, ""},
+		{"# dentro de un token", "mkdir -path=/a#b", []string{"mkdir", "-path=/a#b"}, ""},
+		{"comillas sin cerrar", `mkdir -path="/a`, nil, "comillas sin cerrar"},
+		{"comilla simple sin cerrar", `mkdir -path='/a`, nil, "comillas sin cerrar"},
+		{"escape al final", `mkdir -path=/a\`, nil, "sin carácter a escapar"},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			obtenido, err := Tokenizar(c.linea)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("Tokenizar(%q) devolvió el error %v, se esperaba uno con %q", c.linea, err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Tokenizar(%q): %v", c.linea, err)
+			}
+			if !reflect.DeepEqual(obtenido, c.esperado) {
+				t.Errorf("Tokenizar(%q) = %q, se esperaba %q", c.linea, obtenido, c.esperado)
+			}
+		})
+	}
+}
+
+func TestParsearParametros(t *testing.T) {
+	parametros := []string{"path", "size"}
+	banderas := []string{"r"}
+	casos := []struct {
+		nombre     string
+		tokens     []string
+		parametros map[string]string
+		banderas   map[string]bool
+		err        string
+	}{
+		{"parámetros y bandera", []string{"-path=/a", "-size=10", "-r"}, map[string]string{"path": "/a", "size": "10"}, map[string]bool{"r": true}, ""},
+		{"nombres en mayúsculas", []string{"-PATH=/A", "-R"}, map[string]string{"path": "/A"}, map[string]bool{"r": true}, ""},
+		{"valor con '='", []string{"-path=/a=b"}, map[string]string{"path": "/a=b"}, map[string]bool{}, ""},
+		{"valor vacío", []string{"-path="}, map[string]string{"path": ""}, map[string]bool{}, ""},
+		{"parámetro desconocido", []string{"-path=/a", "-nombre=x"}, nil, nil, "parámetro desconocido: -nombre"},
+		{"bandera desconocida", []string{"-p"}, nil, nil, "parámetro desconocido: -p"},
+		{"bandera con valor", []string{"-r=1"}, nil, nil, "la bandera -r no recibe valor"},
+		{"parámetro sin valor", []string{"-path"}, nil, nil, "el parámetro -path requiere un valor"},
+		{"duplicado", []string{"-path=/a", "-Path=/b"}, nil, nil, "parámetro duplicado: -path"},
+		{"sin guion", []string{"path=/a"}, nil, nil, "argumento inesperado"},
+		{"guion solo", []string{"-"}, nil, nil, "argumento inesperado"},
+		{"sin nombre", []string{"-=/a"}, nil, nil, "argumento inesperado"},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			cmd, err := ParsearParametros(c.tokens, parametros, banderas)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("ParsearParametros(%q) devolvió el error %v, se esperaba uno con %q", c.tokens, err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsearParametros(%q): %v", c.tokens, err)
+			}
+			if !reflect.DeepEqual(cmd.Parametros, c.parametros) || !reflect.DeepEqual(cmd.Banderas, c.banderas) {
+				t.Errorf("ParsearParametros(%q) = %v %v, se esperaba %v %v", c.tokens, cmd.Parametros, cmd.Banderas, c.parametros, c.banderas)
+			}
+		})
+	}
+}
+
+func TestClavesEnOrden(t *testing.T) {
+	cmd, err := ParsearParametros([]string{"-size=1", "-r", "-path=/a"}, []string{"path", "size"}, []string{"r"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claves := cmd.Claves(); !reflect.DeepEqual(claves, []string{"size", "path"}) {
+		t.Errorf("Claves() = %q, se esperaba [size path]", claves)
+	}
+	if !cmd.Tiene("path") || cmd.Tiene("r") || cmd.Valor("falta") != "" {
+		t.Errorf("Tiene/Valor no distinguen parámetros de banderas: %+v", cmd)
+	}
+}
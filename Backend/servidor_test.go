@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTokenDeSolicitud(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	casos := []struct {
+		nombre        string
+		authorization string
+		cookie        string
+		esperado      string
+	}{
+		{"sin token", "", "", ""},
+		{"bearer", "Bearer abc123", "", "abc123"},
+		{"bearer con espacios", "Bearer   abc123 ", "", "abc123"},
+		{"cookie", "", "def456", "def456"},
+		{"bearer antes que la cookie", "Bearer abc123", "def456", "abc123"},
+		{"otro esquema usa la cookie", "Basic dXNlcjpwYXNz", "def456", "def456"},
+		{"bearer en minúsculas no cuenta", "bearer abc123", "", ""},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/analizar", nil)
+			if c.authorization != "" {
+				ctx.Request.Header.Set("Authorization", c.authorization)
+			}
+			if c.cookie != "" {
+				ctx.Request.AddCookie(&http.Cookie{Name: cookieSesion, Value: c.cookie})
+			}
+			if obtenido := tokenDeSolicitud(ctx); obtenido != c.esperado {
+				t.Errorf("tokenDeSolicitud(Authorization=%q, cookie=%q) = %q, se esperaba %q", c.authorization, c.cookie, obtenido, c.esperado)
+			}
+		})
+	}
+}
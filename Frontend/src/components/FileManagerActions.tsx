@@ -79,9 +79,8 @@ const FileManagerActions = ({ partitionId, currentPath, onRefresh }: FileManager
     }
 
     try {
-      const command = itemType === 'folder' 
-        ? `rmdir -path="${itemPath}" -id=${partitionId}`
-        : `remove -path="${itemPath}" -id=${partitionId}`;
+      // remove elimina tanto archivos como carpetas
+      const command = `remove -path="${itemPath}"`;
 
       const response = await fetch(API_ENDPOINTS.analizar, {
         method: 'POST',